@@ -14,18 +14,35 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	_ "time-guard-bot/docs/swagger"
 	"time-guard-bot/internal/api"
 	"time-guard-bot/internal/bot"
+	"time-guard-bot/internal/discord"
+	"time-guard-bot/internal/matrix"
+	"time-guard-bot/internal/metrics"
+	"time-guard-bot/internal/notify"
+	"time-guard-bot/internal/ratelimit"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/slack"
 	"time-guard-bot/internal/storage"
+	"time-guard-bot/internal/webhook"
+)
+
+// Default API rate limits
+var (
+	defaultIPRateLimit  = ratelimit.Limit{Requests: 300, Period: time.Minute}
+	defaultKeyRateLimit = ratelimit.Limit{Requests: 120, Period: time.Minute}
 )
 
 func main() {
@@ -62,6 +79,18 @@ func main() {
 		apiAddr = "0.0.0.0:8080"
 	}
 
+	// Legacy forgeable keys are rejected unless a cutoff date is set
+	var legacyKeysUntil time.Time
+
+	if legacyStr := os.Getenv("LEGACY_API_KEYS_UNTIL"); legacyStr != "" {
+		var err error
+
+		legacyKeysUntil, err = time.Parse(time.DateOnly, legacyStr)
+		if err != nil {
+			log.Printf("Warning: invalid LEGACY_API_KEYS_UNTIL, legacy API keys disabled: %v", err)
+		}
+	}
+
 	// Create Redis storage
 	redisStorage, err := storage.NewRedisStorage(redisAddr, redisPassword, redisDB)
 	if err != nil {
@@ -77,32 +106,161 @@ func main() {
 	botConfig := &bot.Config{
 		Token:           tgToken,
 		LongPollTimeout: 60,
+		WebhookURL:      os.Getenv("TELEGRAM_WEBHOOK_URL"),
+		WebhookSecret:   os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+		TierChats:       tierChats(),
 	}
 
+	// Rules shared by the bot and the API
+	taskService := service.New(redisStorage)
+
+	// Outgoing webhooks receive every change made through the service
+	webhookDispatcher := webhook.NewDispatcher(redisStorage)
+	taskService.Subscribe(webhookDispatcher.HandleEvent)
+
+	// Timer counters of /metrics
+	taskService.Subscribe(recordTimerMetrics)
+
+	if err := metrics.RegisterActiveTimers(redisStorage, metricsChatLabels()); err != nil {
+		log.Fatalf("Failed to register metrics: %v", err)
+	}
+
+	// Event streams of the API follow changes through the bus
+	eventBus := service.NewBus()
+	taskService.Subscribe(eventBus.Publish)
+
 	// Create bot
-	b, err := bot.NewBot(botConfig, redisStorage)
+	b, err := bot.NewBot(botConfig, redisStorage, taskService)
 	if err != nil {
 		log.Fatalf("Failed to create bot: %v", err)
 	}
 
 	// Create API server
 	apiConfig := &api.Config{
-		Addr: apiAddr,
+		Addr:               apiAddr,
+		LegacyAPIKeysUntil: legacyKeysUntil,
+		RateLimit:          rateLimitConfig(redisStorage),
+		SocketOrigins:      strings.FieldsFunc(os.Getenv("API_WS_ORIGINS"), func(r rune) bool { return r == ',' || r == ' ' }),
+		ReadyChecks: map[string]api.ReadyCheck{
+			"telegram": b.CheckUpdates,
+			"timers":   b.CheckTimers,
+		},
 	}
-	apiServer := api.NewServer(apiConfig, redisStorage)
 
-	// Start API server
-	if err := apiServer.Start(); err != nil {
-		log.Fatalf("Failed to start API server: %v", err)
+	// In webhook mode Telegram sends updates to the API server
+	if botConfig.WebhookURL != "" {
+		webhookPath, err := b.WebhookPath()
+		if err != nil {
+			log.Fatalf("Invalid TELEGRAM_WEBHOOK_URL: %v", err)
+		}
+
+		apiConfig.TelegramWebhook = b.WebhookHandler()
+		apiConfig.TelegramWebhookPath = webhookPath
+	}
+
+	// Slack channels are chats of their own, served by the API server
+	slackConfig := &slack.Config{
+		SigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
+		BotToken:      os.Getenv("SLACK_BOT_TOKEN"),
+	}
+
+	switch {
+	case slackConfig.SigningSecret != "" && slackConfig.BotToken != "":
+		apiConfig.Slack = slack.New(slackConfig, redisStorage, taskService).Handler()
+	case slackConfig.SigningSecret != "" || slackConfig.BotToken != "":
+		log.Printf("Warning: Slack needs both SLACK_SIGNING_SECRET and SLACK_BOT_TOKEN, Slack disabled")
 	}
 
-	// Start bot
+	// Discord guild channels are chats of their own too
+	discordConfig := &discord.Config{
+		PublicKey:     os.Getenv("DISCORD_PUBLIC_KEY"),
+		BotToken:      os.Getenv("DISCORD_BOT_TOKEN"),
+		ApplicationID: os.Getenv("DISCORD_APPLICATION_ID"),
+	}
+
+	switch {
+	case discordConfig.PublicKey != "" && discordConfig.BotToken != "" && discordConfig.ApplicationID != "":
+		discordAdapter, err := discord.New(discordConfig, redisStorage, taskService)
+		if err != nil {
+			log.Fatalf("Invalid DISCORD_PUBLIC_KEY: %v", err)
+		}
+
+		// Commands still work with the ones registered before if Discord is unreachable
+		registerCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := discordAdapter.RegisterCommands(registerCtx); err != nil {
+			log.Printf("Failed to register Discord commands: %v", err)
+		}
+
+		cancel()
+
+		apiConfig.Discord = discordAdapter.Handler()
+	case discordConfig.PublicKey != "" || discordConfig.BotToken != "" || discordConfig.ApplicationID != "":
+		log.Printf("Warning: Discord needs DISCORD_PUBLIC_KEY, DISCORD_BOT_TOKEN and DISCORD_APPLICATION_ID, Discord disabled")
+	}
+
+	// Matrix rooms are chats of their own, the adapter syncs with the homeserver itself
+	var matrixAdapter *matrix.Adapter
+
+	matrixConfig := &matrix.Config{
+		HomeserverURL: os.Getenv("MATRIX_HOMESERVER_URL"),
+		AccessToken:   os.Getenv("MATRIX_ACCESS_TOKEN"),
+	}
+
+	switch {
+	case matrixConfig.HomeserverURL != "" && matrixConfig.AccessToken != "":
+		matrixAdapter = matrix.New(matrixConfig, redisStorage, taskService)
+	case matrixConfig.HomeserverURL != "" || matrixConfig.AccessToken != "":
+		log.Printf("Warning: Matrix needs both MATRIX_HOMESERVER_URL and MATRIX_ACCESS_TOKEN, Matrix disabled")
+	}
+
+	// Email notifications about timers and watched tasks
+	var notifier *notify.Notifier
+
+	smtpConfig := notify.SMTPConfig{
+		Addr:     os.Getenv("SMTP_ADDR"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+
+	switch {
+	case smtpConfig.Addr != "" && smtpConfig.From != "":
+		sender, err := notify.NewSMTPSender(smtpConfig)
+		if err != nil {
+			log.Fatalf("Invalid SMTP configuration: %v", err)
+		}
+
+		notifier = notify.New(redisStorage, taskService, sender)
+		b.SetNotifier(notifier)
+	case smtpConfig.Addr != "" || smtpConfig.From != "":
+		log.Printf("Warning: email notifications need both SMTP_ADDR and SMTP_FROM, notifications disabled")
+	}
+
+	apiServer := api.NewServer(apiConfig, redisStorage, taskService, eventBus)
+
+	// Start bot first: timers started through the API run in the bot
 	log.Println("Starting bot...")
 
 	if err := b.Start(); err != nil {
 		log.Fatalf("Failed to start bot: %v", err)
 	}
 
+	webhookDispatcher.Start()
+
+	// Telegram keeps working while the homeserver is unreachable
+	if matrixAdapter != nil {
+		if err := matrixAdapter.Start(); err != nil {
+			log.Printf("Failed to start Matrix client, Matrix disabled: %v", err)
+
+			matrixAdapter = nil
+		}
+	}
+
+	// Start API server
+	if err := apiServer.Start(); err != nil {
+		log.Fatalf("Failed to start API server: %v", err)
+	}
+
 	// Create channel for receiving signals from the operating system
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
@@ -116,8 +274,113 @@ func main() {
 		log.Printf("Error stopping API server: %v", err)
 	}
 
+	if matrixAdapter != nil {
+		matrixAdapter.Stop()
+	}
+
 	// Stop bot
 	b.Stop()
 
+	// Let the notifications of the shutdown go out
+	if notifier != nil {
+		notifier.Stop()
+	}
+
+	// Stop webhooks last, so events of the shutdown are still queued
+	webhookDispatcher.Stop()
+
 	log.Println("Bot stopped")
 }
+
+// Reads the rate limit tiers granted to chats from the environment
+func tierChats() map[int64]string {
+	value := os.Getenv("RATE_LIMIT_TIER_CHATS")
+	if value == "" {
+		return nil
+	}
+
+	chats, err := ratelimit.ParseTierChats(value)
+	if err != nil {
+		log.Printf("Warning: invalid RATE_LIMIT_TIER_CHATS, no tiers granted: %v", err)
+		return nil
+	}
+
+	return chats
+}
+
+// Reads the chats whose active timers are labelled in /metrics from the environment
+func metricsChatLabels() metrics.ChatLabels {
+	value := os.Getenv("METRICS_CHAT_LABELS")
+	if value == "" {
+		return metrics.ChatLabels{}
+	}
+
+	labels, err := metrics.ParseChatLabels(value)
+	if err != nil {
+		log.Printf("Warning: invalid METRICS_CHAT_LABELS, no chats labelled: %v", err)
+		return metrics.ChatLabels{}
+	}
+
+	return labels
+}
+
+// Reads API rate limits from the environment
+// Token buckets are kept in memory unless RATE_LIMIT_SHARED is set, then in store
+func rateLimitConfig(store ratelimit.Store) *api.RateLimitConfig {
+	ipLimit := defaultIPRateLimit
+
+	if ipStr := os.Getenv("RATE_LIMIT_IP"); ipStr != "" {
+		limit, err := ratelimit.ParseLimit(ipStr)
+		if err != nil {
+			log.Printf("Warning: invalid RATE_LIMIT_IP, using %s: %v", defaultIPRateLimit, err)
+		} else {
+			ipLimit = limit
+		}
+	}
+
+	tiers := map[string]ratelimit.Limit{}
+
+	if tiersStr := os.Getenv("RATE_LIMIT_TIERS"); tiersStr != "" {
+		parsed, err := ratelimit.ParseTiers(tiersStr)
+		if err != nil {
+			log.Printf("Warning: invalid RATE_LIMIT_TIERS, using defaults: %v", err)
+		} else {
+			tiers = parsed
+		}
+	}
+
+	// Keys with an unknown tier fall back to the default one, so it always has a limit
+	if _, ok := tiers[ratelimit.DefaultTier]; !ok {
+		tiers[ratelimit.DefaultTier] = defaultKeyRateLimit
+	}
+
+	config := &api.RateLimitConfig{
+		IP:         ipLimit,
+		Tiers:      tiers,
+		Limiter:    ratelimit.NewMemoryLimiter(),
+		TrustProxy: os.Getenv("API_TRUST_PROXY") == "true",
+	}
+
+	// Replicas behind a load balancer share limits through storage
+	if os.Getenv("RATE_LIMIT_SHARED") == "true" {
+		config.Limiter = ratelimit.NewStoreLimiter(store)
+	}
+
+	return config
+}
+
+// Counts started and ended timers and leases
+func recordTimerMetrics(ctx context.Context, event *service.Event) {
+	switch event.Type {
+	case service.EventTimerStarted, service.EventLeaseAcquired:
+		metrics.TimerStarted(string(event.Source))
+	case service.EventTimerDone:
+		metrics.TimerEnded("done")
+	case service.EventTimerCancelled:
+		metrics.TimerEnded("cancelled")
+	case service.EventTimerExpired:
+		metrics.TimerEnded("expired")
+	case service.EventLeaseReleased:
+		metrics.TimerEnded("released")
+	}
+}
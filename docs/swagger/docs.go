@@ -15,14 +15,66 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/task/list": {
+        "/events": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a list of all chat tasks",
+                "description": "Server-Sent Events stream of the chat's tasks. The first event is a \"snapshot\" with all tasks,\nthen a \"task\" event follows every change and a \"tick\" event with running timers is sent every 15 seconds\nBrowsers can't set headers on EventSource, so the key may also be passed as the api_key query parameter",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream task changes",
+                "operationId": "stream-events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key, if the Authorization header can't be set",
+                        "name": "api_key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "snapshot event, followed by models.TaskEvent and models.TickEvent",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.SnapshotEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/lease/acquire": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Takes a task for a CI job. The lease expires after ttl seconds unless renewed, which frees the task\nThe returned token is required to renew and release the lease. The holder is shown in the chat as \"CI: {holder}\"",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,15 +82,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "leases"
+                ],
+                "summary": "Acquire a lease",
+                "operationId": "acquire-lease",
+                "parameters": [
+                    {
+                        "description": "Lease to acquire",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.AcquireLeaseRequest"
+                        }
+                    }
                 ],
-                "summary": "Get list of tasks",
-                "operationId": "get-task-list",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskListResponse"
+                            "$ref": "#/definitions/time-guard-bot_internal_models.LeaseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, holder (invalid_holder) or TTL (invalid_ttl)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
                         }
                     },
                     "401": {
@@ -47,8 +116,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "API key lacks the timers:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Chat not found or has no tasks",
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task is locked, busy or conflicts with an active task",
                         "schema": {
                             "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
                         }
@@ -62,14 +143,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/task/status": {
-            "get": {
+        "/lease/release": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns the status of a specific task",
+                "description": "Ends a lease and frees the task",
                 "consumes": [
                     "application/json"
                 ],
@@ -77,17 +158,19 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "leases"
                 ],
-                "summary": "Get task status",
-                "operationId": "get-task-status",
+                "summary": "Release a lease",
+                "operationId": "release-lease",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Task ID",
-                        "name": "task_id",
-                        "in": "query",
-                        "required": true
+                        "description": "Lease to release",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.LeaseTokenRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -98,7 +181,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Missing task_id parameter",
+                        "description": "Invalid request",
                         "schema": {
                             "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
                         }
@@ -109,12 +192,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "API key lacks the timers:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Task not found",
                         "schema": {
                             "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Lease is no longer held (lease_lost)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -123,55 +218,1822 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "time-guard-bot_internal_models.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                }
-            }
         },
-        "time-guard-bot_internal_models.TaskInfo": {
-            "type": "object",
-            "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "lock_reason": {
-                    "description": "Only present when status is \"locked\"",
-                    "type": "string"
-                },
-                "status": {
-                    "description": "\"free\", \"busy\", \"locked\"",
-                    "type": "string"
+        "/lease/renew": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Heartbeat of a lease, moves its expiry ttl seconds from now\nA lease_lost error means the lease has expired or was released and the job no longer holds the task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "leases"
+                ],
+                "summary": "Renew a lease",
+                "operationId": "renew-lease",
+                "parameters": [
+                    {
+                        "description": "Lease to renew",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.LeaseTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.LeaseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the timers:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Lease is no longer held (lease_lost)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "time-guard-bot_internal_models.TaskListResponse": {
-            "type": "object",
-            "additionalProperties": {
-                "$ref": "#/definitions/time-guard-bot_internal_models.TaskInfo"
-            }
-        },
-        "time-guard-bot_internal_models.TaskStatusResponse": {
-            "type": "object",
-            "properties": {
-                "lock_reason": {
-                    "description": "Reason for lock if status is \"locked\"",
-                    "type": "string"
-                },
-                "status": {
-                    "description": "\"free\", \"busy\", \"locked\"",
-                    "type": "string"
-                },
-                "task_name": {
-                    "description": "Name of the task",
+        "/task": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a task, like /add in the chat. Keys restricted to task patterns may only create tasks whose name matches them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Create a task",
+                "operationId": "create-task",
+                "parameters": [
+                    {
+                        "description": "Task to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.CreateTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or task name (invalid_task_name)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:write scope or may not create this task",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Name is taken (task_name_taken) or the chat has too many tasks (task_limit_reached)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a task that nobody holds, like /delete in the chat",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Delete a task",
+                "operationId": "delete-task",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing task_id parameter",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found (task_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task is in use (task_in_use)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Renames a task or changes its description. Omitted fields are left as they are",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Update a task",
+                "operationId": "update-task",
+                "parameters": [
+                    {
+                        "description": "Task changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.UpdateTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or task name (invalid_task_name)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:write scope or may not use the new name",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found (task_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Name is taken (task_name_taken)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a list of all chat tasks\nDeprecated: use GET /v1/tasks, which returns an ordered page with holders and timers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get list of tasks",
+                "operationId": "get-task-list",
+                "deprecated": true,
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Chat not found or has no tasks",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/lock": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Locks a task, preventing it from being started, like /lock in the chat",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Lock a task",
+                "operationId": "lock-task",
+                "parameters": [
+                    {
+                        "description": "Task to lock",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.LockTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task is already locked or in use",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the status of a specific task\nDeprecated: use GET /v1/tasks/{id}, which also returns the task ID and its timer",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get task status",
+                "operationId": "get-task-status",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing task_id parameter",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unlocks a previously locked task, like /unlock in the chat",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Unlock a task",
+                "operationId": "unlock-task",
+                "parameters": [
+                    {
+                        "description": "Task to unlock",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.UnlockTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task is not locked",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/timer/extend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds minutes to a running or paused timer",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timers"
+                ],
+                "summary": "Extend a timer",
+                "operationId": "extend-timer",
+                "parameters": [
+                    {
+                        "description": "Extension",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ExtendTimerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TimerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the timers:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task has no active timer or is held by a lease",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/timer/release": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops the timer and frees the task, as done or cancelled",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timers"
+                ],
+                "summary": "Release a task",
+                "operationId": "release-timer",
+                "parameters": [
+                    {
+                        "description": "Task to release",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ReleaseTimerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the timers:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task has no active timer or is held by a lease",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/timer/start": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Starts a timer on a task for a user label, like /{minutes} {task_name} in the chat\nThe start is announced in the Telegram chat",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timers"
+                ],
+                "summary": "Start a timer",
+                "operationId": "start-timer",
+                "parameters": [
+                    {
+                        "description": "Timer to start",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.StartTimerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TimerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the timers:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task is locked, busy or conflicts with an active task",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a page of tasks with their holder and timer. Tasks can be filtered by status and name prefix and sorted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "List tasks",
+                "operationId": "list-tasks-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses to include: free, busy, locked",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only tasks whose name starts with the prefix, case-insensitive",
+                        "name": "name_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name (default), status, remaining or end_time, prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tasks per page, 50 by default, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of tasks to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskPageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter, sort or pagination parameter",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tasks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a task with its holder and timer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get a task",
+                "operationId": "get-task-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskState"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found (task_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tasks/{id}/notes": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the notes attached by replies to the running timer of a task, the last 20 are kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get session notes of a task",
+                "operationId": "get-task-notes-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TaskNotesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the history:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found (task_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the webhooks of the chat without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "operationId": "list-webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/time-guard-bot_internal_models.WebhookResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the webhooks:manage scope or is restricted to tasks",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Registers a URL that receives task events of the chat as signed JSON\nThe secret is only returned here, requests carry X-TimeGuard-Signature: sha256=HMAC(secret, \"{X-TimeGuard-Timestamp}.{body}\")",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Add a webhook",
+                "operationId": "create-webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL or event (invalid_webhook)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the webhooks:manage scope or is restricted to tasks",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many webhooks (webhook_limit_reached)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a webhook, its pending deliveries are marked as failed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Remove a webhook",
+                "operationId": "delete-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Missing id parameter",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the webhooks:manage scope or is restricted to tasks",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found (webhook_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the recent deliveries of a webhook with all their attempts, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "operationId": "list-webhook-deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries, 20 by default, at most 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/time-guard-bot_internal_models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Missing id parameter or invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the webhooks:manage scope or is restricted to tasks",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found (webhook_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Bidirectional JSON API for interactive clients. Every message is a models.SocketRequest, requests\nwith an \"id\" get a reply with the same id: \"result\" with the response of the matching REST endpoint or \"error\"\n\"subscribe\" (tasks:read) replies with a snapshot and then pushes \"task\" and \"tick\" events like /events,\n\"start\", \"extend\" and \"release\" (timers:write) follow the same rules as the bot commands\nThe key may also be passed as the api_key query parameter. Browser pages of other origins are refused\nunless configured, the connection is closed when its key is revoked",
+                "tags": [
+                    "events"
+                ],
+                "summary": "WebSocket API",
+                "operationId": "websocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key, if the Authorization header can't be set",
+                        "name": "api_key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching protocols",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.SocketMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the tasks:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "time-guard-bot_internal_models.AcquireLeaseRequest": {
+            "type": "object",
+            "properties": {
+                "holder": {
+                    "description": "Who takes the task, shown in the chat as \"CI: {holder}\"",
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                },
+                "ttl": {
+                    "description": "Seconds the lease lives without a heartbeat, 300 by default",
+                    "type": "integer"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.CreateTaskRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "Optional description",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the task, same rules as /add",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "Event types to send, all if empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "description": "Receiver URL, http or https",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.DeliveryAttempt": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "When the request was sent",
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "description": "Time to response in milliseconds",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Network error or unexpected status",
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "Response status, zero if there was no response",
+                    "type": "integer"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Machine-readable code, one of the ErrorCode constants",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Human-readable message",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.ExtendTimerRequest": {
+            "type": "object",
+            "properties": {
+                "minutes": {
+                    "description": "Minutes to add",
+                    "type": "integer"
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.LeaseResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "When the lease expires without another heartbeat",
+                    "type": "string"
+                },
+                "holder": {
+                    "description": "Holder as shown in the chat",
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                },
+                "task_name": {
+                    "description": "Name of the task",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Lease token, only returned by the acquire call",
+                    "type": "string"
+                },
+                "ttl": {
+                    "description": "Seconds the lease lives after each heartbeat",
+                    "type": "integer"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.LeaseTokenRequest": {
+            "type": "object",
+            "properties": {
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token returned by the acquire call",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.LockTaskRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "Optional reason shown to users",
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.Note": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "When the note was added",
+                    "type": "string"
+                },
+                "text": {
+                    "description": "Note text",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Author of the note",
+                    "type": "integer"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.ReleaseTimerRequest": {
+            "type": "object",
+            "properties": {
+                "outcome": {
+                    "description": "\"done\" (default) or \"cancel\"",
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.SnapshotEvent": {
+            "type": "object",
+            "properties": {
+                "tasks": {
+                    "description": "All tasks of the chat visible to the API key, by name",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/time-guard-bot_internal_models.TaskState"
+                    }
+                },
+                "time": {
+                    "description": "When the snapshot was taken",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.SocketMessage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Reply or event payload",
+                    "type": "object"
+                },
+                "error": {
+                    "description": "Present when type is \"error\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.ErrorResponse"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "ID of the answered request, absent on pushed events",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"result\", \"error\" or a stream event: \"task\" or \"tick\"",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.StartTimerRequest": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Duration in minutes",
+                    "type": "integer"
+                },
+                "task_id": {
+                    "description": "ID of the task to start",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "Label of whoever takes the task, e.g. \"ci-staging\"",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.TaskInfo": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "holder": {
+                    "description": "Only present when status is \"busy\"",
+                    "type": "string"
+                },
+                "holder_id": {
+                    "description": "Only present when status is \"busy\"",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lock_reason": {
+                    "description": "Only present when status is \"locked\"",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"free\", \"busy\", \"locked\"",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.TaskListResponse": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/time-guard-bot_internal_models.TaskInfo"
+            }
+        },
+        "time-guard-bot_internal_models.TaskNotesResponse": {
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "description": "Notes by time added, empty if the task is free",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/time-guard-bot_internal_models.Note"
+                    }
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.TaskPageResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "Maximum number of tasks per page",
+                    "type": "integer"
+                },
+                "next_offset": {
+                    "description": "Offset of the next page, absent on the last page",
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "Number of matching tasks before the page",
+                    "type": "integer"
+                },
+                "tasks": {
+                    "description": "Tasks of the page",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/time-guard-bot_internal_models.TaskState"
+                    }
+                },
+                "total": {
+                    "description": "Number of tasks matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.TaskResponse": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.TaskState": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "description": "Users that can hold the task at once, always 1",
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lock_reason": {
+                    "description": "Only present when status is \"locked\"",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "queue_length": {
+                    "description": "Users waiting for the task to get free (/notify watch)",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "\"free\", \"busy\", \"locked\" or \"deleted\"",
+                    "type": "string"
+                },
+                "timer": {
+                    "description": "Only present when status is \"busy\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time-guard-bot_internal_models.TimerResponse"
+                        }
+                    ]
+                }
+            }
+        },
+        "time-guard-bot_internal_models.TaskStatusResponse": {
+            "type": "object",
+            "properties": {
+                "holder": {
+                    "description": "Name of the user holding the task if status is \"busy\"",
+                    "type": "string"
+                },
+                "holder_id": {
+                    "description": "ID of the user holding the task if status is \"busy\"",
+                    "type": "integer"
+                },
+                "lock_reason": {
+                    "description": "Reason for lock if status is \"locked\"",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"free\", \"busy\", \"locked\"",
+                    "type": "string"
+                },
+                "task_name": {
+                    "description": "Name of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.TimerResponse": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Total duration in minutes",
+                    "type": "integer"
+                },
+                "end_time": {
+                    "description": "When the timer is scheduled to end",
+                    "type": "string"
+                },
+                "holder": {
+                    "description": "Name of the user holding the task",
+                    "type": "string"
+                },
+                "holder_id": {
+                    "description": "ID of the user holding the task, negative for API labels",
+                    "type": "integer"
+                },
+                "leased": {
+                    "description": "Whether the task is held by a CI lease that ends without heartbeats",
+                    "type": "boolean"
+                },
+                "paused": {
+                    "description": "Whether the timer is paused",
+                    "type": "boolean"
+                },
+                "remaining": {
+                    "description": "Seconds left",
+                    "type": "integer"
+                },
+                "start_time": {
+                    "description": "When the timer started",
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                },
+                "task_name": {
+                    "description": "Name of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.UnlockTaskRequest": {
+            "type": "object",
+            "properties": {
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.UpdateTaskRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "New description, unchanged if omitted",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "New name, unchanged if omitted",
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "ID of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts made so far, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/time-guard-bot_internal_models.DeliveryAttempt"
+                    }
+                },
+                "chat_id": {
+                    "description": "Chat of the webhook",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "When the event happened",
+                    "type": "string"
+                },
+                "event": {
+                    "description": "Event type",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Unique identifier, also sent to the receiver",
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "When the next attempt is due if pending",
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "Signed request body",
+                    "type": "object"
+                },
+                "status": {
+                    "description": "One of the Delivery statuses",
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "description": "Webhook the event is sent to",
+                    "type": "string"
+                }
+            }
+        },
+        "time-guard-bot_internal_models.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "When the webhook was added",
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Event types sent, all if empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "Webhook ID",
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "Signing secret, only returned on creation",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "Receiver URL",
                     "type": "string"
                 }
             }
@@ -14,6 +14,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 
@@ -23,6 +25,8 @@ import (
 
 // @Summary Get task status
 // @Description Returns the status of a specific task
+// @Description Deprecated: use GET /v1/tasks/{id}, which also returns the task ID and its timer
+// @Deprecated
 // @ID get-task-status
 // @Tags tasks
 // @Accept json
@@ -31,6 +35,7 @@ import (
 // @Success 200 {object} models.TaskStatusResponse
 // @Failure 400 {object} models.ErrorResponse "Missing task_id parameter"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:read scope"
 // @Failure 404 {object} models.ErrorResponse "Task not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
@@ -62,7 +67,7 @@ func (s *Server) handleTaskStatus(w http.ResponseWriter, r *http.Request) {
 	task, err := s.storage.GetTask(r.Context(), chatID, taskID)
 	if err != nil {
 		if err == redis.ErrNotFound {
-			sendJSONError(w, "Task not found", http.StatusNotFound)
+			sendJSONErrorCode(w, models.ErrorCodeTaskNotFound, "Task not found", http.StatusNotFound)
 		} else {
 			log.Printf("Failed to get task: %v", err)
 			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
@@ -71,6 +76,12 @@ func (s *Server) handleTaskStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tasks outside of the key's patterns look like missing ones
+	if !allowsTask(r.Context(), task) {
+		sendJSONErrorCode(w, models.ErrorCodeTaskNotFound, "Task not found", http.StatusNotFound)
+		return
+	}
+
 	response := models.TaskStatusResponse{
 		TaskName: task.Name,
 	}
@@ -95,6 +106,8 @@ func (s *Server) handleTaskStatus(w http.ResponseWriter, r *http.Request) {
 
 	if activeTask != nil {
 		response.Status = "busy"
+		response.HolderID = activeTask.UserID
+		response.Holder = s.holderName(r.Context(), chatID, activeTask.UserID)
 		sendJSON(w, response)
 
 		return
@@ -106,12 +119,15 @@ func (s *Server) handleTaskStatus(w http.ResponseWriter, r *http.Request) {
 
 // @Summary Get list of tasks
 // @Description Returns a list of all chat tasks
+// @Description Deprecated: use GET /v1/tasks, which returns an ordered page with holders and timers
+// @Deprecated
 // @ID get-task-list
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.TaskListResponse
 // @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:read scope"
 // @Failure 404 {object} models.ErrorResponse "Chat not found or has no tasks"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Security ApiKeyAuth
@@ -148,16 +164,27 @@ func (s *Server) handleTaskList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create a map of active task IDs for quick lookup
-	activeTaskMap := make(map[string]bool)
+	// Create a map of active tasks for quick lookup
+	activeTaskMap := make(map[string]*models.ActiveTask)
 	for _, activeTask := range activeTasks {
-		activeTaskMap[activeTask.TaskID] = true
+		activeTaskMap[activeTask.TaskID] = activeTask
+	}
+
+	users, err := s.storage.GetChatUsers(r.Context(), chatID)
+	if err != nil {
+		log.Printf("Failed to get chat users: %v", err)
+
+		users = map[int64]*models.ChatUser{} // Names are optional, keep going without them
 	}
 
 	// Build the response
 	response := make(models.TaskListResponse)
 
 	for _, task := range tasks {
+		if !allowsTask(r.Context(), task) {
+			continue
+		}
+
 		taskInfo := models.TaskInfo{
 			ID:          task.ID,
 			Description: task.Description,
@@ -166,8 +193,13 @@ func (s *Server) handleTaskList(w http.ResponseWriter, r *http.Request) {
 		if task.IsLocked {
 			taskInfo.Status = "locked"
 			taskInfo.LockReason = task.LockReason
-		} else if activeTaskMap[task.ID] {
+		} else if activeTask, exists := activeTaskMap[task.ID]; exists {
 			taskInfo.Status = "busy"
+			taskInfo.HolderID = activeTask.UserID
+
+			if user, exists := users[activeTask.UserID]; exists {
+				taskInfo.Holder = user.DisplayName()
+			}
 		} else {
 			taskInfo.Status = "free"
 		}
@@ -177,3 +209,17 @@ func (s *Server) handleTaskList(w http.ResponseWriter, r *http.Request) {
 
 	sendJSON(w, response)
 }
+
+// Returns the display name of a chat user, empty if unknown
+func (s *Server) holderName(ctx context.Context, chatID int64, userID int64) string {
+	user, err := s.storage.GetChatUser(ctx, chatID, userID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get chat user: %v", err)
+		}
+
+		return ""
+	}
+
+	return user.DisplayName()
+}
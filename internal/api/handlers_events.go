@@ -0,0 +1,377 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// How often running timers are sent on event streams, also keeps idle connections alive
+// The API key of the stream is checked again on every tick
+const defaultStreamTick = 15 * time.Second
+
+// Is returned when the API key of an open stream was revoked or lost its scope
+var errKeyRevoked = errors.New("API key was revoked")
+
+// @Summary Stream task changes
+// @Description Server-Sent Events stream of the chat's tasks. The first event is a "snapshot" with all tasks,
+// @Description then a "task" event follows every change and a "tick" event with running timers is sent every 15 seconds
+// @Description Browsers can't set headers on EventSource, so the key may also be passed as the api_key query parameter
+// @ID stream-events
+// @Tags events
+// @Produce text/event-stream
+// @Param api_key query string false "API key, if the Authorization header can't be set"
+// @Success 200 {object} models.SnapshotEvent "snapshot event, followed by models.TaskEvent and models.TickEvent"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:read scope"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /events [get]
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		sendJSONError(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Subscribe before taking the snapshot, so no change falls between them
+	sub := s.bus.Subscribe(chatID)
+	defer sub.Close()
+
+	snapshot, err := s.snapshot(ctx, chatID)
+	if err != nil {
+		log.Printf("Failed to build task snapshot: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering in nginx
+
+	if err := writeStreamEvent(w, models.StreamEventSnapshot, snapshot); err != nil {
+		return
+	}
+
+	flusher.Flush()
+
+	ticker := time.NewTicker(s.streamTick)
+	defer ticker.Stop()
+
+	for {
+		var (
+			name string
+			data any
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.ctx.Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Too slow to keep up, the client reconnects and gets a new snapshot
+				return
+			}
+
+			taskEvent, visible := s.taskEvent(ctx, event)
+			if !visible {
+				continue
+			}
+
+			name, data = models.StreamEventTask, taskEvent
+		case <-ticker.C:
+			if err := s.checkStreamKey(ctx, models.ScopeTasksRead); errors.Is(err, errKeyRevoked) {
+				// EventSource reconnects and gets 401 for the revoked key
+				return
+			} else if err != nil {
+				log.Printf("Failed to check API key of stream: %v", err)
+			}
+
+			tick, err := s.tick(ctx, chatID)
+			if err != nil {
+				log.Printf("Failed to build timer tick: %v", err)
+				continue
+			}
+
+			name, data = models.StreamEventTick, tick
+		}
+
+		if err := writeStreamEvent(w, name, data); err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+// Reloads the API key of an open stream, so a revoked key doesn't keep its streams
+// Returns errKeyRevoked if the key is gone or lacks the scope. Legacy keys have no record,
+// they only stop working at their cut-off
+func (s *Server) checkStreamKey(ctx context.Context, scope string) error {
+	key, ok := GetAPIKeyFromContext(ctx)
+	if !ok {
+		if !time.Now().Before(s.legacyKeysUntil) {
+			return errKeyRevoked
+		}
+
+		return nil
+	}
+
+	current, err := s.storage.GetAPIKeyByHash(ctx, key.Hash)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return errKeyRevoked
+		}
+
+		return fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if current.ID != key.ID || !current.HasScope(scope) {
+		return errKeyRevoked
+	}
+
+	return nil
+}
+
+// Moves the api_key query parameter into the Authorization header
+// Only used by streams, where browser clients can't set headers
+func queryKeyAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if key := r.URL.Query().Get("api_key"); key != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+
+		next(w, r)
+	}
+}
+
+// Writes one Server-Sent Event with a JSON payload
+func writeStreamEvent(w io.Writer, name string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", name, err)
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", name, err)
+	}
+
+	return nil
+}
+
+// Builds the state of all tasks of the chat visible to the API key
+func (s *Server) snapshot(ctx context.Context, chatID int64) (*models.SnapshotEvent, error) {
+	tasks, err := s.storage.ListTasks(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	activeTasks, err := s.activeTaskMap(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks = slices.DeleteFunc(tasks, func(task *models.Task) bool {
+		return !allowsTask(ctx, task)
+	})
+
+	taskIDs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	watchers, err := s.storage.CountTaskWatchers(ctx, chatID, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.SnapshotEvent{
+		Time:  time.Now(),
+		Tasks: make([]models.TaskState, 0, len(tasks)),
+	}
+
+	for _, task := range tasks {
+		snapshot.Tasks = append(snapshot.Tasks, s.taskState(ctx, task, activeTasks[task.ID], watchers[task.ID]))
+	}
+
+	sort.Slice(snapshot.Tasks, func(i, j int) bool {
+		return snapshot.Tasks[i].Name < snapshot.Tasks[j].Name
+	})
+
+	return snapshot, nil
+}
+
+// Builds the stream event of a service event
+// The task is read again, so the event shows its current state even if later changes were already made
+// Returns false if the event shouldn't be sent to the API key
+func (s *Server) taskEvent(ctx context.Context, event *service.Event) (*models.TaskEvent, bool) {
+	// Lease heartbeats only move the end time, ticks carry it
+	if event.Type == service.EventLeaseRenewed || !allowsTask(ctx, event.Task) {
+		return nil, false
+	}
+
+	taskEvent := &models.TaskEvent{
+		Event:   string(event.Type),
+		Source:  string(event.Source),
+		Time:    event.Time,
+		Minutes: event.Minutes,
+	}
+
+	deleted := models.TaskState{
+		ID:          event.Task.ID,
+		Name:        event.Task.Name,
+		Description: event.Task.Description,
+		Status:      "deleted",
+	}
+
+	if event.Type == service.EventTaskDeleted {
+		taskEvent.Task = deleted
+		return taskEvent, true
+	}
+
+	task, err := s.storage.GetTask(ctx, event.ChatID, event.Task.ID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get task: %v", err)
+			return nil, false
+		}
+
+		// Deleted right after the change, its own event follows
+		taskEvent.Task = deleted
+
+		return taskEvent, true
+	}
+
+	activeTask, err := s.storage.GetActiveTask(ctx, event.ChatID, task.ID)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		log.Printf("Failed to get active task: %v", err)
+		return nil, false
+	}
+
+	watchers, err := s.storage.CountTaskWatchers(ctx, event.ChatID, []string{task.ID})
+	if err != nil {
+		log.Printf("Failed to count task watchers: %v", err)
+		return nil, false
+	}
+
+	taskEvent.Task = s.taskState(ctx, task, activeTask, watchers[task.ID])
+
+	return taskEvent, true
+}
+
+// Builds the list of running timers of the chat visible to the API key
+func (s *Server) tick(ctx context.Context, chatID int64) (*models.TickEvent, error) {
+	activeTasks, err := s.storage.GetActiveTasks(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active tasks: %w", err)
+	}
+
+	tick := &models.TickEvent{
+		Time:   time.Now(),
+		Timers: make([]models.TimerResponse, 0, len(activeTasks)),
+	}
+
+	for _, activeTask := range activeTasks {
+		task, err := s.storage.GetTask(ctx, chatID, activeTask.TaskID)
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		if allowsTask(ctx, task) {
+			tick.Timers = append(tick.Timers, s.timerResponse(ctx, task, activeTask))
+		}
+	}
+
+	sort.Slice(tick.Timers, func(i, j int) bool {
+		return tick.Timers[i].TaskName < tick.Timers[j].TaskName
+	})
+
+	return tick, nil
+}
+
+// Gets the active tasks of the chat by task ID
+func (s *Server) activeTaskMap(ctx context.Context, chatID int64) (map[string]*models.ActiveTask, error) {
+	activeTasks, err := s.storage.GetActiveTasks(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active tasks: %w", err)
+	}
+
+	activeTaskMap := make(map[string]*models.ActiveTask, len(activeTasks))
+	for _, activeTask := range activeTasks {
+		activeTaskMap[activeTask.TaskID] = activeTask
+	}
+
+	return activeTaskMap, nil
+}
+
+// Builds the stream state of a task, activeTask is nil if the task is free
+// queueLength is the number of users waiting for the task
+func (s *Server) taskState(ctx context.Context, task *models.Task, activeTask *models.ActiveTask, queueLength int64) models.TaskState {
+	state := models.TaskState{
+		ID:          task.ID,
+		Name:        task.Name,
+		Description: task.Description,
+		Capacity:    models.TaskCapacity,
+		QueueLength: queueLength,
+	}
+
+	switch {
+	case task.IsLocked:
+		state.Status = "locked"
+		state.LockReason = task.LockReason
+	case activeTask != nil:
+		timer := s.timerResponse(ctx, task, activeTask)
+
+		state.Status = "busy"
+		state.Timer = &timer
+	default:
+		state.Status = "free"
+	}
+
+	return state
+}
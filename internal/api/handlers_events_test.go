@@ -0,0 +1,259 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Reads the next Server-Sent Event and decodes its data into v
+func readStreamEvent(t *testing.T, reader *bufio.Reader, v any) string {
+	t.Helper()
+
+	var name, data string
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event stream: %v", err)
+		}
+
+		line = strings.TrimRight(line, "\n")
+
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			if err := json.Unmarshal([]byte(data), v); err != nil {
+				t.Fatalf("Failed to decode %s event: %v", name, err)
+			}
+
+			return name
+		}
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	server, mockStorage := createTestServer()
+	server.service.Subscribe(server.bus.Publish)
+
+	deploy := &models.Task{ID: "task1", Name: "deploy", ChatID: 12345}
+	mockTimerStorage(mockStorage, deploy)
+
+	mockStorage.ListTasksFunc = func(ctx context.Context, chatID int64) ([]*models.Task, error) {
+		return []*models.Task{
+			deploy,
+			{ID: "task2", Name: "db", ChatID: 12345, IsLocked: true, LockReason: "migration"},
+		}, nil
+	}
+	mockStorage.GetActiveTasksFunc = func(ctx context.Context, chatID int64) ([]*models.ActiveTask, error) {
+		activeTask, err := mockStorage.GetActiveTask(ctx, chatID, deploy.ID)
+		if err != nil {
+			return nil, nil
+		}
+
+		return []*models.ActiveTask{activeTask}, nil
+	}
+
+	server.streamTick = 50 * time.Millisecond
+
+	stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.handleEvents(w, r.WithContext(context.WithValue(r.Context(), ChatIDKey, int64(12345))))
+	}))
+	defer stream.Close()
+
+	resp, err := http.Get(stream.URL)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("Failed to close stream: %v", err)
+		}
+	}()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", contentType)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+
+	// Сначала приходит полный снимок, задачи отсортированы по имени
+	var snapshot models.SnapshotEvent
+	if name := readStreamEvent(t, reader, &snapshot); name != models.StreamEventSnapshot {
+		t.Fatalf("Expected snapshot first, got %s", name)
+	}
+
+	if len(snapshot.Tasks) != 2 || snapshot.Tasks[0].Status != "locked" || snapshot.Tasks[1].Status != "free" {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+
+	_, _, err = server.service.StartTimer(context.Background(), &service.StartTimerRequest{
+		ChatID:   12345,
+		TaskID:   "task1",
+		UserID:   12345,
+		Duration: 30,
+		Source:   service.SourceTelegram,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	// Изменение приходит отдельным событием, тики могут прийти раньше
+	var taskEvent models.TaskEvent
+
+	for {
+		var raw json.RawMessage
+
+		name := readStreamEvent(t, reader, &raw)
+		if name != models.StreamEventTask {
+			continue
+		}
+
+		if err := json.Unmarshal(raw, &taskEvent); err != nil {
+			t.Fatalf("Failed to decode task event: %v", err)
+		}
+
+		break
+	}
+
+	if taskEvent.Event != "timer.started" || taskEvent.Source != "telegram" || taskEvent.Task.Status != "busy" {
+		t.Fatalf("Unexpected task event: %+v", taskEvent)
+	}
+
+	if timer := taskEvent.Task.Timer; timer == nil || timer.Holder != "Alice" || timer.Duration != 30 {
+		t.Errorf("Expected a 30 minute timer held by Alice, got %+v", timer)
+	}
+
+	var tick models.TickEvent
+	if name := readStreamEvent(t, reader, &tick); name != models.StreamEventTick {
+		t.Fatalf("Expected tick, got %s", name)
+	}
+
+	if len(tick.Timers) != 1 || tick.Timers[0].TaskID != "task1" || tick.Timers[0].Remaining <= 0 {
+		t.Errorf("Unexpected tick: %+v", tick)
+	}
+
+	// Остановка сервера закрывает поток
+	server.cancel()
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("Failed to read stream to the end: %v", err)
+	}
+
+	if server.bus.Subscribers() != 0 {
+		t.Errorf("Expected the subscription to be closed, got %d subscribers", server.bus.Subscribers())
+	}
+}
+
+func TestHandleEventsRevokedKey(t *testing.T) {
+	server, mockStorage := createTestServer()
+	server.streamTick = 20 * time.Millisecond
+
+	mockStorage.ListTasksFunc = func(ctx context.Context, chatID int64) ([]*models.Task, error) {
+		return nil, nil
+	}
+	mockStorage.GetActiveTasksFunc = func(ctx context.Context, chatID int64) ([]*models.ActiveTask, error) {
+		return nil, nil
+	}
+
+	var revoked atomic.Bool
+
+	key := &models.APIKey{ID: "abcd1234", ChatID: 12345, Hash: "hash", Scopes: []string{models.ScopeTasksRead}}
+	mockStorage.GetAPIKeyByHashFunc = func(ctx context.Context, hash string) (*models.APIKey, error) {
+		if revoked.Load() {
+			return nil, redis.ErrNotFound
+		}
+
+		return key, nil
+	}
+
+	stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ChatIDKey, int64(12345))
+		server.handleEvents(w, r.WithContext(context.WithValue(ctx, APIKeyKey, key)))
+	}))
+	defer stream.Close()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	resp, err := client.Get(stream.URL)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("Failed to close stream: %v", err)
+		}
+	}()
+
+	reader := bufio.NewReader(resp.Body)
+
+	var snapshot models.SnapshotEvent
+	if name := readStreamEvent(t, reader, &snapshot); name != models.StreamEventSnapshot {
+		t.Fatalf("Expected snapshot first, got %s", name)
+	}
+
+	// Пока ключ действует, приходят тики
+	var tick models.TickEvent
+	if name := readStreamEvent(t, reader, &tick); name != models.StreamEventTick {
+		t.Fatalf("Expected tick, got %s", name)
+	}
+
+	// Отзыв ключа закрывает поток на следующем тике
+	revoked.Store(true)
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("Expected the stream closed, got: %v", err)
+	}
+}
+
+func TestQueryKeyAuth(t *testing.T) {
+	var header string
+
+	handler := queryKeyAuth(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Authorization")
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/events?api_key=tgk_abc", nil))
+
+	if header != "Bearer tgk_abc" {
+		t.Errorf("Expected key from query, got %q", header)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events?api_key=tgk_abc", nil)
+	req.Header.Set("Authorization", "Bearer tgk_header")
+
+	handler(httptest.NewRecorder(), req)
+
+	if header != "Bearer tgk_header" {
+		t.Errorf("Expected the header to win, got %q", header)
+	}
+}
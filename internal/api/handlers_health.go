@@ -0,0 +1,59 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+// How long all readiness checks together may take
+const readyTimeout = 3 * time.Second
+
+// Checks a dependency of the server, returns what is wrong or nil
+type ReadyCheck func(ctx context.Context) error
+
+// Answers liveness probes: the process is up and serves HTTP
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, models.HealthResponse{Status: "ok"})
+}
+
+// Answers readiness probes: 200 if all checks pass, 503 with the failed ones otherwise
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	response := models.HealthResponse{Status: "ready", Checks: make(map[string]string, len(s.readyChecks))}
+
+	for name, check := range s.readyChecks {
+		if err := check(ctx); err != nil {
+			response.Status = "not_ready"
+			response.Checks[name] = err.Error()
+
+			continue
+		}
+
+		response.Checks[name] = "ok"
+	}
+
+	if response.Status != "ready" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	sendJSON(w, response)
+}
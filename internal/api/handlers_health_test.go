@@ -0,0 +1,92 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestHandleHealth(t *testing.T) {
+	server, _ := createTestServer()
+
+	rec := httptest.NewRecorder()
+	server.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestHandleReady(t *testing.T) {
+	server, mockStorage := createTestServer()
+
+	var restored bool
+
+	server.readyChecks["timers"] = func(ctx context.Context) error {
+		if !restored {
+			return errors.New("timers are not restored yet")
+		}
+
+		return nil
+	}
+
+	ready := func() (int, models.HealthResponse) {
+		rec := httptest.NewRecorder()
+		server.handleReady(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var response models.HealthResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		return rec.Code, response
+	}
+
+	mockStorage.PingFunc = func(ctx context.Context) error {
+		return nil
+	}
+
+	// Таймеры еще не восстановлены
+	code, response := ready()
+	if code != http.StatusServiceUnavailable || response.Status != "not_ready" {
+		t.Errorf("Expected not ready, got %d %+v", code, response)
+	}
+
+	if response.Checks["storage"] != "ok" || response.Checks["timers"] != "timers are not restored yet" {
+		t.Errorf("Unexpected checks: %v", response.Checks)
+	}
+
+	restored = true
+
+	if code, response := ready(); code != http.StatusOK || response.Status != "ready" {
+		t.Errorf("Expected ready, got %d %+v", code, response)
+	}
+
+	// Хранилище недоступно
+	mockStorage.PingFunc = func(ctx context.Context) error {
+		return errors.New("failed to ping Redis: connection refused")
+	}
+
+	code, response = ready()
+	if code != http.StatusServiceUnavailable || response.Checks["storage"] != "failed to ping Redis: connection refused" {
+		t.Errorf("Expected storage failure, got %d %+v", code, response)
+	}
+}
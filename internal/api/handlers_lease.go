@@ -0,0 +1,146 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+)
+
+// @Summary Acquire a lease
+// @Description Takes a task for a CI job. The lease expires after ttl seconds unless renewed, which frees the task
+// @Description The returned token is required to renew and release the lease. The holder is shown in the chat as "CI: {holder}"
+// @ID acquire-lease
+// @Tags leases
+// @Accept json
+// @Produce json
+// @Param request body models.AcquireLeaseRequest true "Lease to acquire"
+// @Success 200 {object} models.LeaseResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request, holder (invalid_holder) or TTL (invalid_ttl)"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the timers:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Task is locked, busy or conflicts with an active task"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /lease/acquire [post]
+func (s *Server) handleLeaseAcquire(w http.ResponseWriter, r *http.Request) {
+	var req models.AcquireLeaseRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok || !s.checkTaskAccess(w, r, chatID, req.TaskID) {
+		return
+	}
+
+	task, activeTask, token, err := s.service.AcquireLease(r.Context(), &service.AcquireLeaseRequest{
+		ChatID: chatID,
+		TaskID: req.TaskID,
+		Holder: req.Holder,
+		TTL:    req.TTL,
+		Source: service.SourceAPI,
+	})
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	response := s.leaseResponse(r.Context(), task, activeTask)
+	response.Token = token
+
+	sendJSON(w, response)
+}
+
+// @Summary Renew a lease
+// @Description Heartbeat of a lease, moves its expiry ttl seconds from now
+// @Description A lease_lost error means the lease has expired or was released and the job no longer holds the task
+// @ID renew-lease
+// @Tags leases
+// @Accept json
+// @Produce json
+// @Param request body models.LeaseTokenRequest true "Lease to renew"
+// @Success 200 {object} models.LeaseResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the timers:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Lease is no longer held (lease_lost)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /lease/renew [post]
+func (s *Server) handleLeaseRenew(w http.ResponseWriter, r *http.Request) {
+	var req models.LeaseTokenRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok || !s.checkTaskAccess(w, r, chatID, req.TaskID) {
+		return
+	}
+
+	task, activeTask, err := s.service.RenewLease(r.Context(), chatID, req.TaskID, req.Token, service.SourceAPI)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, s.leaseResponse(r.Context(), task, activeTask))
+}
+
+// @Summary Release a lease
+// @Description Ends a lease and frees the task
+// @ID release-lease
+// @Tags leases
+// @Accept json
+// @Produce json
+// @Param request body models.LeaseTokenRequest true "Lease to release"
+// @Success 200 {object} models.TaskStatusResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the timers:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Lease is no longer held (lease_lost)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /lease/release [post]
+func (s *Server) handleLeaseRelease(w http.ResponseWriter, r *http.Request) {
+	var req models.LeaseTokenRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok || !s.checkTaskAccess(w, r, chatID, req.TaskID) {
+		return
+	}
+
+	task, _, err := s.service.ReleaseLease(r.Context(), chatID, req.TaskID, req.Token, service.SourceAPI)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, models.TaskStatusResponse{
+		Status:   "free",
+		TaskName: task.Name,
+	})
+}
+
+// Builds the response describing a lease
+func (s *Server) leaseResponse(ctx context.Context, task *models.Task, activeTask *models.ActiveTask) models.LeaseResponse {
+	return models.LeaseResponse{
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		Holder:    s.holderName(ctx, activeTask.ChatID, activeTask.UserID),
+		TTL:       activeTask.Lease.TTL,
+		ExpiresAt: activeTask.EndTime,
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+func TestHandleLease(t *testing.T) {
+	server, mockStorage := createTestServer()
+	mockTimerStorage(mockStorage, &models.Task{ID: "task1", Name: "staging", ChatID: 12345})
+
+	// Метки держателей сохраняются в справочник пользователей
+	users := map[int64]*models.ChatUser{}
+	mockStorage.SaveChatUserFunc = func(ctx context.Context, chatID int64, user *models.ChatUser) error {
+		users[user.ID] = user
+		return nil
+	}
+	mockStorage.GetChatUserFunc = func(ctx context.Context, chatID int64, userID int64) (*models.ChatUser, error) {
+		user, ok := users[userID]
+		if !ok {
+			return nil, redis.ErrNotFound
+		}
+
+		return user, nil
+	}
+
+	var lease models.LeaseResponse
+
+	t.Run("Invalid TTL", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"task_id": "task1", "holder": "pipeline #1", "ttl": %d}`, helpers.MinLeaseTTL-1)
+
+		server.handleLeaseAcquire(rec, newWriteRequest("/api/lease/acquire", body))
+
+		checkErrorCode(t, rec, http.StatusBadRequest, models.ErrorCodeInvalidTTL)
+	})
+
+	t.Run("Acquire", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleLeaseAcquire(rec, newWriteRequest("/api/lease/acquire", `{"task_id": "task1", "holder": "pipeline #1234", "ttl": 120}`))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&lease); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if !strings.HasPrefix(lease.Token, helpers.LeaseTokenPrefix) || lease.Holder != "CI: pipeline #1234" || lease.TTL != 120 {
+			t.Errorf("Unexpected response: %+v", lease)
+		}
+	})
+
+	t.Run("Acquire Busy", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleLeaseAcquire(rec, newWriteRequest("/api/lease/acquire", `{"task_id": "task1", "holder": "pipeline #1235"}`))
+
+		checkErrorCode(t, rec, http.StatusConflict, models.ErrorCodeTaskBusy)
+	})
+
+	t.Run("Renew Wrong Token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleLeaseRenew(rec, newWriteRequest("/api/lease/renew", `{"task_id": "task1", "token": "tgl_nope"}`))
+
+		checkErrorCode(t, rec, http.StatusConflict, models.ErrorCodeLeaseLost)
+	})
+
+	t.Run("Renew", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"task_id": "task1", "token": %q}`, lease.Token)
+
+		server.handleLeaseRenew(rec, newWriteRequest("/api/lease/renew", body))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		var renewed models.LeaseResponse
+		if err := json.NewDecoder(rec.Body).Decode(&renewed); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		// Токен возвращается только при захвате
+		if renewed.Token != "" || renewed.ExpiresAt.Before(lease.ExpiresAt) {
+			t.Errorf("Unexpected response: %+v", renewed)
+		}
+	})
+
+	t.Run("Timer Release", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerRelease(rec, newWriteRequest("/api/timer/release", `{"task_id": "task1", "outcome": "cancel"}`))
+
+		checkErrorCode(t, rec, http.StatusConflict, models.ErrorCodeTaskLeased)
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"task_id": "task1", "token": %q}`, lease.Token)
+
+		server.handleLeaseRelease(rec, newWriteRequest("/api/lease/release", body))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+
+		server.handleLeaseRelease(rec, newWriteRequest("/api/lease/release", body))
+
+		checkErrorCode(t, rec, http.StatusConflict, models.ErrorCodeLeaseLost)
+	})
+}
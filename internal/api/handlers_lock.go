@@ -0,0 +1,94 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"net/http"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+)
+
+// @Summary Lock a task
+// @Description Locks a task, preventing it from being started, like /lock in the chat
+// @ID lock-task
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param request body models.LockTaskRequest true "Task to lock"
+// @Success 200 {object} models.TaskStatusResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Task is already locked or in use"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /task/lock [post]
+func (s *Server) handleTaskLock(w http.ResponseWriter, r *http.Request) {
+	var req models.LockTaskRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok || !s.checkTaskAccess(w, r, chatID, req.TaskID) {
+		return
+	}
+
+	task, err := s.service.LockTask(r.Context(), chatID, req.TaskID, req.Reason, service.SourceAPI)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, models.TaskStatusResponse{
+		Status:     "locked",
+		LockReason: task.LockReason,
+		TaskName:   task.Name,
+	})
+}
+
+// @Summary Unlock a task
+// @Description Unlocks a previously locked task, like /unlock in the chat
+// @ID unlock-task
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param request body models.UnlockTaskRequest true "Task to unlock"
+// @Success 200 {object} models.TaskStatusResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Task is not locked"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /task/unlock [post]
+func (s *Server) handleTaskUnlock(w http.ResponseWriter, r *http.Request) {
+	var req models.UnlockTaskRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok || !s.checkTaskAccess(w, r, chatID, req.TaskID) {
+		return
+	}
+
+	task, err := s.service.UnlockTask(r.Context(), chatID, req.TaskID, service.SourceAPI)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, models.TaskStatusResponse{
+		Status:   "free",
+		TaskName: task.Name,
+	})
+}
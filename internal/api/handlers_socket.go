@@ -0,0 +1,326 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+)
+
+// @Summary WebSocket API
+// @Description Bidirectional JSON API for interactive clients. Every message is a models.SocketRequest, requests
+// @Description with an "id" get a reply with the same id: "result" with the response of the matching REST endpoint or "error"
+// @Description "subscribe" (tasks:read) replies with a snapshot and then pushes "task" and "tick" events like /events,
+// @Description "start", "extend" and "release" (timers:write) follow the same rules as the bot commands
+// @Description The key may also be passed as the api_key query parameter. Browser pages of other origins are refused
+// @Description unless configured, the connection is closed when its key is revoked
+// @ID websocket
+// @Tags events
+// @Param api_key query string false "API key, if the Authorization header can't be set"
+// @Success 101 {object} models.SocketMessage "Switching protocols"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:read scope"
+// @Security ApiKeyAuth
+// @Router /ws [get]
+func (s *Server) handleSocket(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: s.checkSocketOrigin,
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = maxRequestBodySize
+
+			session := &socketSession{
+				server: s,
+				conn:   conn,
+				chatID: chatID,
+				ctx:    r.Context(),
+			}
+			session.run()
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+// Accepts WebSocket handshakes of clients that send no Origin (not browsers), of pages served
+// by the API itself and of the configured origins. Other pages are refused with 403
+func (s *Server) checkSocketOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(s.socketOrigins, origin) {
+		return nil
+	}
+
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return nil
+	}
+
+	return fmt.Errorf("origin %q is not allowed", origin)
+}
+
+// Represents one WebSocket connection
+type socketSession struct {
+	server *Server
+	conn   *websocket.Conn
+	chatID int64
+	ctx    context.Context // Request context, carries the API key
+
+	// Tasks the client follows, nil until it subscribes, empty for all tasks
+	taskIDs    []string
+	subscribed bool
+	mu         sync.Mutex
+}
+
+// Reads requests until the client disconnects, pushing events in the background
+func (ss *socketSession) run() {
+	ctx, cancel := context.WithCancel(ss.ctx)
+
+	sub := ss.server.bus.Subscribe(ss.chatID)
+	defer sub.Close()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ss.push(ctx, sub)
+
+		// Unblock the reader when the server stops or the client falls behind
+		if ctx.Err() == nil {
+			if err := ss.conn.Close(); err != nil {
+				log.Printf("Failed to close WebSocket: %v", err)
+			}
+		}
+	}()
+
+	defer wg.Wait()
+	defer cancel()
+
+	for {
+		var data []byte
+
+		if err := websocket.Message.Receive(ss.conn, &data); err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil && ss.server.ctx.Err() == nil {
+				log.Printf("Failed to read WebSocket message: %v", err)
+			}
+
+			return
+		}
+
+		var req models.SocketRequest
+
+		if err := json.Unmarshal(data, &req); err != nil {
+			ss.send(models.SocketMessage{
+				Type:  models.SocketError,
+				Error: &models.ErrorResponse{Code: models.ErrorCodeBadRequest, Error: "Invalid message: " + err.Error()},
+			})
+
+			continue
+		}
+
+		if ss.keyRevoked(req.ID) {
+			return
+		}
+
+		ss.send(ss.handle(ctx, &req))
+	}
+}
+
+// Reloads the API key of the connection, a revoked key gets an error before the connection is closed
+func (ss *socketSession) keyRevoked(id string) bool {
+	err := ss.server.checkStreamKey(ss.ctx, models.ScopeTasksRead)
+	if errors.Is(err, errKeyRevoked) {
+		ss.send(models.SocketMessage{
+			ID:    id,
+			Type:  models.SocketError,
+			Error: &models.ErrorResponse{Code: models.ErrorCodeInvalidAPIKey, Error: "API key was revoked"},
+		})
+
+		return true
+	}
+
+	if err != nil {
+		log.Printf("Failed to check API key of WebSocket: %v", err)
+	}
+
+	return false
+}
+
+// Sends stream events of the followed tasks until the context is done
+func (ss *socketSession) push(ctx context.Context, sub *service.Subscription) {
+	ticker := time.NewTicker(ss.server.streamTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ss.server.ctx.Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+
+			if !ss.follows(event.Task.ID) {
+				continue
+			}
+
+			taskEvent, visible := ss.server.taskEvent(ss.ctx, event)
+			if visible {
+				ss.send(models.SocketMessage{Type: models.StreamEventTask, Data: taskEvent})
+			}
+		case <-ticker.C:
+			if ss.keyRevoked("") {
+				return
+			}
+
+			if !ss.follows("") {
+				continue
+			}
+
+			tick, err := ss.server.tick(ss.ctx, ss.chatID)
+			if err != nil {
+				log.Printf("Failed to build timer tick: %v", err)
+				continue
+			}
+
+			tick.Timers = slices.DeleteFunc(tick.Timers, func(timer models.TimerResponse) bool {
+				return !ss.follows(timer.TaskID)
+			})
+
+			ss.send(models.SocketMessage{Type: models.StreamEventTick, Data: tick})
+		}
+	}
+}
+
+// Executes a request and builds its reply
+func (ss *socketSession) handle(ctx context.Context, req *models.SocketRequest) models.SocketMessage {
+	var (
+		data any
+		err  error
+	)
+
+	switch req.Type {
+	case models.SocketSubscribe:
+		data, err = ss.subscribe(ctx, req.TaskIDs)
+	case models.SocketUnsubscribe:
+		ss.mu.Lock()
+		ss.subscribed = false
+		ss.taskIDs = nil
+		ss.mu.Unlock()
+	case models.SocketStart:
+		if err = ss.requireScope(models.ScopeTimersWrite); err == nil {
+			data, err = ss.server.startTimer(ctx, ss.chatID, &models.StartTimerRequest{
+				TaskID:   req.TaskID,
+				User:     req.User,
+				Duration: req.Duration,
+			})
+		}
+	case models.SocketExtend:
+		if err = ss.requireScope(models.ScopeTimersWrite); err == nil {
+			data, err = ss.server.extendTimer(ctx, ss.chatID, &models.ExtendTimerRequest{
+				TaskID:  req.TaskID,
+				Minutes: req.Minutes,
+			})
+		}
+	case models.SocketRelease:
+		if err = ss.requireScope(models.ScopeTimersWrite); err == nil {
+			data, err = ss.server.releaseTimer(ctx, ss.chatID, &models.ReleaseTimerRequest{
+				TaskID:  req.TaskID,
+				Outcome: req.Outcome,
+			})
+		}
+	default:
+		err = &requestError{message: "Unknown message type: " + req.Type}
+	}
+
+	if err != nil {
+		_, response := serviceErrorResponse(err)
+
+		return models.SocketMessage{ID: req.ID, Type: models.SocketError, Error: &response}
+	}
+
+	return models.SocketMessage{ID: req.ID, Type: models.SocketResult, Data: data}
+}
+
+// Starts following tasks and returns their current state
+func (ss *socketSession) subscribe(ctx context.Context, taskIDs []string) (*models.SnapshotEvent, error) {
+	// Follow first, so no change falls between the snapshot and the events
+	ss.mu.Lock()
+	ss.subscribed = true
+	ss.taskIDs = taskIDs
+	ss.mu.Unlock()
+
+	snapshot, err := ss.server.snapshot(ctx, ss.chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Tasks = slices.DeleteFunc(snapshot.Tasks, func(task models.TaskState) bool {
+		return !ss.follows(task.ID)
+	})
+
+	return snapshot, nil
+}
+
+// Reports whether the client follows the task, an empty ID asks whether it follows anything
+func (ss *socketSession) follows(taskID string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if !ss.subscribed {
+		return false
+	}
+
+	return taskID == "" || len(ss.taskIDs) == 0 || slices.Contains(ss.taskIDs, taskID)
+}
+
+// Checks that the API key of the connection grants the scope
+func (ss *socketSession) requireScope(scope string) error {
+	key, ok := GetAPIKeyFromContext(ss.ctx)
+	if ok && key.HasScope(scope) {
+		return nil
+	}
+
+	// Legacy keys can only read tasks, and that's already checked on connect
+	return &missingScopeError{scope: scope}
+}
+
+// Sends a message, a failed send closes the connection through the reader
+func (ss *socketSession) send(message models.SocketMessage) {
+	if err := websocket.JSON.Send(ss.conn, message); err != nil {
+		log.Printf("Failed to send WebSocket message: %v", err)
+	}
+}
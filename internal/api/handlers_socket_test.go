@@ -0,0 +1,352 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Starts an HTTP server that serves the WebSocket handler with the API key in the context
+func socketServer(t *testing.T, server *Server, key *models.APIKey) *httptest.Server {
+	t.Helper()
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ChatIDKey, int64(12345))
+		ctx = context.WithValue(ctx, APIKeyKey, key)
+
+		server.handleSocket(w, r.WithContext(ctx))
+	}))
+	t.Cleanup(httpServer.Close)
+
+	return httpServer
+}
+
+// Opens a WebSocket to the handler with the API key in the context
+// The key is reloaded by hash on every command, so it must be returned by storage
+func dialSocket(t *testing.T, server *Server, key *models.APIKey) *websocket.Conn {
+	t.Helper()
+
+	httpServer := socketServer(t, server, key)
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), "", httpServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to dial WebSocket: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Logf("Failed to close WebSocket: %v", err)
+		}
+	})
+
+	return conn
+}
+
+// Sends a request and reads messages until the reply to it
+func socketRequest(t *testing.T, conn *websocket.Conn, req models.SocketRequest) models.SocketMessage {
+	t.Helper()
+
+	if err := websocket.JSON.Send(conn, req); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+
+	for {
+		message := receiveSocket(t, conn)
+		if message.ID == req.ID {
+			return message
+		}
+	}
+}
+
+func receiveSocket(t *testing.T, conn *websocket.Conn) models.SocketMessage {
+	t.Helper()
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("Failed to set deadline: %v", err)
+	}
+
+	var message models.SocketMessage
+	if err := websocket.JSON.Receive(conn, &message); err != nil {
+		t.Fatalf("Failed to receive message: %v", err)
+	}
+
+	return message
+}
+
+// Decodes the payload of a message
+func decodeSocketData(t *testing.T, message models.SocketMessage, v any) {
+	t.Helper()
+
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		t.Fatalf("Failed to marshal data: %v", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("Failed to decode data: %v", err)
+	}
+}
+
+func TestHandleSocket(t *testing.T) {
+	server, mockStorage := createTestServer()
+	server.service.Subscribe(server.bus.Publish)
+	server.streamTick = time.Hour
+
+	deploy := &models.Task{ID: "task1", Name: "deploy", ChatID: 12345}
+	mockTimerStorage(mockStorage, deploy)
+
+	mockStorage.ListTasksFunc = func(ctx context.Context, chatID int64) ([]*models.Task, error) {
+		return []*models.Task{deploy, {ID: "task2", Name: "db", ChatID: 12345}}, nil
+	}
+	mockStorage.GetActiveTasksFunc = func(ctx context.Context, chatID int64) ([]*models.ActiveTask, error) {
+		return nil, nil
+	}
+
+	writer := &models.APIKey{ChatID: 12345, Scopes: []string{models.ScopeTasksRead, models.ScopeTimersWrite}}
+	mockStorage.GetAPIKeyByHashFunc = func(ctx context.Context, hash string) (*models.APIKey, error) {
+		return writer, nil
+	}
+
+	conn := dialSocket(t, server, writer)
+
+	t.Run("Subscribe", func(t *testing.T) {
+		reply := socketRequest(t, conn, models.SocketRequest{ID: "1", Type: models.SocketSubscribe, TaskIDs: []string{"task1"}})
+		if reply.Type != models.SocketResult {
+			t.Fatalf("Expected result, got %+v", reply)
+		}
+
+		// Снимок содержит только отслеживаемые задачи
+		var snapshot models.SnapshotEvent
+		decodeSocketData(t, reply, &snapshot)
+
+		if len(snapshot.Tasks) != 1 || snapshot.Tasks[0].ID != "task1" || snapshot.Tasks[0].Status != "free" {
+			t.Errorf("Unexpected snapshot: %+v", snapshot)
+		}
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		reply := socketRequest(t, conn, models.SocketRequest{ID: "2", Type: models.SocketStart, TaskID: "task1", User: "tray-app", Duration: 30})
+		if reply.Type != models.SocketResult {
+			t.Fatalf("Expected result, got %+v", reply.Error)
+		}
+
+		var timer models.TimerResponse
+		decodeSocketData(t, reply, &timer)
+
+		if timer.TaskID != "task1" || timer.Duration != 30 {
+			t.Errorf("Unexpected timer: %+v", timer)
+		}
+
+		// Изменение приходит и подписчику
+		var event models.SocketMessage
+		for event.Type != models.StreamEventTask {
+			event = receiveSocket(t, conn)
+		}
+
+		var taskEvent models.TaskEvent
+		decodeSocketData(t, event, &taskEvent)
+
+		if taskEvent.Event != "timer.started" || taskEvent.Task.Status != "busy" || event.ID != "" {
+			t.Errorf("Unexpected event: %+v", taskEvent)
+		}
+	})
+
+	t.Run("Business Rule Error", func(t *testing.T) {
+		reply := socketRequest(t, conn, models.SocketRequest{ID: "3", Type: models.SocketStart, TaskID: "task1", User: "other", Duration: 30})
+
+		if reply.Type != models.SocketError || reply.Error == nil || reply.Error.Code != models.ErrorCodeTaskBusy {
+			t.Errorf("Expected task_busy error, got %+v", reply)
+		}
+	})
+
+	t.Run("Unknown Task", func(t *testing.T) {
+		reply := socketRequest(t, conn, models.SocketRequest{ID: "4", Type: models.SocketExtend, TaskID: "missing", Minutes: 5})
+
+		if reply.Type != models.SocketError || reply.Error.Code != models.ErrorCodeTaskNotFound {
+			t.Errorf("Expected task_not_found error, got %+v", reply)
+		}
+	})
+
+	t.Run("Invalid Message", func(t *testing.T) {
+		if err := websocket.Message.Send(conn, "{not json"); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+
+		reply := receiveSocket(t, conn)
+		if reply.Type != models.SocketError || reply.Error.Code != models.ErrorCodeBadRequest {
+			t.Errorf("Expected bad_request error, got %+v", reply)
+		}
+
+		reply = socketRequest(t, conn, models.SocketRequest{ID: "5", Type: "pause"})
+		if reply.Type != models.SocketError || reply.Error.Code != models.ErrorCodeBadRequest {
+			t.Errorf("Expected bad_request for unknown type, got %+v", reply)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		reply := socketRequest(t, conn, models.SocketRequest{ID: "6", Type: models.SocketRelease, TaskID: "task1", Outcome: "cancel"})
+		if reply.Type != models.SocketResult {
+			t.Fatalf("Expected result, got %+v", reply.Error)
+		}
+
+		var status models.TaskStatusResponse
+		decodeSocketData(t, reply, &status)
+
+		if status.Status != "free" {
+			t.Errorf("Expected free task, got %+v", status)
+		}
+	})
+}
+
+func TestHandleSocketScopes(t *testing.T) {
+	server, mockStorage := createTestServer()
+	mockTimerStorage(mockStorage, &models.Task{ID: "task1", Name: "deploy", ChatID: 12345})
+
+	reader := &models.APIKey{ChatID: 12345, Scopes: []string{models.ScopeTasksRead}}
+	mockStorage.GetAPIKeyByHashFunc = func(ctx context.Context, hash string) (*models.APIKey, error) {
+		return reader, nil
+	}
+
+	conn := dialSocket(t, server, reader)
+
+	reply := socketRequest(t, conn, models.SocketRequest{ID: "1", Type: models.SocketStart, TaskID: "task1", User: "tray-app", Duration: 30})
+
+	if reply.Type != models.SocketError || reply.Error.Code != models.ErrorCodeMissingScope {
+		t.Errorf("Expected missing_scope error, got %+v", reply)
+	}
+}
+
+func TestHandleSocketRevokedKey(t *testing.T) {
+	// Ключ отзывают, пока соединение открыто
+	setup := func(t *testing.T, tick time.Duration) (*atomic.Bool, *websocket.Conn) {
+		t.Helper()
+
+		server, mockStorage := createTestServer()
+		server.streamTick = tick
+
+		mockStorage.ListTasksFunc = func(ctx context.Context, chatID int64) ([]*models.Task, error) {
+			return nil, nil
+		}
+		mockStorage.GetActiveTasksFunc = func(ctx context.Context, chatID int64) ([]*models.ActiveTask, error) {
+			return nil, nil
+		}
+
+		var revoked atomic.Bool
+
+		key := &models.APIKey{ID: "abcd1234", ChatID: 12345, Hash: "hash", Scopes: []string{models.ScopeTasksRead}}
+		mockStorage.GetAPIKeyByHashFunc = func(ctx context.Context, hash string) (*models.APIKey, error) {
+			if revoked.Load() || hash != key.Hash {
+				return nil, redis.ErrNotFound
+			}
+
+			return key, nil
+		}
+
+		conn := dialSocket(t, server, key)
+
+		if reply := socketRequest(t, conn, models.SocketRequest{ID: "1", Type: models.SocketSubscribe}); reply.Type != models.SocketResult {
+			t.Fatalf("Expected result before revocation, got %+v", reply)
+		}
+
+		return &revoked, conn
+	}
+
+	expectClosed := func(t *testing.T, conn *websocket.Conn) {
+		t.Helper()
+
+		if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("Failed to set deadline: %v", err)
+		}
+
+		var message models.SocketMessage
+		if err := websocket.JSON.Receive(conn, &message); err == nil {
+			t.Errorf("Expected the connection closed, got %+v", message)
+		}
+	}
+
+	t.Run("Command", func(t *testing.T) {
+		revoked, conn := setup(t, time.Hour)
+		revoked.Store(true)
+
+		reply := socketRequest(t, conn, models.SocketRequest{ID: "2", Type: models.SocketUnsubscribe})
+		if reply.Type != models.SocketError || reply.Error.Code != models.ErrorCodeInvalidAPIKey {
+			t.Fatalf("Expected invalid_api_key error, got %+v", reply)
+		}
+
+		expectClosed(t, conn)
+	})
+
+	t.Run("Tick", func(t *testing.T) {
+		revoked, conn := setup(t, 20*time.Millisecond)
+		revoked.Store(true)
+
+		// Тики идут до отзыва, затем приходит ошибка без id
+		message := receiveSocket(t, conn)
+		for message.Type == models.StreamEventTick {
+			message = receiveSocket(t, conn)
+		}
+
+		if message.Type != models.SocketError || message.ID != "" || message.Error.Code != models.ErrorCodeInvalidAPIKey {
+			t.Fatalf("Expected invalid_api_key error, got %+v", message)
+		}
+
+		expectClosed(t, conn)
+	})
+}
+
+func TestHandleSocketOrigin(t *testing.T) {
+	server, _ := createTestServer()
+	server.socketOrigins = []string{"https://dash.example.com"}
+
+	httpServer := socketServer(t, server, &models.APIKey{ChatID: 12345, Scopes: []string{models.ScopeTasksRead}})
+	socketURL := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"Same origin", httpServer.URL, true},
+		{"Configured origin", "https://dash.example.com", true},
+		// Чужая страница могла бы использовать ключ, который браузер хранит для API
+		{"Other origin", "https://evil.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := websocket.Dial(socketURL, "", tt.origin)
+			if tt.allowed != (err == nil) {
+				t.Fatalf("Expected allowed %v, got error: %v", tt.allowed, err)
+			}
+
+			if conn != nil {
+				if err := conn.Close(); err != nil {
+					t.Errorf("Failed to close WebSocket: %v", err)
+				}
+			}
+		})
+	}
+}
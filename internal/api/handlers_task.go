@@ -0,0 +1,162 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"net/http"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+)
+
+// Handles create, update and delete requests for tasks
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handleTaskCreate(w, r)
+	case http.MethodPatch:
+		s.handleTaskUpdate(w, r)
+	case http.MethodDelete:
+		s.handleTaskDelete(w, r)
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary Create a task
+// @Description Creates a task, like /add in the chat. Keys restricted to task patterns may only create tasks whose name matches them
+// @ID create-task
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param request body models.CreateTaskRequest true "Task to create"
+// @Success 201 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request or task name (invalid_task_name)"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:write scope or may not create this task"
+// @Failure 409 {object} models.ErrorResponse "Name is taken (task_name_taken) or the chat has too many tasks (task_limit_reached)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /task [post]
+func (s *Server) handleTaskCreate(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTaskRequest
+
+	chatID, ok := readRequestBody(w, r, &req)
+	if !ok {
+		return
+	}
+
+	if !allowsTask(r.Context(), &models.Task{Name: req.Name}) {
+		sendJSONErrorCode(w, models.ErrorCodeForbidden, "API key may not create a task with this name", http.StatusForbidden)
+		return
+	}
+
+	task, err := s.service.CreateTask(r.Context(), chatID, req.Name, req.Description, service.SourceAPI)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	sendJSON(w, taskResponse(task))
+}
+
+// @Summary Update a task
+// @Description Renames a task or changes its description. Omitted fields are left as they are
+// @ID update-task
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateTaskRequest true "Task changes"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request or task name (invalid_task_name)"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:write scope or may not use the new name"
+// @Failure 404 {object} models.ErrorResponse "Task not found (task_not_found)"
+// @Failure 409 {object} models.ErrorResponse "Name is taken (task_name_taken)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /task [patch]
+func (s *Server) handleTaskUpdate(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateTaskRequest
+
+	chatID, ok := readRequestBody(w, r, &req)
+	if !ok || !s.checkTaskAccess(w, r, chatID, req.TaskID) {
+		return
+	}
+
+	// A restricted key must not rename a task out of its own reach
+	if req.Name != nil && !allowsTask(r.Context(), &models.Task{ID: req.TaskID, Name: *req.Name}) {
+		sendJSONErrorCode(w, models.ErrorCodeForbidden, "API key may not rename the task to this name", http.StatusForbidden)
+		return
+	}
+
+	update := service.TaskUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	task, err := s.service.UpdateTask(r.Context(), chatID, req.TaskID, update, service.SourceAPI)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, taskResponse(task))
+}
+
+// @Summary Delete a task
+// @Description Deletes a task that nobody holds, like /delete in the chat
+// @ID delete-task
+// @Tags tasks
+// @Produce json
+// @Param task_id query string true "Task ID"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse "Missing task_id parameter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found (task_not_found)"
+// @Failure 409 {object} models.ErrorResponse "Task is in use (task_in_use)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /task [delete]
+func (s *Server) handleTaskDelete(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	taskID := r.URL.Query().Get("task_id")
+	if !s.checkTaskAccess(w, r, chatID, taskID) {
+		return
+	}
+
+	task, err := s.service.DeleteTask(r.Context(), chatID, taskID, service.SourceAPI)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, taskResponse(task))
+}
+
+// Converts a task to its API representation
+func taskResponse(task *models.Task) models.TaskResponse {
+	return models.TaskResponse{
+		ID:          task.ID,
+		Name:        task.Name,
+		Description: task.Description,
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Creates a request of chat 12345 with the given method and JSON body
+func newTaskRequest(method string, target string, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), ChatIDKey, int64(12345))
+
+	return req.WithContext(ctx)
+}
+
+// Sets up the mock storage to keep tasks in memory
+func mockTaskStorage(mockStorage *MockStorage, tasks map[string]*models.Task) {
+	mockStorage.GetTaskFunc = func(ctx context.Context, chatID int64, taskID string) (*models.Task, error) {
+		task, ok := tasks[taskID]
+		if !ok {
+			return nil, redis.ErrNotFound
+		}
+
+		copied := *task
+
+		return &copied, nil
+	}
+	mockStorage.GetTaskByNameFunc = func(ctx context.Context, chatID int64, name string) (*models.Task, error) {
+		for _, task := range tasks {
+			if task.Name == name {
+				return task, nil
+			}
+		}
+
+		return nil, redis.ErrNotFound
+	}
+	mockStorage.CountTasksFunc = func(ctx context.Context, chatID int64) (int64, error) {
+		return int64(len(tasks)), nil
+	}
+	mockStorage.TaskExistsFunc = func(ctx context.Context, chatID int64, taskID string) (bool, error) {
+		_, ok := tasks[taskID]
+		return ok, nil
+	}
+	mockStorage.AddTaskFunc = func(ctx context.Context, task *models.Task) error {
+		tasks[task.ID] = task
+		return nil
+	}
+	mockStorage.UpdateTaskFunc = func(ctx context.Context, task *models.Task) error {
+		tasks[task.ID] = task
+		return nil
+	}
+	mockStorage.DeleteTaskFunc = func(ctx context.Context, chatID int64, taskID string) error {
+		delete(tasks, taskID)
+		return nil
+	}
+}
+
+// Decodes an error response and checks its code
+func checkErrorCode(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("Expected status %d, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+
+	var response models.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+
+	if response.Code != code {
+		t.Errorf("Expected error code %q, got %q", code, response.Code)
+	}
+}
+
+func TestHandleTask(t *testing.T) {
+	server, mockStorage := createTestServer()
+
+	tasks := map[string]*models.Task{
+		"task1": {ID: "task1", Name: "deploy", Description: "Production", ChatID: 12345},
+	}
+	mockTaskStorage(mockStorage, tasks)
+
+	t.Run("Method Not Allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodGet, "/api/task", ""))
+
+		checkErrorCode(t, rec, http.StatusMethodNotAllowed, models.ErrorCodeMethodNotAllowed)
+	})
+
+	t.Run("Create Invalid Name", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodPost, "/api/task", `{"name": "bad name!"}`))
+
+		checkErrorCode(t, rec, http.StatusBadRequest, models.ErrorCodeInvalidTaskName)
+	})
+
+	t.Run("Create Name Taken", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodPost, "/api/task", `{"name": "deploy"}`))
+
+		checkErrorCode(t, rec, http.StatusConflict, models.ErrorCodeTaskNameTaken)
+	})
+
+	t.Run("Create Restricted Key", func(t *testing.T) {
+		key := &models.APIKey{Scopes: []string{models.ScopeTasksWrite}, TaskPatterns: []string{"staging-*"}}
+
+		req := newTaskRequest(http.MethodPost, "/api/task", `{"name": "prod"}`)
+		req = req.WithContext(context.WithValue(req.Context(), APIKeyKey, key))
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, req)
+
+		checkErrorCode(t, rec, http.StatusForbidden, models.ErrorCodeForbidden)
+	})
+
+	var created models.TaskResponse
+
+	t.Run("Create", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodPost, "/api/task", `{"name": "staging-1", "description": "Staging"}`))
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if created.ID == "" || created.Name != "staging-1" || created.Description != "Staging" {
+			t.Errorf("Unexpected response: %+v", created)
+		}
+
+		if _, ok := tasks[created.ID]; !ok {
+			t.Error("Expected task to be stored")
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodPatch, "/api/task", `{"task_id": "task1", "description": "Prod env"}`))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		// Имя не передано и должно остаться прежним
+		if task := tasks["task1"]; task.Name != "deploy" || task.Description != "Prod env" {
+			t.Errorf("Unexpected task after update: %+v", task)
+		}
+	})
+
+	t.Run("Update Not Found", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodPatch, "/api/task", `{"task_id": "nope", "name": "x"}`))
+
+		checkErrorCode(t, rec, http.StatusNotFound, models.ErrorCodeTaskNotFound)
+	})
+
+	t.Run("Delete In Use", func(t *testing.T) {
+		tasks["task1"].OwnerID = 42
+		defer func() { tasks["task1"].OwnerID = 0 }()
+
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodDelete, "/api/task?task_id=task1", ""))
+
+		checkErrorCode(t, rec, http.StatusConflict, models.ErrorCodeTaskInUse)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodDelete, "/api/task?task_id="+created.ID, ""))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		if _, ok := tasks[created.ID]; ok {
+			t.Error("Expected task to be deleted")
+		}
+	})
+
+	t.Run("Task Limit", func(t *testing.T) {
+		mockStorage.CountTasksFunc = func(ctx context.Context, chatID int64) (int64, error) {
+			return 16, nil
+		}
+
+		rec := httptest.NewRecorder()
+
+		server.handleTask(rec, newTaskRequest(http.MethodPost, "/api/task", `{"name": "one-more"}`))
+
+		checkErrorCode(t, rec, http.StatusConflict, models.ErrorCodeTaskLimit)
+	})
+}
+
+func TestHandleTaskEmptyChat(t *testing.T) {
+	server, mockStorage := createTestServer()
+
+	// В чате еще нет ни одной задачи
+	tasks := map[string]*models.Task{}
+	mockTaskStorage(mockStorage, tasks)
+
+	mockStorage.ChatExistsFunc = func(ctx context.Context, chatID int64) (bool, error) {
+		return len(tasks) > 0, nil
+	}
+
+	const secretKey = "tgb_abcd1234_c2VjcmV0"
+
+	mockStorage.GetAPIKeyByHashFunc = func(ctx context.Context, hash string) (*models.APIKey, error) {
+		if hash != helpers.HashAPIKey(secretKey) {
+			return nil, redis.ErrNotFound
+		}
+
+		return &models.APIKey{ID: "abcd1234", ChatID: 12345, Scopes: []string{models.ScopeTasksWrite}}, nil
+	}
+	mockStorage.TouchAPIKeyFunc = func(ctx context.Context, keyID string, usedAt time.Time) error {
+		return nil
+	}
+
+	handler := server.authMiddleware(models.ScopeTasksWrite, server.handleTask)
+
+	request := func(method string, target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+secretKey)
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		return rec
+	}
+
+	// Первую задачу чата можно создать через API
+	rec := request(http.MethodPost, "/api/task", `{"name": "staging"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	var created models.TaskResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+
+	// После удаления последней задачи ключ продолжает работать
+	if rec := request(http.MethodDelete, "/api/task?task_id="+created.ID, ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	if rec := request(http.MethodPost, "/api/task", `{"name": "staging"}`); rec.Code != http.StatusCreated {
+		t.Errorf("Expected status %d in an emptied chat, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+}
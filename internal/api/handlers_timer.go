@@ -0,0 +1,217 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+)
+
+// Release outcomes of a timer
+const (
+	outcomeDone   = "done"
+	outcomeCancel = "cancel"
+)
+
+// @Summary Start a timer
+// @Description Starts a timer on a task for a user label, like /{minutes} {task_name} in the chat
+// @Description The start is announced in the Telegram chat
+// @ID start-timer
+// @Tags timers
+// @Accept json
+// @Produce json
+// @Param request body models.StartTimerRequest true "Timer to start"
+// @Success 200 {object} models.TimerResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the timers:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Task is locked, busy or conflicts with an active task"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /timer/start [post]
+func (s *Server) handleTimerStart(w http.ResponseWriter, r *http.Request) {
+	var req models.StartTimerRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok {
+		return
+	}
+
+	response, err := s.startTimer(r.Context(), chatID, &req)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, response)
+}
+
+// @Summary Extend a timer
+// @Description Adds minutes to a running or paused timer
+// @ID extend-timer
+// @Tags timers
+// @Accept json
+// @Produce json
+// @Param request body models.ExtendTimerRequest true "Extension"
+// @Success 200 {object} models.TimerResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the timers:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Task has no active timer or is held by a lease"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /timer/extend [post]
+func (s *Server) handleTimerExtend(w http.ResponseWriter, r *http.Request) {
+	var req models.ExtendTimerRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok {
+		return
+	}
+
+	response, err := s.extendTimer(r.Context(), chatID, &req)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, response)
+}
+
+// @Summary Release a task
+// @Description Stops the timer and frees the task, as done or cancelled
+// @ID release-timer
+// @Tags timers
+// @Accept json
+// @Produce json
+// @Param request body models.ReleaseTimerRequest true "Task to release"
+// @Success 200 {object} models.TaskStatusResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the timers:write scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found"
+// @Failure 409 {object} models.ErrorResponse "Task has no active timer or is held by a lease"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /timer/release [post]
+func (s *Server) handleTimerRelease(w http.ResponseWriter, r *http.Request) {
+	var req models.ReleaseTimerRequest
+
+	chatID, ok := readWriteRequest(w, r, &req)
+	if !ok {
+		return
+	}
+
+	response, err := s.releaseTimer(r.Context(), chatID, &req)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	sendJSON(w, response)
+}
+
+// Starts a timer for a user label
+// Shared by the HTTP and WebSocket APIs, errors are mapped by serviceErrorResponse
+func (s *Server) startTimer(ctx context.Context, chatID int64, req *models.StartTimerRequest) (*models.TimerResponse, error) {
+	if err := helpers.ValidateTaskName(req.User); err != nil {
+		return nil, &requestError{message: fmt.Sprintf("Invalid user label: %s", err.Error())}
+	}
+
+	if _, err := s.accessibleTask(ctx, chatID, req.TaskID); err != nil {
+		return nil, err
+	}
+
+	userID, err := s.service.LabelUser(ctx, chatID, req.User)
+	if err != nil {
+		return nil, err
+	}
+
+	task, activeTask, err := s.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   chatID,
+		TaskID:   req.TaskID,
+		UserID:   userID,
+		Duration: req.Duration,
+		Source:   service.SourceAPI,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.timerResponse(ctx, task, activeTask)
+
+	return &response, nil
+}
+
+// Extends a running or paused timer
+func (s *Server) extendTimer(ctx context.Context, chatID int64, req *models.ExtendTimerRequest) (*models.TimerResponse, error) {
+	if _, err := s.accessibleTask(ctx, chatID, req.TaskID); err != nil {
+		return nil, err
+	}
+
+	task, activeTask, err := s.service.ExtendTimer(ctx, chatID, req.TaskID, req.Minutes, service.SourceAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.timerResponse(ctx, task, activeTask)
+
+	return &response, nil
+}
+
+// Releases a task as done or cancelled
+func (s *Server) releaseTimer(ctx context.Context, chatID int64, req *models.ReleaseTimerRequest) (*models.TaskStatusResponse, error) {
+	if req.Outcome != "" && req.Outcome != outcomeDone && req.Outcome != outcomeCancel {
+		return nil, &requestError{message: "Invalid outcome, expected 'done' or 'cancel'"}
+	}
+
+	if _, err := s.accessibleTask(ctx, chatID, req.TaskID); err != nil {
+		return nil, err
+	}
+
+	done := req.Outcome != outcomeCancel
+
+	task, _, err := s.service.ReleaseTimer(ctx, chatID, req.TaskID, done, service.SourceAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskStatusResponse{
+		Status:   "free",
+		TaskName: task.Name,
+	}, nil
+}
+
+// Builds the response describing a timer
+func (s *Server) timerResponse(ctx context.Context, task *models.Task, activeTask *models.ActiveTask) models.TimerResponse {
+	return models.TimerResponse{
+		TaskID:    task.ID,
+		TaskName:  task.Name,
+		HolderID:  activeTask.UserID,
+		Holder:    s.holderName(ctx, activeTask.ChatID, activeTask.UserID),
+		Duration:  activeTask.Duration,
+		StartTime: activeTask.StartTime,
+		EndTime:   activeTask.EndTime,
+		Remaining: activeTask.TimeRemaining(),
+		Paused:    activeTask.IsPaused(),
+		Leased:    activeTask.IsLeased(),
+	}
+}
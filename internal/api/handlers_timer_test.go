@@ -0,0 +1,312 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Creates a write request of chat 12345 with a JSON body
+func newWriteRequest(target string, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), ChatIDKey, int64(12345))
+
+	return req.WithContext(ctx)
+}
+
+// Sets up the mock storage around a single task, keeping its timer in memory
+func mockTimerStorage(mockStorage *MockStorage, task *models.Task) {
+	var active *models.ActiveTask
+
+	mockStorage.GetTaskFunc = func(ctx context.Context, chatID int64, taskID string) (*models.Task, error) {
+		if taskID != task.ID {
+			return nil, redis.ErrNotFound
+		}
+
+		copied := *task
+
+		return &copied, nil
+	}
+	mockStorage.CheckConflictsFunc = func(ctx context.Context, chatID int64, taskID string) error {
+		return nil
+	}
+	mockStorage.GetCountUserActiveTasksFunc = func(ctx context.Context, chatID int64, userID int64) (int64, error) {
+		return 0, nil
+	}
+	mockStorage.SaveChatUserFunc = func(ctx context.Context, chatID int64, user *models.ChatUser) error {
+		return nil
+	}
+	mockStorage.StartTaskFunc = func(ctx context.Context, activeTask *models.ActiveTask) error {
+		active = activeTask
+		task.OwnerID = activeTask.UserID
+
+		return nil
+	}
+	mockStorage.GetActiveTaskFunc = func(ctx context.Context, chatID int64, taskID string) (*models.ActiveTask, error) {
+		if active == nil || taskID != task.ID {
+			return nil, redis.ErrNotFound
+		}
+
+		return active, nil
+	}
+	mockStorage.UpdateActiveTaskFunc = func(ctx context.Context, activeTask *models.ActiveTask) error {
+		active = activeTask
+		return nil
+	}
+	mockStorage.EndTaskFunc = func(ctx context.Context, chatID int64, taskID string) error {
+		active = nil
+		task.OwnerID = 0
+
+		return nil
+	}
+}
+
+func TestHandleTimerStart(t *testing.T) {
+	server, mockStorage := createTestServer()
+	mockTimerStorage(mockStorage, &models.Task{ID: "task1", Name: "deploy", ChatID: 12345})
+
+	var events []*service.Event
+
+	server.service.Subscribe(func(ctx context.Context, event *service.Event) {
+		events = append(events, event)
+	})
+
+	t.Run("Method Not Allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/timer/start", nil)
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+
+	t.Run("Invalid Body", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, newWriteRequest("/api/timer/start", `{"task_id": 1}`))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Missing User Label", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, newWriteRequest("/api/timer/start", `{"task_id": "task1", "duration": 30}`))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Task Not Found", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, newWriteRequest("/api/timer/start", `{"task_id": "nope", "user": "ci", "duration": 30}`))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("Duration Too Long", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, newWriteRequest("/api/timer/start", `{"task_id": "task1", "user": "ci", "duration": 100000}`))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Restricted Key", func(t *testing.T) {
+		key := &models.APIKey{Scopes: []string{models.ScopeTimersWrite}, TaskPatterns: []string{"staging-*"}}
+
+		req := newWriteRequest("/api/timer/start", `{"task_id": "task1", "user": "ci", "duration": 30}`)
+		req = req.WithContext(context.WithValue(req.Context(), APIKeyKey, key))
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, req)
+
+		// Задача вне шаблонов ключа выглядит как несуществующая
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, newWriteRequest("/api/timer/start", `{"task_id": "task1", "user": "ci", "duration": 30}`))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		var response models.TimerResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if response.TaskName != "deploy" || response.Duration != 30 || response.HolderID != service.LabelUserID("ci") {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+
+		if len(events) != 1 || events[0].Type != service.EventTimerStarted || events[0].Source != service.SourceAPI {
+			t.Errorf("Expected one timer.started event from api, got %v", events)
+		}
+	})
+
+	t.Run("Task Busy", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerStart(rec, newWriteRequest("/api/timer/start", `{"task_id": "task1", "user": "other", "duration": 30}`))
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+}
+
+func TestHandleTimerExtendAndRelease(t *testing.T) {
+	server, mockStorage := createTestServer()
+	mockTimerStorage(mockStorage, &models.Task{ID: "task1", Name: "deploy", ChatID: 12345})
+
+	t.Run("Extend Not Active", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerExtend(rec, newWriteRequest("/api/timer/extend", `{"task_id": "task1", "minutes": 10}`))
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	server.handleTimerStart(rec, newWriteRequest("/api/timer/start", `{"task_id": "task1", "user": "ci", "duration": 30}`))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to start timer: %d %s", rec.Code, rec.Body.String())
+	}
+
+	t.Run("Extend", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerExtend(rec, newWriteRequest("/api/timer/extend", `{"task_id": "task1", "minutes": 10}`))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var response models.TimerResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if response.Duration != 40 {
+			t.Errorf("Expected duration 40, got %d", response.Duration)
+		}
+	})
+
+	t.Run("Release Invalid Outcome", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerRelease(rec, newWriteRequest("/api/timer/release", `{"task_id": "task1", "outcome": "maybe"}`))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTimerRelease(rec, newWriteRequest("/api/timer/release", `{"task_id": "task1", "outcome": "cancel"}`))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var response models.TaskStatusResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if response.Status != "free" {
+			t.Errorf("Expected status 'free', got %q", response.Status)
+		}
+	})
+}
+
+func TestHandleTaskLockUnlock(t *testing.T) {
+	server, mockStorage := createTestServer()
+
+	task := &models.Task{ID: "task1", Name: "deploy", ChatID: 12345}
+	mockTimerStorage(mockStorage, task)
+
+	mockStorage.UpdateTaskFunc = func(ctx context.Context, updated *models.Task) error {
+		*task = *updated
+		return nil
+	}
+
+	t.Run("Lock", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTaskLock(rec, newWriteRequest("/api/task/lock", `{"task_id": "task1", "reason": "release freeze"}`))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		if !task.IsLocked || task.LockReason != "release freeze" {
+			t.Errorf("Expected task to be locked with reason, got %+v", task)
+		}
+	})
+
+	t.Run("Lock Again", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTaskLock(rec, newWriteRequest("/api/task/lock", `{"task_id": "task1"}`))
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("Unlock", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleTaskUnlock(rec, newWriteRequest("/api/task/unlock", `{"task_id": "task1"}`))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		if task.IsLocked {
+			t.Error("Expected task to be unlocked")
+		}
+	})
+}
@@ -0,0 +1,313 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	// Number of tasks per page when the request doesn't give a limit
+	defaultTaskPageLimit = 50
+	// Maximum number of tasks per page
+	maxTaskPageLimit = 200
+)
+
+// Task statuses that can be used as filters
+var taskStatuses = []string{"free", "busy", "locked"}
+
+// Orders of tasks, by the value of the sort parameter without the "-" prefix
+var taskOrders = map[string]taskOrder{
+	"name": {compare: func(a, b models.TaskState) int {
+		return strings.Compare(a.Name, b.Name)
+	}},
+	"status": {compare: func(a, b models.TaskState) int {
+		return strings.Compare(a.Status, b.Status)
+	}},
+	"remaining": {timed: true, compare: func(a, b models.TaskState) int {
+		return cmp.Compare(a.Timer.Remaining, b.Timer.Remaining)
+	}},
+	"end_time": {timed: true, compare: func(a, b models.TaskState) int {
+		return a.Timer.EndTime.Compare(b.Timer.EndTime)
+	}},
+}
+
+// Compares two tasks for sorting
+type taskOrder struct {
+	compare func(a, b models.TaskState) int
+	// Compares timers, tasks without a timer go last in both directions
+	timed bool
+}
+
+// @Summary List tasks
+// @Description Returns a page of tasks with their holder and timer. Tasks can be filtered by status and name prefix and sorted
+// @ID list-tasks-v1
+// @Tags tasks
+// @Produce json
+// @Param status query string false "Comma-separated statuses to include: free, busy, locked"
+// @Param name_prefix query string false "Only tasks whose name starts with the prefix, case-insensitive"
+// @Param sort query string false "name (default), status, remaining or end_time, prefix with - for descending order"
+// @Param limit query int false "Tasks per page, 50 by default, at most 200"
+// @Param offset query int false "Number of tasks to skip"
+// @Success 200 {object} models.TaskPageResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid filter, sort or pagination parameter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:read scope"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /v1/tasks [get]
+func (s *Server) handleTasksV1(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	query, err := parseTaskQuery(r)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	snapshot, err := s.snapshot(r.Context(), chatID)
+	if err != nil {
+		log.Printf("Failed to list tasks: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	tasks := slices.DeleteFunc(snapshot.Tasks, func(task models.TaskState) bool {
+		return !query.matches(task)
+	})
+
+	slices.SortStableFunc(tasks, query.compare)
+
+	page := models.TaskPageResponse{
+		Tasks:  []models.TaskState{},
+		Total:  len(tasks),
+		Limit:  query.limit,
+		Offset: query.offset,
+	}
+
+	if query.offset < len(tasks) {
+		end := min(query.offset+query.limit, len(tasks))
+		page.Tasks = tasks[query.offset:end]
+
+		if end < len(tasks) {
+			page.NextOffset = end
+		}
+	}
+
+	sendJSON(w, page)
+}
+
+// @Summary Get a task
+// @Description Returns a task with its holder and timer
+// @ID get-task-v1
+// @Tags tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} models.TaskState
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the tasks:read scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found (task_not_found)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /v1/tasks/{id} [get]
+func (s *Server) handleTaskV1(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	task, err := s.accessibleTask(r.Context(), chatID, r.PathValue("id"))
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	activeTask, err := s.storage.GetActiveTask(r.Context(), chatID, task.ID)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		log.Printf("Failed to get active task: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	watchers, err := s.storage.CountTaskWatchers(r.Context(), chatID, []string{task.ID})
+	if err != nil {
+		log.Printf("Failed to count task watchers: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	sendJSON(w, s.taskState(r.Context(), task, activeTask, watchers[task.ID]))
+}
+
+// @Summary Get session notes of a task
+// @Description Returns the notes attached by replies to the running timer of a task, the last 20 are kept
+// @ID get-task-notes-v1
+// @Tags tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} models.TaskNotesResponse
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the history:read scope"
+// @Failure 404 {object} models.ErrorResponse "Task not found (task_not_found)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /v1/tasks/{id}/notes [get]
+func (s *Server) handleTaskNotesV1(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	task, err := s.accessibleTask(r.Context(), chatID, r.PathValue("id"))
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	response := models.TaskNotesResponse{TaskID: task.ID, Notes: []models.Note{}}
+
+	activeTask, err := s.storage.GetActiveTask(r.Context(), chatID, task.ID)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		log.Printf("Failed to get active task: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	if activeTask != nil && len(activeTask.Notes) > 0 {
+		response.Notes = activeTask.Notes
+	}
+
+	sendJSON(w, response)
+}
+
+// Represents the filters, order and page of a task list request
+type taskQuery struct {
+	statuses   []string
+	namePrefix string
+	order      taskOrder
+	descending bool
+	limit      int
+	offset     int
+}
+
+// Reads the task list parameters of a request
+func parseTaskQuery(r *http.Request) (*taskQuery, error) {
+	params := r.URL.Query()
+	query := &taskQuery{
+		namePrefix: strings.ToLower(params.Get("name_prefix")),
+		order:      taskOrders["name"],
+		limit:      defaultTaskPageLimit,
+	}
+
+	if status := params.Get("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			if !slices.Contains(taskStatuses, s) {
+				return nil, &requestError{message: fmt.Sprintf("Invalid status %q, expected free, busy or locked", s)}
+			}
+
+			query.statuses = append(query.statuses, s)
+		}
+	}
+
+	if sort := params.Get("sort"); sort != "" {
+		field, descending := strings.CutPrefix(sort, "-")
+
+		order, ok := taskOrders[field]
+		if !ok {
+			return nil, &requestError{message: fmt.Sprintf("Invalid sort %q, expected name, status, remaining or end_time", sort)}
+		}
+
+		query.order = order
+		query.descending = descending
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxTaskPageLimit {
+			return nil, &requestError{message: fmt.Sprintf("Invalid limit, expected a number from 1 to %d", maxTaskPageLimit)}
+		}
+
+		query.limit = value
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return nil, &requestError{message: "Invalid offset, expected a non-negative number"}
+		}
+
+		query.offset = value
+	}
+
+	return query, nil
+}
+
+// Reports whether a task passes the filters
+func (q *taskQuery) matches(task models.TaskState) bool {
+	if len(q.statuses) > 0 && !slices.Contains(q.statuses, task.Status) {
+		return false
+	}
+
+	return strings.HasPrefix(strings.ToLower(task.Name), q.namePrefix)
+}
+
+// Compares tasks in the requested order
+func (q *taskQuery) compare(a, b models.TaskState) int {
+	if q.order.timed {
+		switch {
+		case a.Timer == nil && b.Timer == nil:
+			return 0
+		case a.Timer == nil:
+			return 1
+		case b.Timer == nil:
+			return -1
+		}
+	}
+
+	if q.descending {
+		return q.order.compare(b, a)
+	}
+
+	return q.order.compare(a, b)
+}
+
+// Marks a v0 endpoint as deprecated in favor of its v1 successor
+func deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Sets up the mock storage with tasks and timers for the v1 list
+func mockTaskListStorage(mockStorage *MockStorage) {
+	now := time.Now()
+	tasks := []*models.Task{
+		{ID: "t1", Name: "staging-2", ChatID: 12345},
+		{ID: "t2", Name: "staging-1", ChatID: 12345},
+		{ID: "t3", Name: "prod", ChatID: 12345, IsLocked: true, LockReason: "release"},
+		{ID: "t4", Name: "Staging-3", ChatID: 12345},
+		{ID: "t5", Name: "db", ChatID: 12345},
+	}
+	activeTasks := []*models.ActiveTask{
+		{TaskID: "t1", UserID: 12345, ChatID: 12345, StartTime: now, EndTime: now.Add(10 * time.Minute), Duration: 10, Notes: []models.Note{
+			{UserID: 12345, Text: "migrations applied", CreatedAt: now},
+		}},
+		{TaskID: "t4", UserID: 12345, ChatID: 12345, StartTime: now, EndTime: now.Add(40 * time.Minute), Duration: 40},
+	}
+
+	mockStorage.ListTasksFunc = func(ctx context.Context, chatID int64) ([]*models.Task, error) {
+		return tasks, nil
+	}
+	mockStorage.GetActiveTasksFunc = func(ctx context.Context, chatID int64) ([]*models.ActiveTask, error) {
+		return activeTasks, nil
+	}
+	mockStorage.GetTaskFunc = func(ctx context.Context, chatID int64, taskID string) (*models.Task, error) {
+		for _, task := range tasks {
+			if task.ID == taskID {
+				return task, nil
+			}
+		}
+
+		return nil, redis.ErrNotFound
+	}
+	mockStorage.GetActiveTaskFunc = func(ctx context.Context, chatID int64, taskID string) (*models.ActiveTask, error) {
+		for _, activeTask := range activeTasks {
+			if activeTask.TaskID == taskID {
+				return activeTask, nil
+			}
+		}
+
+		return nil, redis.ErrNotFound
+	}
+}
+
+func TestHandleTasksV1(t *testing.T) {
+	server, mockStorage := createTestServer()
+	mockTaskListStorage(mockStorage)
+
+	list := func(t *testing.T, query string) models.TaskPageResponse {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+
+		server.handleTasksV1(rec, newTaskRequest(http.MethodGet, "/api/v1/tasks"+query, ""))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		var page models.TaskPageResponse
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		return page
+	}
+
+	names := func(page models.TaskPageResponse) []string {
+		result := make([]string, 0, len(page.Tasks))
+		for _, task := range page.Tasks {
+			result = append(result, task.Name)
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"Default Order", "", []string{"Staging-3", "db", "prod", "staging-1", "staging-2"}},
+		{"Status Filter", "?status=free,locked", []string{"db", "prod", "staging-1"}},
+		{"Name Prefix", "?name_prefix=staging", []string{"Staging-3", "staging-1", "staging-2"}},
+		{"Descending", "?sort=-name&name_prefix=staging", []string{"staging-2", "staging-1", "Staging-3"}},
+		// Задачи без таймера всегда в конце
+		{"Remaining", "?sort=remaining", []string{"staging-2", "Staging-3", "db", "prod", "staging-1"}},
+		{"Remaining Descending", "?sort=-remaining", []string{"Staging-3", "staging-2", "db", "prod", "staging-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(list(t, tt.query))
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("Expected %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+
+	t.Run("Details", func(t *testing.T) {
+		page := list(t, "?status=busy&sort=end_time")
+
+		busy := page.Tasks[0]
+		if busy.ID != "t1" || busy.Status != "busy" || busy.Timer == nil {
+			t.Fatalf("Expected busy task t1 with a timer, got %+v", busy)
+		}
+
+		if busy.Timer.Holder != "Alice" || busy.Timer.Remaining <= 0 || busy.Timer.EndTime.IsZero() {
+			t.Errorf("Expected holder and remaining time, got %+v", busy.Timer)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		page := list(t, "?limit=2&offset=1")
+
+		if page.Total != 5 || page.Limit != 2 || page.Offset != 1 || page.NextOffset != 3 || len(page.Tasks) != 2 {
+			t.Errorf("Unexpected page: %+v", page)
+		}
+
+		last := list(t, "?limit=2&offset=4")
+		if len(last.Tasks) != 1 || last.NextOffset != 0 {
+			t.Errorf("Expected the last page without next offset, got %+v", last)
+		}
+
+		empty := list(t, "?offset=10")
+		if empty.Tasks == nil || len(empty.Tasks) != 0 || empty.Total != 5 {
+			t.Errorf("Expected an empty page, got %+v", empty)
+		}
+	})
+
+	t.Run("Invalid Parameters", func(t *testing.T) {
+		for _, query := range []string{"?status=busy,idle", "?sort=holder", "?limit=0", "?limit=500", "?offset=-1"} {
+			rec := httptest.NewRecorder()
+
+			server.handleTasksV1(rec, newTaskRequest(http.MethodGet, "/api/v1/tasks"+query, ""))
+
+			checkErrorCode(t, rec, http.StatusBadRequest, models.ErrorCodeBadRequest)
+		}
+	})
+
+	t.Run("Restricted Key", func(t *testing.T) {
+		req := newTaskRequest(http.MethodGet, "/api/v1/tasks", "")
+		key := &models.APIKey{Scopes: []string{models.ScopeTasksRead}, TaskPatterns: []string{"staging-*"}}
+		req = req.WithContext(context.WithValue(req.Context(), APIKeyKey, key))
+		rec := httptest.NewRecorder()
+
+		server.handleTasksV1(rec, req)
+
+		var page models.TaskPageResponse
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if got := names(page); len(got) != 2 || page.Total != 2 {
+			t.Errorf("Expected only staging-1 and staging-2, got %v", got)
+		}
+	})
+}
+
+func TestHandleTaskV1(t *testing.T) {
+	server, mockStorage := createTestServer()
+	mockTaskListStorage(mockStorage)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req := newTaskRequest(http.MethodGet, "/api/v1/tasks/"+id, "")
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+
+		server.handleTaskV1(rec, req)
+
+		return rec
+	}
+
+	t.Run("Locked", func(t *testing.T) {
+		rec := get("t3")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var task models.TaskState
+		if err := json.NewDecoder(rec.Body).Decode(&task); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if task.ID != "t3" || task.Status != "locked" || task.LockReason != "release" || task.Timer != nil {
+			t.Errorf("Unexpected task: %+v", task)
+		}
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		// Очередь - пользователи, ждущие освобождения задачи через /notify watch
+		mockStorage.CountTaskWatchersFunc = func(ctx context.Context, chatID int64, taskIDs []string) (map[string]int64, error) {
+			if len(taskIDs) != 1 || taskIDs[0] != "t1" {
+				t.Errorf("Expected watchers of t1 to be counted, got %v", taskIDs)
+			}
+
+			return map[string]int64{"t1": 2}, nil
+		}
+
+		rec := get("t1")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var task models.TaskState
+		if err := json.NewDecoder(rec.Body).Decode(&task); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if task.Status != "busy" || task.Capacity != 1 || task.QueueLength != 2 {
+			t.Errorf("Expected a busy task with capacity 1 and 2 waiting, got %+v", task)
+		}
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		checkErrorCode(t, get("missing"), http.StatusNotFound, models.ErrorCodeTaskNotFound)
+	})
+}
+
+func TestHandleTaskNotesV1(t *testing.T) {
+	server, mockStorage := createTestServer()
+	mockTaskListStorage(mockStorage)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req := newTaskRequest(http.MethodGet, "/api/v1/tasks/"+id+"/notes", "")
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+
+		server.handleTaskNotesV1(rec, req)
+
+		return rec
+	}
+
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) models.TaskNotesResponse {
+		t.Helper()
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var notes models.TaskNotesResponse
+		if err := json.NewDecoder(rec.Body).Decode(&notes); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		return notes
+	}
+
+	t.Run("Busy", func(t *testing.T) {
+		notes := decode(t, get("t1"))
+		if notes.TaskID != "t1" || len(notes.Notes) != 1 || notes.Notes[0].Text != "migrations applied" {
+			t.Errorf("Unexpected notes: %+v", notes)
+		}
+	})
+
+	t.Run("Free", func(t *testing.T) {
+		// Свободная задача отдает пустой список, а не null
+		rec := get("t2")
+		if body := rec.Body.String(); !strings.Contains(body, `"notes":[]`) {
+			t.Errorf("Expected an empty list of notes, got %s", body)
+		}
+
+		if notes := decode(t, rec); len(notes.Notes) != 0 {
+			t.Errorf("Expected no notes, got %+v", notes)
+		}
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		checkErrorCode(t, get("missing"), http.StatusNotFound, models.ErrorCodeTaskNotFound)
+	})
+
+	t.Run("Missing Scope", func(t *testing.T) {
+		// Ключ с tasks:read не читает заметки сессий
+		const secretKey = "tgb_abcd1234_c2VjcmV0"
+
+		mockStorage.GetAPIKeyByHashFunc = func(ctx context.Context, hash string) (*models.APIKey, error) {
+			if hash != helpers.HashAPIKey(secretKey) {
+				return nil, redis.ErrNotFound
+			}
+
+			return &models.APIKey{ID: "abcd1234", ChatID: 12345, Scopes: []string{models.ScopeTasksRead}}, nil
+		}
+		mockStorage.TouchAPIKeyFunc = func(ctx context.Context, keyID string, usedAt time.Time) error {
+			return nil
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t1/notes", nil)
+		req.Header.Set("Authorization", "Bearer "+secretKey)
+		rec := httptest.NewRecorder()
+
+		server.authMiddleware(models.ScopeHistoryRead, server.handleTaskNotesV1)(rec, req)
+		checkErrorCode(t, rec, http.StatusForbidden, models.ErrorCodeMissingScope)
+	})
+}
+
+func TestDeprecatedEndpoints(t *testing.T) {
+	handler := deprecated("/api/v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/task/list", nil))
+
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Link") != `</api/v1/tasks>; rel="successor-version"` {
+		t.Errorf("Expected deprecation headers, got %v", rec.Header())
+	}
+}
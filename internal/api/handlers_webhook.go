@@ -0,0 +1,249 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	// Number of deliveries returned when the request doesn't give a limit
+	defaultDeliveriesLimit = 20
+	// Maximum number of deliveries returned at once
+	maxDeliveriesLimit = 50
+)
+
+// Handles list, create and delete requests for webhooks
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	// Webhooks receive events of all tasks, keys restricted to some tasks can't manage them
+	if key, ok := GetAPIKeyFromContext(r.Context()); ok && len(key.TaskPatterns) > 0 {
+		sendJSONErrorCode(w, models.ErrorCodeForbidden, "API keys restricted to tasks can't manage webhooks", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleWebhookList(w, r)
+	case http.MethodPost:
+		s.handleWebhookCreate(w, r)
+	case http.MethodDelete:
+		s.handleWebhookDelete(w, r)
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// @Summary List webhooks
+// @Description Returns the webhooks of the chat without their secrets
+// @ID list-webhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.WebhookResponse
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the webhooks:manage scope or is restricted to tasks"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks [get]
+func (s *Server) handleWebhookList(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	webhooks, err := s.storage.ListWebhooks(r.Context(), chatID)
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	response := make([]models.WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, webhookResponse(webhook))
+	}
+
+	sendJSON(w, response)
+}
+
+// @Summary Add a webhook
+// @Description Registers a URL that receives task events of the chat as signed JSON
+// @Description The secret is only returned here, requests carry X-TimeGuard-Signature: sha256=HMAC(secret, "{X-TimeGuard-Timestamp}.{body}")
+// @ID create-webhook
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWebhookRequest true "Webhook to add"
+// @Success 201 {object} models.WebhookResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid URL or event (invalid_webhook)"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the webhooks:manage scope or is restricted to tasks"
+// @Failure 409 {object} models.ErrorResponse "Too many webhooks (webhook_limit_reached)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks [post]
+func (s *Server) handleWebhookCreate(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+
+	chatID, ok := readRequestBody(w, r, &req)
+	if !ok {
+		return
+	}
+
+	webhook, err := s.service.AddWebhook(r.Context(), chatID, req.URL, req.Events, 0)
+	if err != nil {
+		sendServiceError(w, err)
+		return
+	}
+
+	response := webhookResponse(webhook)
+	response.Secret = webhook.Secret
+
+	w.WriteHeader(http.StatusCreated)
+	sendJSON(w, response)
+}
+
+// @Summary Remove a webhook
+// @Description Removes a webhook, its pending deliveries are marked as failed
+// @ID delete-webhook
+// @Tags webhooks
+// @Produce json
+// @Param id query string true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse "Missing id parameter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the webhooks:manage scope or is restricted to tasks"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found (webhook_not_found)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks [delete]
+func (s *Server) handleWebhookDelete(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	webhookID := r.URL.Query().Get("id")
+	if webhookID == "" {
+		sendJSONError(w, "Missing required parameter: id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.storage.DeleteWebhook(r.Context(), chatID, webhookID); err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			sendJSONErrorCode(w, models.ErrorCodeWebhookNotFound, "Webhook not found", http.StatusNotFound)
+			return
+		}
+
+		log.Printf("Failed to delete webhook: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary List webhook deliveries
+// @Description Returns the recent deliveries of a webhook with all their attempts, newest first
+// @ID list-webhook-deliveries
+// @Tags webhooks
+// @Produce json
+// @Param id query string true "Webhook ID"
+// @Param limit query int false "Maximum number of deliveries, 20 by default, at most 50"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} models.ErrorResponse "Missing id parameter or invalid limit"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the webhooks:manage scope or is restricted to tasks"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found (webhook_not_found)"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Router /webhooks/deliveries [get]
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if key, ok := GetAPIKeyFromContext(r.Context()); ok && len(key.TaskPatterns) > 0 {
+		sendJSONErrorCode(w, models.ErrorCodeForbidden, "API keys restricted to tasks can't manage webhooks", http.StatusForbidden)
+		return
+	}
+
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	webhookID := r.URL.Query().Get("id")
+	if webhookID == "" {
+		sendJSONError(w, "Missing required parameter: id", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+			sendJSONError(w, "Invalid limit, expected a number from 1 to 50", http.StatusBadRequest)
+			return
+		}
+	}
+
+	webhook, err := s.storage.GetWebhook(r.Context(), webhookID)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		log.Printf("Failed to get webhook: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	// Webhooks of other chats look like missing ones
+	if err != nil || webhook.ChatID != chatID {
+		sendJSONErrorCode(w, models.ErrorCodeWebhookNotFound, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	deliveries, err := s.storage.ListDeliveries(r.Context(), webhookID, limit)
+	if err != nil {
+		log.Printf("Failed to list webhook deliveries: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+		return
+	}
+
+	sendJSON(w, deliveries)
+}
+
+// Converts a webhook to its API representation without the secret
+func webhookResponse(webhook *models.Webhook) models.WebhookResponse {
+	return models.WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
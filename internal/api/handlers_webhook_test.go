@@ -0,0 +1,226 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Sets up the mock storage to keep webhooks in memory
+func mockWebhookStorage(mockStorage *MockStorage, webhooks map[string]*models.Webhook) {
+	mockStorage.AddWebhookFunc = func(ctx context.Context, webhook *models.Webhook) error {
+		webhooks[webhook.ID] = webhook
+		return nil
+	}
+	mockStorage.GetWebhookFunc = func(ctx context.Context, webhookID string) (*models.Webhook, error) {
+		webhook, ok := webhooks[webhookID]
+		if !ok {
+			return nil, redis.ErrNotFound
+		}
+
+		return webhook, nil
+	}
+	mockStorage.ListWebhooksFunc = func(ctx context.Context, chatID int64) ([]*models.Webhook, error) {
+		var result []*models.Webhook
+
+		for _, webhook := range webhooks {
+			if webhook.ChatID == chatID {
+				result = append(result, webhook)
+			}
+		}
+
+		return result, nil
+	}
+	mockStorage.DeleteWebhookFunc = func(ctx context.Context, chatID int64, webhookID string) error {
+		webhook, ok := webhooks[webhookID]
+		if !ok || webhook.ChatID != chatID {
+			return redis.ErrNotFound
+		}
+
+		delete(webhooks, webhookID)
+
+		return nil
+	}
+}
+
+func TestHandleWebhooks(t *testing.T) {
+	server, mockStorage := createTestServer()
+
+	webhooks := map[string]*models.Webhook{
+		"other001": {ID: "other001", ChatID: 777, URL: "https://example.com/other", Secret: "s"},
+	}
+	mockWebhookStorage(mockStorage, webhooks)
+
+	var created models.WebhookResponse
+
+	t.Run("Create", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		body := `{"url": "https://example.com/hook", "events": ["timer.started", "timer.expired"]}`
+
+		server.handleWebhooks(rec, newTaskRequest(http.MethodPost, "/api/webhooks", body))
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if !strings.HasPrefix(created.Secret, helpers.WebhookSecretPrefix) || len(created.Events) != 2 {
+			t.Errorf("Unexpected response: %+v", created)
+		}
+
+		if webhooks[created.ID] == nil || webhooks[created.ID].ChatID != 12345 {
+			t.Errorf("Expected webhook to be saved for chat 12345")
+		}
+	})
+
+	t.Run("Create Invalid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"url": "ftp://example.com"}`,
+			`{"url": "https://example.com", "events": ["task.renamed"]}`,
+		} {
+			rec := httptest.NewRecorder()
+
+			server.handleWebhooks(rec, newTaskRequest(http.MethodPost, "/api/webhooks", body))
+
+			checkErrorCode(t, rec, http.StatusBadRequest, models.ErrorCodeInvalidWebhook)
+		}
+	})
+
+	t.Run("List Without Secrets", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleWebhooks(rec, newTaskRequest(http.MethodGet, "/api/webhooks", ""))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var response []models.WebhookResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if len(response) != 1 || response[0].ID != created.ID || response[0].Secret != "" {
+			t.Errorf("Expected only the chat's webhook without secret, got %+v", response)
+		}
+	})
+
+	t.Run("Restricted Key", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := newTaskRequest(http.MethodGet, "/api/webhooks", "")
+		key := &models.APIKey{Scopes: []string{models.ScopeWebhooks}, TaskPatterns: []string{"staging-*"}}
+		req = req.WithContext(context.WithValue(req.Context(), APIKeyKey, key))
+
+		server.handleWebhooks(rec, req)
+
+		checkErrorCode(t, rec, http.StatusForbidden, models.ErrorCodeForbidden)
+	})
+
+	t.Run("Delete Other Chat", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleWebhooks(rec, newTaskRequest(http.MethodDelete, "/api/webhooks?id=other001", ""))
+
+		checkErrorCode(t, rec, http.StatusNotFound, models.ErrorCodeWebhookNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleWebhooks(rec, newTaskRequest(http.MethodDelete, "/api/webhooks?id="+created.ID, ""))
+
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+		}
+
+		if _, ok := webhooks[created.ID]; ok {
+			t.Error("Expected webhook to be deleted")
+		}
+	})
+}
+
+func TestHandleWebhookDeliveries(t *testing.T) {
+	server, mockStorage := createTestServer()
+
+	mockWebhookStorage(mockStorage, map[string]*models.Webhook{
+		"hook0001": {ID: "hook0001", ChatID: 12345, URL: "https://example.com/hook"},
+		"other001": {ID: "other001", ChatID: 777, URL: "https://example.com/other"},
+	})
+
+	var requestedLimit int
+
+	mockStorage.ListDeliveriesFunc = func(ctx context.Context, webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+		requestedLimit = limit
+
+		return []*models.WebhookDelivery{{
+			ID:        "d1",
+			WebhookID: webhookID,
+			Event:     "timer.started",
+			Payload:   json.RawMessage(`{"id":"d1"}`),
+			Status:    models.DeliveryFailed,
+			Attempts:  []models.DeliveryAttempt{{StatusCode: 500, Error: "unexpected status 500"}},
+		}}, nil
+	}
+
+	t.Run("List", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleWebhookDeliveries(rec, newTaskRequest(http.MethodGet, "/api/webhooks/deliveries?id=hook0001&limit=5", ""))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		var response []models.WebhookDelivery
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		if len(response) != 1 || len(response[0].Attempts) != 1 || response[0].Attempts[0].StatusCode != 500 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+
+		if requestedLimit != 5 {
+			t.Errorf("Expected limit 5, got %d", requestedLimit)
+		}
+	})
+
+	t.Run("Invalid Limit", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleWebhookDeliveries(rec, newTaskRequest(http.MethodGet, "/api/webhooks/deliveries?id=hook0001&limit=500", ""))
+
+		checkErrorCode(t, rec, http.StatusBadRequest, models.ErrorCodeBadRequest)
+	})
+
+	t.Run("Other Chat", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		server.handleWebhookDeliveries(rec, newTaskRequest(http.MethodGet, "/api/webhooks/deliveries?id=other001", ""))
+
+		checkErrorCode(t, rec, http.StatusNotFound, models.ErrorCodeWebhookNotFound)
+	})
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"time-guard-bot/internal/discord"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/ratelimit"
+	"time-guard-bot/internal/slack"
+)
+
+// Represents rate limits of the API
+type RateLimitConfig struct {
+	// Limit per client IP, applied to every request before authentication, zero disables it
+	IP ratelimit.Limit
+	// Limits per API key tier, keys with an unknown tier get the ratelimit.DefaultTier one
+	Tiers map[string]ratelimit.Limit
+	// Keeps token buckets, in memory or shared between replicas
+	Limiter ratelimit.Limiter
+	// Take the client IP from the last X-Forwarded-For entry, only behind a trusted proxy
+	TrustProxy bool
+}
+
+// Paths of the probes and the metrics, not limited per IP
+var unlimitedPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Returns the limit of the API key tier
+func (c *RateLimitConfig) tierLimit(tier string) ratelimit.Limit {
+	if limit, ok := c.Tiers[tier]; ok {
+		return limit
+	}
+
+	return c.Tiers[ratelimit.DefaultTier]
+}
+
+// Limits requests per client IP
+func (s *Server) ipRateLimit(next http.Handler) http.Handler {
+	if s.rateLimit == nil || !s.rateLimit.IP.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Telegram, Slack and Discord deliver the updates of all chats from a few addresses,
+		// probes and the metrics scraper poll from one address all the time
+		if s.telegramHandler != nil && r.URL.Path == s.telegramPath ||
+			s.slackHandler != nil && strings.HasPrefix(r.URL.Path, slack.PathPrefix) ||
+			s.discordHandler != nil && strings.HasPrefix(r.URL.Path, discord.PathPrefix) ||
+			unlimitedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := "ip:" + s.clientIP(r)
+		if !s.allowRequest(r.Context(), w, key, s.rateLimit.IP) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Limits requests of an API key by its tier, legacy keys are limited per chat
+// Reports whether the request may proceed, otherwise the response is already sent
+func (s *Server) keyRateLimit(ctx context.Context, w http.ResponseWriter, key *models.APIKey, chatID int64) bool {
+	if s.rateLimit == nil {
+		return true
+	}
+
+	if key == nil {
+		return s.allowRequest(ctx, w, fmt.Sprintf("legacy:%d", chatID), s.rateLimit.tierLimit(ratelimit.DefaultTier))
+	}
+
+	return s.allowRequest(ctx, w, "key:"+key.ID, s.rateLimit.tierLimit(key.Tier))
+}
+
+// Takes a token for the request and sets the X-RateLimit-* headers
+// Sends 429 with Retry-After when the limit is exceeded. If the shared store
+// can't be reached, the request is let through: an unavailable limiter must not
+// take the API down. Any other limiter failure rejects the request
+func (s *Server) allowRequest(ctx context.Context, w http.ResponseWriter, key string, limit ratelimit.Limit) bool {
+	if !limit.Enabled() {
+		return true
+	}
+
+	res, err := s.rateLimit.Limiter.Allow(ctx, key, limit)
+	if errors.Is(err, ratelimit.ErrStoreUnavailable) {
+		log.Printf("Rate limiter unavailable, letting the request through: %v", err)
+		return true
+	}
+
+	if err != nil {
+		log.Printf("Rate limiter error: %v", err)
+
+		w.Header().Set("Retry-After", "1")
+		sendJSONErrorCode(w, models.ErrorCodeRateLimited, "Rate limit could not be checked, retry later", http.StatusTooManyRequests)
+
+		return false
+	}
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		sendJSONErrorCode(w, models.ErrorCodeRateLimited, fmt.Sprintf("Rate limit of %s exceeded", limit), http.StatusTooManyRequests)
+
+		return false
+	}
+
+	return true
+}
+
+// Returns the IP address of the client
+// Behind a trusted proxy it is the last X-Forwarded-For entry: the one the proxy appended.
+// Earlier entries come from the client and can be anything
+func (s *Server) clientIP(r *http.Request) string {
+	if s.rateLimit.TrustProxy {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := values[len(values)-1]
+			if last := strings.TrimSpace(forwarded[strings.LastIndex(forwarded, ",")+1:]); last != "" {
+				return last
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// Rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
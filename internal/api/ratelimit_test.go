@@ -0,0 +1,266 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/ratelimit"
+)
+
+func TestKeyRateLimit(t *testing.T) {
+	server, mockStorage := createTestServer()
+	server.rateLimit = &RateLimitConfig{
+		Tiers: map[string]ratelimit.Limit{
+			ratelimit.DefaultTier: {Requests: 1, Period: time.Minute},
+			"ci":                  {Requests: 2, Period: time.Minute},
+		},
+		Limiter: ratelimit.NewMemoryLimiter(),
+	}
+
+	keys := map[string]*models.APIKey{
+		helpers.HashAPIKey("tgb_ci000001_secret"): {ID: "ci000001", ChatID: 777, Scopes: []string{models.ScopeTasksRead}, Tier: "ci"},
+		helpers.HashAPIKey("tgb_plain001_secret"): {ID: "plain001", ChatID: 777, Scopes: []string{models.ScopeTasksRead}},
+		helpers.HashAPIKey("tgb_unknown1_secret"): {ID: "unknown1", ChatID: 777, Scopes: []string{models.ScopeTasksRead}, Tier: "gone"},
+	}
+	mockStorage.GetAPIKeyByHashFunc = func(ctx context.Context, hash string) (*models.APIKey, error) {
+		return keys[hash], nil
+	}
+	mockStorage.TouchAPIKeyFunc = func(ctx context.Context, keyID string, usedAt time.Time) error {
+		return nil
+	}
+
+	handler := server.authMiddleware(models.ScopeTasksRead, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		return rec
+	}
+
+	// Ключ тарифа ci получает 2 запроса в минуту
+	for i := range 2 {
+		rec := request("tgb_ci000001_secret")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i, http.StatusOK, rec.Code)
+		}
+
+		if rec.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Errorf("Expected X-RateLimit-Limit 2, got %q", rec.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+
+	rec := request("tgb_ci000001_secret")
+	checkErrorCode(t, rec, http.StatusTooManyRequests, models.ErrorCodeRateLimited)
+
+	if rec.Header().Get("Retry-After") != "30" || rec.Header().Get("X-RateLimit-Remaining") != "0" || rec.Header().Get("X-RateLimit-Reset") != "60" {
+		t.Errorf("Unexpected rate limit headers: %v", rec.Header())
+	}
+
+	// Ключ без тарифа и с неизвестным тарифом получают тариф по умолчанию, у каждого свой bucket
+	for _, apiKey := range []string{"tgb_plain001_secret", "tgb_unknown1_secret"} {
+		if rec := request(apiKey); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" {
+			t.Errorf("Expected first request of %s to pass with limit 1, got %d %v", apiKey, rec.Code, rec.Header())
+		}
+
+		checkErrorCode(t, request(apiKey), http.StatusTooManyRequests, models.ErrorCodeRateLimited)
+	}
+
+	// Legacy ключи ограничиваются по чату
+	legacyKey := helpers.GenerateAPIKey(12345)
+	if rec := request(legacyKey); rec.Code != http.StatusOK {
+		t.Errorf("Expected legacy key to pass, got %d", rec.Code)
+	}
+
+	checkErrorCode(t, request(legacyKey), http.StatusTooManyRequests, models.ErrorCodeRateLimited)
+}
+
+func TestIPRateLimit(t *testing.T) {
+	server, _ := createTestServer()
+	server.rateLimit = &RateLimitConfig{
+		IP:      ratelimit.Limit{Requests: 1, Period: time.Second},
+		Limiter: ratelimit.NewMemoryLimiter(),
+	}
+
+	handler := server.ipRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil)
+		req.RemoteAddr = remoteAddr
+
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	if code := request("10.0.0.1:1234", ""); code != http.StatusOK {
+		t.Errorf("Expected first request to pass, got %d", code)
+	}
+
+	// Порт не влияет на адрес клиента, заголовок прокси игнорируется без TrustProxy
+	if code := request("10.0.0.1:5678", "10.0.0.9"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected second request from the same IP to be limited, got %d", code)
+	}
+
+	if code := request("10.0.0.2:1234", ""); code != http.StatusOK {
+		t.Errorf("Expected request from another IP to pass, got %d", code)
+	}
+
+	// За доверенным прокси клиент определяется по последней записи X-Forwarded-For,
+	// которую добавил сам прокси
+	server.rateLimit.TrustProxy = true
+
+	if code := request("10.0.0.3:1234", "192.0.2.1"); code != http.StatusOK {
+		t.Errorf("Expected forwarded request to pass, got %d", code)
+	}
+
+	if code := request("10.0.0.4:1234", "192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected second request of forwarded client to be limited, got %d", code)
+	}
+
+	// Записи перед ней подставляет клиент, случайные значения не обходят лимит
+	if code := request("10.0.0.3:1234", "203.0.113.7, 192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected a spoofed first entry not to reset the limit, got %d", code)
+	}
+
+	if code := request("10.0.0.3:1234", "192.0.2.1, 192.0.2.2"); code != http.StatusOK {
+		t.Errorf("Expected a request of another forwarded client to pass, got %d", code)
+	}
+}
+
+func TestIPRateLimitChatApps(t *testing.T) {
+	server, _ := createTestServer()
+	server.rateLimit = &RateLimitConfig{
+		IP:      ratelimit.Limit{Requests: 1, Period: time.Second},
+		Limiter: ratelimit.NewMemoryLimiter(),
+	}
+	server.slackHandler = http.NotFoundHandler()
+	server.discordHandler = http.NotFoundHandler()
+
+	handler := server.ipRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Slack и Discord присылают запросы всех каналов с нескольких адресов
+	for _, path := range []string{"/slack/commands", "/discord/interactions"} {
+		for i := range 3 {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("Request %d to %s: expected not to be limited, got %d", i, path, rec.Code)
+			}
+		}
+	}
+}
+
+func TestIPRateLimitProbes(t *testing.T) {
+	server, _ := createTestServer()
+	server.rateLimit = &RateLimitConfig{
+		IP:      ratelimit.Limit{Requests: 1, Period: time.Second},
+		Limiter: ratelimit.NewMemoryLimiter(),
+	}
+
+	handler := server.ipRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Kubernetes и Prometheus опрашивают эти пути постоянно с одного адреса
+	for _, path := range []string{"/healthz", "/readyz", "/metrics"} {
+		for i := range 3 {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("Request %d to %s: expected not to be limited, got %d", i, path, rec.Code)
+			}
+		}
+	}
+
+	// Запросы к API с того же адреса по-прежнему ограничены
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("API request %d: expected %d, got %d", i, want, rec.Code)
+		}
+	}
+}
+
+func TestRateLimitStorageFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		// Недоступное хранилище не должно блокировать API
+		{"Unreachable", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, http.StatusOK},
+		// Любой другой сбой не должен снимать ограничение
+		{"Failed", errors.New("ERR script failed"), http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mockStorage := createTestServer()
+			mockStorage.TakeRateLimitTokenFunc = func(ctx context.Context, key string, requests int, period time.Duration, now time.Time) (bool, float64, error) {
+				return false, 0, tt.err
+			}
+			server.rateLimit = &RateLimitConfig{
+				IP:      ratelimit.Limit{Requests: 1, Period: time.Second},
+				Limiter: ratelimit.NewStoreLimiter(mockStorage),
+			}
+
+			handler := server.ipRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil))
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected %d when the limiter fails, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
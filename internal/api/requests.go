@@ -0,0 +1,105 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Maximum size of a JSON request body
+const maxRequestBodySize = 64 << 10
+
+// Checks the method of a write request, reads its JSON body and returns the chat ID
+// Sends an error response and returns false if the request can't be handled
+func readWriteRequest(w http.ResponseWriter, r *http.Request, body any) (int64, bool) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return 0, false
+	}
+
+	return readRequestBody(w, r, body)
+}
+
+// Reads the JSON body of a request and returns the chat ID
+// Sends an error response and returns false if the body is invalid
+func readRequestBody(w http.ResponseWriter, r *http.Request, body any) (int64, bool) {
+	chatID, ok := GetChatIDFromContext(r.Context())
+	if !ok {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(body); err != nil {
+		sendJSONError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return 0, false
+	}
+
+	return chatID, true
+}
+
+// Describes a request rejected before it reaches the service
+type requestError struct {
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// Describes a request the API key isn't allowed to make
+type missingScopeError struct {
+	scope string
+}
+
+func (e *missingScopeError) Error() string {
+	return "API key lacks required scope: " + e.scope
+}
+
+// Checks that the task exists and the API key of the request may access it
+// Sends an error response and returns false otherwise
+func (s *Server) checkTaskAccess(w http.ResponseWriter, r *http.Request, chatID int64, taskID string) bool {
+	if _, err := s.accessibleTask(r.Context(), chatID, taskID); err != nil {
+		sendServiceError(w, err)
+		return false
+	}
+
+	return true
+}
+
+// Gets a task the API key of the context may access
+// Tasks outside of the key's patterns look like missing ones
+func (s *Server) accessibleTask(ctx context.Context, chatID int64, taskID string) (*models.Task, error) {
+	if taskID == "" {
+		return nil, &requestError{message: "Missing required parameter: task_id"}
+	}
+
+	task, err := s.storage.GetTask(ctx, chatID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowsTask(ctx, task) {
+		return nil, redis.ErrNotFound
+	}
+
+	return task, nil
+}
@@ -15,12 +15,49 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
+	"time-guard-bot/internal/helpers"
 	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
 )
 
+// Service errors caused by the current state of a task and their error codes
+var stateErrors = []struct {
+	err  error
+	code string
+}{
+	{service.ErrTaskLocked, models.ErrorCodeTaskLocked},
+	{service.ErrTaskBusy, models.ErrorCodeTaskBusy},
+	{service.ErrAlreadyHolding, models.ErrorCodeAlreadyHolding},
+	{service.ErrTooManyTasks, models.ErrorCodeTooManyTasks},
+	{service.ErrTaskNotActive, models.ErrorCodeTaskNotActive},
+	{service.ErrTimerPaused, models.ErrorCodeTimerPaused},
+	{service.ErrTimerNotPaused, models.ErrorCodeTimerNotPaused},
+	{service.ErrAlreadyLocked, models.ErrorCodeTaskAlreadyLocked},
+	{service.ErrNotLocked, models.ErrorCodeTaskNotLocked},
+	{service.ErrTaskInUse, models.ErrorCodeTaskInUse},
+	{service.ErrTaskLimit, models.ErrorCodeTaskLimit},
+	{service.ErrTaskNameTaken, models.ErrorCodeTaskNameTaken},
+	{service.ErrTaskLeased, models.ErrorCodeTaskLeased},
+	{service.ErrLeaseLost, models.ErrorCodeLeaseLost},
+}
+
+// Generic error codes for HTTP statuses
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:          models.ErrorCodeBadRequest,
+	http.StatusUnauthorized:        models.ErrorCodeUnauthorized,
+	http.StatusForbidden:           models.ErrorCodeForbidden,
+	http.StatusNotFound:            models.ErrorCodeNotFound,
+	http.StatusMethodNotAllowed:    models.ErrorCodeMethodNotAllowed,
+	http.StatusConflict:            models.ErrorCodeConflict,
+	http.StatusInternalServerError: models.ErrorCodeInternal,
+}
+
 // Sends a JSON response
 func sendJSON(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -31,12 +68,88 @@ func sendJSON(w http.ResponseWriter, data any) {
 	}
 }
 
-// Sends a JSON error response
+// Sends a JSON error response with the generic error code of the status
 func sendJSONError(w http.ResponseWriter, message string, statusCode int) {
+	code, ok := statusErrorCodes[statusCode]
+	if !ok {
+		code = models.ErrorCodeInternal
+	}
+
+	sendJSONErrorCode(w, code, message, statusCode)
+}
+
+// Sends a JSON error response with a specific error code
+func sendJSONErrorCode(w http.ResponseWriter, code string, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
-	if err := json.NewEncoder(w).Encode(models.ErrorResponse{Error: message}); err != nil {
+	if err := json.NewEncoder(w).Encode(models.ErrorResponse{Error: message, Code: code}); err != nil {
 		log.Printf("Error encoding JSON error response: %v", err)
 	}
 }
+
+// Sends the error response for an error returned by the service
+func sendServiceError(w http.ResponseWriter, err error) {
+	status, response := serviceErrorResponse(err)
+	sendJSONErrorCode(w, response.Code, response.Error, status)
+}
+
+// Maps an error returned by the service to an HTTP status and an error response
+func serviceErrorResponse(err error) (int, models.ErrorResponse) {
+	var (
+		conflictErr *redis.ConflictError
+		nameErr     *service.InvalidNameError
+		requestErr  *requestError
+		scopeErr    *missingScopeError
+	)
+
+	switch {
+	case errors.As(err, &requestErr):
+		return http.StatusBadRequest, models.ErrorResponse{Code: models.ErrorCodeBadRequest, Error: requestErr.message}
+	case errors.As(err, &scopeErr):
+		return http.StatusForbidden, models.ErrorResponse{Code: models.ErrorCodeMissingScope, Error: scopeErr.Error()}
+	case errors.Is(err, redis.ErrNotFound):
+		return http.StatusNotFound, models.ErrorResponse{Code: models.ErrorCodeTaskNotFound, Error: "Task not found"}
+	case errors.As(err, &nameErr):
+		return http.StatusBadRequest, models.ErrorResponse{
+			Code:  models.ErrorCodeInvalidTaskName,
+			Error: fmt.Sprintf("Invalid task name: %s", nameErr.Reason),
+		}
+	case errors.Is(err, service.ErrInvalidDuration):
+		return http.StatusBadRequest, models.ErrorResponse{
+			Code:  models.ErrorCodeInvalidDuration,
+			Error: fmt.Sprintf("Duration must be at least %d minute", helpers.MinTaskDuration),
+		}
+	case errors.Is(err, service.ErrDurationLimit):
+		return http.StatusBadRequest, models.ErrorResponse{
+			Code:  models.ErrorCodeInvalidDuration,
+			Error: fmt.Sprintf("Duration exceeds maximum allowed limit (%d minutes)", helpers.MaxTaskDuration),
+		}
+	case errors.Is(err, service.ErrInvalidLeaseTTL):
+		return http.StatusBadRequest, models.ErrorResponse{
+			Code:  models.ErrorCodeInvalidTTL,
+			Error: fmt.Sprintf("Lease TTL must be between %d and %d seconds", helpers.MinLeaseTTL, helpers.MaxLeaseTTL),
+		}
+	case errors.Is(err, service.ErrInvalidWebhook):
+		return http.StatusBadRequest, models.ErrorResponse{Code: models.ErrorCodeInvalidWebhook, Error: err.Error()}
+	case errors.Is(err, service.ErrWebhookLimit):
+		return http.StatusConflict, models.ErrorResponse{
+			Code:  models.ErrorCodeWebhookLimit,
+			Error: fmt.Sprintf("Maximum number of webhooks per chat reached (%d)", helpers.MaxWebhooksPerChat),
+		}
+	case errors.Is(err, service.ErrInvalidHolder):
+		return http.StatusBadRequest, models.ErrorResponse{Code: models.ErrorCodeInvalidHolder, Error: err.Error()}
+	case errors.As(err, &conflictErr):
+		return http.StatusConflict, models.ErrorResponse{Code: models.ErrorCodeTaskConflict, Error: conflictErr.Error()}
+	}
+
+	for _, stateErr := range stateErrors {
+		if errors.Is(err, stateErr.err) {
+			return http.StatusConflict, models.ErrorResponse{Code: stateErr.code, Error: stateErr.err.Error()}
+		}
+	}
+
+	log.Printf("Service error: %v", err)
+
+	return http.StatusInternalServerError, models.ErrorResponse{Code: models.ErrorCodeInternal, Error: "Internal server error"}
+}
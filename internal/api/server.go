@@ -15,13 +15,22 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"maps"
 	"net/http"
 	"strings"
 	"time"
 
+	"time-guard-bot/internal/discord"
 	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/metrics"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/slack"
 	"time-guard-bot/internal/storage"
+	"time-guard-bot/internal/storage/redis"
 )
 
 // Custom type for context keys
@@ -30,31 +39,91 @@ type ContextKey string
 // Context key constants
 const (
 	ChatIDKey ContextKey = "chatID"
+	APIKeyKey ContextKey = "apiKey"
 )
 
+// How often the last-used time of an API key is written to storage
+const apiKeyTouchInterval = time.Minute
+
 // Extracts the chat ID from the context // FIXME?
 func GetChatIDFromContext(ctx context.Context) (int64, bool) {
 	chatID, ok := ctx.Value(ChatIDKey).(int64)
 	return chatID, ok
 }
 
+// Extracts the API key record from the context, absent for legacy keys
+func GetAPIKeyFromContext(ctx context.Context) (*models.APIKey, bool) {
+	key, ok := ctx.Value(APIKeyKey).(*models.APIKey)
+	return key, ok
+}
+
 // Represents the API server
 type Server struct {
-	storage storage.Storage
-	addr    string
-	server  *http.Server
+	storage         storage.Storage
+	service         *service.Service
+	bus             *service.Bus
+	addr            string
+	server          *http.Server
+	legacyKeysUntil time.Time
+	streamTick      time.Duration
+	rateLimit       *RateLimitConfig
+	readyChecks     map[string]ReadyCheck
+	socketOrigins   []string
+	telegramPath    string
+	telegramHandler http.Handler
+	slackHandler    http.Handler
+	discordHandler  http.Handler
+
+	// Cancelled on stop to close event streams
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // Represents API server configuration
 type Config struct {
 	Addr string
+	// Legacy base64 keys (tg:<chatID>) are accepted until this moment, zero rejects them
+	LegacyAPIKeysUntil time.Time
+	// Request rate limits, nil disables them
+	RateLimit *RateLimitConfig
+	// Checks of /readyz in addition to the storage ping, by name
+	ReadyChecks map[string]ReadyCheck
+	// Origins of web pages allowed to open /api/ws besides the API's own, e.g. https://dash.example.com
+	SocketOrigins []string
+	// Receives Telegram updates in webhook mode on TelegramWebhookPath, nil in long polling mode
+	TelegramWebhook     http.Handler
+	TelegramWebhookPath string
+	// Serves the Slack app under slack.PathPrefix, nil if Slack is off
+	Slack http.Handler
+	// Serves the Discord app under discord.PathPrefix, nil if Discord is off
+	Discord http.Handler
 }
 
 // Creates a new API server
-func NewServer(config *Config, s storage.Storage) *Server {
+// Write endpoints go through svc, so they follow the same rules as bot commands
+// Event streams read changes from bus, which must be subscribed to svc
+func NewServer(config *Config, s storage.Storage, svc *service.Service, bus *service.Bus) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	readyChecks := map[string]ReadyCheck{"storage": s.Ping}
+	maps.Copy(readyChecks, config.ReadyChecks)
+
 	return &Server{
-		storage: s,
-		addr:    config.Addr,
+		storage:         s,
+		service:         svc,
+		bus:             bus,
+		addr:            config.Addr,
+		legacyKeysUntil: config.LegacyAPIKeysUntil,
+		streamTick:      defaultStreamTick,
+		rateLimit:       config.RateLimit,
+		readyChecks:     readyChecks,
+		socketOrigins:   config.SocketOrigins,
+		telegramPath:    config.TelegramWebhookPath,
+		telegramHandler: config.TelegramWebhook,
+		slackHandler:    config.Slack,
+		discordHandler:  config.Discord,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 }
 
@@ -63,8 +132,40 @@ func (s *Server) Start() error {
 	mux := http.NewServeMux()
 
 	// API endpoints
-	mux.HandleFunc("/api/task/status", s.authMiddleware(s.handleTaskStatus))
-	mux.HandleFunc("/api/task/list", s.authMiddleware(s.handleTaskList))
+	mux.HandleFunc("/api/task", s.authMiddleware(models.ScopeTasksWrite, s.handleTask))
+	mux.HandleFunc("/api/task/status", deprecated("/api/v1/tasks/{id}", s.authMiddleware(models.ScopeTasksRead, s.handleTaskStatus)))
+	mux.HandleFunc("/api/task/list", deprecated("/api/v1/tasks", s.authMiddleware(models.ScopeTasksRead, s.handleTaskList)))
+	mux.HandleFunc("/api/task/lock", s.authMiddleware(models.ScopeTasksWrite, s.handleTaskLock))
+	mux.HandleFunc("/api/task/unlock", s.authMiddleware(models.ScopeTasksWrite, s.handleTaskUnlock))
+	mux.HandleFunc("/api/timer/start", s.authMiddleware(models.ScopeTimersWrite, s.handleTimerStart))
+	mux.HandleFunc("/api/timer/extend", s.authMiddleware(models.ScopeTimersWrite, s.handleTimerExtend))
+	mux.HandleFunc("/api/timer/release", s.authMiddleware(models.ScopeTimersWrite, s.handleTimerRelease))
+	mux.HandleFunc("/api/lease/acquire", s.authMiddleware(models.ScopeTimersWrite, s.handleLeaseAcquire))
+	mux.HandleFunc("/api/lease/renew", s.authMiddleware(models.ScopeTimersWrite, s.handleLeaseRenew))
+	mux.HandleFunc("/api/lease/release", s.authMiddleware(models.ScopeTimersWrite, s.handleLeaseRelease))
+	mux.HandleFunc("/api/webhooks", s.authMiddleware(models.ScopeWebhooks, s.handleWebhooks))
+	mux.HandleFunc("/api/webhooks/deliveries", s.authMiddleware(models.ScopeWebhooks, s.handleWebhookDeliveries))
+	mux.HandleFunc("/api/events", queryKeyAuth(s.authMiddleware(models.ScopeTasksRead, s.handleEvents)))
+	mux.HandleFunc("GET /api/v1/tasks", s.authMiddleware(models.ScopeTasksRead, s.handleTasksV1))
+	mux.HandleFunc("GET /api/v1/tasks/{id}", s.authMiddleware(models.ScopeTasksRead, s.handleTaskV1))
+	mux.HandleFunc("GET /api/v1/tasks/{id}/notes", s.authMiddleware(models.ScopeHistoryRead, s.handleTaskNotesV1))
+	mux.HandleFunc("/api/ws", queryKeyAuth(s.authMiddleware(models.ScopeTasksRead, s.handleSocket)))
+
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+
+	if s.telegramHandler != nil {
+		mux.Handle(s.telegramPath, s.telegramHandler)
+	}
+
+	if s.slackHandler != nil {
+		mux.Handle(slack.PathPrefix, s.slackHandler)
+	}
+
+	if s.discordHandler != nil {
+		mux.Handle(discord.PathPrefix, s.discordHandler)
+	}
 
 	// Register Swagger routes
 	RegisterSwaggerRoutes(mux)
@@ -72,7 +173,7 @@ func (s *Server) Start() error {
 	// Create HTTP server
 	s.server = &http.Server{
 		Addr:    s.addr,
-		Handler: mux,
+		Handler: metrics.InstrumentHandler(s.ipRateLimit(mux)),
 	}
 
 	go func() {
@@ -91,14 +192,17 @@ func (s *Server) Start() error {
 func (s *Server) Stop() error {
 	log.Println("Stopping API server")
 
+	// Event streams never end on their own, close them so shutdown doesn't wait for them
+	s.cancel()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	return s.server.Shutdown(ctx)
 }
 
-// Checks API key validity
-func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// Checks API key validity and that the key grants the scope required by the route
+func (s *Server) authMiddleware(scope string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 
@@ -114,32 +218,109 @@ func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		apiKey := parts[1]
+		ctx := r.Context()
 
-		chatID, err := helpers.ExtractChatID(apiKey)
-		if err != nil {
-			log.Printf("Error verifying API key: %v", err)
-			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		var (
+			chatID int64
+			key    *models.APIKey
+		)
 
-			return
-		}
+		if helpers.IsSecretAPIKey(apiKey) {
+			var err error
 
-		// Check if the chat exists in the database
-		exists, err := s.storage.ChatExists(r.Context(), chatID)
-		if err != nil {
-			log.Printf("Error checking if chat exists: %v", err)
-			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			key, err = s.storage.GetAPIKeyByHash(ctx, helpers.HashAPIKey(apiKey))
+			if err != nil {
+				if errors.Is(err, redis.ErrNotFound) {
+					sendJSONErrorCode(w, models.ErrorCodeInvalidAPIKey, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
 
-			return
+				log.Printf("Error verifying API key: %v", err)
+				sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+				return
+			}
+
+			if !key.HasScope(scope) {
+				sendJSONErrorCode(w, models.ErrorCodeMissingScope, fmt.Sprintf("API key lacks required scope: %s", scope), http.StatusForbidden)
+				return
+			}
+
+			s.touchAPIKey(ctx, key)
+
+			chatID = key.ChatID
+			ctx = context.WithValue(ctx, APIKeyKey, key)
+		} else {
+			if !time.Now().Before(s.legacyKeysUntil) {
+				sendJSONErrorCode(
+					w,
+					models.ErrorCodeInvalidAPIKey,
+					"Legacy API keys are no longer accepted. Create a new key with /api_key create",
+					http.StatusUnauthorized,
+				)
+
+				return
+			}
+
+			// Legacy keys were only ever able to read tasks
+			if scope != models.ScopeTasksRead {
+				sendJSONErrorCode(w, models.ErrorCodeMissingScope, fmt.Sprintf("API key lacks required scope: %s", scope), http.StatusForbidden)
+				return
+			}
+
+			var err error
+
+			chatID, err = helpers.ExtractChatID(apiKey)
+			if err != nil {
+				sendJSONErrorCode(w, models.ErrorCodeInvalidAPIKey, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			// A legacy key is only the chat ID, the chat must have tasks to prove it's real
+			// Keys from /api_key create are stored records, which already prove the chat
+			exists, err := s.storage.ChatExists(ctx, chatID)
+			if err != nil {
+				log.Printf("Error checking if chat exists: %v", err)
+				sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+
+				return
+			}
+
+			if !exists {
+				sendJSONErrorCode(w, models.ErrorCodeChatNotFound, "Chat not found or has no tasks", http.StatusNotFound)
+				return
+			}
 		}
 
-		if !exists {
-			sendJSONError(w, "Chat not found or has no tasks", http.StatusNotFound)
+		if !s.keyRateLimit(ctx, w, key, chatID) {
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), ChatIDKey, chatID)
+		ctx = context.WithValue(ctx, ChatIDKey, chatID)
 
 		// Call the next handler
 		next(w, r.WithContext(ctx))
 	}
 }
+
+// Records the use of an API key, at most once per apiKeyTouchInterval
+func (s *Server) touchAPIKey(ctx context.Context, key *models.APIKey) {
+	now := time.Now()
+	if now.Sub(key.LastUsedAt) < apiKeyTouchInterval {
+		return
+	}
+
+	if err := s.storage.TouchAPIKey(ctx, key.ID, now); err != nil {
+		log.Printf("Failed to update API key last use: %v", err)
+	}
+}
+
+// Reports whether the API key of the request may access the task
+func allowsTask(ctx context.Context, task *models.Task) bool {
+	key, ok := GetAPIKeyFromContext(ctx)
+	if !ok {
+		return true
+	}
+
+	return key.AllowsTask(task)
+}
@@ -18,6 +18,8 @@ import (
 	"net/http"
 	"testing"
 	"time"
+
+	"time-guard-bot/internal/service"
 )
 
 func TestNewServer(t *testing.T) {
@@ -34,7 +36,7 @@ func TestNewServer(t *testing.T) {
 		Addr: ":9090",
 	}
 
-	server := NewServer(config, mockStorage)
+	server := NewServer(config, mockStorage, service.New(mockStorage), service.NewBus())
 
 	if server == nil {
 		t.Fatal("Expected server to be created, got nil")
@@ -63,7 +65,7 @@ func TestServerStartStop(t *testing.T) {
 		Addr: ":9091",
 	}
 
-	server := NewServer(config, mockStorage)
+	server := NewServer(config, mockStorage, service.New(mockStorage), service.NewBus())
 
 	if err := server.Start(); err != nil {
 		t.Fatalf("Failed to start server: %v", err)
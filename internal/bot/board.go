@@ -0,0 +1,264 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	// Minimum interval between two edits of the same board (Telegram allows ~20 messages per minute in a group)
+	boardEditInterval = 5 * time.Second
+	// How often pending board updates are flushed
+	boardFlushInterval = time.Second
+	// How often all boards are refreshed so remaining time ticks down
+	boardTickInterval = time.Minute
+)
+
+// Represents the throttling state of a chat's status board
+type boardState struct {
+	dirty    bool      // Board needs to be edited
+	lastEdit time.Time // When the board was edited last time
+	lastText string    // Text of the last edit
+}
+
+// Handles the /board command: /board [off]
+// Posts a status message, pins it and keeps it up to date
+func (b *Bot) HandleBoardCommand(ctx context.Context, message *tgbotapi.Message, args []string) error {
+	chatID := message.Chat.ID
+
+	oldMessageID, err := b.storage.GetBoardMessage(ctx, chatID)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return fmt.Errorf("failed to get board message: %w", err)
+	}
+
+	if len(args) > 0 && args[0] == "off" {
+		if oldMessageID == 0 {
+			return b.sendErrorMessage(chatID, message.MessageID, "There is no status board in this chat")
+		}
+
+		if err := b.storage.DeleteBoardMessage(ctx, chatID); err != nil {
+			return fmt.Errorf("failed to delete board message: %w", err)
+		}
+
+		b.unpinMessage(chatID, oldMessageID)
+		b.forgetBoard(chatID)
+
+		msg := tgbotapi.NewMessage(chatID, "Status board stopped")
+		msg.ReplyToMessageID = message.MessageID
+		_, err = b.messenger.Send(msg)
+
+		return err
+	}
+
+	text, err := b.boardText(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+
+	sentMsg, err := b.messenger.Send(msg)
+	if err != nil {
+		return fmt.Errorf("failed to send board message: %w", err)
+	}
+
+	if err := b.storage.SetBoardMessage(ctx, chatID, sentMsg.MessageID); err != nil {
+		return fmt.Errorf("failed to save board message: %w", err)
+	}
+
+	b.boardsMx.Lock()
+	b.boards[chatID] = &boardState{lastEdit: b.clock.Now(), lastText: text}
+	b.boardsMx.Unlock()
+
+	if oldMessageID != 0 {
+		b.unpinMessage(chatID, oldMessageID)
+	}
+
+	if err := b.messenger.Pin(chatID, sentMsg.MessageID); err != nil {
+		log.Printf("Failed to pin board message: %v", err)
+
+		return b.sendErrorMessage(chatID, message.MessageID, "Can't pin the status board. It will be updated anyway, give the bot the right to pin messages to pin it")
+	}
+
+	return nil
+}
+
+// Schedules an update of the chat's status board
+func (b *Bot) refreshBoard(chatID int64) {
+	b.boardsMx.Lock()
+	defer b.boardsMx.Unlock()
+
+	state, exists := b.boards[chatID]
+	if !exists {
+		state = &boardState{}
+		b.boards[chatID] = state
+	}
+
+	state.dirty = true
+}
+
+// Drops the throttling state of the chat's status board
+func (b *Bot) forgetBoard(chatID int64) {
+	b.boardsMx.Lock()
+	delete(b.boards, chatID)
+	b.boardsMx.Unlock()
+}
+
+// Keeps status boards up to date until the bot is stopped
+func (b *Bot) runBoardUpdater() {
+	defer b.wg.Done()
+
+	flushTicker := time.NewTicker(boardFlushInterval)
+	defer flushTicker.Stop()
+
+	tickTicker := time.NewTicker(boardTickInterval)
+	defer tickTicker.Stop()
+
+	// Boards stored before restart
+	b.refreshAllBoards()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-flushTicker.C:
+			b.flushBoards()
+		case <-tickTicker.C:
+			b.refreshAllBoards()
+		}
+	}
+}
+
+// Schedules an update of every known board
+func (b *Bot) refreshAllBoards() {
+	ctx, cancel := context.WithTimeout(b.ctx, 5*time.Second)
+	defer cancel()
+
+	chats, err := b.storage.GetBoardChats(ctx)
+	if err != nil {
+		log.Printf("Failed to get board chats: %v", err)
+		return
+	}
+
+	for _, chatID := range chats {
+		b.refreshBoard(chatID)
+	}
+}
+
+// Edits boards that have pending updates and weren't edited recently
+func (b *Bot) flushBoards() {
+	now := b.clock.Now()
+
+	var chats []int64
+
+	b.boardsMx.Lock()
+	for chatID, state := range b.boards {
+		if state.dirty && now.Sub(state.lastEdit) >= boardEditInterval {
+			state.dirty = false
+			chats = append(chats, chatID)
+		}
+	}
+	b.boardsMx.Unlock()
+
+	for _, chatID := range chats {
+		b.updateBoard(chatID)
+	}
+}
+
+// Edits the chat's board message with the current status
+func (b *Bot) updateBoard(chatID int64) {
+	ctx, cancel := context.WithTimeout(b.ctx, 5*time.Second)
+	defer cancel()
+
+	messageID, err := b.storage.GetBoardMessage(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			// Chat has no board
+			b.forgetBoard(chatID)
+		} else {
+			log.Printf("Failed to get board message: %v", err)
+		}
+
+		return
+	}
+
+	text, err := b.boardText(ctx, chatID)
+	if err != nil {
+		log.Printf("Failed to build board text: %v", err)
+		return
+	}
+
+	b.boardsMx.Lock()
+	state, exists := b.boards[chatID]
+
+	if !exists || state.lastText == text {
+		b.boardsMx.Unlock()
+		return
+	}
+
+	state.lastEdit = b.clock.Now()
+	state.lastText = text
+	b.boardsMx.Unlock()
+
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	editMsg.ParseMode = tgbotapi.ModeMarkdown
+
+	if err := b.messenger.EditText(editMsg); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "message is not modified"):
+			// Same text as before restart
+		case strings.Contains(err.Error(), "message to edit not found"):
+			// Board message was deleted by someone
+			if err := b.storage.DeleteBoardMessage(ctx, chatID); err != nil {
+				log.Printf("Failed to delete board message: %v", err)
+			}
+
+			b.forgetBoard(chatID)
+		default:
+			log.Printf("Failed to edit board message: %v", err)
+		}
+	}
+}
+
+// Builds the text of the chat's status board
+func (b *Bot) boardText(ctx context.Context, chatID int64) (string, error) {
+	tasks, err := b.storage.ListTasks(ctx, chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	if len(tasks) == 0 {
+		return "📌 No tasks found. Use /add to create a task", nil
+	}
+
+	return "📌 " + b.formatTasksStatus(ctx, chatID, tasks), nil
+}
+
+// Unpins a message, ignoring failures
+func (b *Bot) unpinMessage(chatID int64, messageID int) {
+	if err := b.messenger.Unpin(chatID, messageID); err != nil {
+		log.Printf("Failed to unpin message: %v", err)
+	}
+}
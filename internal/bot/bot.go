@@ -18,25 +18,52 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/messenger"
+	"time-guard-bot/internal/metrics"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/notify"
+	"time-guard-bot/internal/service"
 	"time-guard-bot/internal/storage"
 )
 
 // Represents TimeGuardBot
 type Bot struct {
-	config   *Config
-	api      *tgbotapi.BotAPI
-	storage  storage.Storage
-	handlers map[string]CommandHandler
-	ctx      context.Context
-	cancel   context.CancelFunc
-
-	timers   map[string]*time.Timer
+	config    *Config
+	api       *tgbotapi.BotAPI    // Receives updates
+	messenger messenger.Messenger // Sends everything handlers answer
+	storage   storage.Storage
+	service   *service.Service
+	clock     clock.Clock // Clock of the service, measures timers
+	handlers  map[string]CommandHandler
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	timers   map[string]clock.Timer
+	warnings map[string]clock.Timer // Fire service.TimerWarningLead before the timers with the same keys
 	timersMx sync.RWMutex
 
+	boards   map[int64]*boardState
+	boardsMx sync.Mutex
+
+	users   map[string]*models.ChatUser // Last saved names by "chatID:userID"
+	usersMx sync.Mutex
+
+	// Readiness: when Telegram last answered a poll or delivered an update (Unix nanoseconds)
+	// and whether timers are restored
+	lastContact    atomic.Int64
+	timersRestored atomic.Bool
+	webhookInfo    webhookInfoCache
+
+	webhookSecret string // Expected X-Telegram-Bot-Api-Secret-Token in webhook mode
+
+	notifier *notify.Notifier // Email notifications, nil if not configured
+
 	wg sync.WaitGroup
 }
 
@@ -44,28 +71,72 @@ type Bot struct {
 type Config struct {
 	Token           string
 	LongPollTimeout int
+	// Bot API endpoint format, tgbotapi.APIEndpoint if empty
+	APIEndpoint string
+	// Public HTTPS URL Telegram sends updates to. Empty uses long polling
+	WebhookURL string
+	// Secret Telegram sends with every update, a random one is generated if empty
+	WebhookSecret string
+	// Rate limit tiers the operator granted to chats, other chats only create keys of the default tier
+	TierChats map[int64]string
 }
 
+// Pause after a failed poll before trying again
+const pollRetryDelay = 3 * time.Second
+
 // Represents a function that handles a bot command
 type CommandHandler func(ctx context.Context, message *tgbotapi.Message, args []string) error
 
 // Creates a new Bot instance
-func NewBot(config *Config, storage storage.Storage) (*Bot, error) {
-	api, err := tgbotapi.NewBotAPI(config.Token)
+// The bot runs timers and announces changes for everything done through the service
+func NewBot(config *Config, storage storage.Storage, svc *service.Service) (*Bot, error) {
+	endpoint := config.APIEndpoint
+	if endpoint == "" {
+		endpoint = tgbotapi.APIEndpoint
+	}
+
+	api, err := tgbotapi.NewBotAPIWithClient(config.Token, endpoint, metrics.TelegramClient())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot API: %w", err)
 	}
 
+	webhookSecret := config.WebhookSecret
+	if config.WebhookURL != "" && webhookSecret == "" {
+		if webhookSecret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	bot := newBot(config, messenger.NewTelegram(api), storage, svc)
+	bot.api = api
+	bot.webhookSecret = webhookSecret
+
+	return bot, nil
+}
+
+// Creates a bot that answers through m and doesn't receive updates by itself
+func newBot(config *Config, m messenger.Messenger, storage storage.Storage, svc *service.Service) *Bot {
 	bot := &Bot{
-		config:  config,
-		api:     api,
-		storage: storage,
-		timers:  make(map[string]*time.Timer),
+		config:    config,
+		messenger: m,
+		storage:   storage,
+		service:   svc,
+		clock:     svc.Clock(),
+		timers:    make(map[string]clock.Timer),
+		warnings:  make(map[string]clock.Timer),
+		boards:    make(map[int64]*boardState),
+		users:     make(map[string]*models.ChatUser),
 	}
 
 	bot.registerHandlers()
+	svc.Subscribe(bot.handleServiceEvent)
 
-	return bot, nil
+	return bot
+}
+
+// Enables the /notify command, call before Start
+func (b *Bot) SetNotifier(notifier *notify.Notifier) {
+	b.notifier = notifier
 }
 
 // Starts the bot
@@ -75,12 +146,6 @@ func (b *Bot) Start() error {
 	b.ctx = ctx
 	b.cancel = cancel
 
-	// Create update config
-	updateConfig := tgbotapi.NewUpdate(-1)
-	updateConfig.Timeout = b.config.LongPollTimeout
-
-	updates := b.api.GetUpdatesChan(updateConfig)
-
 	b.setBotCommands()
 
 	// Restore active tasks
@@ -92,8 +157,31 @@ func (b *Bot) Start() error {
 		log.Println("Timers restored successfully")
 	}
 
-	// Start update loop in a goroutine
-	go b.processUpdates(updates)
+	b.timersRestored.Store(true)
+
+	if b.config.WebhookURL != "" {
+		// Updates arrive at WebhookHandler from now on
+		if err := b.setWebhook(); err != nil {
+			return err
+		}
+	} else {
+		// A webhook left from webhook mode makes getUpdates fail
+		if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			log.Printf("Failed to delete webhook: %v", err)
+		}
+
+		// Create update config
+		updateConfig := tgbotapi.NewUpdate(-1)
+		updateConfig.Timeout = b.config.LongPollTimeout
+
+		// Start update loop in a goroutine
+		go b.pollUpdates(updateConfig)
+	}
+
+	// Start status boards updater
+	b.wg.Add(1)
+
+	go b.runBoardUpdater()
 
 	return nil
 }
@@ -111,6 +199,10 @@ func (b *Bot) Stop() {
 	for _, timer := range b.timers {
 		timer.Stop()
 	}
+
+	for _, warning := range b.warnings {
+		warning.Stop()
+	}
 	b.timersMx.Unlock()
 
 	// Waiting for the completion of all goroutines
@@ -128,11 +220,14 @@ func (b *Bot) setBotCommands() {
 		{Command: "lock", Description: "Lock a task: /lock id [reason]"},
 		{Command: "unlock", Description: "Unlock a task: /unlock id"},
 		{Command: "delete", Description: "Delete a task: /delete id"},
+		{Command: "conflict", Description: "Manage conflict groups: /conflict add|remove|list"},
+		{Command: "board", Description: "Pin a live status board: /board [off]"},
+		{Command: "api_key", Description: "Manage API keys: /api_key create|list|revoke|rotate"},
+		{Command: "webhook", Description: "Manage webhooks: /webhook add|list|remove|deliveries"},
+		{Command: "notify", Description: "Notifications: /notify dm|email|confirm|watch"},
 	}
 
-	config := tgbotapi.NewSetMyCommands(commands...)
-
-	_, err := b.api.Request(config)
+	err := b.messenger.SetCommands(commands)
 	if err != nil {
 		log.Printf("Failed to set bot commands: %v", err)
 	}
@@ -140,23 +235,47 @@ func (b *Bot) setBotCommands() {
 	log.Printf("Set bot commands is successful")
 }
 
-// Processes updates from Telegram
-func (b *Bot) processUpdates(updates tgbotapi.UpdatesChannel) {
-	for {
-		select {
-		case <-b.ctx.Done():
-			log.Println("Stopping updates processing...")
-			return
-		case update := <-updates:
-			b.wg.Add(1)
-
-			go func(u tgbotapi.Update) {
-				defer b.wg.Done()
-
-				b.processUpdate(b.ctx, u)
-			}(update)
+// Long-polls Telegram for updates and processes them until the bot stops
+// Every answered poll is recorded, so readiness can tell a wedged loop from a quiet chat
+func (b *Bot) pollUpdates(config tgbotapi.UpdateConfig) {
+	for b.ctx.Err() == nil {
+		updates, err := b.api.GetUpdates(config)
+		if err != nil {
+			log.Printf("Failed to get updates, retrying in %s: %v", pollRetryDelay, err)
+
+			select {
+			case <-b.ctx.Done():
+			case <-time.After(pollRetryDelay):
+			}
+
+			continue
+		}
+
+		b.lastContact.Store(time.Now().UnixNano())
+
+		for _, update := range updates {
+			if update.UpdateID < config.Offset {
+				continue
+			}
+
+			config.Offset = update.UpdateID + 1
+
+			b.dispatchUpdate(update)
 		}
 	}
+
+	log.Println("Stopping updates processing...")
+}
+
+// Processes an update in its own goroutine
+func (b *Bot) dispatchUpdate(update tgbotapi.Update) {
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+
+		b.processUpdate(b.ctx, update)
+	}()
 }
 
 // Processes a single update from Telegram
@@ -219,23 +338,23 @@ func (b *Bot) restoreActiveTasks() error {
 			continue
 		}
 
-		// Restore each task's timer
+		// Restore each task's timer, paused ones run until their pause is over
 		for _, task := range activeTasks {
-			remaining := task.TimeRemaining()
-
-			if remaining <= 0 {
-				go b.handleTaskTimeout(ctx, task.ChatID, task.TaskID)
+			// Timers outlive this function, so they get the bot context
+			if b.timerDelay(task) <= 0 {
+				go b.handleTaskTimeout(b.ctx, task.ChatID, task.TaskID)
 				continue
 			}
 
 			// Start a new timer with the remaining time
-			b.startTaskTimer(ctx, task.ChatID, task.TaskID, time.Duration(remaining)*time.Second)
+			b.startTaskTimer(b.ctx, task)
 
 			restoredCount++
 		}
 	}
 
 	log.Printf("Restored %d active timers", restoredCount)
+	metrics.TimersRestored(restoredCount)
 
 	return nil
 }
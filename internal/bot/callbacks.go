@@ -15,23 +15,31 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/service"
 )
 
 // Sends an alert for a callback query
 func (b *Bot) sendCallbackAlert(query *tgbotapi.CallbackQuery, text string) {
 	callback := tgbotapi.NewCallback(query.ID, text)
-	if _, err := b.api.Request(callback); err != nil {
+	if err := b.messenger.AnswerCallback(callback); err != nil {
 		log.Printf("Failed to send callback alert: %v", err)
 	}
 }
 
 // Handles callback queries from inline buttons
 func (b *Bot) handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	if query.Message != nil {
+		b.rememberUser(ctx, query.Message.Chat.ID, query.From)
+	}
+
 	// Parse the callback data
 	data := query.Data
 	parts := strings.Split(data, ":")
@@ -48,6 +56,10 @@ func (b *Bot) handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQ
 		taskID := parts[1]
 
 		b.handleRemainingTimeCallback(ctx, query, taskID)
+	case "extend", "pause", "resume", "done", "cancel":
+		taskID := parts[1]
+
+		b.handleTimerControlCallback(ctx, query, action, taskID)
 	default:
 		log.Printf("Unknown callback action: %s", action)
 	}
@@ -62,7 +74,7 @@ func (b *Bot) handleRemainingTimeCallback(ctx context.Context, query *tgbotapi.C
 		return
 	}
 
-	remaining := activeTask.TimeRemaining()
+	remaining := activeTask.RemainingAt(b.clock.Now())
 
 	// Calculate remaining time
 	if remaining <= 0 {
@@ -84,3 +96,102 @@ func (b *Bot) handleRemainingTimeCallback(ctx context.Context, query *tgbotapi.C
 	// Send alert with remaining time
 	b.sendCallbackAlert(query, remainingText)
 }
+
+// Handles the timer control buttons: extend, pause, resume, done and cancel
+// Only the user who started the timer can use them
+func (b *Bot) handleTimerControlCallback(ctx context.Context, query *tgbotapi.CallbackQuery, action string, taskID string) {
+	chatID := query.Message.Chat.ID
+
+	activeTask, err := b.storage.GetActiveTask(ctx, chatID, taskID)
+	if err != nil {
+		b.sendCallbackAlert(query, "Task not found or not active")
+		return
+	}
+
+	if query.From.ID != activeTask.UserID {
+		b.sendCallbackAlert(query, "Only the user who started the timer can do this")
+		return
+	}
+
+	task, err := b.storage.GetTask(ctx, chatID, taskID)
+	if err != nil {
+		log.Printf("Failed to get task for timer control: %v", err)
+		b.sendCallbackAlert(query, "Task not found or not active")
+
+		return
+	}
+
+	var text, alert string
+
+	finished := false
+
+	switch action {
+	case "extend":
+		_, activeTask, err = b.service.ExtendTimer(ctx, chatID, taskID, timerExtendStep, service.SourceTelegram)
+		if errors.Is(err, service.ErrDurationLimit) {
+			b.sendCallbackAlert(query, fmt.Sprintf("Duration can't exceed %d minutes", helpers.MaxTaskDuration))
+			return
+		}
+
+		text = fmt.Sprintf("Timer for task *%s* extended by %d minutes", task.Name, timerExtendStep)
+		alert = fmt.Sprintf("+%d minutes", timerExtendStep)
+	case "pause":
+		_, activeTask, err = b.service.PauseTimer(ctx, chatID, taskID, service.SourceTelegram)
+		if errors.Is(err, service.ErrTimerPaused) {
+			b.sendCallbackAlert(query, "Timer is already paused")
+			return
+		}
+
+		text = fmt.Sprintf("⏸ Timer for task *%s* paused", task.Name)
+		alert = "Paused"
+	case "resume":
+		_, activeTask, err = b.service.ResumeTimer(ctx, chatID, taskID, service.SourceTelegram)
+		if errors.Is(err, service.ErrTimerNotPaused) {
+			b.sendCallbackAlert(query, "Timer is not paused")
+			return
+		}
+
+		text = fmt.Sprintf("▶️ Timer for task *%s* resumed", task.Name)
+		alert = "Resumed"
+	case "done":
+		_, _, err = b.service.ReleaseTimer(ctx, chatID, taskID, true, service.SourceTelegram)
+		text = fmt.Sprintf("✅ Task *%s* done", task.Name)
+		alert = "Done"
+		finished = true
+	case "cancel":
+		_, _, err = b.service.ReleaseTimer(ctx, chatID, taskID, false, service.SourceTelegram)
+		text = fmt.Sprintf("Timer for task *%s* has been cancelled", task.Name)
+		alert = "Cancelled"
+		finished = true
+	}
+
+	if errors.Is(err, service.ErrTaskNotActive) {
+		b.sendCallbackAlert(query, "Task not found or not active")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Failed to %s timer: %v", action, err)
+		b.sendCallbackAlert(query, "Error processing action. Please try again")
+
+		return
+	}
+
+	var editMsg tgbotapi.EditMessageTextConfig
+
+	if finished {
+		// No markup removes the keyboard
+		editMsg = tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+	} else {
+		text += fmt.Sprintf(" (%s remaining)", formatRemaining(activeTask.RemainingAt(b.clock.Now())))
+		editMsg = tgbotapi.NewEditMessageTextAndMarkup(chatID, query.Message.MessageID, text, timerKeyboard(activeTask))
+	}
+
+	editMsg.ParseMode = tgbotapi.ModeMarkdown
+
+	if err := b.messenger.EditText(editMsg); err != nil {
+		log.Printf("Failed to edit timer message: %v", err)
+	}
+
+	b.sendCallbackAlert(query, alert)
+}
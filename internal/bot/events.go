@@ -0,0 +1,195 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/service"
+)
+
+// Keeps timers and status boards in sync with changes made through the service
+// Changes that didn't come from Telegram are announced in the chat
+// Timers of other messengers' chats run here too, their adapters announce the changes
+func (b *Bot) handleServiceEvent(ctx context.Context, event *service.Event) {
+	switch event.Type {
+	case service.EventTimerStarted, service.EventTimerExtended, service.EventTimerPaused, service.EventTimerResumed,
+		service.EventLeaseAcquired, service.EventLeaseRenewed:
+		b.startTaskTimer(b.ctx, event.ActiveTask)
+	case service.EventTimerDone, service.EventTimerCancelled, service.EventTimerExpired, service.EventLeaseReleased:
+		b.stopTaskTimer(event.ChatID, event.Task.ID)
+	}
+
+	if service.IsExternalChat(event.ChatID) {
+		return
+	}
+
+	b.refreshBoard(event.ChatID)
+
+	if event.Source != service.SourceTelegram {
+		b.announceEvent(ctx, event)
+	}
+}
+
+// Posts a change made outside of Telegram to the chat, the same way bot commands answer
+// News about a user's own timer is routed to their private chat too, see routeNotification
+func (b *Bot) announceEvent(ctx context.Context, event *service.Event) {
+	task := event.Task
+
+	var (
+		text    string
+		private string // Text for the holder's private chat, empty if the change isn't about their timer
+	)
+
+	switch event.Type {
+	case service.EventTimerStarted:
+		b.announceTimerStart(ctx, event)
+		return
+	case service.EventTimerExtended:
+		text = fmt.Sprintf(
+			"Timer for task *%s* extended by %d minutes (%s remaining)",
+			task.Name,
+			event.Minutes,
+			formatRemaining(event.ActiveTask.RemainingAt(b.clock.Now())),
+		)
+	case service.EventTimerPaused:
+		text = fmt.Sprintf("⏸ Timer for task *%s* paused", task.Name)
+	case service.EventTimerResumed:
+		text = fmt.Sprintf("▶️ Timer for task *%s* resumed", task.Name)
+	case service.EventTimerDone:
+		text = fmt.Sprintf("✅ Task *%s* done", task.Name)
+	case service.EventTimerCancelled:
+		text = fmt.Sprintf("Timer for task *%s* has been cancelled", task.Name)
+	case service.EventTimerExpiring:
+		minutes := int(service.TimerWarningLead.Minutes())
+		text = fmt.Sprintf(
+			"⏳ %s, timer for task *%s* expires in %d minutes",
+			holderName(b.chatUsers(ctx, event.ChatID), event.ActiveTask.UserID),
+			task.Name,
+			minutes,
+		)
+		private = fmt.Sprintf("⏳ Your timer for task *%s* expires in %d minutes", task.Name, minutes)
+	case service.EventTimerExpired:
+		text = "Time has expired! How's it going?"
+		private = fmt.Sprintf("⌛ Your timer for task *%s* has expired. How's it going?", task.Name)
+
+		if event.ActiveTask.IsPaused() {
+			text = fmt.Sprintf("⌛ Timer for task *%s* was paused for too long and expired. The task is free", task.Name)
+			private = fmt.Sprintf("⌛ Your timer for task *%s* was paused for too long and expired", task.Name)
+		}
+
+		if event.ActiveTask.IsLeased() {
+			text = fmt.Sprintf(
+				"⌛ Lease on task *%s* expired, %s stopped sending heartbeats. The task is free",
+				task.Name,
+				holderName(b.chatUsers(ctx, event.ChatID), event.ActiveTask.UserID),
+			)
+			private = ""
+		}
+	case service.EventTaskLocked:
+		text = fmt.Sprintf("🔒 Task *%s* locked", task.Name)
+		if task.LockReason != "" {
+			text += fmt.Sprintf(". Reason: %s", task.LockReason)
+		}
+	case service.EventTaskUnlocked:
+		text = fmt.Sprintf("🟢 Task *%s* unlocked", task.Name)
+	case service.EventLeaseAcquired:
+		text = fmt.Sprintf(
+			"🤖 Task *%s* taken by %s (lease, expires without a heartbeat for %d s)",
+			task.Name,
+			holderName(b.chatUsers(ctx, event.ChatID), event.ActiveTask.UserID),
+			event.ActiveTask.Lease.TTL,
+		)
+	case service.EventLeaseReleased:
+		text = fmt.Sprintf(
+			"✅ Task *%s* released by %s",
+			task.Name,
+			holderName(b.chatUsers(ctx, event.ChatID), event.ActiveTask.UserID),
+		)
+	case service.EventTaskCreated:
+		text = fmt.Sprintf("Task added!\n\nName: *%s*\nID: `%s`", task.Name, task.ID)
+		if task.Description != "" {
+			text += fmt.Sprintf("\nDescription: %s", task.Description)
+		}
+	case service.EventTaskUpdated:
+		text = fmt.Sprintf("Task *%s* (`%s`) updated", task.Name, task.ID)
+	case service.EventTaskDeleted:
+		text = fmt.Sprintf("Task *%s* has been deleted", task.Name)
+	default:
+		return
+	}
+
+	msg := tgbotapi.NewMessage(event.ChatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+
+	if activeTask := event.ActiveTask; activeTask != nil && activeTask.BotResponseID > 0 {
+		msg.ReplyToMessageID = activeTask.BotResponseID
+
+		switch event.Type {
+		case service.EventTimerDone, service.EventTimerCancelled, service.EventTimerExpired:
+			b.removeTimerKeyboard(event.ChatID, activeTask.BotResponseID)
+		}
+	}
+
+	// An expired timer replies to the command that started it
+	if event.Type == service.EventTimerExpired && event.ActiveTask.MessageID > 0 {
+		msg.ReplyToMessageID = event.ActiveTask.MessageID
+	}
+
+	if private != "" {
+		b.routeNotification(ctx, &notification{
+			userID:  event.ActiveTask.UserID,
+			group:   msg,
+			private: private,
+			// The chat only hears about a warning if the holder can't be reached privately
+			groupOnlyOnFailure: event.Type == service.EventTimerExpiring,
+		})
+
+		return
+	}
+
+	if _, err := b.messenger.Send(msg); err != nil {
+		log.Printf("Failed to announce %s: %v", event.Type, err)
+	}
+}
+
+// Posts the timer message with control buttons for a timer started outside of Telegram
+func (b *Bot) announceTimerStart(ctx context.Context, event *service.Event) {
+	users := b.chatUsers(ctx, event.ChatID)
+
+	text := fmt.Sprintf(
+		"%s: task *%s*, %s",
+		timerStartedText(event.ActiveTask.Duration),
+		event.Task.Name,
+		holderName(users, event.ActiveTask.UserID),
+	)
+
+	msg := tgbotapi.NewMessage(event.ChatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	msg.ReplyMarkup = timerKeyboard(event.ActiveTask)
+
+	sentMsg, err := b.messenger.Send(msg)
+	if err != nil {
+		log.Printf("Failed to announce %s: %v", event.Type, err)
+		return
+	}
+
+	if err := b.service.SetTimerMessage(ctx, event.ActiveTask, sentMsg.MessageID); err != nil {
+		log.Printf("Failed to save timer message: %v", err)
+	}
+}
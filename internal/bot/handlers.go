@@ -23,26 +23,35 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/metrics"
 )
 
 // Registers all command handlers
 func (b *Bot) registerHandlers() {
 	b.handlers = map[string]CommandHandler{
-		"start":   b.HandleStartCommand,
-		"help":    b.HandleHelpCommand,
-		"add":     b.HandleAddCommand,
-		"delete":  b.HandleDeleteCommand,
-		"tasks":   b.HandleTasksCommand,
-		"status":  b.HandleStatusCommand,
-		"lock":    b.HandleLockCommand,
-		"unlock":  b.HandleUnlockCommand,
-		"cancel":  b.HandleCancelCommand,
-		"api_key": b.HandleAPICommand,
+		"start":    b.HandleStartCommand,
+		"help":     b.HandleHelpCommand,
+		"add":      b.HandleAddCommand,
+		"delete":   b.HandleDeleteCommand,
+		"tasks":    b.HandleTasksCommand,
+		"status":   b.HandleStatusCommand,
+		"lock":     b.HandleLockCommand,
+		"unlock":   b.HandleUnlockCommand,
+		"cancel":   b.HandleCancelCommand,
+		"api_key":  b.HandleAPICommand,
+		"conflict": b.HandleConflictCommand,
+		"board":    b.HandleBoardCommand,
+		"webhook":  b.HandleWebhookCommand,
+		"notify":   b.HandleNotifyCommand,
 	}
 }
 
 // Process a message from Telegram
 func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) {
+	// Keep the user directory and private chats up to date
+	b.rememberUser(ctx, message.Chat.ID, message.From)
+	b.rememberPrivateChat(ctx, message.Chat, message.From)
+
 	// Check if it's a command message
 	if message.IsCommand() {
 		command := message.Command()
@@ -60,7 +69,10 @@ func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 
 			// Execute handler
 			args := strings.Fields(message.CommandArguments())
-			if err := handler(ctx, message, args); err != nil {
+			err := handler(ctx, message, args)
+			metrics.CommandHandled(command, err)
+
+			if err != nil {
 				log.Printf("Error handling command %s: %v", command, err)
 
 				if err := b.sendErrorMessage(message.Chat.ID, message.MessageID, "Error processing command. Please try again"); err != nil {
@@ -104,7 +116,10 @@ func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 			return
 		}
 
-		if err := b.handleTimeCommand(ctx, message, duration, args[0]); err != nil {
+		err = b.handleTimeCommand(ctx, message, duration, args[0])
+		metrics.CommandHandled("timer", err)
+
+		if err != nil {
 			log.Printf("Error handling command %s: %v", command, err)
 
 			if err := b.sendErrorMessage(message.Chat.ID, message.MessageID, "Error processing timer command"); err != nil {
@@ -121,9 +136,3 @@ func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 		return
 	}
 }
-
-// Handles a reply to a message
-func (b *Bot) handleReplyMessage(ctx context.Context, message *tgbotapi.Message) {
-	// TODO: Implement reply handling
-	log.Printf("Ignoring reply message: %v", message)
-}
@@ -15,24 +15,392 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/ratelimit"
+	"time-guard-bot/internal/storage/redis"
 )
 
-// Handles the /api_key command
+// Usage of the /api_key command
+const apiKeyUsage = "Usage: `/api_key create [name] [scopes=a,b] [tasks=pattern,...] [tier=name] | list | revoke {key_id} | rotate {key_id}`"
+
+// Answer to a user the bot can't send a new key to
+const apiKeyStartText = "API keys are sent by private message. Open a private chat with me, press Start and repeat the command"
+
+// Handles the /api_key command: /api_key create|list|revoke|rotate ...
+// Anyone may list keys, only chat administrators may change them
 func (b *Bot) HandleAPICommand(ctx context.Context, message *tgbotapi.Message, args []string) error {
-	chatID := message.Chat.ID
+	if len(args) == 0 {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, apiKeyUsage)
+	}
+
+	if args[0] == "create" || args[0] == "revoke" || args[0] == "rotate" {
+		admin, err := b.isChatAdmin(message)
+		if err != nil {
+			return err
+		}
+
+		if !admin {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Only chat administrators can manage API keys")
+		}
+	}
+
+	switch args[0] {
+	case "create":
+		return b.createAPIKey(ctx, message, args[1:])
+	case "list":
+		return b.listAPIKeys(ctx, message)
+	case "revoke":
+		if len(args) < 2 {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Please provide a key ID")
+		}
+
+		return b.revokeAPIKey(ctx, message, args[1])
+	case "rotate":
+		if len(args) < 2 {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Please provide a key ID")
+		}
+
+		return b.rotateAPIKey(ctx, message, args[1])
+	default:
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, apiKeyUsage)
+	}
+}
+
+// Creates a new API key: /api_key create [name] [scopes=a,b] [tasks=pattern,...] [tier=name]
+func (b *Bot) createAPIKey(ctx context.Context, message *tgbotapi.Message, args []string) error {
+	opts, err := helpers.ParseAPIKeyOptions(args)
+	if err != nil {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("`%s`", err.Error()))
+	}
+
+	// A higher limit is up to the operator, not to the chat
+	if opts.Tier != "" && opts.Tier != ratelimit.DefaultTier && b.config.TierChats[message.Chat.ID] != opts.Tier {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Tier `%s` is not granted to this chat", opts.Tier))
+	}
+
+	keys, err := b.storage.ListAPIKeys(ctx, message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	if len(keys) >= helpers.MaxAPIKeysPerChat {
+		return b.sendErrorMessage(
+			message.Chat.ID,
+			message.MessageID,
+			fmt.Sprintf("Maximum number of API keys (%d) reached. Revoke unused keys first", helpers.MaxAPIKeysPerChat),
+		)
+	}
+
+	settings, ok, err := b.apiKeyRecipient(ctx, message)
+	if err != nil || !ok {
+		return err
+	}
+
+	key, secret, err := b.issueAPIKey(ctx, message, opts)
+	if err != nil {
+		return err
+	}
+
+	return b.sendAPIKey(ctx, message, settings, key, secret, "API key created")
+}
+
+// Lists API keys of the chat without their secrets
+func (b *Bot) listAPIKeys(ctx context.Context, message *tgbotapi.Message) error {
+	keys, err := b.storage.ListAPIKeys(ctx, message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	if len(keys) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "No API keys. Use /api_key create [name] to create one")
+		msg.ReplyToMessageID = message.MessageID
+		_, err = b.messenger.Send(msg)
+
+		return err
+	}
+
+	var text strings.Builder
+
+	text.WriteString("API keys:\n\n")
+
+	for _, key := range keys {
+		text.WriteString(fmt.Sprintf("`%s`", key.ID))
 
-	apiKey := helpers.GenerateAPIKey(chatID)
+		if key.Name != "" {
+			text.WriteString(fmt.Sprintf(" `%s`", key.Name))
+		}
 
-	responseMsg := fmt.Sprintf("`%s`", apiKey)
+		text.WriteString(fmt.Sprintf(" (%s)\n", apiKeyAccess(key)))
+		text.WriteString(fmt.Sprintf("Created: %s", key.CreatedAt.Format(time.DateTime)))
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, responseMsg)
-	msg.ParseMode = "Markdown"
-	_, err := b.api.Send(msg)
+		if key.LastUsedAt.IsZero() {
+			text.WriteString(", never used\n")
+		} else {
+			text.WriteString(fmt.Sprintf(", last used: %s\n", key.LastUsedAt.Format(time.DateTime)))
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.messenger.Send(msg)
 
 	return err
 }
+
+// Revokes an API key: /api_key revoke {key_id}
+func (b *Bot) revokeAPIKey(ctx context.Context, message *tgbotapi.Message, keyID string) error {
+	if err := b.storage.RevokeAPIKey(ctx, message.Chat.ID, keyID); err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("API key `%s` not found", keyID))
+		}
+
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("API key `%s` revoked", keyID))
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err := b.messenger.Send(msg)
+
+	return err
+}
+
+// Replaces an API key with a new one with the same name, scopes and tier: /api_key rotate {key_id}
+func (b *Bot) rotateAPIKey(ctx context.Context, message *tgbotapi.Message, keyID string) error {
+	keys, err := b.storage.ListAPIKeys(ctx, message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	var old *models.APIKey
+
+	for _, key := range keys {
+		if key.ID == keyID {
+			old = key
+			break
+		}
+	}
+
+	if old == nil {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("API key `%s` not found", keyID))
+	}
+
+	settings, ok, err := b.apiKeyRecipient(ctx, message)
+	if err != nil || !ok {
+		return err
+	}
+
+	key, secret, err := b.issueAPIKey(ctx, message, &helpers.APIKeyOptions{
+		Name:         old.Name,
+		Scopes:       old.Scopes,
+		TaskPatterns: old.TaskPatterns,
+		Tier:         old.Tier,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The old key is revoked only once the new one reached the user,
+	// so the chat is never left without a working key
+	delivered, err := b.deliverAPIKey(ctx, message, settings, key, secret, fmt.Sprintf("API key `%s` rotated", old.ID))
+	if err != nil || !delivered {
+		return err
+	}
+
+	if err := b.storage.RevokeAPIKey(ctx, message.Chat.ID, old.ID); err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return b.confirmAPIKey(message, key, fmt.Sprintf("API key `%s` rotated", old.ID))
+}
+
+// Generates and saves a new API key, returns its record and the full key
+// A key whose random ID is already taken is generated again
+func (b *Bot) issueAPIKey(ctx context.Context, message *tgbotapi.Message, opts *helpers.APIKeyOptions) (*models.APIKey, string, error) {
+	const maxRetries = 10
+
+	for range maxRetries {
+		key, secret, err := b.saveAPIKey(ctx, message, opts)
+		if errors.Is(err, redis.ErrAPIKeyExists) {
+			continue
+		}
+
+		return key, secret, err
+	}
+
+	return nil, "", fmt.Errorf("failed to generate unique API key ID after %d attempts", maxRetries)
+}
+
+// Generates an API key and saves it unless its ID is taken
+func (b *Bot) saveAPIKey(ctx context.Context, message *tgbotapi.Message, opts *helpers.APIKeyOptions) (*models.APIKey, string, error) {
+	keyID, secret, err := helpers.GenerateSecretAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key := &models.APIKey{
+		ID:           keyID,
+		ChatID:       message.Chat.ID,
+		Name:         opts.Name,
+		Hash:         helpers.HashAPIKey(secret),
+		Scopes:       opts.Scopes,
+		TaskPatterns: opts.TaskPatterns,
+		Tier:         opts.Tier,
+		CreatedAt:    b.clock.Now(),
+	}
+
+	if message.From != nil {
+		key.CreatedBy = message.From.ID
+	}
+
+	if err := b.storage.AddAPIKey(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+// Reports whether the sender administers the chat, in a private chat they always do
+func (b *Bot) isChatAdmin(message *tgbotapi.Message) (bool, error) {
+	if message.Chat.IsPrivate() {
+		return true, nil
+	}
+
+	if message.From == nil {
+		return false, nil
+	}
+
+	admins, err := b.messenger.GetChatAdmins(message.Chat.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get chat administrators: %w", err)
+	}
+
+	for _, admin := range admins {
+		if admin.User != nil && admin.User.ID == message.From.ID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Returns the notification settings holding the private chat a new key goes to,
+// nil when the command was sent in the private chat itself
+// Asks the user to start the bot and reports false if there is no private chat yet
+func (b *Bot) apiKeyRecipient(ctx context.Context, message *tgbotapi.Message) (*models.NotifySettings, bool, error) {
+	if message.Chat.IsPrivate() {
+		return nil, true, nil
+	}
+
+	settings, err := b.notifySettings(ctx, message.From.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if settings.DMChatID == 0 {
+		return nil, false, b.sendErrorMessage(message.Chat.ID, message.MessageID, apiKeyStartText)
+	}
+
+	return settings, true, nil
+}
+
+// Sends a new API key to the user and confirms it in the chat without the secret
+func (b *Bot) sendAPIKey(
+	ctx context.Context,
+	message *tgbotapi.Message,
+	settings *models.NotifySettings,
+	key *models.APIKey,
+	secret string,
+	title string,
+) error {
+	delivered, err := b.deliverAPIKey(ctx, message, settings, key, secret, title)
+	if err != nil || !delivered {
+		return err
+	}
+
+	return b.confirmAPIKey(message, key, title)
+}
+
+// Sends a new API key by private message. The key is not stored and can't be shown again
+// A key that can't be delivered is revoked and the user is asked to start the bot
+func (b *Bot) deliverAPIKey(
+	ctx context.Context,
+	message *tgbotapi.Message,
+	settings *models.NotifySettings,
+	key *models.APIKey,
+	secret string,
+	title string,
+) (bool, error) {
+	text := fmt.Sprintf(
+		"%s. ID: `%s`, access: %s\n\n`%s`\n\nSave it now, it won't be shown again",
+		title, key.ID, apiKeyAccess(key), secret,
+	)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+
+	if settings != nil {
+		text = fmt.Sprintf("%s\n\nChat: %s (`%d`)", text, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, message.Chat.Title), message.Chat.ID)
+		msg = tgbotapi.NewMessage(settings.DMChatID, text)
+	}
+
+	msg.ParseMode = tgbotapi.ModeMarkdown
+
+	_, err := b.messenger.Send(msg)
+	if err == nil {
+		return true, nil
+	}
+
+	if settings == nil {
+		return false, err
+	}
+
+	log.Printf("Failed to send API key to user %d: %v", settings.UserID, err)
+	b.forgetBlockedChat(ctx, settings, err)
+
+	if err := b.storage.RevokeAPIKey(ctx, message.Chat.ID, key.ID); err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return false, fmt.Errorf("failed to revoke undelivered API key: %w", err)
+	}
+
+	return false, b.sendErrorMessage(message.Chat.ID, message.MessageID, apiKeyStartText)
+}
+
+// Tells the chat a key was issued, the secret itself went by private message
+func (b *Bot) confirmAPIKey(message *tgbotapi.Message, key *models.APIKey, title string) error {
+	if message.Chat.IsPrivate() {
+		return nil
+	}
+
+	text := fmt.Sprintf("%s. ID: `%s`, access: %s\n\nThe key was sent to you by private message", title, key.ID, apiKeyAccess(key))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err := b.messenger.Send(msg)
+
+	return err
+}
+
+// Describes what an API key can access: its scopes, task patterns and rate limit tier
+func apiKeyAccess(key *models.APIKey) string {
+	access := strings.Join(key.Scopes, ", ")
+
+	if len(key.TaskPatterns) > 0 {
+		access += fmt.Sprintf(" on tasks `%s`", strings.Join(key.TaskPatterns, ", "))
+	}
+
+	if key.Tier != "" {
+		access += fmt.Sprintf(", tier `%s`", key.Tier)
+	}
+
+	return access
+}
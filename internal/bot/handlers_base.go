@@ -31,7 +31,7 @@ func (b *Bot) HandleStartCommand(ctx context.Context, message *tgbotapi.Message,
 	text += "Use /help to get a list of available commands"
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
-	_, err := b.api.Send(msg)
+	_, err := b.messenger.Send(msg)
 
 	return err
 }
@@ -45,12 +45,34 @@ func (b *Bot) HandleHelpCommand(ctx context.Context, message *tgbotapi.Message,
 	text += "/delete {task_id} - Delete a task by ID\n"
 	text += "/tasks - List all tasks\n"
 	text += "/status [task_name] - Show status of all tasks or a specific task\n"
+	text += "/board [off] - Pin a status board that updates itself (or stop it)\n"
 	text += "/lock {task_id} [reason] - Lock a task, preventing it from being started\n"
-	text += "/unlock {task_id} - Unlock a previously locked task\n\n"
+	text += "/unlock {task_id} - Unlock a previously locked task\n"
+	text += "/conflict add {group} {task_id...} - Add tasks to a group where only one can run at a time\n"
+	text += "/conflict remove {group} [task_id...] - Remove tasks from a group (or the whole group)\n"
+	text += "/conflict list - List conflict groups\n\n"
 
 	text += "<b>Time Tracking</b>:\n"
 	text += "/{minutes} {task_name} - Start a timer for a task (e.g., '/30 coding')\n"
-	text += "/cancel [task_name] - Cancel specified timer (defaults to latest)\n\n"
+	text += "/cancel [task_name] - Cancel specified timer (defaults to latest)\n"
+	text += "Reply to a timer message with '+10', 'extend 10', 'done', 'cancel' or any text to add a note\n\n"
+
+	text += "<b>API</b>:\n"
+	text += "/api_key create [name] [scopes=a,b] [tasks=pattern,...] [tier=name] - Create an API key, sent by private message (admins)\n"
+	text += "/api_key list - List API keys with their last use\n"
+	text += "/api_key revoke {key_id} - Revoke an API key (admins)\n"
+	text += "/api_key rotate {key_id} - Replace an API key with a new one (admins)\n"
+	text += "/webhook add {url} [events=a,b] - Send task events to a URL (secret shown once)\n"
+	text += "/webhook list - List webhooks\n"
+	text += "/webhook remove {id} - Remove a webhook\n"
+	text += "/webhook deliveries {id} - Show recent deliveries of a webhook\n\n"
+
+	text += "<b>Notifications</b>:\n"
+	text += "/notify dm on|off - Get private messages before your timers expire and when they do\n"
+	text += "/notify email {address} - Get emails before your timers expire (sends a confirmation code)\n"
+	text += "/notify confirm {code} - Confirm your email with the code\n"
+	text += "/notify email off - Stop email notifications\n"
+	text += "/notify watch {task_name} - Get an email when a busy task is free\n\n"
 
 	text += "<b>Limits</b>:\n"
 	text += fmt.Sprintf("- Maximum task duration: %d minutes (%.1f hours)\n", helpers.MaxTaskDuration, float64(helpers.MaxTaskDuration)/60)
@@ -59,7 +81,7 @@ func (b *Bot) HandleHelpCommand(ctx context.Context, message *tgbotapi.Message,
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ParseMode = tgbotapi.ModeHTML
-	_, err := b.api.Send(msg)
+	_, err := b.messenger.Send(msg)
 
 	return err
 }
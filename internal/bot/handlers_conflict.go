@@ -0,0 +1,196 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Handles the /conflict command: /conflict add|remove|list ...
+func (b *Bot) HandleConflictCommand(ctx context.Context, message *tgbotapi.Message, args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		return b.listConflictGroups(ctx, message)
+	}
+
+	if len(args) < 2 {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Please provide a conflict group name")
+	}
+
+	group := args[1]
+	if err := helpers.ValidateTaskName(group); err != nil {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Invalid group name: %s", err.Error()))
+	}
+
+	switch args[0] {
+	case "add":
+		return b.addToConflictGroup(ctx, message, group, args[2:])
+	case "remove":
+		return b.removeFromConflictGroup(ctx, message, group, args[2:])
+	default:
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Usage: /conflict add|remove {group} {task_id...} or /conflict list")
+	}
+}
+
+// Adds tasks to a conflict group: /conflict add {group} {task_id} [task_id...]
+func (b *Bot) addToConflictGroup(ctx context.Context, message *tgbotapi.Message, group string, taskIDs []string) error {
+	if len(taskIDs) == 0 {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Please provide at least one task ID")
+	}
+
+	names := make([]string, 0, len(taskIDs))
+
+	for _, taskID := range taskIDs {
+		task, err := b.storage.GetTask(ctx, message.Chat.ID, taskID)
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Task with ID *%s* not found", taskID))
+			}
+
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+
+		if err := b.storage.AddTaskToConflictGroup(ctx, message.Chat.ID, group, taskID); err != nil {
+			return fmt.Errorf("failed to add task to conflict group: %w", err)
+		}
+
+		names = append(names, task.Name)
+	}
+
+	text := fmt.Sprintf("Tasks *%s* added to conflict group *%s*", strings.Join(names, ", "), group)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err := b.messenger.Send(msg)
+
+	return err
+}
+
+// Removes tasks from a conflict group, or the whole group if no IDs are given
+func (b *Bot) removeFromConflictGroup(ctx context.Context, message *tgbotapi.Message, group string, taskIDs []string) error {
+	var text string
+
+	if len(taskIDs) == 0 {
+		if err := b.storage.DeleteConflictGroup(ctx, message.Chat.ID, group); err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Conflict group *%s* not found", group))
+			}
+
+			return fmt.Errorf("failed to delete conflict group: %w", err)
+		}
+
+		text = fmt.Sprintf("Conflict group *%s* deleted", group)
+	} else {
+		for _, taskID := range taskIDs {
+			if err := b.storage.RemoveTaskFromConflictGroup(ctx, message.Chat.ID, group, taskID); err != nil {
+				if errors.Is(err, redis.ErrNotFound) {
+					return b.sendErrorMessage(
+						message.Chat.ID,
+						message.MessageID,
+						fmt.Sprintf("Task with ID *%s* is not in conflict group *%s*", taskID, group),
+					)
+				}
+
+				return fmt.Errorf("failed to remove task from conflict group: %w", err)
+			}
+		}
+
+		text = fmt.Sprintf("Tasks removed from conflict group *%s*", group)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err := b.messenger.Send(msg)
+
+	return err
+}
+
+// Lists conflict groups of the chat
+func (b *Bot) listConflictGroups(ctx context.Context, message *tgbotapi.Message) error {
+	groups, err := b.storage.GetConflictGroups(ctx, message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get conflict groups: %w", err)
+	}
+
+	if len(groups) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "No conflict groups. Use /conflict add {group} {task_id...} to create one")
+		msg.ReplyToMessageID = message.MessageID
+		_, err = b.messenger.Send(msg)
+
+		return err
+	}
+
+	tasks, err := b.storage.ListTasks(ctx, message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	taskNames := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		taskNames[task.ID] = task.Name
+	}
+
+	var text strings.Builder
+
+	text.WriteString("Conflict groups:\n\n")
+
+	for _, group := range groups {
+		members := make([]string, 0, len(group.TaskIDs))
+
+		for _, taskID := range group.TaskIDs {
+			name, ok := taskNames[taskID]
+			if !ok {
+				name = "?"
+			}
+
+			members = append(members, fmt.Sprintf("%s `%s`", name, taskID))
+		}
+
+		text.WriteString(fmt.Sprintf("*%s*: %s\n", group.Name, strings.Join(members, ", ")))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.messenger.Send(msg)
+
+	return err
+}
+
+// Builds the error text for a task blocked by a conflicting active task
+func (b *Bot) conflictErrorText(ctx context.Context, chatID int64, conflictErr *redis.ConflictError) string {
+	blockerName := conflictErr.BlockerID
+
+	blocker, err := b.storage.GetTask(ctx, chatID, conflictErr.BlockerID)
+	if err == nil {
+		blockerName = blocker.Name
+	}
+
+	text := fmt.Sprintf("Task *%s* is in use and conflicts with this task (group *%s*)", blockerName, conflictErr.Group)
+
+	if blocker != nil && blocker.OwnerID != 0 {
+		remaining := blocker.RemainingAt(b.clock.Now())
+		text += fmt.Sprintf(". %d:%02d remaining", remaining/60, remaining%60)
+	}
+
+	return text
+}
@@ -24,7 +24,7 @@ func (b *Bot) sendErrorMessage(chatID int64, replyToID int, text string) error {
 	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %s", text))
 	msg.ReplyToMessageID = replyToID
 	msg.ParseMode = tgbotapi.ModeMarkdown
-	_, err := b.api.Send(msg)
+	_, err := b.messenger.Send(msg)
 
 	return err
 }
@@ -21,6 +21,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
+	"time-guard-bot/internal/service"
 	"time-guard-bot/internal/storage/redis"
 )
 
@@ -39,43 +40,29 @@ func (b *Bot) HandleLockCommand(ctx context.Context, message *tgbotapi.Message,
 		reason = strings.Join(args[1:], " ")
 	}
 
-	// Get task to lock
-	task, err := b.storage.GetTask(ctx, message.Chat.ID, taskID)
-	if err != nil {
-		if errors.Is(err, redis.ErrNotFound) {
-			return b.sendErrorMessage(
-				message.Chat.ID,
-				message.MessageID,
-				fmt.Sprintf("Task with ID *%s* not found", taskID),
-			)
-		}
-
-		return fmt.Errorf("failed to get task: %w", err)
-	}
+	task, err := b.service.LockTask(ctx, message.Chat.ID, taskID, reason, service.SourceTelegram)
 
-	// Check if already locked
-	if task.IsLocked {
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+		return b.sendErrorMessage(
+			message.Chat.ID,
+			message.MessageID,
+			fmt.Sprintf("Task with ID *%s* not found", taskID),
+		)
+	case errors.Is(err, service.ErrAlreadyLocked):
 		return b.sendErrorMessage(
 			message.Chat.ID,
 			message.MessageID,
 			fmt.Sprintf("Task *%s* is already locked", task.Name),
 		)
-	}
-
-	if task.OwnerID != 0 {
+	case errors.Is(err, service.ErrTaskInUse):
 		return b.sendErrorMessage(
 			message.Chat.ID,
 			message.MessageID,
 			fmt.Sprintf("Task *%s* is currently in use", task.Name),
 		)
-	}
-
-	// Lock task
-	task.IsLocked = true
-	task.LockReason = reason
-
-	if err := b.storage.UpdateTask(ctx, task); err != nil {
-		return fmt.Errorf("failed to update task: %w", err)
+	case err != nil:
+		return err
 	}
 
 	text := fmt.Sprintf("🔒 Task *%s* locked successfully", task.Name)
@@ -83,7 +70,7 @@ func (b *Bot) HandleLockCommand(ctx context.Context, message *tgbotapi.Message,
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ReplyToMessageID = message.MessageID
 	msg.ParseMode = tgbotapi.ModeMarkdown
-	_, err = b.api.Send(msg)
+	_, err = b.messenger.Send(msg)
 
 	return err
 }
@@ -97,29 +84,17 @@ func (b *Bot) HandleUnlockCommand(ctx context.Context, message *tgbotapi.Message
 
 	taskID := args[0]
 
-	// Get task to unlock
-	task, err := b.storage.GetTask(ctx, message.Chat.ID, taskID)
-	if err != nil {
-		if errors.Is(err, redis.ErrNotFound) {
-			return b.sendErrorMessage(message.Chat.ID, message.MessageID,
-				fmt.Sprintf("Task with ID *%s* not found", taskID))
-		}
-
-		return fmt.Errorf("failed to get task: %w", err)
-	}
+	task, err := b.service.UnlockTask(ctx, message.Chat.ID, taskID, service.SourceTelegram)
 
-	// Check if already unlocked
-	if !task.IsLocked {
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID,
+			fmt.Sprintf("Task with ID *%s* not found", taskID))
+	case errors.Is(err, service.ErrNotLocked):
 		return b.sendErrorMessage(message.Chat.ID, message.MessageID,
 			fmt.Sprintf("Task *%s* is not locked", task.Name))
-	}
-
-	// Unlock task
-	task.IsLocked = false
-	task.LockReason = ""
-
-	if err := b.storage.UpdateTask(ctx, task); err != nil {
-		return fmt.Errorf("failed to update task: %w", err)
+	case err != nil:
+		return err
 	}
 
 	text := fmt.Sprintf("🟢 Task *%s* unlocked successfully", task.Name)
@@ -127,7 +102,7 @@ func (b *Bot) HandleUnlockCommand(ctx context.Context, message *tgbotapi.Message
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ReplyToMessageID = message.MessageID
 	msg.ParseMode = tgbotapi.ModeMarkdown
-	_, err = b.api.Send(msg)
+	_, err = b.messenger.Send(msg)
 
 	return err
 }
@@ -0,0 +1,174 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/notify"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Usage of the /notify command
+const notifyUsage = "Usage: `/notify dm on|off | email {address} | email off | confirm {code} | watch {task_name}`"
+
+// Handles the /notify command: /notify dm|email|confirm|watch ...
+func (b *Bot) HandleNotifyCommand(ctx context.Context, message *tgbotapi.Message, args []string) error {
+	if message.From == nil || len(args) < 2 {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, notifyUsage)
+	}
+
+	if args[0] == "dm" {
+		return b.setDirectMessages(ctx, message, args[1])
+	}
+
+	if b.notifier == nil {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Email notifications are not configured")
+	}
+
+	switch args[0] {
+	case "email":
+		if args[1] == "off" {
+			return b.disableEmail(ctx, message)
+		}
+
+		return b.requestEmail(ctx, message, args[1])
+	case "confirm":
+		return b.confirmEmail(ctx, message, args[1])
+	case "watch":
+		return b.watchTask(ctx, message, args[1])
+	default:
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, notifyUsage)
+	}
+}
+
+// Turns private messages about the user's timers on or off: /notify dm on|off
+func (b *Bot) setDirectMessages(ctx context.Context, message *tgbotapi.Message, state string) error {
+	if state != "on" && state != "off" {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, notifyUsage)
+	}
+
+	settings, err := b.notifySettings(ctx, message.From.ID)
+	if err != nil {
+		return err
+	}
+
+	settings.DM = state == "on"
+	settings.UpdatedAt = b.clock.Now()
+
+	if err := b.storage.SaveNotifySettings(ctx, settings); err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	switch {
+	case !settings.DM:
+		return b.replyNotify(message, "Private messages turned off, timer notifications stay in the chat")
+	case settings.DMChatID == 0:
+		return b.replyNotify(message,
+			"🔔 Private messages turned on. Open a private chat with me and press Start, "+
+				"until then notifications stay in the chat")
+	default:
+		return b.replyNotify(message, "🔔 Private messages turned on. You'll get a message before your timers expire and when they do")
+	}
+}
+
+// Sends a confirmation code to a new address: /notify email {address}
+func (b *Bot) requestEmail(ctx context.Context, message *tgbotapi.Message, address string) error {
+	err := b.notifier.RequestEmail(ctx, message.From.ID, address)
+
+	switch {
+	case errors.Is(err, notify.ErrInvalidEmail):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Invalid email address")
+	case errors.Is(err, notify.ErrCodeRecentlySent):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "A code was sent less than a minute ago, please wait")
+	case err != nil:
+		return err
+	}
+
+	return b.replyNotify(message, fmt.Sprintf(
+		"📧 Confirmation code sent to `%s`. Send `/notify confirm {code}` to start getting notifications there",
+		address,
+	))
+}
+
+// Confirms the pending address: /notify confirm {code}
+func (b *Bot) confirmEmail(ctx context.Context, message *tgbotapi.Message, code string) error {
+	address, err := b.notifier.ConfirmEmail(ctx, message.From.ID, code)
+
+	switch {
+	case errors.Is(err, notify.ErrNoPendingEmail):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "No email to confirm. Use `/notify email {address}` first")
+	case errors.Is(err, notify.ErrCodeExpired), errors.Is(err, notify.ErrTooManyAttempts):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "The code is no longer valid. Use `/notify email {address}` to get a new one")
+	case errors.Is(err, notify.ErrWrongCode):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Wrong code")
+	case err != nil:
+		return err
+	}
+
+	return b.replyNotify(message, fmt.Sprintf(
+		"✅ Email `%s` confirmed. You'll get an email before your timers expire and when they do",
+		address,
+	))
+}
+
+// Stops email notifications: /notify email off
+func (b *Bot) disableEmail(ctx context.Context, message *tgbotapi.Message) error {
+	if err := b.notifier.DisableEmail(ctx, message.From.ID); err != nil {
+		return err
+	}
+
+	return b.replyNotify(message, "Email notifications turned off")
+}
+
+// Emails the user once a busy task gets free: /notify watch {task_name}
+func (b *Bot) watchTask(ctx context.Context, message *tgbotapi.Message, taskName string) error {
+	task, err := b.storage.GetTaskByName(ctx, message.Chat.ID, taskName)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Task *%s* not found", taskName))
+		}
+
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if task.OwnerID == 0 && !task.IsLocked {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Task *%s* is free right now", task.Name))
+	}
+
+	err = b.notifier.Watch(ctx, message.Chat.ID, task.ID, message.From.ID)
+	if errors.Is(err, notify.ErrEmailNotConfirmed) {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Set up an email first: `/notify email {address}`")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return b.replyNotify(message, fmt.Sprintf("👀 You'll get an email when task *%s* is free", task.Name))
+}
+
+// Replies to a /notify command
+func (b *Bot) replyNotify(message *tgbotapi.Message, text string) error {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err := b.messenger.Send(msg)
+
+	return err
+}
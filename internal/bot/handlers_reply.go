@@ -0,0 +1,143 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Handles a reply to a message
+// Replies to a timer (the bot response or the command that started it) are interpreted:
+// "+10" or "extend 10" extends, "done" finishes, "cancel" cancels, free text becomes a note
+func (b *Bot) handleReplyMessage(ctx context.Context, message *tgbotapi.Message) {
+	if message.From == nil || message.Text == "" {
+		return
+	}
+
+	activeTask, err := b.storage.GetActiveTaskByMessage(ctx, message.Chat.ID, message.ReplyToMessage.MessageID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to find active task by reply: %v", err)
+		}
+
+		return
+	}
+
+	command := helpers.ParseReplyCommand(message.Text)
+
+	if activeTask.UserID != message.From.ID {
+		// Other users can talk about the timer freely, but can't control it
+		if command.Action == helpers.ReplyActionNote {
+			return
+		}
+
+		if err := b.sendErrorMessage(message.Chat.ID, message.MessageID, "Only the user who started the timer can do this"); err != nil {
+			log.Printf("Failed to send error message: %v", err)
+		}
+
+		return
+	}
+
+	if err := b.handleReplyCommand(ctx, message, activeTask, command); err != nil {
+		log.Printf("Error handling reply %q: %v", message.Text, err)
+
+		if err := b.sendErrorMessage(message.Chat.ID, message.MessageID, "Error processing reply. Please try again"); err != nil {
+			log.Printf("Failed to send error message: %v", err)
+		}
+	}
+}
+
+// Applies an interpreted reply to the active task
+func (b *Bot) handleReplyCommand(
+	ctx context.Context,
+	message *tgbotapi.Message,
+	activeTask *models.ActiveTask,
+	command helpers.ReplyCommand,
+) error {
+	task, err := b.storage.GetTask(ctx, message.Chat.ID, activeTask.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	var text string
+
+	switch command.Action {
+	case helpers.ReplyActionExtend:
+		_, extended, err := b.service.ExtendTimer(ctx, message.Chat.ID, activeTask.TaskID, command.Minutes, service.SourceTelegram)
+
+		switch {
+		case errors.Is(err, service.ErrInvalidDuration):
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Duration must be more than 1")
+		case errors.Is(err, service.ErrDurationLimit):
+			return b.sendErrorMessage(
+				message.Chat.ID,
+				message.MessageID,
+				fmt.Sprintf("Duration exceeds maximum allowed limit (%d minutes)", helpers.MaxTaskDuration),
+			)
+		case err != nil:
+			return err
+		}
+
+		text = fmt.Sprintf(
+			"Timer for task *%s* extended by %d minutes (%s remaining)",
+			task.Name,
+			command.Minutes,
+			formatRemaining(extended.RemainingAt(b.clock.Now())),
+		)
+	case helpers.ReplyActionDone, helpers.ReplyActionCancel:
+		if activeTask.BotResponseID > 0 {
+			b.removeTimerKeyboard(message.Chat.ID, activeTask.BotResponseID)
+		}
+
+		done := command.Action == helpers.ReplyActionDone
+
+		if _, _, err := b.service.ReleaseTimer(ctx, message.Chat.ID, activeTask.TaskID, done, service.SourceTelegram); err != nil {
+			return err
+		}
+
+		text = fmt.Sprintf("✅ Task *%s* done", task.Name)
+		if !done {
+			text = fmt.Sprintf("Timer for task *%s* has been cancelled", task.Name)
+		}
+	case helpers.ReplyActionNote:
+		note := models.Note{
+			UserID:    message.From.ID,
+			Text:      command.Note,
+			CreatedAt: b.clock.Now(),
+		}
+
+		if err := b.service.AddTimerNote(ctx, activeTask, note); err != nil {
+			return err
+		}
+
+		text = fmt.Sprintf("📝 Note added to task *%s*", task.Name)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.messenger.Send(msg)
+
+	return err
+}
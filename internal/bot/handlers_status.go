@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -70,7 +71,7 @@ func (b *Bot) getTaskStatus(ctx context.Context, message *tgbotapi.Message, task
 		if task.OwnerID != 0 {
 			statusEmoji = "⏱"
 			// Get active task info for remaining time
-			remaining := task.TimeRemaining()
+			remaining := task.RemainingAt(b.clock.Now())
 
 			var remainingTime string
 
@@ -81,6 +82,13 @@ func (b *Bot) getTaskStatus(ctx context.Context, message *tgbotapi.Message, task
 			}
 
 			statusInfo = fmt.Sprintf("Remaining: %s", remainingTime)
+
+			holder, err := b.storage.GetChatUser(ctx, message.Chat.ID, task.OwnerID)
+			if err != nil {
+				holder = &models.ChatUser{ID: task.OwnerID}
+			}
+
+			statusInfo += fmt.Sprintf(" (%s)", holder.DisplayName())
 		} else {
 			statusEmoji = "🟢"
 			statusInfo = "Available"
@@ -89,9 +97,24 @@ func (b *Bot) getTaskStatus(ctx context.Context, message *tgbotapi.Message, task
 
 	text := fmt.Sprintf("%s %s\n", statusEmoji, statusInfo)
 
+	// Add notes attached to the current session
+	if task.OwnerID != 0 {
+		activeTask, err := b.storage.GetActiveTask(ctx, message.Chat.ID, task.ID)
+		if err != nil && !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get active task: %v", err)
+		}
+
+		if activeTask != nil && len(activeTask.Notes) > 0 {
+			text += "\n📝 Notes:\n"
+			for _, note := range activeTask.Notes {
+				text += fmt.Sprintf("- %s\n", note.Text)
+			}
+		}
+	}
+
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ReplyToMessageID = message.MessageID
-	_, err = b.api.Send(msg)
+	_, err = b.messenger.Send(msg)
 
 	return err
 }
@@ -111,20 +134,38 @@ func (b *Bot) getAllTasksStatus(ctx context.Context, message *tgbotapi.Message)
 		)
 	}
 
+	text := b.formatTasksStatus(ctx, message.Chat.ID, tasks)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.messenger.Send(msg)
+
+	return err
+}
+
+// Builds the status lines of the given chat tasks, sorted by name
+func (b *Bot) formatTasksStatus(ctx context.Context, chatID int64, tasks []*models.Task) string {
 	// Get active tasks
-	activeTasks, err := b.storage.GetActiveTasks(ctx, message.Chat.ID)
+	activeTasks, err := b.storage.GetActiveTasks(ctx, chatID)
 	if err != nil {
 		log.Printf("Failed to get active tasks: %v", err) // TODO уточнить
 
 		activeTasks = []*models.ActiveTask{} // Empty slice as fallback
 	}
 
+	users := b.chatUsers(ctx, chatID)
+
 	// Create a map for easier access to active tasks by ID
 	activeTasksMap := make(map[string]*models.ActiveTask)
 	for _, task := range activeTasks {
 		activeTasksMap[task.TaskID] = task
 	}
 
+	// Keep a stable order, the set of task IDs in storage is unordered
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+
 	// Build status message
 	var text strings.Builder
 
@@ -150,7 +191,7 @@ func (b *Bot) getAllTasksStatus(ctx context.Context, message *tgbotapi.Message)
 				// Get active task info for remaining time
 				activeTask, exists := activeTasksMap[task.ID]
 				if exists {
-					remaining := activeTask.TimeRemaining()
+					remaining := activeTask.RemainingAt(b.clock.Now())
 
 					var remainingTime string
 
@@ -161,6 +202,11 @@ func (b *Bot) getAllTasksStatus(ctx context.Context, message *tgbotapi.Message)
 					}
 
 					statusInfo = fmt.Sprintf("Remaining: %s", remainingTime)
+					if activeTask.IsPaused() {
+						statusInfo = fmt.Sprintf("Paused, remaining: %s", remainingTime)
+					}
+
+					statusInfo += fmt.Sprintf(" (%s)", holderName(users, activeTask.UserID))
 				} else {
 					statusInfo = "Unexpected" // TODO обработать
 				}
@@ -174,9 +220,5 @@ func (b *Bot) getAllTasksStatus(ctx context.Context, message *tgbotapi.Message)
 		text.WriteString(taskLine)
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	_, err = b.api.Send(msg)
-
-	return err
+	return text.String()
 }
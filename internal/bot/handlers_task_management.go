@@ -22,7 +22,7 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"time-guard-bot/internal/helpers"
-	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
 	"time-guard-bot/internal/storage/redis"
 )
 
@@ -33,11 +33,7 @@ func (b *Bot) HandleAddCommand(ctx context.Context, message *tgbotapi.Message, a
 		return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Please provide a name for the task")
 	}
 
-	// Check if the name is valid
 	taskName := args[0]
-	if err := helpers.ValidateTaskName(taskName); err != nil {
-		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Invalid task name: %s", err.Error()))
-	}
 
 	// Extract description (all remaining arguments)
 	description := ""
@@ -45,58 +41,30 @@ func (b *Bot) HandleAddCommand(ctx context.Context, message *tgbotapi.Message, a
 		description = strings.Join(args[1:], " ") // FIXME " "
 	}
 
-	// Check if we're at the limit of chat tasks
-	count, err := b.storage.CountTasks(ctx, message.Chat.ID)
-	if err != nil {
-		return fmt.Errorf("failed to count tasks: %w", err)
-	}
+	task, err := b.service.CreateTask(ctx, message.Chat.ID, taskName, description, service.SourceTelegram)
+
+	var nameErr *service.InvalidNameError
 
-	if count >= helpers.MaxTasksPerChat {
+	switch {
+	case errors.As(err, &nameErr):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Invalid task name: %s", nameErr.Reason))
+	case errors.Is(err, service.ErrTaskLimit):
 		return b.sendErrorMessage(
 			message.Chat.ID,
 			message.MessageID,
 			fmt.Sprintf("Maximum number of tasks per chat reached (%d)", helpers.MaxTasksPerChat),
 		)
-	}
-
-	// Check if a task with this name already exists
-	_, err = b.storage.GetTaskByName(ctx, message.Chat.ID, taskName)
-	if err != nil {
-		if errors.Is(err, redis.ErrNotFound) {
-			// Continue
-		} else {
-			return fmt.Errorf("failed to check if task exists: %w", err)
-		}
-	} else {
+	case errors.Is(err, service.ErrTaskNameTaken):
 		return b.sendErrorMessage(
 			message.Chat.ID,
 			message.MessageID,
 			fmt.Sprintf("A task with name *%s* already exists", taskName),
 		)
+	case err != nil:
+		return err
 	}
 
-	// Generate a unique ID for the task
-	taskID, err := helpers.GenerateUniqueTaskID(helpers.TaskIDLength, func(id string) (bool, error) {
-		return b.storage.TaskExists(ctx, message.Chat.ID, id)
-	})
-	if err != nil {
-		return fmt.Errorf("failed to generate task ID: %w", err)
-	}
-
-	// Create task
-	task := &models.Task{
-		ID:          taskID,
-		Name:        taskName,
-		Description: description,
-		ChatID:      message.Chat.ID,
-		IsLocked:    false,
-		LockReason:  "",
-	}
-
-	// Save task
-	if err := b.storage.AddTask(ctx, task); err != nil {
-		return fmt.Errorf("failed to add task: %w", err)
-	}
+	taskID := task.ID
 
 	text := fmt.Sprintf("Task added successfully!\n\nName: *%s*\nID: `%s`", taskName, taskID)
 	if description != "" {
@@ -106,7 +74,7 @@ func (b *Bot) HandleAddCommand(ctx context.Context, message *tgbotapi.Message, a
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ReplyToMessageID = message.MessageID
 	msg.ParseMode = tgbotapi.ModeMarkdown
-	_, err = b.api.Send(msg)
+	_, err = b.messenger.Send(msg)
 
 	return err
 }
@@ -120,32 +88,23 @@ func (b *Bot) HandleDeleteCommand(ctx context.Context, message *tgbotapi.Message
 
 	taskID := args[0]
 
-	// Get task to check if it exists
-	task, err := b.storage.GetTask(ctx, message.Chat.ID, taskID)
-	if err != nil {
-		if errors.Is(err, redis.ErrNotFound) {
-			return b.sendErrorMessage(message.Chat.ID, message.MessageID,
-				fmt.Sprintf("Task with ID *%s* not found", taskID))
-		}
+	task, err := b.service.DeleteTask(ctx, message.Chat.ID, taskID, service.SourceTelegram)
 
-		return fmt.Errorf("failed to get task: %w", err)
-	}
-
-	// Если task запущена
-	if task.OwnerID != 0 {
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID,
+			fmt.Sprintf("Task with ID *%s* not found", taskID))
+	case errors.Is(err, service.ErrTaskInUse):
 		return b.sendErrorMessage(message.Chat.ID, message.MessageID,
 			fmt.Sprintf("Task *%s* is currently in use", task.Name))
-	}
-
-	// Delete task
-	if err := b.storage.DeleteTask(ctx, message.Chat.ID, taskID); err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+	case err != nil:
+		return err
 	}
 
 	text := fmt.Sprintf("Task deleted successfully!\n\nName: %s\nID: %s", task.Name, taskID)
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ReplyToMessageID = message.MessageID
-	_, err = b.api.Send(msg)
+	_, err = b.messenger.Send(msg)
 
 	return err
 }
@@ -161,11 +120,13 @@ func (b *Bot) HandleTasksCommand(ctx context.Context, message *tgbotapi.Message,
 	if len(tasks) == 0 {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "No tasks found. Use /add to create a task")
 		msg.ReplyToMessageID = message.MessageID
-		_, err = b.api.Send(msg)
+		_, err = b.messenger.Send(msg)
 
 		return err
 	}
 
+	users := b.chatUsers(ctx, message.Chat.ID)
+
 	// Format tasks list
 	text := "Tasks:\n\n"
 
@@ -179,12 +140,18 @@ func (b *Bot) HandleTasksCommand(ctx context.Context, message *tgbotapi.Message,
 		}
 
 		// Task info
-		text += fmt.Sprintf("%s *%s* `%s` %s\n", status, task.Name, task.ID, task.Description)
+		text += fmt.Sprintf("%s *%s* `%s` %s", status, task.Name, task.ID, task.Description)
+
+		if task.OwnerID != 0 && !task.IsLocked {
+			text += fmt.Sprintf(" (held by %s)", holderName(users, task.OwnerID))
+		}
+
+		text += "\n"
 	}
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	msg.ParseMode = tgbotapi.ModeMarkdown
-	_, err = b.api.Send(msg)
+	_, err = b.messenger.Send(msg)
 
 	return err
 }
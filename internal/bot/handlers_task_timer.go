@@ -22,69 +22,167 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
+	"time-guard-bot/internal/clock"
 	"time-guard-bot/internal/helpers"
 	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
 	"time-guard-bot/internal/storage/redis"
 )
 
-// Starts a task timer
-func (b *Bot) startTaskTimer(ctx context.Context, chatID int64, taskID string, duration time.Duration) {
-	timer := time.AfterFunc(duration, func() {
+// Extension step of the "+15" timer button in minutes
+const timerExtendStep = 15
+
+// Starts a task timer, replacing the previous one for the same task
+// A running timer fires at its end, timers longer than service.TimerWarningLead
+// also get a warning before it. A paused timer fires when its pause runs out
+func (b *Bot) startTaskTimer(ctx context.Context, activeTask *models.ActiveTask) {
+	chatID, taskID := activeTask.ChatID, activeTask.TaskID
+	duration := b.timerDelay(activeTask)
+
+	timer := b.clock.AfterFunc(duration, func() {
 		b.handleTaskTimeout(ctx, chatID, taskID)
 	})
 
+	var warning clock.Timer
+	if !activeTask.IsPaused() && duration > service.TimerWarningLead {
+		warning = b.clock.AfterFunc(duration-service.TimerWarningLead, func() {
+			b.handleTaskWarning(ctx, chatID, taskID)
+		})
+	}
+
 	b.timersMx.Lock()
 	timerKey := fmt.Sprintf("%d:%s", chatID, taskID)
+
+	if oldTimer, exists := b.timers[timerKey]; exists {
+		oldTimer.Stop()
+	}
+
+	if oldWarning, exists := b.warnings[timerKey]; exists {
+		oldWarning.Stop()
+		delete(b.warnings, timerKey)
+	}
+
 	b.timers[timerKey] = timer
+	if warning != nil {
+		b.warnings[timerKey] = warning
+	}
 	b.timersMx.Unlock()
 }
 
-// Handles a task timeout
-func (b *Bot) handleTaskTimeout(ctx context.Context, chatID int64, taskID string) {
-	// Remove timer from map
+// Returns how long until the timer of an active task fires
+func (b *Bot) timerDelay(activeTask *models.ActiveTask) time.Duration {
+	if activeTask.IsPaused() {
+		return activeTask.PauseEndsAt().Sub(b.clock.Now())
+	}
+
+	return time.Duration(activeTask.RemainingAt(b.clock.Now())) * time.Second
+}
+
+// Stops a task timer and its warning if they are running
+func (b *Bot) stopTaskTimer(chatID int64, taskID string) {
 	b.timersMx.Lock()
+	defer b.timersMx.Unlock()
+
 	timerKey := fmt.Sprintf("%d:%s", chatID, taskID)
-	delete(b.timers, timerKey)
-	b.timersMx.Unlock()
 
-	// Create context
-	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	timer, exists := b.timers[timerKey]
+	if exists {
+		timer.Stop()
+		delete(b.timers, timerKey)
+	}
 
-	// Get task
-	_, err := b.storage.GetTask(timeoutCtx, chatID, taskID)
-	if err != nil {
-		log.Printf("Failed to get task on timeout: %v", err)
-		return
+	warning, exists := b.warnings[timerKey]
+	if exists {
+		warning.Stop()
+		delete(b.warnings, timerKey)
 	}
+}
 
-	// Get active task to find the original message ID
-	activeTask, err := b.storage.GetActiveTask(timeoutCtx, chatID, taskID)
-	if err != nil {
-		log.Printf("Failed to get active task on timeout: %v", err)
-	} else if activeTask.BotResponseID > 0 {
-		// Удаляем inline keyboard
-		emptyMarkup := tgbotapi.InlineKeyboardMarkup{
-			InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
-		}
+// Builds the inline keyboard of a running or paused timer
+func timerKeyboard(activeTask *models.ActiveTask) tgbotapi.InlineKeyboardMarkup {
+	data := func(action string) string {
+		return fmt.Sprintf("%s:%s:%d", action, activeTask.TaskID, activeTask.UserID)
+	}
 
-		editMsg := tgbotapi.NewEditMessageReplyMarkup(chatID, activeTask.BotResponseID, emptyMarkup)
-		if _, err := b.api.Send(editMsg); err != nil {
-			log.Printf("Failed to remove keyboard from original message: %v", err)
-		}
+	pauseButton := tgbotapi.NewInlineKeyboardButtonData("⏸ Pause", data("pause"))
+	if activeTask.IsPaused() {
+		pauseButton = tgbotapi.NewInlineKeyboardButtonData("▶️ Resume", data("resume"))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⌛", data("check_time")),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("+%d", timerExtendStep), data("extend")),
+			pauseButton,
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Done", data("done")),
+			tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", data("cancel")),
+		),
+	)
+}
+
+// Removes the inline keyboard from a timer message
+func (b *Bot) removeTimerKeyboard(chatID int64, messageID int) {
+	emptyMarkup := tgbotapi.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
 	}
 
-	if err := b.storage.EndTask(timeoutCtx, chatID, taskID); err != nil {
-		log.Printf("Failed to end task on timeout: %v", err)
-		return
+	editMsg := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, emptyMarkup)
+	if err := b.messenger.EditMarkup(editMsg); err != nil {
+		log.Printf("Failed to remove keyboard from original message: %v", err)
 	}
+}
+
+// Formats remaining seconds as m:ss
+func formatRemaining(remaining int64) string {
+	return fmt.Sprintf("%d:%02d", remaining/60, remaining%60)
+}
+
+// Handles the warning before a task timeout
+func (b *Bot) handleTaskWarning(ctx context.Context, chatID int64, taskID string) {
+	b.timersMx.Lock()
+	delete(b.warnings, fmt.Sprintf("%d:%s", chatID, taskID))
+	b.timersMx.Unlock()
+
+	warnCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	text := "Time has expired! How's it going?"
-	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ReplyToMessageID = activeTask.MessageID
+	// Notifications are sent by the service listeners
+	_, _, err := b.service.WarnTimer(warnCtx, chatID, taskID)
 
-	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Failed to send task timeout message: %v", err)
+	switch {
+	case errors.Is(err, service.ErrTaskLeased), errors.Is(err, service.ErrTimerRunning),
+		errors.Is(err, service.ErrTimerPaused), errors.Is(err, service.ErrTaskNotActive):
+		// Leases aren't warned about, other timers were changed while the warning was firing
+	case err != nil:
+		log.Printf("Failed to warn about task timer: %v", err)
+	}
+}
+
+// Handles a task timeout
+func (b *Bot) handleTaskTimeout(ctx context.Context, chatID int64, taskID string) {
+	// Remove timer from map
+	b.timersMx.Lock()
+	timerKey := fmt.Sprintf("%d:%s", chatID, taskID)
+	delete(b.timers, timerKey)
+	b.timersMx.Unlock()
+
+	// Create context
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// The expiry is announced by handleServiceEvent
+	_, activeTask, err := b.service.ExpireTimer(timeoutCtx, chatID, taskID)
+
+	switch {
+	case errors.Is(err, service.ErrTimerRunning), errors.Is(err, service.ErrTimerPaused):
+		// A heartbeat, an extension or a new pause moved the end while the timer was firing
+		b.startTaskTimer(ctx, activeTask)
+	case errors.Is(err, service.ErrTaskNotActive):
+		// Stale timer of a released task
+	case err != nil:
+		log.Printf("Failed to expire task timer: %v", err)
 	}
 }
 
@@ -103,109 +201,74 @@ func (b *Bot) handleTimeCommand(ctx context.Context, message *tgbotapi.Message,
 		return fmt.Errorf("failed to get task: %w", err)
 	}
 
-	// Check if task is locked
-	if task.IsLocked {
-		errMsg := "Task is locked"
-		if task.LockReason != "" {
-			errMsg += fmt.Sprintf(". Reason: %s", task.LockReason)
+	task, activeTask, err := b.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:    message.Chat.ID,
+		TaskID:    task.ID,
+		UserID:    message.From.ID,
+		Duration:  duration,
+		MessageID: message.MessageID,
+		Source:    service.SourceTelegram,
+	})
+	if err != nil {
+		if text, ok := b.startErrorText(ctx, message.Chat.ID, task, err); ok {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, text)
 		}
 
-		return b.sendErrorMessage(message.Chat.ID, message.MessageID, errMsg)
+		return err
 	}
 
-	// Проверяем, что задача не в работе
-	if task.OwnerID != 0 {
-		remaining := task.TimeRemaining()
-		remainingMin := remaining / 60
-		remainingSec := remaining % 60
-
-		if task.OwnerID == message.From.ID {
-			errMsg := fmt.Sprintf("You're already working on this task. %d:%02d remaining", remainingMin, remainingSec)
-			return b.sendErrorMessage(message.Chat.ID, message.MessageID, errMsg)
-		}
-
-		errMsg := fmt.Sprintf("Another user is currently working on the task. %d:%02d remaining", remainingMin, remainingSec)
-
-		return b.sendErrorMessage(message.Chat.ID, message.MessageID, errMsg)
-	}
+	replyMsg := tgbotapi.NewMessage(message.Chat.ID, timerStartedText(duration))
+	replyMsg.ReplyToMessageID = message.MessageID
+	replyMsg.ReplyMarkup = timerKeyboard(activeTask)
 
-	// Check if user has too many active tasks
-	count, err := b.storage.GetCountUserActiveTasks(ctx, message.Chat.ID, message.From.ID)
+	sentMsg, err := b.messenger.Send(replyMsg)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to send message: %w", err)
 	}
 
-	if count >= helpers.MaxTasksPerUser {
-		return b.sendErrorMessage(
-			message.Chat.ID,
-			message.MessageID,
-			fmt.Sprintf("You've reached the maximum number of active tasks (%d)", 4),
-		)
+	if err := b.service.SetTimerMessage(ctx, activeTask, sentMsg.MessageID); err != nil {
+		log.Printf("Failed to save timer message: %v", err)
 	}
 
-	// Do timer
-	startTime := time.Now()
-	endTime := startTime.Add(time.Duration(duration) * time.Minute)
-
-	// Update task
-	task.OwnerID = message.From.ID
-	task.StartTime = startTime
-	task.EndTime = endTime
-	task.Duration = duration
-	task.MessageID = message.MessageID
+	return nil
+}
 
-	var responseText string
+// Returns the text of a timer start announcement
+func timerStartedText(duration int) string {
 	if duration == 1 {
-		responseText = fmt.Sprintf("Timer started for %d minute", duration)
-	} else {
-		responseText = fmt.Sprintf("Timer started for %d minutes", duration)
+		return fmt.Sprintf("Timer started for %d minute", duration)
 	}
 
-	replyMsg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-	replyMsg.ReplyToMessageID = message.MessageID
-
-	sentMsg, err := b.api.Send(replyMsg)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
-	}
-
-	task.BotResponseID = sentMsg.MessageID
-
-	// Create active task
-	activeTask := &models.ActiveTask{
-		TaskID:        task.ID,
-		UserID:        int64(message.From.ID),
-		ChatID:        message.Chat.ID,
-		StartTime:     startTime,
-		EndTime:       endTime,
-		Duration:      duration,
-		MessageID:     message.MessageID,
-		BotResponseID: sentMsg.MessageID,
-	}
+	return fmt.Sprintf("Timer started for %d minutes", duration)
+}
 
-	// Start task in storage
-	err = b.storage.StartTask(ctx, activeTask)
-	if err != nil {
-		return fmt.Errorf("failed to start task: %w", err)
-	}
+// Explains why a timer can't be started, false for unexpected errors
+func (b *Bot) startErrorText(ctx context.Context, chatID int64, task *models.Task, err error) (string, bool) {
+	var conflictErr *redis.ConflictError
 
-	// Add hourglass button
-	hourglassButton := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⌛", fmt.Sprintf("check_time:%s:%d", task.ID, message.From.ID)),
-		),
-	)
-	editMsg := tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, sentMsg.MessageID, hourglassButton)
+	switch {
+	case errors.Is(err, service.ErrTaskLocked):
+		text := "Task is locked"
+		if task.LockReason != "" {
+			text += fmt.Sprintf(". Reason: %s", task.LockReason)
+		}
 
-	sentMsg, err = b.api.Send(editMsg)
-	if err != nil {
-		log.Printf("Failed to add inline keyboard: %v", err)
+		return text, true
+	case errors.Is(err, service.ErrAlreadyHolding):
+		return fmt.Sprintf("You're already working on this task. %s remaining", formatRemaining(task.RemainingAt(b.clock.Now()))), true
+	case errors.Is(err, service.ErrTaskBusy):
+		return fmt.Sprintf("Another user is currently working on the task. %s remaining", formatRemaining(task.RemainingAt(b.clock.Now()))), true
+	case errors.As(err, &conflictErr):
+		return b.conflictErrorText(ctx, chatID, conflictErr), true
+	case errors.Is(err, service.ErrTooManyTasks):
+		return fmt.Sprintf("You've reached the maximum number of active tasks (%d)", helpers.MaxTasksPerUser), true
+	case errors.Is(err, service.ErrDurationLimit):
+		return fmt.Sprintf("Duration exceeds maximum allowed limit (%d minutes)", helpers.MaxTaskDuration), true
+	case errors.Is(err, service.ErrInvalidDuration):
+		return "Duration must be more than 1", true
 	}
 
-	// Start timer
-	b.startTaskTimer(ctx, message.Chat.ID, task.ID, time.Duration(duration)*time.Minute)
-
-	return nil
+	return "", false
 }
 
 // Handles the /cancel [name] command
@@ -281,17 +344,6 @@ func (b *Bot) HandleCancelCommand(ctx context.Context, message *tgbotapi.Message
 		taskToCancel = lastTask
 	}
 
-	// Cancel the timer
-	b.timersMx.Lock()
-	timerKey := fmt.Sprintf("%d:%s", message.Chat.ID, taskToCancel.TaskID)
-
-	timer, exists := b.timers[timerKey]
-	if exists {
-		timer.Stop()
-		delete(b.timers, timerKey)
-	}
-	b.timersMx.Unlock()
-
 	// Get task
 	task, err := b.storage.GetTask(ctx, message.Chat.ID, taskToCancel.TaskID)
 	if err != nil {
@@ -299,19 +351,11 @@ func (b *Bot) HandleCancelCommand(ctx context.Context, message *tgbotapi.Message
 	}
 
 	if taskToCancel.BotResponseID > 0 {
-		// Удаляем inline keyboard
-		emptyMarkup := tgbotapi.InlineKeyboardMarkup{
-			InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
-		}
-
-		editMsg := tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, taskToCancel.BotResponseID, emptyMarkup)
-		if _, err := b.api.Send(editMsg); err != nil {
-			log.Printf("Failed to remove keyboard from original message: %v", err)
-		}
+		b.removeTimerKeyboard(message.Chat.ID, taskToCancel.BotResponseID)
 	}
 
-	if err := b.storage.EndTask(ctx, message.Chat.ID, taskToCancel.TaskID); err != nil {
-		return fmt.Errorf("failed to end task: %w", err)
+	if _, _, err := b.service.ReleaseTimer(ctx, message.Chat.ID, taskToCancel.TaskID, false, service.SourceTelegram); err != nil {
+		return err
 	}
 
 	text := fmt.Sprintf("Timer for task *%s* has been cancelled", task.Name)
@@ -319,7 +363,7 @@ func (b *Bot) HandleCancelCommand(ctx context.Context, message *tgbotapi.Message
 	replyMsg.ReplyToMessageID = task.MessageID
 	replyMsg.ParseMode = tgbotapi.ModeMarkdown
 
-	if _, err := b.api.Send(replyMsg); err != nil {
+	if _, err := b.messenger.Send(replyMsg); err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
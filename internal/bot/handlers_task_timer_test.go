@@ -0,0 +1,464 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/messenger"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const expiredText = "Time has expired! How's it going?"
+
+// Запускает таймер на задачу staging и возвращает ее
+func startTestTimer(t *testing.T, b *Bot, minutes string) *models.Task {
+	t.Helper()
+
+	b.handleMessage(b.ctx, commandMessage(1, "/add staging"))
+	b.handleMessage(b.ctx, commandMessage(2, "/"+minutes+" staging"))
+
+	task, err := b.storage.GetTaskByName(b.ctx, testChatID, "staging")
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+
+	return task
+}
+
+// Считает сообщения об истечении таймера
+func countExpired(recorder *messenger.Recorder) int {
+	count := 0
+
+	for _, text := range recorder.SentTexts() {
+		if text == expiredText {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Проверяет, есть ли у задачи активный таймер
+func isActive(t *testing.T, b *Bot, taskID string) bool {
+	t.Helper()
+
+	activeTasks, err := b.storage.GetActiveTasks(b.ctx, testChatID)
+	if err != nil {
+		t.Fatalf("Failed to get active tasks: %v", err)
+	}
+
+	for _, activeTask := range activeTasks {
+		if activeTask.TaskID == taskID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestTaskTimeout(t *testing.T) {
+	b, recorder, fakeClock := newTestBot(t)
+	task := startTestTimer(t, b, "30")
+
+	// За секунду до конца таймер еще идет
+	fakeClock.Advance(30*time.Minute - time.Second)
+
+	if countExpired(recorder) != 0 || !isActive(t, b, task.ID) {
+		t.Fatalf("Expected the timer running a second before the end, sent %q", recorder.SentTexts())
+	}
+
+	fakeClock.Advance(time.Second)
+
+	if countExpired(recorder) != 1 {
+		t.Fatalf("Expected one expiry message, sent %q", recorder.SentTexts())
+	}
+
+	if isActive(t, b, task.ID) {
+		t.Error("Expected the timer to be released")
+	}
+
+	if fakeClock.Pending() != 0 {
+		t.Errorf("Expected no scheduled timers, got %d", fakeClock.Pending())
+	}
+
+	// Сообщение об истечении отвечает на команду, с сообщения таймера снимается клавиатура
+	sent := recorder.CallsOf(messenger.MethodSend)
+	if msg, ok := sent[len(sent)-1].Config.(tgbotapi.MessageConfig); !ok || msg.ReplyToMessageID != 2 {
+		t.Errorf("Expected the expiry message to reply to the command, got %+v", sent[len(sent)-1].Config)
+	}
+
+	if edits := recorder.CallsOf(messenger.MethodEditMarkup); len(edits) != 1 || edits[0].MessageID != sent[1].MessageID {
+		t.Errorf("Expected the keyboard of the timer message removed, got %+v", edits)
+	}
+}
+
+func TestTaskTimerWarning(t *testing.T) {
+	b, _, fakeClock := newTestBot(t)
+
+	var warned []*service.Event
+
+	b.service.Subscribe(func(ctx context.Context, event *service.Event) {
+		if event.Type == service.EventTimerExpiring {
+			warned = append(warned, event)
+		}
+	})
+
+	task := startTestTimer(t, b, "30")
+
+	fakeClock.Advance(30*time.Minute - service.TimerWarningLead - time.Second)
+
+	if len(warned) != 0 {
+		t.Fatalf("Expected no warning yet, got %d", len(warned))
+	}
+
+	fakeClock.Advance(time.Second)
+
+	if len(warned) != 1 || warned[0].Task.ID != task.ID || warned[0].ActiveTask.UserID != testUserID {
+		t.Fatalf("Expected one warning about staging, got %d", len(warned))
+	}
+
+	// Продление переносит предупреждение
+	if _, _, err := b.service.ExtendTimer(b.ctx, testChatID, task.ID, 15, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to extend timer: %v", err)
+	}
+
+	fakeClock.Advance(15 * time.Minute)
+
+	if len(warned) != 2 {
+		t.Fatalf("Expected a second warning after the extension, got %d", len(warned))
+	}
+
+	// Отмена снимает и таймер, и предупреждение
+	if _, _, err := b.service.ExtendTimer(b.ctx, testChatID, task.ID, 15, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to extend timer: %v", err)
+	}
+
+	if _, _, err := b.service.ReleaseTimer(b.ctx, testChatID, task.ID, false, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to release timer: %v", err)
+	}
+
+	if fakeClock.Pending() != 0 {
+		t.Errorf("Expected no scheduled timers, got %d", fakeClock.Pending())
+	}
+
+	// Короткие таймеры не предупреждают
+	startTestTimer(t, b, "5")
+
+	if fakeClock.Pending() != 1 {
+		t.Errorf("Expected only the timer of a short task, got %d", fakeClock.Pending())
+	}
+}
+
+func TestDirectMessages(t *testing.T) {
+	b, recorder, fakeClock := newTestBot(t)
+
+	// Пользователь открывает личный чат с ботом
+	start := commandMessage(1, "/start")
+	start.Chat = &tgbotapi.Chat{ID: testUserID, Type: "private"}
+	b.handleMessage(b.ctx, start)
+
+	b.handleMessage(b.ctx, commandMessage(2, "/notify dm on"))
+
+	if texts := recorder.SentTexts(); !strings.Contains(texts[len(texts)-1], "Private messages turned on. You'll get") {
+		t.Fatalf("Expected DMs turned on, got %q", texts[len(texts)-1])
+	}
+
+	privateTexts := func() []string {
+		var texts []string
+
+		for _, call := range recorder.CallsOf(messenger.MethodSend) {
+			if call.ChatID == testUserID {
+				texts = append(texts, call.Text)
+			}
+		}
+
+		return texts
+	}
+
+	recorder.Reset()
+	startTestTimer(t, b, "30")
+
+	// Предупреждение уходит только в личку
+	fakeClock.Advance(30*time.Minute - service.TimerWarningLead)
+
+	private := privateTexts()
+	if len(private) != 1 || !strings.Contains(private[0], "Your timer for task *staging* expires in 5 minutes") {
+		t.Fatalf("Expected a private warning, got %q", private)
+	}
+
+	for _, text := range recorder.SentTexts() {
+		if strings.Contains(text, "expires in") && !strings.HasPrefix(text, "⏳ Your") {
+			t.Errorf("Expected no warning in the group, got %q", text)
+		}
+	}
+
+	// Истечение приходит и в группу, и в личку
+	fakeClock.Advance(service.TimerWarningLead)
+
+	private = privateTexts()
+	if countExpired(recorder) != 1 || len(private) != 2 || !strings.Contains(private[1], "Your timer for task *staging* has expired") {
+		t.Fatalf("Expected the expiry in the group and in private, got %q", recorder.SentTexts())
+	}
+
+	// Пользователь заблокировал бота: предупреждение уходит в группу
+	recorder.ChatErrors[testUserID] = &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+
+	b.handleMessage(b.ctx, commandMessage(3, "/30 staging"))
+	fakeClock.Advance(30*time.Minute - service.TimerWarningLead)
+
+	texts := recorder.SentTexts()
+	if !strings.Contains(texts[len(texts)-1], "Alice, timer for task *staging* expires in 5 minutes") {
+		t.Errorf("Expected the warning in the group, got %q", texts[len(texts)-1])
+	}
+
+	settings, err := b.storage.GetNotifySettings(b.ctx, testUserID)
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+
+	if !settings.DM || settings.DMChatID != 0 {
+		t.Errorf("Expected DMs on without a private chat, got %+v", settings)
+	}
+}
+
+func TestTaskTimeoutEarly(t *testing.T) {
+	b, recorder, fakeClock := newTestBot(t)
+	task := startTestTimer(t, b, "30")
+
+	fakeClock.Advance(10 * time.Minute)
+
+	// Срабатывание раньше срока перезапускает таймер на оставшиеся 20 минут
+	b.handleTaskTimeout(b.ctx, testChatID, task.ID)
+
+	if countExpired(recorder) != 0 || !isActive(t, b, task.ID) {
+		t.Fatalf("Expected the timer to keep running, sent %q", recorder.SentTexts())
+	}
+
+	fakeClock.Advance(20*time.Minute - time.Second)
+
+	if countExpired(recorder) != 0 {
+		t.Fatalf("Expected no expiry before the end, sent %q", recorder.SentTexts())
+	}
+
+	fakeClock.Advance(time.Second)
+
+	if countExpired(recorder) != 1 {
+		t.Errorf("Expected one expiry message, sent %q", recorder.SentTexts())
+	}
+}
+
+func TestTaskTimerExtended(t *testing.T) {
+	b, recorder, fakeClock := newTestBot(t)
+	task := startTestTimer(t, b, "30")
+
+	fakeClock.Advance(20 * time.Minute)
+
+	if _, _, err := b.service.ExtendTimer(b.ctx, testChatID, task.ID, 15, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to extend timer: %v", err)
+	}
+
+	// Осталось 10 + 15 минут
+	fakeClock.Advance(25*time.Minute - time.Second)
+
+	if countExpired(recorder) != 0 {
+		t.Fatalf("Expected the extended timer running, sent %q", recorder.SentTexts())
+	}
+
+	fakeClock.Advance(time.Second)
+
+	if countExpired(recorder) != 1 {
+		t.Errorf("Expected one expiry message, sent %q", recorder.SentTexts())
+	}
+}
+
+func TestTaskTimerPaused(t *testing.T) {
+	b, recorder, fakeClock := newTestBot(t)
+	task := startTestTimer(t, b, "30")
+
+	fakeClock.Advance(10 * time.Minute)
+
+	if _, _, err := b.service.PauseTimer(b.ctx, testChatID, task.ID, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to pause timer: %v", err)
+	}
+
+	// До конца паузы таймер не истекает, предупреждение на паузе не ставится
+	fakeClock.Advance(models.MaxPause - time.Second)
+
+	if countExpired(recorder) != 0 || fakeClock.Pending() != 1 {
+		t.Fatalf("Expected a paused timer not to expire, sent %q, pending %d", recorder.SentTexts(), fakeClock.Pending())
+	}
+
+	if _, _, err := b.service.ResumeTimer(b.ctx, testChatID, task.ID, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to resume timer: %v", err)
+	}
+
+	fakeClock.Advance(20*time.Minute - time.Second)
+
+	if countExpired(recorder) != 0 {
+		t.Fatalf("Expected the resumed timer running, sent %q", recorder.SentTexts())
+	}
+
+	fakeClock.Advance(time.Second)
+
+	if countExpired(recorder) != 1 {
+		t.Errorf("Expected one expiry message, sent %q", recorder.SentTexts())
+	}
+}
+
+func TestTaskTimerPauseExpires(t *testing.T) {
+	b, recorder, fakeClock := newTestBot(t)
+	task := startTestTimer(t, b, "30")
+
+	if _, _, err := b.service.PauseTimer(b.ctx, testChatID, task.ID, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to pause timer: %v", err)
+	}
+
+	// Слишком долгая пауза освобождает задачу
+	fakeClock.Advance(models.MaxPause)
+
+	texts := recorder.SentTexts()
+	if !strings.Contains(texts[len(texts)-1], "was paused for too long and expired") {
+		t.Fatalf("Expected the pause expiry message, sent %q", texts)
+	}
+
+	if isActive(t, b, task.ID) || fakeClock.Pending() != 0 {
+		t.Errorf("Expected the task released without scheduled timers, pending %d", fakeClock.Pending())
+	}
+}
+
+func TestRestoreActiveTasks(t *testing.T) {
+	t.Run("Running timer", func(t *testing.T) {
+		b, _, fakeClock := newTestBot(t)
+		task := startTestTimer(t, b, "30")
+
+		// Бот останавливается на 10 минут
+		b.Stop()
+		fakeClock.Advance(10 * time.Minute)
+
+		restarted, recorder := newTestBotWith(t, b.storage.(*redis.Storage), fakeClock)
+		if err := restarted.restoreActiveTasks(); err != nil {
+			t.Fatalf("Failed to restore timers: %v", err)
+		}
+
+		// Таймер и предупреждение перед его концом
+		if fakeClock.Pending() != 2 {
+			t.Fatalf("Expected the restored timer and its warning, got %d", fakeClock.Pending())
+		}
+
+		fakeClock.Advance(20*time.Minute - time.Second)
+
+		if countExpired(recorder) != 0 || !isActive(t, restarted, task.ID) {
+			t.Fatalf("Expected the restored timer running, sent %q", recorder.SentTexts())
+		}
+
+		fakeClock.Advance(time.Second)
+
+		if countExpired(recorder) != 1 || isActive(t, restarted, task.ID) {
+			t.Errorf("Expected the restored timer to expire, sent %q", recorder.SentTexts())
+		}
+	})
+
+	t.Run("Timer expired while stopped", func(t *testing.T) {
+		b, _, fakeClock := newTestBot(t)
+		task := startTestTimer(t, b, "30")
+
+		b.Stop()
+		fakeClock.Advance(time.Hour)
+
+		restarted, recorder := newTestBotWith(t, b.storage.(*redis.Storage), fakeClock)
+		if err := restarted.restoreActiveTasks(); err != nil {
+			t.Fatalf("Failed to restore timers: %v", err)
+		}
+
+		// Истекшие таймеры закрываются сразу в отдельной горутине
+		if _, ok := recorder.Wait(messenger.MethodSend, 1, time.Second); !ok || countExpired(recorder) != 1 {
+			t.Fatalf("Expected an expiry message after restore, sent %q", recorder.SentTexts())
+		}
+
+		if isActive(t, restarted, task.ID) {
+			t.Error("Expected the expired timer to be released")
+		}
+	})
+
+	t.Run("Paused timer", func(t *testing.T) {
+		b, _, fakeClock := newTestBot(t)
+		task := startTestTimer(t, b, "30")
+
+		if _, _, err := b.service.PauseTimer(b.ctx, testChatID, task.ID, service.SourceAPI); err != nil {
+			t.Fatalf("Failed to pause timer: %v", err)
+		}
+
+		b.Stop()
+		fakeClock.Advance(models.MaxPause / 2)
+
+		restarted, recorder := newTestBotWith(t, b.storage.(*redis.Storage), fakeClock)
+		if err := restarted.restoreActiveTasks(); err != nil {
+			t.Fatalf("Failed to restore timers: %v", err)
+		}
+
+		// Восстанавливается только таймер конца паузы
+		if fakeClock.Pending() != 1 || len(recorder.SentTexts()) != 0 || !isActive(t, restarted, task.ID) {
+			t.Fatalf("Expected the paused timer kept until its pause ends, pending %d", fakeClock.Pending())
+		}
+
+		fakeClock.Advance(models.MaxPause / 2)
+
+		if isActive(t, restarted, task.ID) {
+			t.Errorf("Expected the paused timer to expire at the end of its pause, sent %q", recorder.SentTexts())
+		}
+	})
+}
+
+func TestExternalChatTimer(t *testing.T) {
+	b, recorder, fakeClock := newTestBot(t)
+
+	chatID, err := b.service.LinkChat(b.ctx, models.PlatformSlack, "C0123ABC")
+	if err != nil {
+		t.Fatalf("Failed to link chat: %v", err)
+	}
+
+	task, err := b.service.CreateTask(b.ctx, chatID, "staging", "", service.SourceSlack)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	if _, _, err := b.service.StartTimer(b.ctx, &service.StartTimerRequest{
+		ChatID:   chatID,
+		TaskID:   task.ID,
+		UserID:   service.ExternalUserID(models.PlatformSlack, "U0123"),
+		Duration: 30,
+		Source:   service.SourceSlack,
+	}); err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	// Таймер идет в боте, но в Telegram ничего не отправляется
+	fakeClock.Advance(30 * time.Minute)
+
+	if _, err := b.storage.GetActiveTask(b.ctx, chatID, task.ID); err == nil {
+		t.Error("Expected the timer of the Slack chat to expire")
+	}
+
+	if calls := recorder.Calls(); len(calls) != 0 {
+		t.Errorf("Expected nothing sent to Telegram, got %+v", calls)
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/messenger"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/notify"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	testChatID = int64(-100)
+	testUserID = int64(42)
+)
+
+// Начало отсчета фальшивых часов в тестах
+var testStart = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// Создает бота, который пишет в Recorder вместо Telegram и живет по фальшивым часам
+func newTestBot(t *testing.T) (*Bot, *messenger.Recorder, *clock.Fake) {
+	t.Helper()
+
+	fakeClock := clock.NewFake(testStart)
+	store := newTestStorage(t)
+	store.SetClock(fakeClock)
+
+	b, recorder := newTestBotWith(t, store, fakeClock)
+
+	return b, recorder, fakeClock
+}
+
+// Создает бота поверх существующего хранилища, как после перезапуска
+func newTestBotWith(t *testing.T, store *redis.Storage, fakeClock *clock.Fake) (*Bot, *messenger.Recorder) {
+	t.Helper()
+
+	svc := service.New(store)
+	svc.SetClock(fakeClock)
+
+	recorder := messenger.NewRecorder()
+	b := newBot(&Config{}, recorder, store, svc)
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
+	t.Cleanup(b.Stop)
+
+	return b, recorder
+}
+
+// Собирает сообщение с командой так же, как его присылает Telegram
+func commandMessage(messageID int, text string) *tgbotapi.Message {
+	command := strings.Fields(text)[0]
+
+	return &tgbotapi.Message{
+		MessageID: messageID,
+		From:      &tgbotapi.User{ID: testUserID, FirstName: "Alice"},
+		Chat:      &tgbotapi.Chat{ID: testChatID, Type: "supergroup"},
+		Text:      text,
+		Entities: []tgbotapi.MessageEntity{
+			{Type: "bot_command", Offset: 0, Length: len(command)},
+		},
+	}
+}
+
+func TestCommandFlows(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		// Подстроки ответов бота по порядку, по одной на отправленное сообщение
+		replies []string
+	}{
+		{
+			name:     "add task",
+			commands: []string{"/add staging Test stand"},
+			replies:  []string{"Task added successfully!\n\nName: *staging*"},
+		},
+		{
+			name:     "duplicate task",
+			commands: []string{"/add staging", "/add staging"},
+			replies:  []string{"Task added successfully!", "A task with name *staging* already exists"},
+		},
+		{
+			name:     "start timer",
+			commands: []string{"/add staging", "/30 staging"},
+			replies:  []string{"Task added successfully!", "Timer started for 30 minutes"},
+		},
+		{
+			name:     "unknown task",
+			commands: []string{"/30 staging"},
+			replies:  []string{"Task *staging* not found"},
+		},
+		{
+			name:     "missing task name",
+			commands: []string{"/30"},
+			replies:  []string{"Please provide a task name"},
+		},
+		{
+			name:     "duration over the limit",
+			commands: []string{"/100000 staging"},
+			replies:  []string{"Duration exceeds maximum allowed limit"},
+		},
+		{
+			name:     "timer already running",
+			commands: []string{"/add staging", "/30 staging", "/10 staging"},
+			replies:  []string{"Task added successfully!", "Timer started for 30 minutes", "You're already working on this task"},
+		},
+		{
+			name:     "cancel timer",
+			commands: []string{"/add staging", "/30 staging", "/cancel"},
+			replies:  []string{"Task added successfully!", "Timer started for 30 minutes", "Timer for task *staging* has been cancelled"},
+		},
+		{
+			name:     "cancel without timers",
+			commands: []string{"/cancel"},
+			replies:  []string{"You don't have any active tasks"},
+		},
+		{
+			name:     "notifications not configured",
+			commands: []string{"/notify email dev@example.com"},
+			replies:  []string{"Email notifications are not configured"},
+		},
+		{
+			name:     "API key by a member",
+			commands: []string{"/api_key create deploy", "/api_key revoke abc", "/api_key rotate abc", "/api_key list"},
+			replies: []string{
+				"Only chat administrators can manage API keys",
+				"Only chat administrators can manage API keys",
+				"Only chat administrators can manage API keys",
+				"No API keys",
+			},
+		},
+		{
+			name:     "unknown command is ignored",
+			commands: []string{"/nope"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, recorder, _ := newTestBot(t)
+
+			for i, command := range tt.commands {
+				b.handleMessage(b.ctx, commandMessage(i+1, command))
+			}
+
+			texts := recorder.SentTexts()
+			if len(texts) != len(tt.replies) {
+				t.Fatalf("Expected %d replies, got %d: %q", len(tt.replies), len(texts), texts)
+			}
+
+			for i, reply := range tt.replies {
+				if !strings.Contains(texts[i], reply) {
+					t.Errorf("Reply %d: expected %q in %q", i, reply, texts[i])
+				}
+			}
+		})
+	}
+}
+
+// Делает пользователя администратором тестового чата и запоминает его личный чат с ботом
+func setupAPIKeyAdmin(t *testing.T, b *Bot, recorder *messenger.Recorder) {
+	t.Helper()
+
+	recorder.Admins[testChatID] = []tgbotapi.ChatMember{{User: &tgbotapi.User{ID: testUserID}, Status: "administrator"}}
+
+	if err := b.storage.SaveNotifySettings(b.ctx, &models.NotifySettings{UserID: testUserID, DMChatID: testUserID}); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+}
+
+func TestAPIKeyTierGranted(t *testing.T) {
+	b, recorder, _ := newTestBot(t)
+	b.config.TierChats = map[int64]string{testChatID: "ci"}
+	setupAPIKeyAdmin(t, b, recorder)
+
+	b.handleMessage(b.ctx, commandMessage(1, "/api_key create deploy tier=ci"))
+	b.handleMessage(b.ctx, commandMessage(2, "/api_key create other tier=partner"))
+
+	texts := recorder.SentTexts()
+	if len(texts) != 3 || !strings.Contains(texts[1], "API key created") || !strings.Contains(texts[2], "Tier `partner` is not granted") {
+		t.Fatalf("Unexpected replies: %q", texts)
+	}
+
+	keys, err := b.storage.ListAPIKeys(b.ctx, testChatID)
+	if err != nil {
+		t.Fatalf("Failed to list API keys: %v", err)
+	}
+
+	// Выданный оператором тариф сохраняется в ключе, чужой ключ не создается
+	if len(keys) != 1 || keys[0].Tier != "ci" {
+		t.Errorf("Expected one key of tier ci, got %+v", keys)
+	}
+}
+
+func TestNotifyCommand(t *testing.T) {
+	b, recorder, _ := newTestBot(t)
+
+	sender := notify.NewRecorder()
+	notifier := notify.New(b.storage, b.service, sender)
+	t.Cleanup(notifier.Stop)
+	b.SetNotifier(notifier)
+
+	// Команды от другого пользователя, который ждет задачу
+	send := func(messageID int, text string) string {
+		t.Helper()
+
+		message := commandMessage(messageID, text)
+		message.From = &tgbotapi.User{ID: testUserID + 1, FirstName: "Bob"}
+
+		b.handleMessage(b.ctx, message)
+
+		texts := recorder.SentTexts()
+
+		return texts[len(texts)-1]
+	}
+
+	if reply := send(1, "/notify email not-an-email"); !strings.Contains(reply, "Invalid email address") {
+		t.Errorf("Expected invalid address, got %q", reply)
+	}
+
+	if reply := send(2, "/notify email bob@example.com"); !strings.Contains(reply, "Confirmation code sent to `bob@example.com`") {
+		t.Fatalf("Expected a code sent, got %q", reply)
+	}
+
+	emails := sender.Emails()
+	if len(emails) != 1 {
+		t.Fatalf("Expected a confirmation email, got %d", len(emails))
+	}
+
+	code := regexp.MustCompile(`\d{6}`).FindString(emails[0].Body)
+
+	if reply := send(3, "/notify confirm "+code); !strings.Contains(reply, "Email `bob@example.com` confirmed") {
+		t.Fatalf("Expected the email confirmed, got %q", reply)
+	}
+
+	b.handleMessage(b.ctx, commandMessage(4, "/add staging"))
+
+	if reply := send(5, "/notify watch staging"); !strings.Contains(reply, "Task *staging* is free right now") {
+		t.Errorf("Expected a free task not to be watched, got %q", reply)
+	}
+
+	b.handleMessage(b.ctx, commandMessage(6, "/30 staging"))
+
+	if reply := send(7, "/notify watch staging"); !strings.Contains(reply, "You'll get an email when task *staging* is free") {
+		t.Fatalf("Expected the task watched, got %q", reply)
+	}
+
+	b.handleMessage(b.ctx, commandMessage(8, "/cancel"))
+
+	emails, ok := sender.Wait(2, time.Second)
+	if !ok || emails[1].To != "bob@example.com" || emails[1].Subject != "Task staging is free" {
+		t.Errorf("Expected an email about the free task, got %+v", emails)
+	}
+}
+
+func TestTimerKeyboard(t *testing.T) {
+	b, recorder, _ := newTestBot(t)
+
+	b.handleMessage(b.ctx, commandMessage(1, "/add staging"))
+	b.handleMessage(b.ctx, commandMessage(2, "/30 staging"))
+
+	sent := recorder.CallsOf(messenger.MethodSend)
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 sent messages, got %d", len(sent))
+	}
+
+	msg, ok := sent[1].Config.(tgbotapi.MessageConfig)
+	if !ok {
+		t.Fatalf("Expected MessageConfig, got %T", sent[1].Config)
+	}
+
+	if msg.ReplyToMessageID != 2 {
+		t.Errorf("Expected reply to message 2, got %d", msg.ReplyToMessageID)
+	}
+
+	keyboard, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok || len(keyboard.InlineKeyboard) != 2 {
+		t.Fatalf("Expected a two-row timer keyboard, got %#v", msg.ReplyMarkup)
+	}
+
+	if data := *keyboard.InlineKeyboard[1][0].CallbackData; !strings.HasPrefix(data, "done:") {
+		t.Errorf("Expected the done button, got %q", data)
+	}
+
+	// Отмена снимает клавиатуру с сообщения таймера
+	b.handleMessage(b.ctx, commandMessage(3, "/cancel staging"))
+
+	edits := recorder.CallsOf(messenger.MethodEditMarkup)
+	if len(edits) != 1 || edits[0].MessageID != sent[1].MessageID {
+		t.Errorf("Expected the keyboard of message %d removed, got %+v", sent[1].MessageID, edits)
+	}
+}
+
+func TestAPIKeyPrivateMessage(t *testing.T) {
+	b, recorder, _ := newTestBot(t)
+	recorder.Admins[testChatID] = []tgbotapi.ChatMember{{User: &tgbotapi.User{ID: testUserID}, Status: "administrator"}}
+
+	keys := func() []*models.APIKey {
+		t.Helper()
+
+		keys, err := b.storage.ListAPIKeys(b.ctx, testChatID)
+		if err != nil {
+			t.Fatalf("Failed to list API keys: %v", err)
+		}
+
+		return keys
+	}
+
+	// Без личного чата ключ не создается: бот просит его запустить
+	b.handleMessage(b.ctx, commandMessage(1, "/api_key create deploy"))
+
+	if texts := recorder.SentTexts(); len(texts) != 1 || !strings.Contains(texts[0], "Open a private chat with me") || len(keys()) != 0 {
+		t.Fatalf("Expected a request to start the bot and no key, got %q", texts)
+	}
+
+	setupAPIKeyAdmin(t, b, recorder)
+	recorder.Reset()
+
+	// Секрет уходит только в личный чат, в группе остается ID ключа
+	b.handleMessage(b.ctx, commandMessage(2, "/api_key create deploy"))
+
+	sent := recorder.CallsOf(messenger.MethodSend)
+	if len(sent) != 2 || sent[0].ChatID != testUserID || !strings.Contains(sent[0].Text, "tgb_") {
+		t.Fatalf("Expected the key in the private chat, got %+v", sent)
+	}
+
+	if sent[1].ChatID != testChatID || strings.Contains(sent[1].Text, "tgb_") || !strings.Contains(sent[1].Text, "sent to you by private message") {
+		t.Fatalf("Expected a confirmation without the secret in the group, got %q", sent[1].Text)
+	}
+
+	created := keys()
+	if len(created) != 1 {
+		t.Fatalf("Expected one key, got %d", len(created))
+	}
+
+	// Пользователь заблокировал бота: новый ключ отзывается, старый продолжает работать
+	recorder.ChatErrors[testUserID] = &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	recorder.Reset()
+
+	b.handleMessage(b.ctx, commandMessage(3, "/api_key rotate "+created[0].ID))
+
+	if texts := recorder.SentTexts(); len(texts) != 1 || !strings.Contains(texts[0], "Open a private chat with me") {
+		t.Fatalf("Expected a request to start the bot, got %q", texts)
+	}
+
+	if rotated := keys(); len(rotated) != 1 || rotated[0].ID != created[0].ID {
+		t.Errorf("Expected only the old key to remain, got %+v", rotated)
+	}
+
+	settings, err := b.storage.GetNotifySettings(b.ctx, testUserID)
+	if err != nil || settings.DMChatID != 0 {
+		t.Errorf("Expected the blocked private chat to be forgotten, got %+v (%v)", settings, err)
+	}
+}
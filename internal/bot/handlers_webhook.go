@@ -0,0 +1,225 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	// Usage of the /webhook command
+	webhookUsage = "Usage: `/webhook add {url} [events=a,b] | list | remove {id} | deliveries {id}`"
+	// Number of deliveries shown by /webhook deliveries
+	webhookDeliveriesShown = 10
+)
+
+// Handles the /webhook command: /webhook add|list|remove|deliveries ...
+func (b *Bot) HandleWebhookCommand(ctx context.Context, message *tgbotapi.Message, args []string) error {
+	if len(args) == 0 {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, webhookUsage)
+	}
+
+	switch args[0] {
+	case "add":
+		return b.addWebhook(ctx, message, args[1:])
+	case "list":
+		return b.listWebhooks(ctx, message)
+	case "remove":
+		if len(args) < 2 {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Please provide a webhook ID")
+		}
+
+		return b.removeWebhook(ctx, message, args[1])
+	case "deliveries":
+		if len(args) < 2 {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Please provide a webhook ID")
+		}
+
+		return b.listWebhookDeliveries(ctx, message, args[1])
+	default:
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, webhookUsage)
+	}
+}
+
+// Adds a webhook: /webhook add {url} [events=a,b]
+func (b *Bot) addWebhook(ctx context.Context, message *tgbotapi.Message, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, webhookUsage)
+	}
+
+	var events []string
+
+	if len(args) == 2 {
+		list, ok := strings.CutPrefix(args[1], "events=")
+		if !ok || list == "" {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, webhookUsage)
+		}
+
+		events = strings.Split(list, ",")
+	}
+
+	var createdBy int64
+	if message.From != nil {
+		createdBy = message.From.ID
+	}
+
+	webhook, err := b.service.AddWebhook(ctx, message.Chat.ID, args[0], events, createdBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidWebhook):
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("`%s`", err.Error()))
+		case errors.Is(err, service.ErrWebhookLimit):
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, "Maximum number of webhooks reached. Remove unused webhooks first")
+		default:
+			return err
+		}
+	}
+
+	text := fmt.Sprintf(
+		"Webhook `%s` added for %s\n\nSigning secret:\n`%s`\n\nSave it now, it won't be shown again",
+		webhook.ID, webhookEvents(webhook), webhook.Secret,
+	)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.messenger.Send(msg)
+
+	return err
+}
+
+// Lists webhooks of the chat without their secrets
+func (b *Bot) listWebhooks(ctx context.Context, message *tgbotapi.Message) error {
+	webhooks, err := b.storage.ListWebhooks(ctx, message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	if len(webhooks) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "No webhooks. Use /webhook add {url} to add one")
+		msg.ReplyToMessageID = message.MessageID
+		_, err = b.messenger.Send(msg)
+
+		return err
+	}
+
+	var text strings.Builder
+
+	text.WriteString("Webhooks:\n\n")
+
+	for _, webhook := range webhooks {
+		text.WriteString(fmt.Sprintf("`%s` `%s`\n", webhook.ID, webhook.URL))
+		text.WriteString(fmt.Sprintf("Events: %s, created: %s\n", webhookEvents(webhook), webhook.CreatedAt.Format(time.DateTime)))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.messenger.Send(msg)
+
+	return err
+}
+
+// Removes a webhook: /webhook remove {id}
+func (b *Bot) removeWebhook(ctx context.Context, message *tgbotapi.Message, webhookID string) error {
+	if err := b.storage.DeleteWebhook(ctx, message.Chat.ID, webhookID); err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Webhook `%s` not found", webhookID))
+		}
+
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Webhook `%s` removed", webhookID))
+	msg.ReplyToMessageID = message.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err := b.messenger.Send(msg)
+
+	return err
+}
+
+// Shows recent deliveries of a webhook with their last attempt: /webhook deliveries {id}
+func (b *Bot) listWebhookDeliveries(ctx context.Context, message *tgbotapi.Message, webhookID string) error {
+	webhook, err := b.storage.GetWebhook(ctx, webhookID)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	if err != nil || webhook.ChatID != message.Chat.ID {
+		return b.sendErrorMessage(message.Chat.ID, message.MessageID, fmt.Sprintf("Webhook `%s` not found", webhookID))
+	}
+
+	deliveries, err := b.storage.ListDeliveries(ctx, webhookID, webhookDeliveriesShown)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	if len(deliveries) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "No deliveries yet")
+		msg.ReplyToMessageID = message.MessageID
+		_, err = b.messenger.Send(msg)
+
+		return err
+	}
+
+	var text strings.Builder
+
+	text.WriteString(fmt.Sprintf("Deliveries of webhook `%s`:\n\n", webhookID))
+
+	for _, delivery := range deliveries {
+		text.WriteString(fmt.Sprintf(
+			"%s `%s` %s, attempts: %d",
+			delivery.CreatedAt.Format(time.DateTime), delivery.Event, delivery.Status, len(delivery.Attempts),
+		))
+
+		if n := len(delivery.Attempts); n > 0 {
+			text.WriteString(", last: " + deliveryAttemptResult(delivery.Attempts[n-1]))
+		}
+
+		text.WriteString("\n")
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	_, err = b.messenger.Send(msg)
+
+	return err
+}
+
+// Describes the events a webhook receives
+func webhookEvents(webhook *models.Webhook) string {
+	if len(webhook.Events) == 0 {
+		return "all events"
+	}
+
+	return fmt.Sprintf("`%s`", strings.Join(webhook.Events, ", "))
+}
+
+// Describes the result of a delivery attempt
+func deliveryAttemptResult(attempt models.DeliveryAttempt) string {
+	if attempt.StatusCode == 0 {
+		return "no response"
+	}
+
+	return fmt.Sprintf("HTTP %d", attempt.StatusCode)
+}
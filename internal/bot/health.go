@@ -0,0 +1,143 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Reports whether Telegram updates are being received
+// A poll is answered at least every LongPollTimeout, so a loop that hasn't heard
+// from Telegram for much longer is stuck or can't reach it. In webhook mode a quiet
+// chat sends nothing, so Telegram is asked whether it can deliver updates instead
+func (b *Bot) CheckUpdates(ctx context.Context) error {
+	if b.config.WebhookURL != "" {
+		return b.checkWebhook(ctx)
+	}
+
+	lastContact := b.lastContact.Load()
+	if lastContact == 0 {
+		return errors.New("no updates polled yet")
+	}
+
+	staleAfter := time.Duration(b.config.LongPollTimeout)*time.Second + time.Minute
+	if since := time.Since(time.Unix(0, lastContact)); since > staleAfter {
+		return fmt.Errorf("no updates polled for %s", since.Truncate(time.Second))
+	}
+
+	return nil
+}
+
+// How long a failed webhook delivery makes the bot not ready
+const webhookErrorWindow = 5 * time.Minute
+
+const (
+	// How long a webhook info answer is reused by readiness checks
+	webhookInfoTTL = 30 * time.Second
+	// How long a readiness check waits for Telegram to answer
+	webhookInfoTimeout = 5 * time.Second
+)
+
+// Last webhook info Telegram returned and the request for a newer one
+type webhookInfoCache struct {
+	mu        sync.Mutex
+	info      tgbotapi.WebhookInfo
+	err       error
+	fetchedAt time.Time
+	pending   chan struct{} // Closed when the running request ends, nil if none runs
+}
+
+// Reports whether Telegram delivers updates to the webhook
+// The last delivery error only counts if it is recent and no update was received after it
+func (b *Bot) checkWebhook(ctx context.Context) error {
+	info, err := b.getWebhookInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook info: %w", err)
+	}
+
+	if info.URL != b.config.WebhookURL {
+		return errors.New("webhook is not registered")
+	}
+
+	lastError := time.Unix(int64(info.LastErrorDate), 0)
+	if info.LastErrorDate != 0 && time.Since(lastError) < webhookErrorWindow && lastError.After(time.Unix(0, b.lastContact.Load())) {
+		return fmt.Errorf("updates can't be delivered to the webhook: %s", info.LastErrorMessage)
+	}
+
+	return nil
+}
+
+// Returns the webhook info, asking Telegram at most once per webhookInfoTTL
+// tgbotapi takes no context, so the request runs in the background: a check that
+// stops waiting for it leaves the answer to the next one
+func (b *Bot) getWebhookInfo(ctx context.Context) (tgbotapi.WebhookInfo, error) {
+	cache := &b.webhookInfo
+
+	cache.mu.Lock()
+
+	if !cache.fetchedAt.IsZero() && time.Since(cache.fetchedAt) < webhookInfoTTL {
+		defer cache.mu.Unlock()
+		return cache.info, cache.err
+	}
+
+	if cache.pending == nil {
+		cache.pending = make(chan struct{})
+		go b.fetchWebhookInfo(cache.pending)
+	}
+
+	pending := cache.pending
+	cache.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, webhookInfoTimeout)
+	defer cancel()
+
+	select {
+	case <-pending:
+	case <-ctx.Done():
+		return tgbotapi.WebhookInfo{}, fmt.Errorf("no answer from Telegram: %w", ctx.Err())
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.info, cache.err
+}
+
+// Asks Telegram for the webhook info and caches the answer, errors included
+func (b *Bot) fetchWebhookInfo(done chan struct{}) {
+	info, err := b.api.GetWebhookInfo()
+
+	cache := &b.webhookInfo
+	cache.mu.Lock()
+	cache.info, cache.err, cache.fetchedAt = info, err, time.Now()
+	cache.pending = nil
+	cache.mu.Unlock()
+
+	close(done)
+}
+
+// Reports whether the timers running before the start were restored
+func (b *Bot) CheckTimers(ctx context.Context) error {
+	if !b.timersRestored.Load() {
+		return errors.New("timers are not restored yet")
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Message about a user's timer, routed by their notification settings
+type notification struct {
+	userID int64
+	group  tgbotapi.MessageConfig // Message in the chat of the task
+	// Markdown text of the private message, the group message is shown without a chat context there
+	private string
+	// Whether the group message is only a fallback for a private message that can't be delivered
+	groupOnlyOnFailure bool
+}
+
+// Sends a notification to the group and, if the user turned DMs on, to their private chat
+// A user who never started a private chat or blocked the bot gets the group message instead
+func (b *Bot) routeNotification(ctx context.Context, n *notification) {
+	settings, err := b.notifySettings(ctx, n.userID)
+	if err != nil {
+		log.Printf("Failed to get notification settings of user %d: %v", n.userID, err)
+
+		settings = &models.NotifySettings{UserID: n.userID}
+	}
+
+	delivered := settings.DM && b.sendPrivate(ctx, settings, n)
+
+	// Private-only notifications aren't posted for users who didn't turn DMs on
+	if n.groupOnlyOnFailure && (delivered || !settings.DM) {
+		return
+	}
+
+	if _, err := b.messenger.Send(n.group); err != nil {
+		log.Printf("Failed to send notification to chat %d: %v", n.group.ChatID, err)
+	}
+}
+
+// Sends the private copy of a notification, reports whether the user got it
+func (b *Bot) sendPrivate(ctx context.Context, settings *models.NotifySettings, n *notification) bool {
+	// No private chat yet, or the timer runs in the private chat itself
+	if settings.DMChatID == 0 || settings.DMChatID == n.group.ChatID {
+		return false
+	}
+
+	msg := tgbotapi.NewMessage(settings.DMChatID, n.private)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+
+	_, err := b.messenger.Send(msg)
+	if err == nil {
+		return true
+	}
+
+	log.Printf("Failed to send private notification to user %d: %v", n.userID, err)
+	b.forgetBlockedChat(ctx, settings, err)
+
+	return false
+}
+
+// Forgets the private chat of a user if a message failed because they blocked the bot
+// Telegram answers 403 then, the bot can't write to them until they start it again
+func (b *Bot) forgetBlockedChat(ctx context.Context, settings *models.NotifySettings, err error) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return
+	}
+
+	settings.DMChatID = 0
+	settings.UpdatedAt = b.clock.Now()
+
+	if err := b.storage.SaveNotifySettings(ctx, settings); err != nil {
+		log.Printf("Failed to forget private chat of user %d: %v", settings.UserID, err)
+	}
+}
+
+// Remembers the private chat of a user, so notifications can be sent there
+func (b *Bot) rememberPrivateChat(ctx context.Context, chat *tgbotapi.Chat, from *tgbotapi.User) {
+	if chat == nil || !chat.IsPrivate() || from == nil || from.IsBot {
+		return
+	}
+
+	settings, err := b.notifySettings(ctx, from.ID)
+	if err != nil {
+		log.Printf("Failed to get notification settings: %v", err)
+		return
+	}
+
+	if settings.DMChatID == chat.ID {
+		return
+	}
+
+	settings.DMChatID = chat.ID
+	settings.UpdatedAt = b.clock.Now()
+
+	if err := b.storage.SaveNotifySettings(ctx, settings); err != nil {
+		log.Printf("Failed to save private chat: %v", err)
+	}
+}
+
+// Gets the notification settings of a user, empty ones if they have none yet
+func (b *Bot) notifySettings(ctx context.Context, userID int64) (*models.NotifySettings, error) {
+	settings, err := b.storage.GetNotifySettings(ctx, userID)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return &models.NotifySettings{UserID: userID}, nil
+		}
+
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Header Telegram puts the webhook secret in
+	telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// Maximum size of an update body
+	maxUpdateSize = 1 << 20
+)
+
+// Generates a random webhook secret, Telegram allows letters, digits, _ and -
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// Registers the webhook URL and secret in Telegram
+func (b *Bot) setWebhook() error {
+	params := tgbotapi.Params{
+		"url":          b.config.WebhookURL,
+		"secret_token": b.webhookSecret,
+	}
+
+	// tgbotapi.WebhookConfig can't send secret_token, so the request is made by hand
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	log.Printf("Receiving updates on webhook %s", b.config.WebhookURL)
+
+	return nil
+}
+
+// Returns the path of the webhook URL, where WebhookHandler has to be served
+func (b *Bot) WebhookPath() (string, error) {
+	webhookURL, err := url.Parse(b.config.WebhookURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	if webhookURL.Path == "" {
+		return "/", nil
+	}
+
+	return webhookURL.Path, nil
+}
+
+// Returns the handler of updates sent by Telegram in webhook mode
+// Requests without the webhook secret are rejected, so only Telegram can send updates
+func (b *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		secret := r.Header.Get(telegramSecretHeader)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(b.webhookSecret)) != 1 {
+			http.Error(w, "Invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		// Telegram delivers the update again later if it isn't accepted now
+		if b.ctx == nil || b.ctx.Err() != nil {
+			http.Error(w, "Bot is not running", http.StatusServiceUnavailable)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUpdateSize)).Decode(&update); err != nil {
+			http.Error(w, "Invalid update", http.StatusBadRequest)
+			return
+		}
+
+		b.lastContact.Store(time.Now().UnixNano())
+		b.dispatchUpdate(update)
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
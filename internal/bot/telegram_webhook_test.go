@@ -0,0 +1,325 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Fake Bot API server: remembers the parameters of every call by method
+type fakeBotAPI struct {
+	server *httptest.Server
+	calls  chan fakeCall
+	mu     sync.Mutex
+	seen   map[string]int
+	// Answers of getUpdates, one per call, an empty list when they run out
+	updates []string
+	// Delay of getWebhookInfo answers, set before the first call
+	webhookInfoDelay time.Duration
+}
+
+type fakeCall struct {
+	method string
+	params map[string]string
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	t.Helper()
+
+	api := &fakeBotAPI{calls: make(chan fakeCall, 100), seen: make(map[string]int)}
+	api.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse Bot API request: %v", err)
+		}
+
+		// Путь запроса: /bot{token}/{method}
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		params := make(map[string]string)
+
+		for key := range r.PostForm {
+			params[key] = r.PostForm.Get(key)
+		}
+
+		api.mu.Lock()
+		api.seen[method]++
+		api.mu.Unlock()
+		api.calls <- fakeCall{method: method, params: params}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		var err error
+
+		switch method {
+		case "getMe":
+			_, err = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"test_bot"}}`))
+		case "sendMessage":
+			_, err = w.Write([]byte(`{"ok":true,"result":{"message_id":100,"date":0,"chat":{"id":-100,"type":"group"}}}`))
+		case "getUpdates":
+			_, err = w.Write([]byte(`{"ok":true,"result":[` + api.nextUpdate() + `]}`))
+		case "getWebhookInfo":
+			time.Sleep(api.webhookInfoDelay)
+			_, err = w.Write([]byte(`{"ok":true,"result":{"url":"https://bot.example.com/telegram/updates","pending_update_count":0}}`))
+		default:
+			_, err = w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+
+		if err != nil {
+			t.Errorf("Failed to write Bot API response: %v", err)
+		}
+	}))
+	t.Cleanup(api.server.Close)
+
+	return api
+}
+
+// Returns the next queued update, waits a bit instead of long polling if there are none
+func (f *fakeBotAPI) nextUpdate() string {
+	f.mu.Lock()
+
+	if len(f.updates) == 0 {
+		f.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+
+		return ""
+	}
+
+	update := f.updates[0]
+	f.updates = f.updates[1:]
+	f.mu.Unlock()
+
+	return update
+}
+
+// Waits for a call of the method
+func (f *fakeBotAPI) waitFor(t *testing.T, method string) fakeCall {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case call := <-f.calls:
+			if call.method == method {
+				return call
+			}
+		case <-timeout:
+			t.Fatalf("Bot API method %s was not called", method)
+		}
+	}
+}
+
+// Number of calls of the method so far
+func (f *fakeBotAPI) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.seen[method]
+}
+
+// Update with the /help command in chat -100
+const helpUpdate = `{"update_id": 10, "message": {"message_id": 5, "date": 0,
+	"chat": {"id": -100, "type": "group"}, "from": {"id": 42, "first_name": "Alice"},
+	"text": "/help", "entities": [{"type": "bot_command", "offset": 0, "length": 5}]}}`
+
+// Creates storage backed by miniredis
+func newTestStorage(t *testing.T) *redis.Storage {
+	t.Helper()
+
+	miniRedis := miniredis.RunT(t)
+
+	storage, err := redis.New(miniRedis.Addr(), "", 0)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := storage.Close(); err != nil {
+			t.Errorf("Failed to close storage: %v", err)
+		}
+	})
+
+	return storage
+}
+
+func TestPollingMode(t *testing.T) {
+	storage := newTestStorage(t)
+	fake := newFakeBotAPI(t)
+	fake.updates = []string{helpUpdate}
+
+	b, err := NewBot(&Config{
+		Token:       "TOKEN",
+		APIEndpoint: fake.server.URL + "/bot%s/%s",
+	}, storage, service.New(storage))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	if err := b.CheckUpdates(context.Background()); err == nil {
+		t.Error("Expected bot not to be ready before the first poll")
+	}
+
+	if err := b.Start(); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+
+	defer b.Stop()
+
+	// Вебхук, оставшийся от режима вебхука, удаляется перед опросом
+	fake.waitFor(t, "deleteWebhook")
+
+	call := fake.waitFor(t, "sendMessage")
+	if call.params["chat_id"] != "-100" || !strings.Contains(call.params["text"], "Task Management") {
+		t.Errorf("Expected help message in chat -100, got %v", call.params)
+	}
+
+	if err := b.CheckUpdates(context.Background()); err != nil {
+		t.Errorf("Expected bot to be ready after a poll, got %v", err)
+	}
+
+	if err := b.CheckTimers(context.Background()); err != nil {
+		t.Errorf("Expected timers to be restored, got %v", err)
+	}
+}
+
+func TestWebhookMode(t *testing.T) {
+	storage := newTestStorage(t)
+	fake := newFakeBotAPI(t)
+
+	b, err := NewBot(&Config{
+		Token:         "TOKEN",
+		APIEndpoint:   fake.server.URL + "/bot%s/%s",
+		WebhookURL:    "https://bot.example.com/telegram/updates",
+		WebhookSecret: "s3cret",
+	}, storage, service.New(storage))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	handler := b.WebhookHandler()
+
+	send := func(secret string, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/telegram/updates", strings.NewReader(body))
+		req.Header.Set(telegramSecretHeader, secret)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	// До запуска бота обновления не принимаются, Telegram повторит доставку
+	if code := send("s3cret", helpUpdate); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before start, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	if err := b.Start(); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+
+	defer b.Stop()
+
+	call := fake.waitFor(t, "setWebhook")
+	if call.params["url"] != "https://bot.example.com/telegram/updates" || call.params["secret_token"] != "s3cret" {
+		t.Errorf("Unexpected setWebhook parameters: %v", call.params)
+	}
+
+	// В режиме вебхука обновления не запрашиваются
+	if fake.count("getUpdates") != 0 {
+		t.Error("Expected no polling in webhook mode")
+	}
+
+	if code := send("wrong", helpUpdate); code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for wrong secret, got %d", http.StatusUnauthorized, code)
+	}
+
+	if code := send("s3cret", "{"); code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid update, got %d", http.StatusBadRequest, code)
+	}
+
+	if code := send("s3cret", helpUpdate); code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+	}
+
+	// Обновление обработано как обычная команда
+	call = fake.waitFor(t, "sendMessage")
+	if call.params["chat_id"] != "-100" || !strings.Contains(call.params["text"], "Task Management") {
+		t.Errorf("Expected help message in chat -100, got %v", call.params)
+	}
+
+	if err := b.CheckUpdates(context.Background()); err != nil {
+		t.Errorf("Expected webhook to be ready, got %v", err)
+	}
+}
+
+func TestWebhookReadiness(t *testing.T) {
+	storage := newTestStorage(t)
+	fake := newFakeBotAPI(t)
+	fake.webhookInfoDelay = 200 * time.Millisecond
+
+	b, err := NewBot(&Config{
+		Token:       "TOKEN",
+		APIEndpoint: fake.server.URL + "/bot%s/%s",
+		WebhookURL:  "https://bot.example.com/telegram/updates",
+	}, storage, service.New(storage))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	// Медленный Telegram не задерживает проверку дольше ее контекста
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if err := b.CheckUpdates(ctx); err == nil {
+		t.Error("Expected check to fail when Telegram doesn't answer in time")
+	}
+
+	if elapsed := time.Since(started); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected check to give up after its timeout, took %s", elapsed)
+	}
+
+	// Следующая проверка дожидается того же запроса, а не начинает новый
+	if err := b.CheckUpdates(context.Background()); err != nil {
+		t.Errorf("Expected webhook to be ready, got %v", err)
+	}
+
+	// Ответ используется повторно, Telegram не спрашивают при каждой проверке
+	if err := b.CheckUpdates(context.Background()); err != nil {
+		t.Errorf("Expected webhook to be ready, got %v", err)
+	}
+
+	if calls := fake.count("getWebhookInfo"); calls != 1 {
+		t.Errorf("Expected one getWebhookInfo call, got %d", calls)
+	}
+}
+
+func TestWebhookPath(t *testing.T) {
+	b := &Bot{config: &Config{WebhookURL: "https://bot.example.com/telegram/updates?x=1"}}
+
+	path, err := b.WebhookPath()
+	if err != nil || path != "/telegram/updates" {
+		t.Errorf("Expected /telegram/updates, got %q (%v)", path, err)
+	}
+}
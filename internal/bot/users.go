@@ -0,0 +1,79 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"time-guard-bot/internal/models"
+)
+
+// Saves the sender to the chat's user directory if their names changed
+func (b *Bot) rememberUser(ctx context.Context, chatID int64, from *tgbotapi.User) {
+	if from == nil || from.IsBot {
+		return
+	}
+
+	user := &models.ChatUser{
+		ID:        from.ID,
+		Username:  from.UserName,
+		FirstName: from.FirstName,
+		LastName:  from.LastName,
+		UpdatedAt: b.clock.Now(),
+	}
+
+	cacheKey := fmt.Sprintf("%d:%d", chatID, from.ID)
+
+	b.usersMx.Lock()
+	known, exists := b.users[cacheKey]
+	b.usersMx.Unlock()
+
+	if exists && known.SameNames(user) {
+		return
+	}
+
+	if err := b.storage.SaveChatUser(ctx, chatID, user); err != nil {
+		log.Printf("Failed to save chat user: %v", err)
+		return
+	}
+
+	b.usersMx.Lock()
+	b.users[cacheKey] = user
+	b.usersMx.Unlock()
+}
+
+// Gets the chat's user directory, empty on failure
+func (b *Bot) chatUsers(ctx context.Context, chatID int64) map[int64]*models.ChatUser {
+	users, err := b.storage.GetChatUsers(ctx, chatID)
+	if err != nil {
+		log.Printf("Failed to get chat users: %v", err)
+		return map[int64]*models.ChatUser{}
+	}
+
+	return users
+}
+
+// Returns the Markdown-escaped name of a user from the directory
+func holderName(users map[int64]*models.ChatUser, userID int64) string {
+	user, exists := users[userID]
+	if !exists {
+		user = &models.ChatUser{ID: userID}
+	}
+
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, user.DisplayName())
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clock
+
+import "time"
+
+// Tells the time and schedules functions. Timer code takes it instead of calling
+// the time package directly, so tests can move time by hand
+type Clock interface {
+	Now() time.Time
+	// Calls f in its own goroutine once d has passed
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// A function scheduled by AfterFunc
+type Timer interface {
+	// Cancels the call, false if it already ran or was stopped
+	Stop() bool
+}
+
+// Implements Clock with the time package
+type system struct{}
+
+// Returns the system clock
+func New() Clock {
+	return system{}
+}
+
+func (system) Now() time.Time {
+	return time.Now()
+}
+
+func (system) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
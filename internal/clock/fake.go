@@ -0,0 +1,135 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ Clock = (*Fake)(nil)
+
+// Implements Clock for tests: time stands still until Advance moves it
+// Safe for concurrent use
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	nextID int
+}
+
+type fakeTimer struct {
+	clock *Fake
+	id    int // Keeps timers with the same deadline in scheduling order
+	at    time.Time
+	f     func()
+}
+
+// Creates a fake clock showing now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Returns the current fake time
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Schedules f to run when Advance reaches now + d
+func (c *Fake) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	timer := &fakeTimer{clock: c, id: c.nextID, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+
+	return timer
+}
+
+// Moves the time forward by d and runs the functions that became due, earliest
+// first. Unlike time.AfterFunc they run synchronously, so everything they do is
+// finished when Advance returns
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	// Functions may schedule new timers, which also run if they are due by target
+	for {
+		c.mu.Lock()
+
+		timer := c.popDue(target)
+		if timer == nil {
+			c.now = target
+			c.mu.Unlock()
+
+			return
+		}
+
+		c.now = timer.at
+		c.mu.Unlock()
+
+		timer.f()
+	}
+}
+
+// Returns the number of scheduled functions that haven't run yet
+func (c *Fake) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// Removes and returns the earliest timer due by target, nil if there is none
+func (c *Fake) popDue(target time.Time) *fakeTimer {
+	sort.Slice(c.timers, func(i, j int) bool {
+		if c.timers[i].at.Equal(c.timers[j].at) {
+			return c.timers[i].id < c.timers[j].id
+		}
+
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+
+	if len(c.timers) == 0 || c.timers[0].at.After(target) {
+		return nil
+	}
+
+	timer := c.timers[0]
+	c.timers = c.timers[1:]
+
+	return timer
+}
+
+// Cancels the call if it hasn't run yet
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
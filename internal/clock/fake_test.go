@@ -0,0 +1,108 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFakeNow(t *testing.T) {
+	clock := NewFake(start)
+
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected %v, got %v", start, clock.Now())
+	}
+
+	clock.Advance(90 * time.Second)
+
+	if want := start.Add(90 * time.Second); !clock.Now().Equal(want) {
+		t.Errorf("Expected %v, got %v", want, clock.Now())
+	}
+}
+
+func TestFakeAfterFunc(t *testing.T) {
+	clock := NewFake(start)
+
+	var fired []string
+
+	clock.AfterFunc(2*time.Minute, func() { fired = append(fired, "second") })
+	clock.AfterFunc(time.Minute, func() { fired = append(fired, "first") })
+	stopped := clock.AfterFunc(time.Minute, func() { fired = append(fired, "stopped") })
+
+	if !stopped.Stop() {
+		t.Error("Expected Stop to cancel a pending timer")
+	}
+
+	if stopped.Stop() {
+		t.Error("Expected a second Stop to report false")
+	}
+
+	// За секунду до срока ничего не срабатывает
+	clock.Advance(time.Minute - time.Second)
+
+	if len(fired) != 0 {
+		t.Fatalf("Expected nothing fired yet, got %v", fired)
+	}
+
+	clock.Advance(time.Second)
+
+	if len(fired) != 1 || fired[0] != "first" {
+		t.Fatalf("Expected only the first timer fired, got %v", fired)
+	}
+
+	clock.Advance(time.Hour)
+
+	if len(fired) != 2 || fired[1] != "second" {
+		t.Fatalf("Expected the second timer fired, got %v", fired)
+	}
+
+	if clock.Pending() != 0 {
+		t.Errorf("Expected no pending timers, got %d", clock.Pending())
+	}
+}
+
+func TestFakeAfterFuncChain(t *testing.T) {
+	clock := NewFake(start)
+
+	var firedAt []time.Time
+
+	// Функция, перезапускающая себя, как таймер задачи после продления
+	var tick func()
+	tick = func() {
+		firedAt = append(firedAt, clock.Now())
+		if len(firedAt) < 3 {
+			clock.AfterFunc(time.Minute, tick)
+		}
+	}
+
+	clock.AfterFunc(time.Minute, tick)
+	clock.Advance(10 * time.Minute)
+
+	if len(firedAt) != 3 {
+		t.Fatalf("Expected 3 calls, got %d", len(firedAt))
+	}
+
+	for i, at := range firedAt {
+		if want := start.Add(time.Duration(i+1) * time.Minute); !at.Equal(want) {
+			t.Errorf("Call %d: expected at %v, got %v", i, want, at)
+		}
+	}
+
+	if want := start.Add(10 * time.Minute); !clock.Now().Equal(want) {
+		t.Errorf("Expected the clock at %v, got %v", want, clock.Now())
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Posts changes of Discord chats that didn't come from Discord, like expired
+// timers or changes made through the API, to their channel
+func (a *Adapter) handleServiceEvent(ctx context.Context, event *service.Event) {
+	if event.Source == service.SourceDiscord || !service.IsExternalChat(event.ChatID) {
+		return
+	}
+
+	link, err := a.storage.GetChatLink(ctx, event.ChatID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get chat link: %v", err)
+		}
+
+		return
+	}
+
+	if link.Platform != models.PlatformDiscord {
+		return
+	}
+
+	resp := a.eventMessage(ctx, event)
+	if resp == nil {
+		return
+	}
+
+	if err := a.client.createMessage(ctx, link.ExternalID, resp.Data); err != nil {
+		log.Printf("Failed to announce %s in Discord: %v", event.Type, err)
+	}
+}
+
+// Returns the announcement of a change, nil for quiet ones
+func (a *Adapter) eventMessage(ctx context.Context, event *service.Event) *response {
+	holder := func() string {
+		users, err := a.storage.GetChatUsers(ctx, event.ChatID)
+		if err != nil {
+			log.Printf("Failed to get chat users: %v", err)
+
+			users = map[int64]*models.ChatUser{}
+		}
+
+		return holderName(users, event.ActiveTask.UserID)
+	}
+
+	text, ok := platform.EventText(event, a.clock.Now(), markup, holder)
+	if !ok {
+		return nil
+	}
+
+	if event.Type == service.EventTimerStarted {
+		return timerMessage(text, event.Task.ID)
+	}
+
+	return publicMessage(text)
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// Part of an error answer of Discord kept for the log
+const maxErrorSize = 1 << 10
+
+// Calls the Discord REST API as the bot
+type client struct {
+	apiURL string
+	token  string
+	http   *http.Client
+}
+
+// Posts a message to a channel
+func (c *client) createMessage(ctx context.Context, channelID string, msg *message) error {
+	return c.do(ctx, http.MethodPost, "/channels/"+url.PathEscape(channelID)+"/messages", msg)
+}
+
+// Replaces the global commands of the application
+func (c *client) registerCommands(ctx context.Context, applicationID string, commands []applicationCommand) error {
+	return c.do(ctx, http.MethodPut, "/applications/"+url.PathEscape(applicationID)+"/commands", commands)
+}
+
+// Sends a request with a JSON body, answers other than 2xx are errors
+func (c *client) do(ctx context.Context, method string, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Discord: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close Discord response: %v", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Discord explains errors in the body, e.g. missing access to the channel
+		reason, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorSize))
+		if err != nil {
+			log.Printf("Failed to read Discord error: %v", err)
+		}
+
+		return fmt.Errorf("discord answered with status %d: %s", resp.StatusCode, reason)
+	}
+
+	return nil
+}
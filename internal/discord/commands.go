@@ -0,0 +1,605 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Option types of application commands
+const (
+	optionString  = 3
+	optionInteger = 4
+)
+
+// Interaction context of commands offered in guilds only
+const contextGuild = 0
+
+// Application command registered with Discord
+type applicationCommand struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Options     []commandSpec `json:"options,omitempty"`
+	Contexts    []int         `json:"contexts"`
+}
+
+// Option of an application command
+type commandSpec struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+	MinValue    int    `json:"min_value,omitempty"`
+	MaxValue    int    `json:"max_value,omitempty"`
+}
+
+// Commands of the Telegram bot (see setBotCommands) with typed options
+// /{minutes} name becomes /timer, Discord command names can't be numbers.
+// Conflict groups, the pinned board, API keys and webhooks are Telegram only
+func applicationCommands() []applicationCommand {
+	guild := []int{contextGuild}
+
+	taskName := func(required bool) commandSpec {
+		return commandSpec{Type: optionString, Name: "name", Description: "Task name", Required: required}
+	}
+
+	taskID := commandSpec{Type: optionString, Name: "id", Description: "Task ID", Required: true}
+
+	return []applicationCommand{
+		{
+			Name:        "add",
+			Description: "Add a new task",
+			Options: []commandSpec{
+				taskName(true),
+				{Type: optionString, Name: "description", Description: "Task description"},
+			},
+			Contexts: guild,
+		},
+		{
+			Name:        "timer",
+			Description: "Start a task timer",
+			Options: []commandSpec{
+				{
+					Type:        optionInteger,
+					Name:        "minutes",
+					Description: "Duration in minutes",
+					Required:    true,
+					MinValue:    helpers.MinTaskDuration,
+					MaxValue:    helpers.MaxTaskDuration,
+				},
+				taskName(true),
+			},
+			Contexts: guild,
+		},
+		{Name: "cancel", Description: "Cancel a task timer, the latest if no name is given", Options: []commandSpec{taskName(false)}, Contexts: guild},
+		{Name: "status", Description: "Show task(s) status", Options: []commandSpec{taskName(false)}, Contexts: guild},
+		{Name: "tasks", Description: "List all tasks", Contexts: guild},
+		{
+			Name:        "lock",
+			Description: "Lock a task",
+			Options: []commandSpec{
+				taskID,
+				{Type: optionString, Name: "reason", Description: "Why the task is locked"},
+			},
+			Contexts: guild,
+		},
+		{Name: "unlock", Description: "Unlock a task", Options: []commandSpec{taskID}, Contexts: guild},
+		{Name: "delete", Description: "Delete a task", Options: []commandSpec{taskID}, Contexts: guild},
+	}
+}
+
+// Registers the application commands with Discord, replacing the ones registered before
+func (a *Adapter) RegisterCommands(ctx context.Context) error {
+	if err := a.client.registerCommands(ctx, a.config.ApplicationID, applicationCommands()); err != nil {
+		return fmt.Errorf("failed to register commands: %w", err)
+	}
+
+	return nil
+}
+
+// Command typed in a guild channel
+type command struct {
+	chatID int64
+	userID int64 // User ID in the user directory
+	user   user
+	name   string
+	data   interactionData
+}
+
+// Returns a string option of the command, empty if it isn't given
+func (c *command) option(name string) string {
+	for _, opt := range c.data.Options {
+		if opt.Name == name {
+			if value, ok := opt.Value.(string); ok {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+
+	return ""
+}
+
+// Returns an integer option of the command, 0 if it isn't given
+func (c *command) intOption(name string) int {
+	for _, opt := range c.data.Options {
+		if opt.Name == name {
+			// Numbers arrive as float64 from JSON
+			if value, ok := opt.Value.(float64); ok {
+				return int(value)
+			}
+		}
+	}
+
+	return 0
+}
+
+// Handles an interaction: pings, commands and timer buttons
+func (a *Adapter) handleInteraction(w http.ResponseWriter, r *http.Request, body []byte) {
+	var in interaction
+	if err := json.Unmarshal(body, &in); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	var resp *response
+
+	switch in.Type {
+	case interactionPing:
+		resp = &response{Type: responsePong}
+	case interactionApplicationCommand:
+		resp = a.runCommand(r.Context(), &in)
+	case interactionMessageComponent:
+		resp = a.runComponent(r.Context(), &in)
+	default:
+		http.Error(w, "unknown interaction type", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, resp)
+}
+
+// Runs an application command and returns the answer to it
+func (a *Adapter) runCommand(ctx context.Context, in *interaction) *response {
+	if in.GuildID == "" || in.Member == nil {
+		return privateMessage("Commands work in server channels only")
+	}
+
+	chatID, err := a.service.LinkChat(ctx, models.PlatformDiscord, in.ChannelID)
+	if err != nil {
+		log.Printf("Failed to link Discord channel: %v", err)
+		return privateMessage("Error processing command. Please try again")
+	}
+
+	author := in.Member.User
+
+	name := author.GlobalName
+	if name == "" {
+		name = author.Username
+	}
+
+	// The Discord user ID is kept as the username, so holders can be mentioned
+	userID, err := a.service.ExternalUser(ctx, chatID, models.PlatformDiscord, author.ID, &models.ChatUser{
+		Username:  author.ID,
+		FirstName: name,
+	})
+	if err != nil {
+		log.Printf("Failed to save Discord user: %v", err)
+		return privateMessage("Error processing command. Please try again")
+	}
+
+	cmd := &command{chatID: chatID, userID: userID, user: author, name: in.Data.Name, data: in.Data}
+
+	var resp *response
+
+	switch cmd.name {
+	case "add":
+		resp, err = a.addTask(ctx, cmd)
+	case "timer":
+		resp, err = a.startTimer(ctx, cmd)
+	case "cancel":
+		resp, err = a.cancelTimer(ctx, cmd)
+	case "status":
+		resp, err = a.status(ctx, cmd)
+	case "tasks":
+		resp, err = a.tasks(ctx, cmd)
+	case "lock":
+		resp, err = a.lockTask(ctx, cmd)
+	case "unlock":
+		resp, err = a.unlockTask(ctx, cmd)
+	case "delete":
+		resp, err = a.deleteTask(ctx, cmd)
+	default:
+		log.Printf("Ignore not found Discord command: %s", cmd.name)
+		return privateMessage(fmt.Sprintf("Unknown command **%s**", cmd.name))
+	}
+
+	if err != nil {
+		log.Printf("Error handling Discord command %s: %v", cmd.name, err)
+		return privateMessage("Error processing command. Please try again")
+	}
+
+	return resp
+}
+
+// Handles /add name [description]
+func (a *Adapter) addTask(ctx context.Context, cmd *command) (*response, error) {
+	taskName := cmd.option("name")
+	description := cmd.option("description")
+
+	task, err := a.service.CreateTask(ctx, cmd.chatID, taskName, description, service.SourceDiscord)
+
+	var nameErr *service.InvalidNameError
+
+	switch {
+	case errors.As(err, &nameErr):
+		return privateMessage(fmt.Sprintf("Invalid task name: %s", nameErr.Reason)), nil
+	case errors.Is(err, service.ErrTaskLimit):
+		return privateMessage(fmt.Sprintf("Maximum number of tasks per chat reached (%d)", helpers.MaxTasksPerChat)), nil
+	case errors.Is(err, service.ErrTaskNameTaken):
+		return privateMessage(fmt.Sprintf("A task with name **%s** already exists", taskName)), nil
+	case err != nil:
+		return nil, err
+	}
+
+	return publicMessage(platform.TaskAddedText(markup, task)), nil
+}
+
+// Handles /timer minutes name
+func (a *Adapter) startTimer(ctx context.Context, cmd *command) (*response, error) {
+	taskName := cmd.option("name")
+
+	task, err := a.storage.GetTaskByName(ctx, cmd.chatID, taskName)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return privateMessage(fmt.Sprintf("Task **%s** not found", taskName)), nil
+		}
+
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	duration := cmd.intOption("minutes")
+
+	task, _, err = a.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   cmd.chatID,
+		TaskID:   task.ID,
+		UserID:   cmd.userID,
+		Duration: duration,
+		Source:   service.SourceDiscord,
+	})
+	if err != nil {
+		if text, ok := a.startErrorText(ctx, cmd.chatID, task, err); ok {
+			return privateMessage(text), nil
+		}
+
+		return nil, err
+	}
+
+	text := fmt.Sprintf("%s: task **%s**, <@%s>", platform.TimerStartedText(duration), task.Name, cmd.user.ID)
+
+	return timerMessage(text, task.ID), nil
+}
+
+// Explains why a timer can't be started, false for unexpected errors
+func (a *Adapter) startErrorText(ctx context.Context, chatID int64, task *models.Task, err error) (string, bool) {
+	return platform.StartErrorText(err, task, a.clock.Now(), markup, func(taskID string) string {
+		if blocker, err := a.storage.GetTask(ctx, chatID, taskID); err == nil {
+			return blocker.Name
+		}
+
+		return taskID
+	})
+}
+
+// Handles /cancel [name]: cancels a timer of the user, the last started one
+// if no name is given
+func (a *Adapter) cancelTimer(ctx context.Context, cmd *command) (*response, error) {
+	activeTasks, err := a.storage.GetUserActiveTasks(ctx, cmd.chatID, cmd.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user's active tasks: %w", err)
+	}
+
+	if len(activeTasks) == 0 {
+		return privateMessage("You don't have any active tasks"), nil
+	}
+
+	var target *models.ActiveTask
+
+	if taskName := cmd.option("name"); taskName != "" {
+		task, err := a.storage.GetTaskByName(ctx, cmd.chatID, taskName)
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				return privateMessage(fmt.Sprintf("Task **%s** not found", taskName)), nil
+			}
+
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		for _, activeTask := range activeTasks {
+			if activeTask.TaskID == task.ID {
+				target = activeTask
+				break
+			}
+		}
+
+		if target == nil {
+			return privateMessage(fmt.Sprintf("You don't have an active timer for task **%s**", taskName)), nil
+		}
+	} else {
+		for _, activeTask := range activeTasks {
+			if target == nil || activeTask.StartTime.After(target.StartTime) {
+				target = activeTask
+			}
+		}
+	}
+
+	task, _, err := a.service.ReleaseTimer(ctx, cmd.chatID, target.TaskID, false, service.SourceDiscord)
+	if err != nil {
+		return nil, err
+	}
+
+	return publicMessage(platform.ReleasedText(markup, task.Name, false)), nil
+}
+
+// Handles /status [name]: shows the tasks of the channel, or one of them, as an embed
+func (a *Adapter) status(ctx context.Context, cmd *command) (*response, error) {
+	users, err := a.storage.GetChatUsers(ctx, cmd.chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat users: %w", err)
+	}
+
+	if taskName := cmd.option("name"); taskName != "" {
+		return a.taskStatus(ctx, cmd.chatID, taskName, users)
+	}
+
+	tasks, err := a.storage.ListTasks(ctx, cmd.chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	if len(tasks) == 0 {
+		return privateMessage("No tasks found. Use /add to create a task"), nil
+	}
+
+	// Keep a stable order, the set of task IDs in storage is unordered
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+
+	// A chat has at most helpers.MaxTasksPerChat tasks, below the limit of 25 fields
+	fields := make([]embedField, 0, len(tasks))
+
+	for _, task := range tasks {
+		emoji, info := a.describeTask(ctx, task, users)
+		fields = append(fields, embedField{Name: emoji + " " + task.Name, Value: info})
+	}
+
+	return embedMessage(embed{Title: "Tasks Status", Color: embedColor, Fields: fields}), nil
+}
+
+// Handles /status name
+func (a *Adapter) taskStatus(ctx context.Context, chatID int64, taskName string, users map[int64]*models.ChatUser) (*response, error) {
+	task, err := a.storage.GetTaskByName(ctx, chatID, taskName)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return privateMessage(fmt.Sprintf("Task **%s** not found", taskName)), nil
+		}
+
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	emoji, info := a.describeTask(ctx, task, users)
+
+	e := embed{
+		Title:       emoji + " " + task.Name,
+		Description: task.Description,
+		Color:       embedColor,
+		Fields: []embedField{
+			{Name: "Status", Value: info, Inline: true},
+			{Name: "ID", Value: "`" + task.ID + "`", Inline: true},
+		},
+	}
+
+	// Add notes attached to the current session
+	if task.OwnerID != 0 {
+		activeTask, err := a.storage.GetActiveTask(ctx, chatID, task.ID)
+		if err != nil && !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get active task: %v", err)
+		}
+
+		if activeTask != nil && len(activeTask.Notes) > 0 {
+			var notes strings.Builder
+
+			for _, note := range activeTask.Notes {
+				notes.WriteString(fmt.Sprintf("- %s\n", note.Text))
+			}
+
+			e.Fields = append(e.Fields, embedField{Name: "📝 Notes", Value: notes.String()})
+		}
+	}
+
+	return embedMessage(e), nil
+}
+
+// Returns the status emoji and the state of a task
+func (a *Adapter) describeTask(ctx context.Context, task *models.Task, users map[int64]*models.ChatUser) (string, string) {
+	switch {
+	case task.IsLocked:
+		info := "Locked"
+		if task.LockReason != "" {
+			info += fmt.Sprintf(" (%s)", task.LockReason)
+		}
+
+		return "🔒", info
+	case task.OwnerID != 0:
+		info := fmt.Sprintf("Remaining: %s", remainingMinutes(task.RemainingAt(a.clock.Now())))
+
+		activeTask, err := a.storage.GetActiveTask(ctx, task.ChatID, task.ID)
+		if err != nil && !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get active task: %v", err)
+		}
+
+		if activeTask != nil && activeTask.IsPaused() {
+			info = fmt.Sprintf("Paused, remaining: %s", remainingMinutes(activeTask.RemainingAt(a.clock.Now())))
+		}
+
+		return "⏱", fmt.Sprintf("%s (%s)", info, holderName(users, task.OwnerID))
+	default:
+		return "🟢", "Available"
+	}
+}
+
+// Handles /tasks
+func (a *Adapter) tasks(ctx context.Context, cmd *command) (*response, error) {
+	tasks, err := a.storage.ListTasks(ctx, cmd.chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	if len(tasks) == 0 {
+		return privateMessage("No tasks found. Use /add to create a task"), nil
+	}
+
+	users, err := a.storage.GetChatUsers(ctx, cmd.chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat users: %w", err)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+
+	var text strings.Builder
+
+	text.WriteString("Tasks:\n\n")
+
+	for _, task := range tasks {
+		status := "🟢"
+		if task.IsLocked {
+			status = "🔒"
+		} else if task.OwnerID != 0 {
+			status = "⏱"
+		}
+
+		text.WriteString(fmt.Sprintf("%s **%s** `%s` %s", status, task.Name, task.ID, task.Description))
+
+		if task.OwnerID != 0 && !task.IsLocked {
+			text.WriteString(fmt.Sprintf(" (held by %s)", holderName(users, task.OwnerID)))
+		}
+
+		text.WriteString("\n")
+	}
+
+	return publicMessage(text.String()), nil
+}
+
+// Handles /lock id [reason]
+func (a *Adapter) lockTask(ctx context.Context, cmd *command) (*response, error) {
+	taskID := cmd.option("id")
+
+	task, err := a.service.LockTask(ctx, cmd.chatID, taskID, cmd.option("reason"), service.SourceDiscord)
+
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+		return privateMessage(fmt.Sprintf("Task with ID **%s** not found", taskID)), nil
+	case errors.Is(err, service.ErrAlreadyLocked):
+		return privateMessage(fmt.Sprintf("Task **%s** is already locked", task.Name)), nil
+	case errors.Is(err, service.ErrTaskInUse):
+		return privateMessage(fmt.Sprintf("Task **%s** is currently in use", task.Name)), nil
+	case err != nil:
+		return nil, err
+	}
+
+	return publicMessage(fmt.Sprintf("🔒 Task **%s** locked successfully", task.Name)), nil
+}
+
+// Handles /unlock id
+func (a *Adapter) unlockTask(ctx context.Context, cmd *command) (*response, error) {
+	taskID := cmd.option("id")
+
+	task, err := a.service.UnlockTask(ctx, cmd.chatID, taskID, service.SourceDiscord)
+
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+		return privateMessage(fmt.Sprintf("Task with ID **%s** not found", taskID)), nil
+	case errors.Is(err, service.ErrNotLocked):
+		return privateMessage(fmt.Sprintf("Task **%s** is not locked", task.Name)), nil
+	case err != nil:
+		return nil, err
+	}
+
+	return publicMessage(fmt.Sprintf("🟢 Task **%s** unlocked successfully", task.Name)), nil
+}
+
+// Handles /delete id
+func (a *Adapter) deleteTask(ctx context.Context, cmd *command) (*response, error) {
+	taskID := cmd.option("id")
+
+	task, err := a.service.DeleteTask(ctx, cmd.chatID, taskID, service.SourceDiscord)
+
+	switch {
+	case errors.Is(err, redis.ErrNotFound):
+		return privateMessage(fmt.Sprintf("Task with ID **%s** not found", taskID)), nil
+	case errors.Is(err, service.ErrTaskInUse):
+		return privateMessage(fmt.Sprintf("Task **%s** is currently in use", task.Name)), nil
+	case err != nil:
+		return nil, err
+	}
+
+	return publicMessage(fmt.Sprintf("Task deleted successfully!\n\nName: %s\nID: %s", task.Name, task.ID)), nil
+}
+
+// Returns the name of a user from the directory, Discord users are mentioned
+func holderName(users map[int64]*models.ChatUser, userID int64) string {
+	user, exists := users[userID]
+	if !exists {
+		user = &models.ChatUser{ID: userID}
+	}
+
+	if user.Username != "" && service.ExternalUserID(models.PlatformDiscord, user.Username) == userID {
+		return "<@" + user.Username + ">"
+	}
+
+	return user.DisplayName()
+}
+
+// Formats remaining seconds in whole minutes like the Telegram status
+func remainingMinutes(remaining int64) string {
+	if remaining < 60 {
+		return "< 1 min."
+	}
+
+	return fmt.Sprintf("%d min.", remaining/60)
+}
+
+// Writes the answer to an interaction
+func writeJSON(w http.ResponseWriter, resp *response) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to write Discord response: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+)
+
+// Runs a timer button and returns the answer to it
+func (a *Adapter) runComponent(ctx context.Context, in *interaction) *response {
+	action, taskID, ok := parseCustomID(in.Data.CustomID)
+	if !ok || in.Member == nil {
+		return privateMessage("Unknown action")
+	}
+
+	chatID := service.ExternalChatID(models.PlatformDiscord, in.ChannelID)
+
+	activeTask, err := a.storage.GetActiveTask(ctx, chatID, taskID)
+	if err != nil {
+		return privateMessage("Task not found or not active")
+	}
+
+	if action == actionCheckTime {
+		return privateMessage(remainingText(activeTask.RemainingAt(a.clock.Now())))
+	}
+
+	if service.ExternalUserID(models.PlatformDiscord, in.Member.User.ID) != activeTask.UserID {
+		return privateMessage("Only the user who started the timer can do this")
+	}
+
+	switch action {
+	case actionExtend:
+		task, activeTask, err := a.service.ExtendTimer(ctx, chatID, taskID, timerExtendStep, service.SourceDiscord)
+		if errors.Is(err, service.ErrDurationLimit) {
+			return privateMessage(fmt.Sprintf("Duration can't exceed %d minutes", helpers.MaxTaskDuration))
+		}
+
+		if err != nil {
+			return actionError(action, err)
+		}
+
+		return publicMessage(fmt.Sprintf(
+			"Timer for task **%s** extended by %d minutes (%s remaining)",
+			task.Name,
+			timerExtendStep,
+			platform.FormatRemaining(activeTask.RemainingAt(a.clock.Now())),
+		))
+	case actionDone, actionCancel:
+		done := action == actionDone
+
+		task, _, err := a.service.ReleaseTimer(ctx, chatID, taskID, done, service.SourceDiscord)
+		if err != nil {
+			return actionError(action, err)
+		}
+
+		// The finished timer takes the place of its message, buttons removed
+		resp := publicMessage(platform.ReleasedText(markup, task.Name, done))
+		resp.Type = responseUpdateMessage
+
+		return resp
+	default:
+		log.Printf("Unknown Discord action: %s", action)
+		return privateMessage("Unknown action")
+	}
+}
+
+// Explains a failed timer button
+func actionError(action string, err error) *response {
+	if errors.Is(err, service.ErrTaskNotActive) {
+		return privateMessage("Task not found or not active")
+	}
+
+	log.Printf("Error handling Discord action %s: %v", action, err)
+
+	return privateMessage("Error processing action. Please try again")
+}
+
+// Returns the answer of the ⌛ button
+func remainingText(remaining int64) string {
+	if remaining <= 0 {
+		return "Task time has expired"
+	}
+
+	remainingMin := remaining / 60
+	if remainingMin < 5 {
+		return fmt.Sprintf("%d:%02d remaining", remainingMin, remaining%60)
+	}
+
+	return fmt.Sprintf("%d minutes remaining", remainingMin)
+}
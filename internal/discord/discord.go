@@ -0,0 +1,97 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage"
+)
+
+// Discord interactions are served under this path
+const PathPrefix = "/discord/"
+
+// REST API of Discord
+const defaultAPIURL = "https://discord.com/api/v10"
+
+// Timeout of calls to the REST API
+const requestTimeout = 10 * time.Second
+
+// Represents Discord app configuration
+type Config struct {
+	// Public key of the application (hex), verifies that interactions come from Discord
+	PublicKey string
+	// Bot token used to register commands and post messages
+	BotToken string
+	// ID of the application the commands are registered for
+	ApplicationID string
+	// REST API base URL, defaultAPIURL if empty
+	APIURL string
+}
+
+// Serves a Discord app on top of the service: every guild channel is a chat
+// of its own, timers of these chats run in the bot like all others
+type Adapter struct {
+	config    *Config
+	publicKey ed25519.PublicKey
+	storage   storage.Storage
+	service   *service.Service
+	clock     clock.Clock
+	client    *client
+}
+
+// Creates a Discord adapter and subscribes it to svc to announce changes in Discord channels
+func New(config *Config, storage storage.Storage, svc *service.Service) (*Adapter, error) {
+	publicKey, err := hex.DecodeString(config.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: expected %d hex encoded bytes", ed25519.PublicKeySize)
+	}
+
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+
+	a := &Adapter{
+		config:    config,
+		publicKey: publicKey,
+		storage:   storage,
+		service:   svc,
+		clock:     svc.Clock(),
+		client: &client{
+			apiURL: apiURL,
+			token:  config.BotToken,
+			http:   &http.Client{Timeout: requestTimeout},
+		},
+	}
+
+	svc.Subscribe(a.handleServiceEvent)
+
+	return a, nil
+}
+
+// Returns the handler of the Interactions Endpoint URL, to be mounted on PathPrefix
+func (a *Adapter) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST "+PathPrefix+"interactions", a.verified(a.handleInteraction))
+
+	return mux
+}
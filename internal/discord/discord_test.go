@@ -0,0 +1,538 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform/platformtest"
+	"time-guard-bot/internal/service"
+)
+
+const (
+	testToken       = "test-bot-token"
+	testApplication = "1290000000000000000"
+	testChannel     = "1280000000000000555"
+	testAlice       = "400000000000000001"
+	testBob         = "400000000000000002"
+)
+
+// Ключ приложения, которым фальшивый Discord подписывает запросы
+var testKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+// Фальшивый Discord: REST API, запоминает все запросы бота
+type fakeDiscord struct {
+	server   *httptest.Server
+	requests platformtest.Recorder[fakeRequest]
+}
+
+type fakeRequest struct {
+	method string
+	path   string
+	body   []byte
+}
+
+func newFakeDiscord(t *testing.T) *fakeDiscord {
+	t.Helper()
+
+	discord := &fakeDiscord{}
+	discord.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot "+testToken {
+			t.Errorf("Expected the bot token, got %q", r.Header.Get("Authorization"))
+		}
+
+		var body bytes.Buffer
+		if _, err := body.ReadFrom(r.Body); err != nil {
+			t.Errorf("Failed to read request: %v", err)
+		}
+
+		discord.requests.Add(fakeRequest{method: r.Method, path: r.URL.Path, body: body.Bytes()})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(discord.server.Close)
+
+	return discord
+}
+
+// Возвращает сообщения, отправленные в канал
+func (d *fakeDiscord) messages(t *testing.T) []message {
+	t.Helper()
+
+	var messages []message
+
+	for _, req := range d.requests.Take() {
+		if req.method != http.MethodPost || req.path != "/api/channels/"+testChannel+"/messages" {
+			t.Errorf("Unexpected request %s %s", req.method, req.path)
+			continue
+		}
+
+		var msg message
+		if err := json.Unmarshal(req.body, &msg); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+type testEnv struct {
+	*platformtest.Backend
+	adapter *Adapter
+	handler http.Handler
+	discord *fakeDiscord
+}
+
+func setupAdapter(t *testing.T) *testEnv {
+	t.Helper()
+
+	backend := platformtest.NewBackend(t)
+	discord := newFakeDiscord(t)
+
+	adapter, err := New(&Config{
+		PublicKey:     hex.EncodeToString(testKey.Public().(ed25519.PublicKey)),
+		BotToken:      testToken,
+		ApplicationID: testApplication,
+		APIURL:        discord.server.URL + "/api",
+	}, backend.Storage, backend.Service)
+	if err != nil {
+		t.Fatalf("Failed to create adapter: %v", err)
+	}
+
+	return &testEnv{Backend: backend, adapter: adapter, handler: adapter.Handler(), discord: discord}
+}
+
+// Подписывает тело запроса так же, как Discord
+func sign(key ed25519.PrivateKey, timestamp string, body string) string {
+	return hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body)))
+}
+
+// Отправляет подписанное взаимодействие и возвращает ответ на него
+func (e *testEnv) send(t *testing.T, body string) response {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(e.Clock.Now().Unix(), 10)
+
+	req := httptest.NewRequest(http.MethodPost, PathPrefix+"interactions", strings.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", sign(testKey, timestamp, body))
+
+	rec := httptest.NewRecorder()
+	e.handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode answer: %v", err)
+	}
+
+	return resp
+}
+
+// Выполняет команду от имени пользователя, options - JSON-массив опций
+func (e *testEnv) command(t *testing.T, userID string, name string, options string) response {
+	t.Helper()
+
+	if options == "" {
+		options = "[]"
+	}
+
+	return e.send(t, platformtest.ReadPayload(t, "application_command.json", map[string]string{
+		"channel_id":  testChannel,
+		"user_id":     userID,
+		"username":    "user" + userID[len(userID)-1:],
+		"global_name": "User " + userID[len(userID)-1:],
+		"name":        name,
+		"options":     options,
+	}))
+}
+
+// Нажимает кнопку таймера
+func (e *testEnv) click(t *testing.T, userID string, action string, taskID string) response {
+	t.Helper()
+
+	return e.send(t, platformtest.ReadPayload(t, "message_component.json", map[string]string{
+		"channel_id": testChannel,
+		"user_id":    userID,
+		"username":   "user",
+		"custom_id":  action + ":" + taskID,
+	}))
+}
+
+// Описывает адаптер для общих проверок платформ
+func (e *testEnv) harness() *platformtest.Harness {
+	return &platformtest.Harness{
+		Platform: models.PlatformDiscord,
+		Channel:  testChannel,
+		UserID:   testAlice,
+		AddTask: func(t *testing.T, name string) {
+			e.command(t, testAlice, "add", fmt.Sprintf(`[{"name":"name","type":3,"value":%q}]`, name))
+		},
+		StartTimer: func(t *testing.T, minutes int, name string) bool {
+			resp := e.command(t, testAlice, "timer", fmt.Sprintf(`[{"name":"minutes","type":4,"value":%d},{"name":"name","type":3,"value":%q}]`, minutes, name))
+			return resp.Data != nil && len(resp.Data.Components) == 1
+		},
+		Posts: func(t *testing.T) []platformtest.Post {
+			var posts []platformtest.Post
+
+			for _, msg := range e.discord.messages(t) {
+				controls := len(msg.Components) == 1 && len(msg.Components[0].Components) == 4
+				posts = append(posts, platformtest.Post{Text: msg.Content, Controls: controls})
+			}
+
+			return posts
+		},
+	}
+}
+
+// Создает задачу staging и запускает на нее таймер Алисы
+func (e *testEnv) startTimer(t *testing.T) *models.Task {
+	t.Helper()
+
+	return e.StartTimer(t, e.harness())
+}
+
+func TestNewInvalidPublicKey(t *testing.T) {
+	svc := service.New(nil)
+
+	for _, key := range []string{"", "not-hex", "abcd"} {
+		if _, err := New(&Config{PublicKey: key}, nil, svc); err == nil {
+			t.Errorf("Expected an error for public key %q", key)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	env := setupAdapter(t)
+	body := platformtest.ReadPayload(t, "ping.json", nil)
+	timestamp := strconv.FormatInt(platformtest.Start.Unix(), 10)
+
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		advance   time.Duration
+		want      int
+	}{
+		{"Valid signature", timestamp, sign(testKey, timestamp, body), 0, http.StatusOK},
+		// Discord проверяет, что запросы с чужой подписью отклоняются
+		{"Wrong key", timestamp, sign(otherKey, timestamp, body), 0, http.StatusUnauthorized},
+		{"Missing signature", timestamp, "", 0, http.StatusUnauthorized},
+		{"Not hex", timestamp, "zz", 0, http.StatusUnauthorized},
+		{"Signature of another timestamp", timestamp, sign(testKey, "1735732801", body), 0, http.StatusUnauthorized},
+		{"Invalid timestamp", "yesterday", sign(testKey, "yesterday", body), 0, http.StatusUnauthorized},
+		// Повтор запроса, подписанного больше 5 минут назад
+		{"Replayed request", timestamp, sign(testKey, timestamp, body), 5*time.Minute + time.Second, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.Clock.Advance(tt.advance)
+
+			req := httptest.NewRequest(http.MethodPost, PathPrefix+"interactions", strings.NewReader(body))
+			req.Header.Set("X-Signature-Timestamp", tt.timestamp)
+			req.Header.Set("X-Signature-Ed25519", tt.signature)
+
+			rec := httptest.NewRecorder()
+			env.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	env := setupAdapter(t)
+
+	if resp := env.send(t, platformtest.ReadPayload(t, "ping.json", nil)); resp.Type != responsePong || resp.Data != nil {
+		t.Errorf("Expected a pong, got %+v", resp)
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	env := setupAdapter(t)
+
+	if err := env.adapter.RegisterCommands(context.Background()); err != nil {
+		t.Fatalf("Failed to register commands: %v", err)
+	}
+
+	requests := env.discord.requests.Take()
+	if len(requests) != 1 || requests[0].method != http.MethodPut || requests[0].path != "/api/applications/"+testApplication+"/commands" {
+		t.Fatalf("Expected one bulk overwrite of the commands, got %+v", requests)
+	}
+
+	var commands []applicationCommand
+	if err := json.Unmarshal(requests[0].body, &commands); err != nil {
+		t.Fatalf("Failed to decode commands: %v", err)
+	}
+
+	var names []string
+
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+
+		if len(cmd.Contexts) != 1 || cmd.Contexts[0] != contextGuild {
+			t.Errorf("Expected /%s to be offered in guilds only, got %v", cmd.Name, cmd.Contexts)
+		}
+	}
+
+	if strings.Join(names, ",") != "add,timer,cancel,status,tasks,lock,unlock,delete" {
+		t.Errorf("Unexpected commands: %v", names)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	env := setupAdapter(t)
+
+	tests := []struct {
+		name     string
+		user     string
+		command  string
+		options  string
+		public   bool
+		contains string
+	}{
+		{"Add task", testAlice, "add", `[{"name":"name","type":3,"value":"staging"},{"name":"description","type":3,"value":"Test stand"}]`, true, "Task added successfully!\n\nName: **staging**"},
+		{"Duplicate task", testAlice, "add", `[{"name":"name","type":3,"value":"staging"}]`, false, "A task with name **staging** already exists"},
+		{"Unknown task", testAlice, "timer", `[{"name":"minutes","type":4,"value":30},{"name":"name","type":3,"value":"prod"}]`, false, "Task **prod** not found"},
+		{"Start timer", testAlice, "timer", `[{"name":"minutes","type":4,"value":30},{"name":"name","type":3,"value":"staging"}]`, true, "Timer started for 30 minutes: task **staging**, <@400000000000000001>"},
+		{"Task busy", testBob, "timer", `[{"name":"minutes","type":4,"value":10},{"name":"name","type":3,"value":"staging"}]`, false, "Another user is currently working on the task. 30:00 remaining"},
+		{"Tasks", testBob, "tasks", "", true, "⏱ **staging** `"},
+		{"Cancel without timers", testBob, "cancel", "", false, "You don't have any active tasks"},
+		{"Cancel", testAlice, "cancel", `[{"name":"name","type":3,"value":"staging"}]`, true, "Timer for task **staging** has been cancelled"},
+		{"Unknown command", testAlice, "board", "", false, "Unknown command **board**"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.command(t, tt.user, tt.command, tt.options)
+
+			if resp.Type != responseChannelMessage || resp.Data == nil {
+				t.Fatalf("Expected a message, got %+v", resp)
+			}
+
+			if public := resp.Data.Flags&flagEphemeral == 0; public != tt.public {
+				t.Errorf("Expected public %v, got flags %d", tt.public, resp.Data.Flags)
+			}
+
+			if !strings.Contains(resp.Data.Content, tt.contains) {
+				t.Errorf("Expected %q in %q", tt.contains, resp.Data.Content)
+			}
+		})
+	}
+
+	// Ответы на команды Discord не дублируются в канал
+	if requests := env.discord.requests.Take(); len(requests) != 0 {
+		t.Errorf("Expected no posts for Discord's own changes, got %+v", requests)
+	}
+}
+
+func TestLockCommands(t *testing.T) {
+	env := setupAdapter(t)
+
+	env.command(t, testAlice, "add", `[{"name":"name","type":3,"value":"staging"}]`)
+
+	task, err := env.Storage.GetTaskByName(context.Background(), service.ExternalChatID(models.PlatformDiscord, testChannel), "staging")
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+
+	id := `[{"name":"id","type":3,"value":"` + task.ID + `"}]`
+
+	tests := []struct {
+		name     string
+		command  string
+		options  string
+		contains string
+	}{
+		{"Lock", "lock", `[{"name":"id","type":3,"value":"` + task.ID + `"},{"name":"reason","type":3,"value":"release"}]`, "🔒 Task **staging** locked successfully"},
+		{"Lock twice", "lock", id, "Task **staging** is already locked"},
+		{"Unlock", "unlock", id, "🟢 Task **staging** unlocked successfully"},
+		{"Unlock twice", "unlock", id, "Task **staging** is not locked"},
+		{"Delete", "delete", id, "Task deleted successfully!\n\nName: staging"},
+		{"Delete missing", "delete", id, "Task with ID **" + task.ID + "** not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.command(t, testAlice, tt.command, tt.options)
+
+			if !strings.Contains(resp.Data.Content, tt.contains) {
+				t.Errorf("Expected %q in %q", tt.contains, resp.Data.Content)
+			}
+		})
+	}
+}
+
+func TestStatusEmbed(t *testing.T) {
+	env := setupAdapter(t)
+	env.startTimer(t)
+
+	env.command(t, testBob, "add", `[{"name":"name","type":3,"value":"prod"},{"name":"description","type":3,"value":"Production"}]`)
+	env.Clock.Advance(10 * time.Minute)
+
+	resp := env.command(t, testBob, "status", "")
+	if resp.Data == nil || len(resp.Data.Embeds) != 1 {
+		t.Fatalf("Expected an embed, got %+v", resp.Data)
+	}
+
+	want := []embedField{
+		{Name: "🟢 prod", Value: "Available"},
+		{Name: "⏱ staging", Value: "Remaining: 20 min. (<@400000000000000001>)"},
+	}
+
+	fields := resp.Data.Embeds[0].Fields
+	if len(fields) != len(want) {
+		t.Fatalf("Expected %d fields, got %+v", len(want), fields)
+	}
+
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("Field %d: expected %+v, got %+v", i, want[i], fields[i])
+		}
+	}
+
+	// Статус одной задачи с описанием
+	resp = env.command(t, testBob, "status", `[{"name":"name","type":3,"value":"prod"}]`)
+	if resp.Data == nil || len(resp.Data.Embeds) != 1 {
+		t.Fatalf("Expected an embed, got %+v", resp.Data)
+	}
+
+	if e := resp.Data.Embeds[0]; e.Title != "🟢 prod" || e.Description != "Production" {
+		t.Errorf("Unexpected embed of one task: %+v", e)
+	}
+}
+
+func TestDirectMessage(t *testing.T) {
+	env := setupAdapter(t)
+
+	// Команда из личных сообщений приходит без guild_id и member
+	body := `{"type":2,"channel_id":"1","user":{"id":"` + testAlice + `"},"data":{"name":"tasks"}}`
+
+	resp := env.send(t, body)
+	if resp.Data == nil || resp.Data.Flags != flagEphemeral || resp.Data.Content != "Commands work in server channels only" {
+		t.Errorf("Expected a private refusal, got %+v", resp.Data)
+	}
+}
+
+func TestButtons(t *testing.T) {
+	env := setupAdapter(t)
+	task := env.startTimer(t)
+	chatID := service.ExternalChatID(models.PlatformDiscord, testChannel)
+
+	env.Clock.Advance(10 * time.Minute)
+
+	tests := []struct {
+		name     string
+		user     string
+		action   string
+		respType int
+		public   bool
+		contains string
+	}{
+		{"Check time", testBob, actionCheckTime, responseChannelMessage, false, "20 minutes remaining"},
+		{"Extend by another user", testBob, actionExtend, responseChannelMessage, false, "Only the user who started the timer can do this"},
+		{"Extend", testAlice, actionExtend, responseChannelMessage, true, "Timer for task **staging** extended by 15 minutes (35:00 remaining)"},
+		{"Check time after extension", testAlice, actionCheckTime, responseChannelMessage, false, "35 minutes remaining"},
+		{"Done", testAlice, actionDone, responseUpdateMessage, true, "✅ Task **staging** done"},
+		{"Check time after done", testAlice, actionCheckTime, responseChannelMessage, false, "Task not found or not active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.click(t, tt.user, tt.action, task.ID)
+
+			if resp.Type != tt.respType || resp.Data == nil {
+				t.Fatalf("Expected response type %d, got %+v", tt.respType, resp)
+			}
+
+			if public := resp.Data.Flags&flagEphemeral == 0; public != tt.public {
+				t.Errorf("Expected public %v, got flags %d", tt.public, resp.Data.Flags)
+			}
+
+			if !strings.Contains(resp.Data.Content, tt.contains) {
+				t.Errorf("Expected %q in %q", tt.contains, resp.Data.Content)
+			}
+		})
+	}
+
+	if _, err := env.Storage.GetActiveTask(context.Background(), chatID, task.ID); err == nil {
+		t.Error("Expected the timer released by the done button")
+	}
+}
+
+func TestDoneRemovesButtons(t *testing.T) {
+	env := setupAdapter(t)
+	task := env.startTimer(t)
+
+	body := platformtest.ReadPayload(t, "message_component.json", map[string]string{
+		"channel_id": testChannel,
+		"user_id":    testAlice,
+		"username":   "alice",
+		"custom_id":  actionCancel + ":" + task.ID,
+	})
+	timestamp := strconv.FormatInt(env.Clock.Now().Unix(), 10)
+
+	req := httptest.NewRequest(http.MethodPost, PathPrefix+"interactions", strings.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", sign(testKey, timestamp, body))
+
+	rec := httptest.NewRecorder()
+	env.handler.ServeHTTP(rec, req)
+
+	// Пустой список компонентов убирает кнопки с обновленного сообщения
+	if !strings.Contains(rec.Body.String(), `"components":[]`) {
+		t.Errorf("Expected an empty component list, got %s", rec.Body)
+	}
+}
+
+func TestAnnounceEvents(t *testing.T) {
+	env := setupAdapter(t)
+
+	env.CheckAnnouncements(
+		t,
+		env.harness(),
+		"⌛ Time for task **staging** has expired! How's it going, <@400000000000000001>?",
+		"Timer started for 15 minutes: task **staging**, <@400000000000000001>",
+	)
+}
+
+func TestHandlerMethods(t *testing.T) {
+	platformtest.CheckPostOnly(t, setupAdapter(t).handler, PathPrefix+"interactions")
+}
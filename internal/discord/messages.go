@@ -0,0 +1,200 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"time-guard-bot/internal/platform"
+)
+
+// Interaction types
+const (
+	interactionPing               = 1
+	interactionApplicationCommand = 2
+	interactionMessageComponent   = 3
+)
+
+// Interaction response types
+const (
+	responsePong           = 1
+	responseChannelMessage = 4 // Replies with a new message
+	responseUpdateMessage  = 7 // Edits the message of the clicked button
+)
+
+// Message flag that shows a reply only to the user who asked
+const flagEphemeral = 1 << 6
+
+// Discord Markdown puts bold text between double asterisks
+var markup = platform.Markdown("**")
+
+// Component types and button styles
+const (
+	componentActionRow = 1
+	componentButton    = 2
+
+	buttonPrimary   = 1
+	buttonSecondary = 2
+	buttonSuccess   = 3
+	buttonDanger    = 4
+)
+
+// Actions of the timer buttons, the custom ID of a button is action:taskID
+const (
+	actionCheckTime = "check_time"
+	actionExtend    = "extend"
+	actionDone      = "done"
+	actionCancel    = "cancel"
+)
+
+// Extension step of the "+15" timer button in minutes
+const timerExtendStep = 15
+
+// Colour of the status embeds
+const embedColor = 0x2ecc71
+
+// Interaction Discord sends for a command, a button click or a ping
+type interaction struct {
+	Type      int    `json:"type"`
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	Member    *struct {
+		User user `json:"user"`
+	} `json:"member"` // Set in guilds only
+	Data interactionData `json:"data"`
+}
+
+// Discord user
+type user struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+}
+
+// Command with its options or the custom ID of a clicked button
+type interactionData struct {
+	Name     string          `json:"name"`
+	Options  []commandOption `json:"options"`
+	CustomID string          `json:"custom_id"`
+}
+
+// Value of a command option, a string or a number
+type commandOption struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// Answer to an interaction
+type response struct {
+	Type int      `json:"type"`
+	Data *message `json:"data,omitempty"`
+}
+
+// Message posted to a channel or as a reply to an interaction
+type message struct {
+	Content string  `json:"content"`
+	Embeds  []embed `json:"embeds,omitempty"`
+	// Always sent: an empty list removes the buttons of an updated message
+	Components []actionRow `json:"components"`
+	Flags      int         `json:"flags,omitempty"`
+}
+
+// Rich message block
+type embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Fields      []embedField `json:"fields,omitempty"`
+}
+
+// Field of an embed
+type embedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Row of buttons under a message
+type actionRow struct {
+	Type       int      `json:"type"`
+	Components []button `json:"components"`
+}
+
+// Message button
+type button struct {
+	Type     int    `json:"type"`
+	Style    int    `json:"style"`
+	Label    string `json:"label"`
+	CustomID string `json:"custom_id"`
+}
+
+// Creates a reply visible to everyone in the channel
+func publicMessage(text string) *response {
+	return &response{
+		Type: responseChannelMessage,
+		Data: &message{Content: text, Components: []actionRow{}},
+	}
+}
+
+// Creates a reply visible only to the user who asked
+func privateMessage(text string) *response {
+	resp := publicMessage(text)
+	resp.Data.Flags = flagEphemeral
+
+	return resp
+}
+
+// Creates a reply with an embed
+func embedMessage(e embed) *response {
+	resp := publicMessage("")
+	resp.Data.Embeds = []embed{e}
+
+	return resp
+}
+
+// Creates the message of a running timer with its control buttons
+func timerMessage(text string, taskID string) *response {
+	newButton := func(label string, action string, style int) button {
+		return button{
+			Type:     componentButton,
+			Style:    style,
+			Label:    label,
+			CustomID: action + ":" + taskID,
+		}
+	}
+
+	resp := publicMessage(text)
+	resp.Data.Components = []actionRow{{
+		Type: componentActionRow,
+		Components: []button{
+			newButton("⌛", actionCheckTime, buttonSecondary),
+			newButton(fmt.Sprintf("+%d", timerExtendStep), actionExtend, buttonPrimary),
+			newButton("✅ Done", actionDone, buttonSuccess),
+			newButton("✖️ Cancel", actionCancel, buttonDanger),
+		},
+	}}
+
+	return resp
+}
+
+// Splits the custom ID of a timer button into the action and the task ID
+func parseCustomID(customID string) (string, string, bool) {
+	action, taskID, found := strings.Cut(customID, ":")
+	if !found || taskID == "" {
+		return "", "", false
+	}
+
+	return action, taskID, true
+}
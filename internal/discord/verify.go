@@ -0,0 +1,84 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"time-guard-bot/internal/platform"
+)
+
+// Largest interaction Discord sends is well below this
+const maxBodySize = 1 << 20
+
+// Reasons to reject a request
+var (
+	errMissingSignature = errors.New("missing signature headers")
+	errBadSignature     = errors.New("signature mismatch")
+)
+
+// Handles a request whose body is already verified
+type verifiedHandler func(w http.ResponseWriter, r *http.Request, body []byte)
+
+// Checks the signature of a request before passing it on, rejects it with 401 otherwise
+// Discord sends requests with invalid signatures on purpose and disables the
+// endpoint unless they are rejected
+func (a *Adapter) verified(next verifiedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		if err := a.verify(r.Header, body); err != nil {
+			log.Printf("Rejected Discord request: %v", err)
+			http.Error(w, "invalid request signature", http.StatusUnauthorized)
+
+			return
+		}
+
+		next(w, r, body)
+	}
+}
+
+// Checks X-Signature-Ed25519 of a request body
+func (a *Adapter) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Signature-Timestamp")
+	signature := header.Get("X-Signature-Ed25519")
+
+	if timestamp == "" || signature == "" {
+		return errMissingSignature
+	}
+
+	if err := platform.CheckTimestamp(timestamp, a.clock.Now()); err != nil {
+		return err
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errBadSignature
+	}
+
+	if !ed25519.Verify(a.publicKey, append([]byte(timestamp), body...), sig) {
+		return errBadSignature
+	}
+
+	return nil
+}
@@ -14,13 +14,120 @@
 package helpers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/ratelimit"
+)
+
+const (
+	// Prefix of random API keys, tells them apart from legacy ones
+	APIKeyPrefix = "tgb_"
+	// Length of the public API key ID
+	APIKeyIDLength = 8
+	// Number of random bytes in the secret part of an API key
+	apiKeySecretBytes = 32
+	// Maximum number of API keys per chat
+	MaxAPIKeysPerChat = 10
 )
 
+// Generates a random API key: tgb_{id}_{secret}
+// Returns the public key ID and the full key
+func GenerateSecretAPIKey() (string, string, error) {
+	id, err := GenerateTaskID(APIKeyIDLength)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key ID: %w", err)
+	}
+
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+
+	key := APIKeyPrefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return id, key, nil
+}
+
+// Reports whether the key has the random key format (not a legacy one)
+func IsSecretAPIKey(apiKey string) bool {
+	return strings.HasPrefix(apiKey, APIKeyPrefix)
+}
+
+// Hashes an API key for storage and lookup
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// Options of a new API key given to /api_key create
+type APIKeyOptions struct {
+	Name         string
+	Scopes       []string
+	TaskPatterns []string
+	Tier         string
+}
+
+// Parses /api_key create arguments: [name] [scopes=a,b] [tasks=pattern,...] [tier=name]
+// Without scopes= the key is read-only
+func ParseAPIKeyOptions(args []string) (*APIKeyOptions, error) {
+	opts := &APIKeyOptions{}
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "scopes="):
+			for _, scope := range strings.Split(strings.TrimPrefix(arg, "scopes="), ",") {
+				if !models.IsValidScope(scope) {
+					return nil, fmt.Errorf("unknown scope %q, available: %s", scope, strings.Join(models.APIKeyScopes, ", "))
+				}
+
+				if !slices.Contains(opts.Scopes, scope) {
+					opts.Scopes = append(opts.Scopes, scope)
+				}
+			}
+		case strings.HasPrefix(arg, "tasks="):
+			for _, pattern := range strings.Split(strings.TrimPrefix(arg, "tasks="), ",") {
+				if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+					return nil, fmt.Errorf("invalid task pattern %q", pattern)
+				}
+
+				opts.TaskPatterns = append(opts.TaskPatterns, pattern)
+			}
+		case strings.HasPrefix(arg, "tier="):
+			tier := strings.TrimPrefix(arg, "tier=")
+			if !ratelimit.IsValidTier(tier) {
+				return nil, fmt.Errorf("invalid tier %q", tier)
+			}
+
+			opts.Tier = tier
+		case opts.Name == "":
+			if err := ValidateTaskName(arg); err != nil {
+				return nil, fmt.Errorf("invalid key name: %w", err)
+			}
+
+			opts.Name = arg
+		default:
+			return nil, fmt.Errorf("unexpected argument %q", arg)
+		}
+	}
+
+	if len(opts.Scopes) == 0 {
+		opts.Scopes = []string{models.ScopeTasksRead}
+	}
+
+	return opts, nil
+}
+
 // Generates API key based on chatID
+// Deprecated: legacy keys can be forged from a chat ID, use GenerateSecretAPIKey
 func GenerateAPIKey(chatID int64) string {
 	keyData := fmt.Sprintf("tg:%d", chatID)
 	key := base64.StdEncoding.EncodeToString([]byte(keyData))
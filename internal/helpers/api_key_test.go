@@ -19,6 +19,8 @@ import (
 	"math"
 	"strings"
 	"testing"
+
+	"time-guard-bot/internal/models"
 )
 
 func TestGenerateAPIKey(t *testing.T) {
@@ -173,3 +175,110 @@ func TestExtractChatID(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSecretAPIKey(t *testing.T) {
+	id, key, err := GenerateSecretAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateSecretAPIKey returned error: %v", err)
+	}
+
+	if len(id) != APIKeyIDLength {
+		t.Errorf("Expected key ID of length %d, got %d: %s", APIKeyIDLength, len(id), id)
+	}
+
+	if !strings.HasPrefix(key, APIKeyPrefix+id+"_") {
+		t.Errorf("Key %q doesn't start with prefix and ID", key)
+	}
+
+	if !IsSecretAPIKey(key) {
+		t.Errorf("IsSecretAPIKey(%q) = false, want true", key)
+	}
+
+	// Ключи старого формата не считаются новыми
+	if IsSecretAPIKey(GenerateAPIKey(12345)) {
+		t.Error("Legacy key recognized as a secret key")
+	}
+
+	// Ключи не повторяются
+	_, otherKey, err := GenerateSecretAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateSecretAPIKey returned error: %v", err)
+	}
+
+	if key == otherKey {
+		t.Error("Two generated keys are equal")
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	hash := HashAPIKey("tgb_abc_secret")
+
+	if len(hash) != 64 {
+		t.Errorf("Expected hex SHA-256 of length 64, got %d", len(hash))
+	}
+
+	if hash != HashAPIKey("tgb_abc_secret") {
+		t.Error("Hash is not deterministic")
+	}
+
+	if hash == HashAPIKey("tgb_abc_secreT") {
+		t.Error("Different keys have the same hash")
+	}
+}
+
+func TestParseAPIKeyOptions(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		opts, err := ParseAPIKeyOptions(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Без scopes= ключ только для чтения
+		if opts.Name != "" || len(opts.Scopes) != 1 || opts.Scopes[0] != models.ScopeTasksRead || len(opts.TaskPatterns) != 0 {
+			t.Errorf("Unexpected default options: %+v", opts)
+		}
+	})
+
+	t.Run("All options", func(t *testing.T) {
+		opts, err := ParseAPIKeyOptions([]string{"ci", "scopes=tasks:read,timers:write,tasks:read", "tasks=staging-*,qa-?", "tier=ci"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if opts.Name != "ci" {
+			t.Errorf("Expected name 'ci', got %q", opts.Name)
+		}
+
+		if strings.Join(opts.Scopes, ",") != "tasks:read,timers:write" {
+			t.Errorf("Unexpected scopes: %v", opts.Scopes)
+		}
+
+		if strings.Join(opts.TaskPatterns, ",") != "staging-*,qa-?" {
+			t.Errorf("Unexpected task patterns: %v", opts.TaskPatterns)
+		}
+
+		if opts.Tier != "ci" {
+			t.Errorf("Expected tier 'ci', got %q", opts.Tier)
+		}
+	})
+
+	invalid := map[string][]string{
+		"Unknown scope":  {"scopes=tasks:admin"},
+		"Bad pattern":    {"tasks=staging-["},
+		"Empty pattern":  {"tasks="},
+		"Invalid name":   {"@ci"},
+		"Two names":      {"ci", "deploy"},
+		"Empty scope":    {"scopes="},
+		"Trailing comma": {"scopes=tasks:read,"},
+		"Empty tier":     {"tier="},
+		"Invalid tier":   {"tier=CI!"},
+	}
+
+	for name, args := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseAPIKeyOptions(args); err == nil {
+				t.Errorf("Expected error for %v", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// Prefix of lease tokens, tells them apart from API keys
+	LeaseTokenPrefix = "tgl_"
+	// Number of random bytes in a lease token
+	leaseTokenBytes = 24
+
+	// Lease TTL used when the client doesn't give one, in seconds
+	DefaultLeaseTTL = 300
+	// Minimum lease TTL in seconds
+	MinLeaseTTL = 30
+	// Maximum lease TTL in seconds (1 hour)
+	MaxLeaseTTL = 3600
+
+	// Label prefix of lease holders, shown in the chat as "CI: pipeline #1234"
+	LeaseHolderPrefix = "CI: "
+)
+
+// Generates a random lease token
+func GenerateLeaseToken() (string, error) {
+	secret := make([]byte, leaseTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate lease token: %w", err)
+	}
+
+	return LeaseTokenPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// Hashes a lease token for storage and comparison
+func HashLeaseToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
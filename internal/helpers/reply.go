@@ -0,0 +1,74 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package helpers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of actions a reply to a timer message can trigger
+const (
+	ReplyActionNote   = "note"
+	ReplyActionExtend = "extend"
+	ReplyActionDone   = "done"
+	ReplyActionCancel = "cancel"
+)
+
+// Maximum allowed characters for a session note
+const MaxNoteLength = 256
+
+// Maximum notes kept per session, older ones are dropped
+const MaxNotesPerSession = 20
+
+var (
+	// Regexp for extension replies: "+10" or "extend 10"
+	extendReplyRegex = regexp.MustCompile(`^(?:\+\s*|extend\s+)(\d+)$`)
+)
+
+// Represents an interpreted reply to a timer message
+type ReplyCommand struct {
+	Action  string // One of ReplyAction* constants
+	Minutes int    // Extension in minutes for ReplyActionExtend
+	Note    string // Note text for ReplyActionNote
+}
+
+// Interprets the text of a reply to a timer message
+// Anything that isn't a known command becomes a note
+func ParseReplyCommand(text string) ReplyCommand {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+
+	switch lower {
+	case ReplyActionDone:
+		return ReplyCommand{Action: ReplyActionDone}
+	case ReplyActionCancel:
+		return ReplyCommand{Action: ReplyActionCancel}
+	}
+
+	if match := extendReplyRegex.FindStringSubmatch(lower); match != nil {
+		minutes, err := strconv.Atoi(match[1])
+		if err == nil {
+			return ReplyCommand{Action: ReplyActionExtend, Minutes: minutes}
+		}
+	}
+
+	runes := []rune(text)
+	if len(runes) > MaxNoteLength {
+		text = string(runes[:MaxNoteLength])
+	}
+
+	return ReplyCommand{Action: ReplyActionNote, Note: text}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReplyCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected ReplyCommand
+	}{
+		{name: "Plus extension", text: "+10", expected: ReplyCommand{Action: ReplyActionExtend, Minutes: 10}},
+		{name: "Plus extension with space", text: "+ 15", expected: ReplyCommand{Action: ReplyActionExtend, Minutes: 15}},
+		{name: "Extend word", text: "extend 10", expected: ReplyCommand{Action: ReplyActionExtend, Minutes: 10}},
+		{name: "Extend word in upper case", text: "  Extend 5 ", expected: ReplyCommand{Action: ReplyActionExtend, Minutes: 5}},
+		{name: "Done", text: "done", expected: ReplyCommand{Action: ReplyActionDone}},
+		{name: "Done in upper case", text: "DONE", expected: ReplyCommand{Action: ReplyActionDone}},
+		{name: "Cancel", text: "cancel", expected: ReplyCommand{Action: ReplyActionCancel}},
+		{name: "Free text", text: "waiting for review", expected: ReplyCommand{Action: ReplyActionNote, Note: "waiting for review"}},
+		{name: "Extend without minutes", text: "extend", expected: ReplyCommand{Action: ReplyActionNote, Note: "extend"}},
+		{name: "Negative extension", text: "-10", expected: ReplyCommand{Action: ReplyActionNote, Note: "-10"}},
+		{name: "Done inside sentence", text: "almost done", expected: ReplyCommand{Action: ReplyActionNote, Note: "almost done"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseReplyCommand(tt.text)
+			if got != tt.expected {
+				t.Errorf("ParseReplyCommand(%q) = %+v, want %+v", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseReplyCommandLongNote(t *testing.T) {
+	// Слишком длинная заметка обрезается
+	got := ParseReplyCommand(strings.Repeat("я", MaxNoteLength+10))
+
+	if got.Action != ReplyActionNote {
+		t.Fatalf("Expected note action, got %s", got.Action)
+	}
+
+	if len([]rune(got.Note)) != MaxNoteLength {
+		t.Errorf("Expected note of %d characters, got %d", MaxNoteLength, len([]rune(got.Note)))
+	}
+}
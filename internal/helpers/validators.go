@@ -17,11 +17,15 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 const (
 	// Maximum allowed characters for task name
 	maxTaskNameLength = 16 // TODO мб вынести?
+
+	// Maximum allowed characters for a lease holder
+	maxHolderLength = 64
 )
 
 var (
@@ -53,3 +57,27 @@ func ValidateTaskName(name string) error {
 
 	return nil
 }
+
+// Validates the holder of a lease, e.g. "pipeline #1234"
+// Holders are shown in Markdown messages, so Markdown control characters are rejected
+func ValidateHolder(holder string) error {
+	if strings.TrimSpace(holder) == "" {
+		return fmt.Errorf("holder can not be empty")
+	}
+
+	if len(holder) > maxHolderLength {
+		return fmt.Errorf("holder can not be longer than %d characters", maxHolderLength)
+	}
+
+	if strings.ContainsAny(holder, "*_`[]") {
+		return fmt.Errorf("holder can not contain Markdown characters (* _ ` [ ])")
+	}
+
+	for _, r := range holder {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("holder can not contain control characters")
+		}
+	}
+
+	return nil
+}
@@ -174,3 +174,31 @@ func TestValidateTaskNameEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateHolder(t *testing.T) {
+	tests := []struct {
+		name        string
+		holder      string
+		expectError bool
+	}{
+		{name: "Pipeline", holder: "pipeline #1234", expectError: false},
+		{name: "Job URL-like", holder: "deploy/staging 42", expectError: false},
+		{name: "Empty", holder: "", expectError: true},
+		{name: "Only spaces", holder: "   ", expectError: true},
+		{name: "Markdown", holder: "*bold*", expectError: true},
+		{name: "Control character", holder: "job\n1", expectError: true},
+		{name: "Too long", holder: strings.Repeat("a", maxHolderLength+1), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHolder(tt.holder)
+
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for %q, but got nil", tt.holder)
+			} else if !tt.expectError && err != nil {
+				t.Errorf("Expected no error for %q, but got: %v", tt.holder, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package helpers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Prefix of webhook signing secrets
+	WebhookSecretPrefix = "whsec_"
+	// Number of random bytes in a webhook secret
+	webhookSecretBytes = 32
+	// Length of webhook IDs
+	WebhookIDLength = 8
+	// Maximum number of webhooks per chat
+	MaxWebhooksPerChat = 5
+	// Maximum length of a webhook URL
+	maxWebhookURLLength = 2048
+)
+
+// Webhooks are never sent into the bot's own network, it would let chat members probe it
+var ErrPrivateAddress = errors.New("URL must not point to a local or private address")
+
+// Generates a random secret for signing webhook payloads
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// Validates a webhook URL: an absolute http or https URL of a public host
+// Host names are checked again when the dispatcher connects, after DNS resolution
+func ValidateWebhookURL(rawURL string) error {
+	if len(rawURL) > maxWebhookURLLength {
+		return fmt.Errorf("URL can not be longer than %d characters", maxWebhookURLLength)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL must start with http:// or https://")
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("URL must have a host")
+	}
+
+	if host = strings.ToLower(host); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublicAddress(addr) {
+		return ErrPrivateAddress
+	}
+
+	return nil
+}
+
+// Reports whether webhooks may be sent to the address:
+// not loopback, private, link-local, multicast or unspecified
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
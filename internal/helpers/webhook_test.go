@@ -0,0 +1,60 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package helpers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		private bool // Ожидается ErrPrivateAddress
+		valid   bool
+	}{
+		{name: "Public host", url: "https://ci.example.com/hook", valid: true},
+		{name: "Public IP", url: "http://93.184.216.34:8080/hook", valid: true},
+		{name: "Public IPv6", url: "https://[2606:2800:220:1::]/hook", valid: true},
+		{name: "Not HTTP", url: "ftp://example.com/hook"},
+		{name: "No host", url: "http:///hook"},
+		{name: "Too long", url: "https://example.com/" + strings.Repeat("a", maxWebhookURLLength)},
+		{name: "Loopback", url: "http://127.0.0.1:6379", private: true},
+		{name: "Loopback IPv6", url: "http://[::1]/hook", private: true},
+		{name: "IPv4-mapped loopback", url: "http://[::ffff:127.0.0.1]/hook", private: true},
+		{name: "Localhost", url: "http://localhost:6379", private: true},
+		{name: "Localhost subdomain", url: "http://redis.LOCALHOST/hook", private: true},
+		{name: "Cloud metadata", url: "http://169.254.169.254/latest/meta-data/", private: true},
+		{name: "Private network", url: "http://10.0.0.5/hook", private: true},
+		{name: "Private IPv6", url: "http://[fd00::1]/hook", private: true},
+		{name: "Unspecified", url: "http://0.0.0.0:8080/hook", private: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWebhookURL(tt.url)
+
+			switch {
+			case tt.valid && err != nil:
+				t.Errorf("Expected %q to be valid, got %v", tt.url, err)
+			case !tt.valid && err == nil:
+				t.Errorf("Expected %q to be rejected", tt.url)
+			case tt.private && !errors.Is(err, ErrPrivateAddress):
+				t.Errorf("Expected ErrPrivateAddress for %q, got %v", tt.url, err)
+			}
+		})
+	}
+}
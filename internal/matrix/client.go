@@ -0,0 +1,170 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Timeout of calls to the homeserver besides the wait of a sync
+const requestTimeout = 10 * time.Second
+
+// Largest response of the homeserver that is read, syncs of busy rooms included
+const maxResponseSize = 8 << 20
+
+// Path of the client-server API
+const clientAPIPath = "/_matrix/client/v3"
+
+// Calls the client-server API of the homeserver as the bot account
+type client struct {
+	homeserverURL string
+	token         string
+	syncTimeout   time.Duration
+	http          *http.Client
+}
+
+// Error answer of the homeserver
+type apiError struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+// Returns the Matrix ID of the account of the access token
+func (c *client) whoami(ctx context.Context) (string, error) {
+	var result struct {
+		UserID string `json:"user_id"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/account/whoami", nil, nil, &result); err != nil {
+		return "", err
+	}
+
+	return result.UserID, nil
+}
+
+// Returns the events after the since token, waiting up to timeout for new ones
+func (c *client) sync(ctx context.Context, since string, timeout time.Duration) (*syncResponse, error) {
+	query := url.Values{"timeout": {strconv.FormatInt(timeout.Milliseconds(), 10)}}
+	if since != "" {
+		query.Set("since", since)
+	}
+
+	var result syncResponse
+	if err := c.do(ctx, http.MethodGet, "/sync", query, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Joins a room the bot was invited to
+func (c *client) join(ctx context.Context, roomID string) error {
+	return c.do(ctx, http.MethodPost, "/rooms/"+url.PathEscape(roomID)+"/join", nil, struct{}{}, nil)
+}
+
+// Sends an event to a room and returns its ID
+func (c *client) sendEvent(ctx context.Context, roomID string, eventType string, txnID string, content any) (string, error) {
+	path := "/rooms/" + url.PathEscape(roomID) + "/send/" + url.PathEscape(eventType) + "/" + url.PathEscape(txnID)
+
+	var result struct {
+		EventID string `json:"event_id"`
+	}
+
+	if err := c.do(ctx, http.MethodPut, path, nil, content, &result); err != nil {
+		return "", err
+	}
+
+	return result.EventID, nil
+}
+
+// Returns an event of a room
+func (c *client) getEvent(ctx context.Context, roomID string, eventID string) (*event, error) {
+	var result event
+	if err := c.do(ctx, http.MethodGet, "/rooms/"+url.PathEscape(roomID)+"/event/"+url.PathEscape(eventID), nil, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Calls the client-server API and decodes the answer into result unless it is nil
+func (c *client) do(ctx context.Context, method string, path string, query url.Values, payload any, result any) error {
+	var body io.Reader
+
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	endpoint := c.homeserverURL + clientAPIPath + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call the homeserver: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close Matrix response: %v", err)
+		}
+	}()
+
+	decoder := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if err := decoder.Decode(&apiErr); err != nil {
+			return fmt.Errorf("homeserver answered with status %d", resp.StatusCode)
+		}
+
+		return fmt.Errorf("homeserver answered with status %d: %s %s", resp.StatusCode, apiErr.ErrCode, apiErr.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := decoder.Decode(result); err != nil {
+		return fmt.Errorf("failed to decode Matrix response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package matrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Commands start with it, like !30 staging
+const commandPrefix = "!"
+
+// Command typed in a room
+type command struct {
+	roomID string
+	userID string // Matrix ID of the author
+	args   []string
+}
+
+// Runs a command and returns the answer to it, nil for commands of other bots
+func (a *Adapter) runCommand(ctx context.Context, cmd *command) *message {
+	if len(cmd.args) == 0 {
+		return nil
+	}
+
+	name := strings.ToLower(cmd.args[0])
+	args := cmd.args[1:]
+
+	duration, convErr := strconv.Atoi(name)
+
+	switch name {
+	case "help", "add", "cancel", "done", "status", "tasks":
+	default:
+		if convErr != nil {
+			log.Printf("Ignore not found Matrix command: %s", name)
+			return nil
+		}
+	}
+
+	chatID, err := a.service.LinkChat(ctx, models.PlatformMatrix, cmd.roomID)
+	if err != nil {
+		log.Printf("Failed to link Matrix room: %v", err)
+		return newMessage("Error processing command. Please try again")
+	}
+
+	userID, err := a.service.ExternalUser(ctx, chatID, models.PlatformMatrix, cmd.userID, &models.ChatUser{
+		Username:  cmd.userID,
+		FirstName: localpart(cmd.userID),
+	})
+	if err != nil {
+		log.Printf("Failed to save Matrix user: %v", err)
+		return newMessage("Error processing command. Please try again")
+	}
+
+	var msg *message
+
+	switch name {
+	case "help":
+		msg = newMessage(helpText())
+	case "add":
+		msg, err = a.addTask(ctx, chatID, args)
+	case "cancel", "done":
+		msg, err = a.releaseTimer(ctx, chatID, userID, args, name == "done")
+	case "status":
+		msg, err = a.status(ctx, chatID, args)
+	case "tasks":
+		msg, err = a.tasks(ctx, chatID)
+	default:
+		msg, err = a.startTimer(ctx, chatID, userID, cmd.userID, duration, args)
+	}
+
+	if err != nil {
+		log.Printf("Error handling Matrix command %s: %v", name, err)
+		return newMessage("Error processing command. Please try again")
+	}
+
+	return msg
+}
+
+// Returns the list of commands
+func helpText() string {
+	return "<b>Commands</b>\n" +
+		"<code>!add name [description]</code> - add a new task\n" +
+		"<code>!30 name</code> - start a timer for 30 minutes\n" +
+		"<code>!done [name]</code> - finish your timer, the last one if no name is given\n" +
+		"<code>!cancel [name]</code> - cancel your timer, the last one if no name is given\n" +
+		"<code>!status [name]</code> - show task(s) status\n" +
+		"<code>!tasks</code> - list all tasks\n" +
+		"React to a timer with ⌛ to see the time left, with ✅ or ✖ to finish it"
+}
+
+// Handles !add name [description]
+func (a *Adapter) addTask(ctx context.Context, chatID int64, args []string) (*message, error) {
+	if len(args) == 0 {
+		return newMessage("Please provide a name for the task"), nil
+	}
+
+	taskName := args[0]
+	description := strings.Join(args[1:], " ")
+
+	task, err := a.service.CreateTask(ctx, chatID, taskName, description, service.SourceMatrix)
+
+	var nameErr *service.InvalidNameError
+
+	switch {
+	case errors.As(err, &nameErr):
+		return newMessage(fmt.Sprintf("Invalid task name: %s", html.EscapeString(nameErr.Reason.Error()))), nil
+	case errors.Is(err, service.ErrTaskLimit):
+		return newMessage(fmt.Sprintf("Maximum number of tasks per chat reached (%d)", helpers.MaxTasksPerChat)), nil
+	case errors.Is(err, service.ErrTaskNameTaken):
+		return newMessage(fmt.Sprintf("A task with name %s already exists", bold(taskName))), nil
+	case err != nil:
+		return nil, err
+	}
+
+	return newMessage(platform.TaskAddedText(markup, task)), nil
+}
+
+// Handles !{minutes} name
+func (a *Adapter) startTimer(ctx context.Context, chatID int64, userID int64, matrixUserID string, duration int, args []string) (*message, error) {
+	if len(args) == 0 {
+		return newMessage("Please provide a task name"), nil
+	}
+
+	task, err := a.storage.GetTaskByName(ctx, chatID, args[0])
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return newMessage(fmt.Sprintf("Task %s not found", bold(args[0]))), nil
+		}
+
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	task, _, err = a.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   chatID,
+		TaskID:   task.ID,
+		UserID:   userID,
+		Duration: duration,
+		Source:   service.SourceMatrix,
+	})
+	if err != nil {
+		if text, ok := a.startErrorText(ctx, chatID, task, err); ok {
+			return newMessage(text), nil
+		}
+
+		return nil, err
+	}
+
+	text := fmt.Sprintf("%s: task %s, %s", platform.TimerStartedText(duration), bold(task.Name), mention(matrixUserID, localpart(matrixUserID)))
+
+	return timerMessage(text, task.ID), nil
+}
+
+// Explains why a timer can't be started, false for unexpected errors
+func (a *Adapter) startErrorText(ctx context.Context, chatID int64, task *models.Task, err error) (string, bool) {
+	return platform.StartErrorText(err, task, a.clock.Now(), markup, func(taskID string) string {
+		if blocker, err := a.storage.GetTask(ctx, chatID, taskID); err == nil {
+			return blocker.Name
+		}
+
+		return taskID
+	})
+}
+
+// Handles !done [name] and !cancel [name]: finishes a timer of the user, the
+// last started one if no name is given
+func (a *Adapter) releaseTimer(ctx context.Context, chatID int64, userID int64, args []string, done bool) (*message, error) {
+	activeTasks, err := a.storage.GetUserActiveTasks(ctx, chatID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user's active tasks: %w", err)
+	}
+
+	if len(activeTasks) == 0 {
+		return newMessage("You don't have any active tasks"), nil
+	}
+
+	var target *models.ActiveTask
+
+	if len(args) > 0 {
+		task, err := a.storage.GetTaskByName(ctx, chatID, args[0])
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				return newMessage(fmt.Sprintf("Task %s not found", bold(args[0]))), nil
+			}
+
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		for _, activeTask := range activeTasks {
+			if activeTask.TaskID == task.ID {
+				target = activeTask
+				break
+			}
+		}
+
+		if target == nil {
+			return newMessage(fmt.Sprintf("You don't have an active timer for task %s", bold(args[0]))), nil
+		}
+	} else {
+		for _, activeTask := range activeTasks {
+			if target == nil || activeTask.StartTime.After(target.StartTime) {
+				target = activeTask
+			}
+		}
+	}
+
+	task, _, err := a.service.ReleaseTimer(ctx, chatID, target.TaskID, done, service.SourceMatrix)
+	if err != nil {
+		return nil, err
+	}
+
+	return newMessage(platform.ReleasedText(markup, task.Name, done)), nil
+}
+
+// Handles !status [name]: shows the tasks of the room, or one of them
+func (a *Adapter) status(ctx context.Context, chatID int64, args []string) (*message, error) {
+	users, err := a.storage.GetChatUsers(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat users: %w", err)
+	}
+
+	var tasks []*models.Task
+
+	if len(args) > 0 {
+		task, err := a.storage.GetTaskByName(ctx, chatID, args[0])
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				return newMessage(fmt.Sprintf("Task %s not found", bold(args[0]))), nil
+			}
+
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		tasks = []*models.Task{task}
+	} else {
+		tasks, err = a.storage.ListTasks(ctx, chatID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks: %w", err)
+		}
+
+		if len(tasks) == 0 {
+			return newMessage("No tasks found. Use <code>!add</code> to create a task"), nil
+		}
+	}
+
+	// Keep a stable order, the set of task IDs in storage is unordered
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+
+	var text strings.Builder
+
+	text.WriteString("Tasks Status:\n")
+
+	for _, task := range tasks {
+		text.WriteString("\n")
+		text.WriteString(a.taskStatusLine(ctx, task, users))
+	}
+
+	return newMessage(text.String()), nil
+}
+
+// Returns a status line of a task
+func (a *Adapter) taskStatusLine(ctx context.Context, task *models.Task, users map[int64]*models.ChatUser) string {
+	switch {
+	case task.IsLocked:
+		line := fmt.Sprintf("🔒 %s - Locked", bold(task.Name))
+		if task.LockReason != "" {
+			line += fmt.Sprintf(" (%s)", html.EscapeString(task.LockReason))
+		}
+
+		return line
+	case task.OwnerID != 0:
+		info := "Remaining"
+
+		activeTask, err := a.storage.GetActiveTask(ctx, task.ChatID, task.ID)
+		if err != nil && !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get active task: %v", err)
+		}
+
+		if activeTask != nil && activeTask.IsPaused() {
+			info = "Paused, remaining"
+		}
+
+		return fmt.Sprintf(
+			"⏱ %s - %s: %s (%s)",
+			bold(task.Name),
+			info,
+			platform.FormatRemaining(task.RemainingAt(a.clock.Now())),
+			holderName(users, task.OwnerID),
+		)
+	default:
+		return fmt.Sprintf("🟢 %s - Available", bold(task.Name))
+	}
+}
+
+// Handles !tasks
+func (a *Adapter) tasks(ctx context.Context, chatID int64) (*message, error) {
+	tasks, err := a.storage.ListTasks(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	if len(tasks) == 0 {
+		return newMessage("No tasks found. Use <code>!add</code> to create a task"), nil
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+
+	var text strings.Builder
+
+	text.WriteString("Tasks:\n")
+
+	for _, task := range tasks {
+		status := "🟢"
+		if task.IsLocked {
+			status = "🔒"
+		} else if task.OwnerID != 0 {
+			status = "⏱"
+		}
+
+		text.WriteString(fmt.Sprintf("\n%s %s %s %s", status, bold(task.Name), code(task.ID), html.EscapeString(task.Description)))
+	}
+
+	return newMessage(text.String()), nil
+}
+
+// Returns the answer to the ⌛ reaction
+func remainingText(remaining int64) string {
+	if remaining <= 0 {
+		return "Task time has expired"
+	}
+
+	remainingMin := remaining / 60
+	if remainingMin < 5 {
+		return fmt.Sprintf("%d:%02d remaining", remainingMin, remaining%60)
+	}
+
+	return fmt.Sprintf("%d minutes remaining", remainingMin)
+}
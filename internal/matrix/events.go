@@ -0,0 +1,218 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+)
+
+// Answer of /sync, only the parts the bot reads
+type syncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join   map[string]joinedRoom      `json:"join"`
+		Invite map[string]json.RawMessage `json:"invite"`
+	} `json:"rooms"`
+}
+
+// New events of a joined room
+type joinedRoom struct {
+	Timeline struct {
+		Events []event `json:"events"`
+	} `json:"timeline"`
+}
+
+// Room event
+type event struct {
+	Type    string          `json:"type"`
+	EventID string          `json:"event_id"`
+	Sender  string          `json:"sender"`
+	Content json.RawMessage `json:"content"`
+}
+
+// Content of a received m.room.message event
+type messageContent struct {
+	MsgType   string    `json:"msgtype"`
+	Body      string    `json:"body"`
+	RelatesTo *relation `json:"m.relates_to"`
+	TaskID    string    `json:"io.github.likebutterfly.time_guard.task_id"`
+}
+
+// Joins the rooms the bot was invited to
+func (a *Adapter) joinInvites(ctx context.Context, resp *syncResponse) {
+	for roomID := range resp.Rooms.Invite {
+		if err := a.client.join(ctx, roomID); err != nil {
+			log.Printf("Failed to join Matrix room %s: %v", roomID, err)
+			continue
+		}
+
+		log.Printf("Joined Matrix room %s", roomID)
+	}
+}
+
+// Handles the new events of joined rooms, room by room in a stable order
+func (a *Adapter) handleSync(ctx context.Context, resp *syncResponse) {
+	roomIDs := make([]string, 0, len(resp.Rooms.Join))
+	for roomID := range resp.Rooms.Join {
+		roomIDs = append(roomIDs, roomID)
+	}
+
+	sort.Strings(roomIDs)
+
+	for _, roomID := range roomIDs {
+		for _, ev := range resp.Rooms.Join[roomID].Timeline.Events {
+			if ev.Sender == a.userID {
+				continue
+			}
+
+			switch ev.Type {
+			case eventMessage:
+				a.handleMessage(ctx, roomID, &ev)
+			case eventReaction:
+				a.handleReaction(ctx, roomID, &ev)
+			}
+		}
+	}
+}
+
+// Runs a command like !30 staging
+func (a *Adapter) handleMessage(ctx context.Context, roomID string, ev *event) {
+	var content messageContent
+	if err := json.Unmarshal(ev.Content, &content); err != nil {
+		log.Printf("Invalid Matrix message %s: %v", ev.EventID, err)
+		return
+	}
+
+	// Edits repeat the whole message, the command already ran
+	if content.MsgType != "m.text" || content.RelatesTo != nil && content.RelatesTo.RelType == "m.replace" {
+		return
+	}
+
+	text := strings.TrimSpace(content.Body)
+	if !strings.HasPrefix(text, commandPrefix) {
+		return
+	}
+
+	msg := a.runCommand(ctx, &command{
+		roomID: roomID,
+		userID: ev.Sender,
+		args:   strings.Fields(text[len(commandPrefix):]),
+	})
+	if msg == nil {
+		return
+	}
+
+	eventID, err := a.send(ctx, roomID, msg.replyTo(ev.EventID))
+	if err != nil {
+		log.Printf("Failed to answer Matrix command: %v", err)
+		return
+	}
+
+	if msg.TaskID != "" {
+		a.addTimerReactions(ctx, roomID, eventID)
+	}
+}
+
+// Handles a reaction to a timer message: ⌛ shows the time left, ✅ and ✖
+// finish the timer like the buttons in Telegram
+func (a *Adapter) handleReaction(ctx context.Context, roomID string, ev *event) {
+	var content reaction
+	if err := json.Unmarshal(ev.Content, &content); err != nil {
+		log.Printf("Invalid Matrix reaction %s: %v", ev.EventID, err)
+		return
+	}
+
+	// Clients may add the emoji presentation selector to the key
+	key := strings.TrimSuffix(content.RelatesTo.Key, "\ufe0f")
+	if content.RelatesTo.RelType != "m.annotation" ||
+		key != reactionCheckTime && key != reactionDone && key != reactionCancel {
+		return
+	}
+
+	target, err := a.client.getEvent(ctx, roomID, content.RelatesTo.EventID)
+	if err != nil {
+		log.Printf("Failed to get reacted Matrix event: %v", err)
+		return
+	}
+
+	var timer messageContent
+	if err := json.Unmarshal(target.Content, &timer); err != nil || target.Sender != a.userID || timer.TaskID == "" {
+		return
+	}
+
+	msg := a.runReaction(ctx, roomID, ev.Sender, key, timer.TaskID)
+
+	if _, err := a.send(ctx, roomID, msg.replyTo(target.EventID)); err != nil {
+		log.Printf("Failed to answer Matrix reaction: %v", err)
+	}
+}
+
+// Runs a reaction to a timer message and returns the answer to it
+func (a *Adapter) runReaction(ctx context.Context, roomID string, sender string, key string, taskID string) *message {
+	chatID := service.ExternalChatID(models.PlatformMatrix, roomID)
+
+	activeTask, err := a.storage.GetActiveTask(ctx, chatID, taskID)
+	if err != nil {
+		return newMessage("Task not found or not active")
+	}
+
+	if key == reactionCheckTime {
+		return newMessage(remainingText(activeTask.RemainingAt(a.clock.Now())))
+	}
+
+	if service.ExternalUserID(models.PlatformMatrix, sender) != activeTask.UserID {
+		return newMessage("Only the user who started the timer can do this")
+	}
+
+	done := key == reactionDone
+
+	task, _, err := a.service.ReleaseTimer(ctx, chatID, taskID, done, service.SourceMatrix)
+	if err != nil {
+		if errors.Is(err, service.ErrTaskNotActive) {
+			return newMessage("Task not found or not active")
+		}
+
+		log.Printf("Error handling Matrix reaction %s: %v", key, err)
+
+		return newMessage("Error processing action. Please try again")
+	}
+
+	return newMessage(platform.ReleasedText(markup, task.Name, done))
+}
+
+// Sends a message to a room and returns its event ID
+func (a *Adapter) send(ctx context.Context, roomID string, msg *message) (string, error) {
+	return a.client.sendEvent(ctx, roomID, eventMessage, a.nextTxnID(), msg)
+}
+
+// Adds the reactions users click on a timer message
+func (a *Adapter) addTimerReactions(ctx context.Context, roomID string, eventID string) {
+	for _, key := range []string{reactionCheckTime, reactionDone, reactionCancel} {
+		content := &reaction{RelatesTo: relation{RelType: "m.annotation", EventID: eventID, Key: key}}
+
+		if _, err := a.client.sendEvent(ctx, roomID, eventReaction, a.nextTxnID(), content); err != nil {
+			log.Printf("Failed to add reaction to Matrix timer: %v", err)
+			return
+		}
+	}
+}
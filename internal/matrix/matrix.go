@@ -0,0 +1,174 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package matrix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage"
+)
+
+// How long the homeserver holds a sync request open without new events
+const defaultSyncTimeout = 30 * time.Second
+
+// Delay before another sync after a failed one
+const syncRetryDelay = 5 * time.Second
+
+// Represents Matrix client configuration
+type Config struct {
+	// Base URL of the homeserver, e.g. https://matrix.example.org
+	HomeserverURL string
+	// Access token of the bot account
+	AccessToken string
+	// Long polling timeout of /sync, defaultSyncTimeout if zero
+	SyncTimeout time.Duration
+}
+
+// Serves Matrix rooms on top of the service through the client-server API:
+// every room is a chat of its own, timers of these chats run in the bot like all others
+type Adapter struct {
+	config  *Config
+	storage storage.Storage
+	service *service.Service
+	clock   clock.Clock
+	client  *client
+
+	userID    string // Matrix ID of the bot account, its own events are skipped
+	nextBatch string // Sync token of the events handled so far
+	txnID     atomic.Int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Creates a Matrix adapter and subscribes it to svc to announce changes in Matrix rooms
+func New(config *Config, storage storage.Storage, svc *service.Service) *Adapter {
+	syncTimeout := config.SyncTimeout
+	if syncTimeout == 0 {
+		syncTimeout = defaultSyncTimeout
+	}
+
+	a := &Adapter{
+		config:  config,
+		storage: storage,
+		service: svc,
+		clock:   svc.Clock(),
+		client: &client{
+			homeserverURL: config.HomeserverURL,
+			token:         config.AccessToken,
+			syncTimeout:   syncTimeout,
+			// A sync is held open for syncTimeout, leave room for the answer
+			http: &http.Client{Timeout: syncTimeout + requestTimeout},
+		},
+	}
+
+	// Transaction IDs must not repeat across restarts of the same access token
+	a.txnID.Store(a.clock.Now().UnixNano())
+
+	svc.Subscribe(a.handleServiceEvent)
+
+	return a
+}
+
+// Connects to the homeserver and starts handling room events
+// Messages sent while the bot was offline are skipped, like stale commands in Telegram
+func (a *Adapter) Start() error {
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+
+	userID, err := a.client.whoami(a.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to identify the bot account: %w", err)
+	}
+
+	a.userID = userID
+
+	resp, err := a.client.sync(a.ctx, "", 0)
+	if err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+
+	a.nextBatch = resp.NextBatch
+	a.joinInvites(a.ctx, resp)
+
+	log.Printf("Matrix client started as %s", a.userID)
+
+	a.wg.Add(1)
+
+	go func() {
+		defer a.wg.Done()
+
+		a.run()
+	}()
+
+	return nil
+}
+
+// Stops handling room events and waits for the running sync to finish
+func (a *Adapter) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+
+	a.wg.Wait()
+}
+
+// Syncs with the homeserver until the adapter is stopped
+func (a *Adapter) run() {
+	for a.ctx.Err() == nil {
+		if err := a.syncOnce(a.ctx); err != nil {
+			if a.ctx.Err() != nil {
+				break
+			}
+
+			log.Printf("Failed to sync with Matrix, retrying in %s: %v", syncRetryDelay, err)
+
+			select {
+			case <-a.ctx.Done():
+			case <-time.After(syncRetryDelay):
+			}
+		}
+	}
+
+	log.Println("Stopping Matrix sync...")
+}
+
+// Waits for new events and handles them
+func (a *Adapter) syncOnce(ctx context.Context) error {
+	resp, err := a.client.sync(ctx, a.nextBatch, a.client.syncTimeout)
+	if err != nil {
+		return err
+	}
+
+	a.joinInvites(ctx, resp)
+	a.handleSync(ctx, resp)
+
+	a.nextBatch = resp.NextBatch
+
+	return nil
+}
+
+// Returns a transaction ID for the next event sent
+func (a *Adapter) nextTxnID() string {
+	return strconv.FormatInt(a.txnID.Add(1), 10)
+}
@@ -0,0 +1,590 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform/platformtest"
+	"time-guard-bot/internal/service"
+)
+
+const (
+	testToken = "syt_dGltZWd1YXJk_test"
+	testBot   = "@timeguard:example.org"
+	testRoom  = "!deploys:example.org"
+	testAlice = "@alice:example.org"
+	testBob   = "@bob:example.org"
+)
+
+// Событие, отправленное ботом
+type sentEvent struct {
+	roomID    string
+	eventType string
+	eventID   string
+	content   json.RawMessage
+}
+
+// Фальшивый homeserver: отдает события через /sync и запоминает события бота
+type fakeHomeserver struct {
+	t       *testing.T
+	server  *httptest.Server
+	batches chan *syncResponse // Ответы /sync после первого
+	initial *syncResponse      // Ответ первого /sync без since
+
+	sent platformtest.Recorder[sentEvent] // События бота по порядку отправки
+
+	mu     sync.Mutex
+	events map[string]sentEvent // Все события бота по ID, для /event
+	txnIDs map[string]bool
+	joined []string
+	added  chan struct{} // Сигнал о новом событии бота
+}
+
+func newFakeHomeserver(t *testing.T) *fakeHomeserver {
+	t.Helper()
+
+	hs := &fakeHomeserver{
+		t:       t,
+		batches: make(chan *syncResponse, 10),
+		initial: &syncResponse{NextBatch: "s0"},
+		events:  map[string]sentEvent{},
+		txnIDs:  map[string]bool{},
+		added:   make(chan struct{}, 100),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /_matrix/client/v3/account/whoami", func(w http.ResponseWriter, r *http.Request) {
+		hs.writeJSON(w, http.StatusOK, map[string]string{"user_id": testBot})
+	})
+	mux.HandleFunc("GET /_matrix/client/v3/sync", hs.handleSync)
+	mux.HandleFunc("POST /_matrix/client/v3/rooms/{room}/join", func(w http.ResponseWriter, r *http.Request) {
+		hs.mu.Lock()
+		hs.joined = append(hs.joined, r.PathValue("room"))
+		hs.mu.Unlock()
+
+		hs.writeJSON(w, http.StatusOK, map[string]string{"room_id": r.PathValue("room")})
+	})
+	mux.HandleFunc("PUT /_matrix/client/v3/rooms/{room}/send/{type}/{txn}", hs.handleSend)
+	mux.HandleFunc("GET /_matrix/client/v3/rooms/{room}/event/{event}", func(w http.ResponseWriter, r *http.Request) {
+		hs.mu.Lock()
+		ev, exists := hs.events[r.PathValue("event")]
+		hs.mu.Unlock()
+
+		if !exists {
+			hs.writeJSON(w, http.StatusNotFound, apiError{ErrCode: "M_NOT_FOUND", Error: "Event not found"})
+			return
+		}
+
+		hs.writeJSON(w, http.StatusOK, event{Type: ev.eventType, EventID: ev.eventID, Sender: testBot, Content: ev.content})
+	})
+
+	hs.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			hs.writeJSON(w, http.StatusUnauthorized, apiError{ErrCode: "M_UNKNOWN_TOKEN", Error: "Invalid access token"})
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(hs.server.Close)
+
+	return hs
+}
+
+func (hs *fakeHomeserver) handleSync(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("since") == "" {
+		hs.writeJSON(w, http.StatusOK, hs.initial)
+		return
+	}
+
+	// Как настоящий homeserver, держит запрос до новых событий или таймаута
+	select {
+	case batch := <-hs.batches:
+		hs.writeJSON(w, http.StatusOK, batch)
+	case <-time.After(100 * time.Millisecond):
+		hs.writeJSON(w, http.StatusOK, &syncResponse{NextBatch: r.URL.Query().Get("since")})
+	case <-r.Context().Done():
+	}
+}
+
+func (hs *fakeHomeserver) handleSend(w http.ResponseWriter, r *http.Request) {
+	var content json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&content); err != nil {
+		hs.t.Errorf("Failed to decode event: %v", err)
+	}
+
+	hs.mu.Lock()
+
+	if hs.txnIDs[r.PathValue("txn")] {
+		hs.t.Errorf("Transaction ID %s used twice", r.PathValue("txn"))
+	}
+
+	hs.txnIDs[r.PathValue("txn")] = true
+
+	ev := sentEvent{
+		roomID:    r.PathValue("room"),
+		eventType: r.PathValue("type"),
+		eventID:   fmt.Sprintf("$event%d", len(hs.events)+1),
+		content:   content,
+	}
+	hs.events[ev.eventID] = ev
+	hs.mu.Unlock()
+
+	hs.sent.Add(ev)
+
+	hs.added <- struct{}{}
+
+	hs.writeJSON(w, http.StatusOK, map[string]string{"event_id": ev.eventID})
+}
+
+func (hs *fakeHomeserver) writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		hs.t.Errorf("Failed to write response: %v", err)
+	}
+}
+
+// Ждет, пока бот отправит n событий
+func (hs *fakeHomeserver) wait(t *testing.T, n int) []sentEvent {
+	t.Helper()
+
+	for range n {
+		select {
+		case <-hs.added:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %d events", n)
+		}
+	}
+
+	return hs.sent.Take()
+}
+
+type testEnv struct {
+	*platformtest.Backend
+	adapter *Adapter
+	hs      *fakeHomeserver
+	lastID  int
+	timer   sentEvent // Сообщение последнего запущенного таймера
+}
+
+func setupAdapter(t *testing.T) *testEnv {
+	t.Helper()
+
+	backend := platformtest.NewBackend(t)
+	hs := newFakeHomeserver(t)
+
+	adapter := New(&Config{HomeserverURL: hs.server.URL, AccessToken: testToken, SyncTimeout: time.Second}, backend.Storage, backend.Service)
+	adapter.userID = testBot
+
+	return &testEnv{Backend: backend, adapter: adapter, hs: hs}
+}
+
+// Передает адаптеру одно событие комнаты, как его вернул бы /sync, и возвращает ответы бота
+func (e *testEnv) deliver(t *testing.T, eventType string, sender string, content string) []sentEvent {
+	t.Helper()
+
+	e.lastID++
+
+	resp := &syncResponse{NextBatch: fmt.Sprintf("s%d", e.lastID)}
+	resp.Rooms.Join = map[string]joinedRoom{testRoom: {}}
+
+	room := resp.Rooms.Join[testRoom]
+	room.Timeline.Events = []event{{
+		Type:    eventType,
+		EventID: fmt.Sprintf("$incoming%d", e.lastID),
+		Sender:  sender,
+		Content: json.RawMessage(content),
+	}}
+	resp.Rooms.Join[testRoom] = room
+
+	e.adapter.handleSync(context.Background(), resp)
+
+	return e.hs.sent.Take()
+}
+
+// Пишет сообщение в комнату
+func (e *testEnv) say(t *testing.T, sender string, body string) []sentEvent {
+	t.Helper()
+
+	content, err := json.Marshal(map[string]string{"msgtype": "m.text", "body": body})
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+
+	return e.deliver(t, eventMessage, sender, string(content))
+}
+
+// Ставит реакцию на событие
+func (e *testEnv) react(t *testing.T, sender string, eventID string, key string) []sentEvent {
+	t.Helper()
+
+	content, err := json.Marshal(reaction{RelatesTo: relation{RelType: "m.annotation", EventID: eventID, Key: key}})
+	if err != nil {
+		t.Fatalf("Failed to marshal reaction: %v", err)
+	}
+
+	return e.deliver(t, eventReaction, sender, string(content))
+}
+
+// Разбирает отправленное ботом сообщение
+func decodeMessage(t *testing.T, ev sentEvent) message {
+	t.Helper()
+
+	if ev.eventType != eventMessage {
+		t.Fatalf("Expected a message, got %s", ev.eventType)
+	}
+
+	var msg message
+	if err := json.Unmarshal(ev.content, &msg); err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+
+	return msg
+}
+
+// Описывает адаптер для общих проверок платформ
+func (e *testEnv) harness() *platformtest.Harness {
+	return &platformtest.Harness{
+		Platform: models.PlatformMatrix,
+		Channel:  testRoom,
+		UserID:   testAlice,
+		AddTask: func(t *testing.T, name string) {
+			e.say(t, testAlice, "!add "+name)
+		},
+		StartTimer: func(t *testing.T, minutes int, name string) bool {
+			sent := e.say(t, testAlice, fmt.Sprintf("!%d %s", minutes, name))
+			if len(sent) != 4 || decodeMessage(t, sent[0]).TaskID == "" {
+				return false
+			}
+
+			e.timer = sent[0]
+
+			return true
+		},
+		Posts: func(t *testing.T) []platformtest.Post {
+			var posts []platformtest.Post
+
+			for _, ev := range e.hs.sent.Take() {
+				if ev.roomID != testRoom {
+					t.Errorf("Unexpected event in room %s", ev.roomID)
+					continue
+				}
+
+				// Реакции под сообщением таймера управляют им, как кнопки
+				if ev.eventType == eventReaction && len(posts) > 0 {
+					posts[len(posts)-1].Controls = true
+					continue
+				}
+
+				posts = append(posts, platformtest.Post{Text: decodeMessage(t, ev).Body})
+			}
+
+			return posts
+		},
+	}
+}
+
+// Создает задачу staging, запускает на нее таймер Алисы и возвращает событие сообщения таймера
+func (e *testEnv) startTimer(t *testing.T) (*models.Task, sentEvent) {
+	t.Helper()
+
+	task := e.StartTimer(t, e.harness())
+
+	return task, e.timer
+}
+
+func TestNewMessage(t *testing.T) {
+	msg := newMessage(fmt.Sprintf("Task %s\nheld by %s", bold("a<b"), mention(testAlice, "alice")))
+
+	if want := "Task a<b\nheld by alice"; msg.Body != want {
+		t.Errorf("Expected body %q, got %q", want, msg.Body)
+	}
+
+	if want := `Task <b>a&lt;b</b><br>held by <a href="https://matrix.to/#/@alice:example.org">alice</a>`; msg.FormattedBody != want {
+		t.Errorf("Expected formatted body %q, got %q", want, msg.FormattedBody)
+	}
+
+	if msg.Mentions == nil || len(msg.Mentions.UserIDs) != 1 || msg.Mentions.UserIDs[0] != testAlice {
+		t.Errorf("Expected alice mentioned, got %+v", msg.Mentions)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	env := setupAdapter(t)
+
+	tests := []struct {
+		name   string
+		sender string
+		body   string
+		want   string // Ожидаемый текст ответа, пусто - ответа нет
+	}{
+		{"Help", testAlice, "!help", "Commands\n!add name [description] - add a new task"},
+		{"Add task", testAlice, "!add staging Test stand", "Task added successfully!\n\nName: staging"},
+		{"Duplicate task", testBob, "!add staging", "A task with name staging already exists"},
+		{"Unknown task", testAlice, "!30 prod", "Task prod not found"},
+		{"Duration over the limit", testAlice, "!100000 staging", "Duration exceeds maximum allowed limit"},
+		{"Start timer", testAlice, "!30 staging", "Timer started for 30 minutes: task staging, alice"},
+		{"Task busy", testBob, "!10 staging", "Another user is currently working on the task. 30:00 remaining"},
+		{"Status", testBob, "!status", "Tasks Status:\n\n⏱ staging - Remaining: 30:00 (alice)"},
+		{"Tasks", testBob, "!tasks", "⏱ staging"},
+		{"Cancel without timers", testBob, "!cancel", "You don't have any active tasks"},
+		{"Done", testAlice, "!done staging", "✅ Task staging done"},
+		{"Status of one task", testAlice, "!status staging", "🟢 staging - Available"},
+		// Команды других ботов и обычные сообщения без ответа
+		{"Unknown command", testAlice, "!deploy", ""},
+		{"Plain message", testAlice, "30 staging", ""},
+		{"Own message", testBot, "!help", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := env.say(t, tt.sender, tt.body)
+
+			if tt.want == "" {
+				if len(sent) != 0 {
+					t.Errorf("Expected no answer, got %+v", sent)
+				}
+
+				return
+			}
+
+			if len(sent) == 0 {
+				t.Fatal("Expected an answer")
+			}
+
+			msg := decodeMessage(t, sent[0])
+
+			if !strings.Contains(msg.Body, tt.want) {
+				t.Errorf("Expected %q in %q", tt.want, msg.Body)
+			}
+
+			if msg.MsgType != "m.notice" || msg.RelatesTo == nil || msg.RelatesTo.InReplyTo == nil {
+				t.Errorf("Expected a notice replying to the command, got %+v", msg)
+			}
+
+			if sent[0].roomID != testRoom {
+				t.Errorf("Expected the answer in %s, got %s", testRoom, sent[0].roomID)
+			}
+		})
+	}
+}
+
+func TestIgnoredMessages(t *testing.T) {
+	env := setupAdapter(t)
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Notice", `{"msgtype":"m.notice","body":"!help"}`},
+		// Правка команды не выполняет ее еще раз
+		{"Edit", `{"msgtype":"m.text","body":"* !help","m.relates_to":{"rel_type":"m.replace","event_id":"$old"}}`},
+		{"Invalid content", `{"msgtype":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sent := env.deliver(t, eventMessage, testAlice, tt.content); len(sent) != 0 {
+				t.Errorf("Expected no answer, got %+v", sent)
+			}
+		})
+	}
+}
+
+func TestTimerMessage(t *testing.T) {
+	env := setupAdapter(t)
+
+	env.say(t, testAlice, "!add staging")
+	sent := env.say(t, testAlice, "!30 staging")
+
+	if len(sent) != 4 {
+		t.Fatalf("Expected a timer message and 3 reactions, got %+v", sent)
+	}
+
+	msg := decodeMessage(t, sent[0])
+
+	if msg.Mentions == nil || len(msg.Mentions.UserIDs) != 1 || msg.Mentions.UserIDs[0] != testAlice {
+		t.Errorf("Expected the holder mentioned, got %+v", msg.Mentions)
+	}
+
+	var keys []string
+
+	for _, ev := range sent[1:] {
+		var content reaction
+		if err := json.Unmarshal(ev.content, &content); err != nil {
+			t.Fatalf("Failed to decode reaction: %v", err)
+		}
+
+		if ev.eventType != eventReaction || content.RelatesTo.EventID != sent[0].eventID {
+			t.Errorf("Expected a reaction to the timer message, got %s %+v", ev.eventType, content)
+		}
+
+		keys = append(keys, content.RelatesTo.Key)
+	}
+
+	if strings.Join(keys, ",") != "⌛,✅,✖" {
+		t.Errorf("Unexpected reactions: %v", keys)
+	}
+}
+
+func TestReactions(t *testing.T) {
+	env := setupAdapter(t)
+	task, timer := env.startTimer(t)
+	chatID := service.ExternalChatID(models.PlatformMatrix, testRoom)
+
+	env.Clock.Advance(10 * time.Minute)
+
+	tests := []struct {
+		name    string
+		sender  string
+		eventID string
+		key     string
+		want    string // Пусто - ответа нет
+	}{
+		{"Check time", testBob, timer.eventID, "⌛", "20 minutes remaining"},
+		{"Check time with emoji presentation", testBob, timer.eventID, "⌛️", "20 minutes remaining"},
+		{"Done by another user", testBob, timer.eventID, "✅", "Only the user who started the timer can do this"},
+		{"Other emoji", testAlice, timer.eventID, "👍", ""},
+		{"Reaction to another message", testAlice, "$unknown", "⌛", ""},
+		{"Cancel", testAlice, timer.eventID, "✖️", "Timer for task staging has been cancelled"},
+		{"Check time after cancel", testAlice, timer.eventID, "⌛", "Task not found or not active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := env.react(t, tt.sender, tt.eventID, tt.key)
+
+			if tt.want == "" {
+				if len(sent) != 0 {
+					t.Errorf("Expected no answer, got %+v", sent)
+				}
+
+				return
+			}
+
+			if len(sent) != 1 {
+				t.Fatalf("Expected one answer, got %+v", sent)
+			}
+
+			msg := decodeMessage(t, sent[0])
+
+			if msg.Body != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, msg.Body)
+			}
+
+			if msg.RelatesTo == nil || msg.RelatesTo.InReplyTo == nil || msg.RelatesTo.InReplyTo.EventID != timer.eventID {
+				t.Errorf("Expected a reply to the timer message, got %+v", msg.RelatesTo)
+			}
+		})
+	}
+
+	if _, err := env.Storage.GetActiveTask(context.Background(), chatID, task.ID); err == nil {
+		t.Error("Expected the timer released by the reaction")
+	}
+}
+
+func TestAnnounceEvents(t *testing.T) {
+	env := setupAdapter(t)
+
+	env.CheckAnnouncements(
+		t,
+		env.harness(),
+		"⌛ Time for task staging has expired! How's it going, alice?",
+		"Timer started for 15 minutes: task staging, alice",
+	)
+
+	// Держатель истекшего таймера получает уведомление
+	env.Clock.Advance(15 * time.Minute)
+
+	task := env.Task(t, models.PlatformMatrix, testRoom, "staging")
+	if _, _, err := env.Service.ExpireTimer(context.Background(), task.ChatID, task.ID); err != nil {
+		t.Fatalf("Failed to expire timer: %v", err)
+	}
+
+	sent := env.hs.sent.Take()
+	if len(sent) != 1 {
+		t.Fatalf("Expected one message in the room, got %+v", sent)
+	}
+
+	if msg := decodeMessage(t, sent[0]); msg.Mentions == nil || msg.Mentions.UserIDs[0] != testAlice {
+		t.Errorf("Expected the holder notified, got %+v", msg.Mentions)
+	}
+}
+
+func TestSyncLoop(t *testing.T) {
+	env := setupAdapter(t)
+
+	// Приглашение и команда, отправленная, пока бот был выключен
+	env.hs.initial.Rooms.Invite = map[string]json.RawMessage{"!new:example.org": json.RawMessage(`{}`)}
+	env.hs.initial.Rooms.Join = map[string]joinedRoom{testRoom: {}}
+
+	room := env.hs.initial.Rooms.Join[testRoom]
+	room.Timeline.Events = []event{{Type: eventMessage, EventID: "$old", Sender: testAlice, Content: json.RawMessage(`{"msgtype":"m.text","body":"!help"}`)}}
+	env.hs.initial.Rooms.Join[testRoom] = room
+
+	if err := env.adapter.Start(); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+
+	defer env.adapter.Stop()
+
+	if env.adapter.userID != testBot {
+		t.Errorf("Expected user ID %s, got %s", testBot, env.adapter.userID)
+	}
+
+	env.hs.mu.Lock()
+	joined := env.hs.joined
+	env.hs.mu.Unlock()
+
+	if len(joined) != 1 || joined[0] != "!new:example.org" {
+		t.Errorf("Expected the invite accepted, got %v", joined)
+	}
+
+	// Новая команда приходит через /sync
+	batch := &syncResponse{NextBatch: "s1"}
+	batch.Rooms.Join = map[string]joinedRoom{testRoom: {}}
+	room = batch.Rooms.Join[testRoom]
+	room.Timeline.Events = []event{{Type: eventMessage, EventID: "$new", Sender: testAlice, Content: json.RawMessage(`{"msgtype":"m.text","body":"!tasks"}`)}}
+	batch.Rooms.Join[testRoom] = room
+	env.hs.batches <- batch
+
+	sent := env.hs.wait(t, 1)
+
+	msg := decodeMessage(t, sent[0])
+	if msg.RelatesTo == nil || msg.RelatesTo.InReplyTo.EventID != "$new" {
+		t.Errorf("Expected only the new command answered, got %+v", msg)
+	}
+}
+
+func TestStartInvalidToken(t *testing.T) {
+	env := setupAdapter(t)
+	env.adapter.client.token = "wrong"
+
+	err := env.adapter.Start()
+	if err == nil || !strings.Contains(err.Error(), "M_UNKNOWN_TOKEN") {
+		t.Errorf("Expected an unknown token error, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package matrix
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+)
+
+// Event types
+const (
+	eventMessage  = "m.room.message"
+	eventReaction = "m.reaction"
+)
+
+// Reactions of a timer message, the bot adds them so users only have to click
+const (
+	reactionCheckTime = "⌛"
+	reactionDone      = "✅"
+	reactionCancel    = "✖"
+)
+
+// Content key of the task a timer message belongs to, reactions find the task through it
+const taskIDKey = "io.github.likebutterfly.time_guard.task_id"
+
+// Link of a user mention, clients render it as a pill
+const mentionURL = "https://matrix.to/#/"
+
+// Mentions in the HTML of a message
+var mentionLink = regexp.MustCompile(`<a href="` + regexp.QuoteMeta(mentionURL) + `([^"]+)">`)
+
+// Tags in the HTML of a message, removed from the plain text body
+var htmlTag = regexp.MustCompile(`<[^>]+>`)
+
+// Messages are HTML, so everything users typed is escaped
+var markup = platform.Markup{Bold: bold, Code: code, Text: html.EscapeString}
+
+// Content of an m.room.message event sent by the bot
+type message struct {
+	MsgType       string    `json:"msgtype"`
+	Body          string    `json:"body"`
+	Format        string    `json:"format,omitempty"`
+	FormattedBody string    `json:"formatted_body,omitempty"`
+	RelatesTo     *relation `json:"m.relates_to,omitempty"`
+	Mentions      *mentions `json:"m.mentions,omitempty"`
+	TaskID        string    `json:"io.github.likebutterfly.time_guard.task_id,omitempty"`
+}
+
+// Relation of an event to another one: a reply or a reaction
+type relation struct {
+	RelType   string     `json:"rel_type,omitempty"`
+	EventID   string     `json:"event_id,omitempty"`
+	Key       string     `json:"key,omitempty"`
+	InReplyTo *replyInfo `json:"m.in_reply_to,omitempty"`
+}
+
+// Event a message replies to
+type replyInfo struct {
+	EventID string `json:"event_id"`
+}
+
+// Users a message notifies
+type mentions struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// Content of an m.reaction event
+type reaction struct {
+	RelatesTo relation `json:"m.relates_to"`
+}
+
+// Creates a message from HTML, the plain text body is derived from it
+// Users mentioned with mentionLink are notified
+func newMessage(text string) *message {
+	msg := &message{
+		MsgType:       "m.notice",
+		Body:          html.UnescapeString(htmlTag.ReplaceAllString(text, "")),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: strings.ReplaceAll(text, "\n", "<br>"),
+	}
+
+	for _, match := range mentionLink.FindAllStringSubmatch(text, -1) {
+		if msg.Mentions == nil {
+			msg.Mentions = &mentions{}
+		}
+
+		msg.Mentions.UserIDs = append(msg.Mentions.UserIDs, match[1])
+	}
+
+	return msg
+}
+
+// Creates the message of a running timer, reactions to it reach the task
+func timerMessage(text string, taskID string) *message {
+	msg := newMessage(text)
+	msg.TaskID = taskID
+
+	return msg
+}
+
+// Makes the message a reply to an event
+func (m *message) replyTo(eventID string) *message {
+	m.RelatesTo = &relation{InReplyTo: &replyInfo{EventID: eventID}}
+	return m
+}
+
+// Returns escaped text in bold
+func bold(text string) string {
+	return "<b>" + html.EscapeString(text) + "</b>"
+}
+
+// Returns escaped text as code
+func code(text string) string {
+	return "<code>" + html.EscapeString(text) + "</code>"
+}
+
+// Returns a mention of a Matrix user
+func mention(userID string, name string) string {
+	return fmt.Sprintf(`<a href="%s%s">%s</a>`, mentionURL, html.EscapeString(userID), html.EscapeString(name))
+}
+
+// Returns the localpart of a Matrix ID, alice for @alice:example.org
+func localpart(userID string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(userID, "@"), ":")
+	return name
+}
+
+// Returns the name of a user from the directory, Matrix users are mentioned
+func holderName(users map[int64]*models.ChatUser, userID int64) string {
+	user, exists := users[userID]
+	if !exists {
+		user = &models.ChatUser{ID: userID}
+	}
+
+	if user.Username != "" && service.ExternalUserID(models.PlatformMatrix, user.Username) == userID {
+		return mention(user.Username, user.DisplayName())
+	}
+
+	return html.EscapeString(user.DisplayName())
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package messenger sends and edits chat messages for the bot
+package messenger
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// The part of the Telegram Bot API the bot handlers use
+// Receiving updates is not part of it, handlers only answer them
+type Messenger interface {
+	// Sends a text message, returns it with its ID
+	Send(msg tgbotapi.MessageConfig) (tgbotapi.Message, error)
+	// Replaces the text (and the keyboard, if given) of a message
+	EditText(edit tgbotapi.EditMessageTextConfig) error
+	// Replaces the inline keyboard of a message, an empty one removes it
+	EditMarkup(edit tgbotapi.EditMessageReplyMarkupConfig) error
+	// Answers a callback query of an inline button
+	AnswerCallback(callback tgbotapi.CallbackConfig) error
+	// Sends a file
+	SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error)
+	// Returns the administrators of a chat
+	GetChatAdmins(chatID int64) ([]tgbotapi.ChatMember, error)
+	// Pins a message without notifying the chat
+	Pin(chatID int64, messageID int) error
+	// Unpins a message
+	Unpin(chatID int64, messageID int) error
+	// Sets the command list shown by Telegram clients
+	SetCommands(commands []tgbotapi.BotCommand) error
+}
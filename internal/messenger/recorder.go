@@ -0,0 +1,232 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package messenger
+
+import (
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Names of recorded calls, one per Messenger method
+const (
+	MethodSend           = "send"
+	MethodEditText       = "edit_text"
+	MethodEditMarkup     = "edit_markup"
+	MethodAnswerCallback = "answer_callback"
+	MethodSendDocument   = "send_document"
+	MethodGetChatAdmins  = "get_chat_admins"
+	MethodPin            = "pin"
+	MethodUnpin          = "unpin"
+	MethodSetCommands    = "set_commands"
+)
+
+var _ Messenger = (*Recorder)(nil)
+
+// Describes a call made to a Recorder
+type Call struct {
+	Method    string
+	ChatID    int64
+	MessageID int    // Message the call is about, the new message for sends
+	Text      string // Text of sent and edited messages and callback answers
+	Config    any    // The tgbotapi config the method was called with, nil for Pin and Unpin
+}
+
+// Implements Messenger in memory for tests: records every call and gives sent
+// messages increasing IDs. Safe for concurrent use
+type Recorder struct {
+	// Administrators returned by GetChatAdmins, by chat
+	Admins map[int64][]tgbotapi.ChatMember
+	// Errors returned by methods instead of succeeding, by method name
+	Errors map[string]error
+	// Errors returned by calls about a chat instead of succeeding, by chat, e.g. a user who blocked the bot
+	ChatErrors map[int64]error
+
+	mu        sync.Mutex
+	calls     []Call
+	messageID int
+	changed   chan struct{}
+}
+
+// Creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		Admins:     make(map[int64][]tgbotapi.ChatMember),
+		Errors:     make(map[string]error),
+		ChatErrors: make(map[int64]error),
+		changed:    make(chan struct{}),
+	}
+}
+
+// Records a call and returns the error configured for its method
+func (r *Recorder) record(call Call) (Call, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.Errors[call.Method]; err != nil {
+		return call, err
+	}
+
+	if err := r.ChatErrors[call.ChatID]; err != nil {
+		return call, err
+	}
+
+	if call.Method == MethodSend || call.Method == MethodSendDocument {
+		r.messageID++
+		call.MessageID = r.messageID
+	}
+
+	r.calls = append(r.calls, call)
+
+	// Wake up everyone in Wait
+	close(r.changed)
+	r.changed = make(chan struct{})
+
+	return call, nil
+}
+
+// Records a sent message
+func (r *Recorder) Send(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	call, err := r.record(Call{Method: MethodSend, ChatID: msg.ChatID, Text: msg.Text, Config: msg})
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	return tgbotapi.Message{
+		MessageID: call.MessageID,
+		Chat:      &tgbotapi.Chat{ID: msg.ChatID},
+		Text:      msg.Text,
+	}, nil
+}
+
+// Records an edited text
+func (r *Recorder) EditText(edit tgbotapi.EditMessageTextConfig) error {
+	_, err := r.record(Call{Method: MethodEditText, ChatID: edit.ChatID, MessageID: edit.MessageID, Text: edit.Text, Config: edit})
+	return err
+}
+
+// Records an edited keyboard
+func (r *Recorder) EditMarkup(edit tgbotapi.EditMessageReplyMarkupConfig) error {
+	_, err := r.record(Call{Method: MethodEditMarkup, ChatID: edit.ChatID, MessageID: edit.MessageID, Config: edit})
+	return err
+}
+
+// Records a callback answer
+func (r *Recorder) AnswerCallback(callback tgbotapi.CallbackConfig) error {
+	_, err := r.record(Call{Method: MethodAnswerCallback, Text: callback.Text, Config: callback})
+	return err
+}
+
+// Records a sent file
+func (r *Recorder) SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error) {
+	call, err := r.record(Call{Method: MethodSendDocument, ChatID: doc.ChatID, Text: doc.Caption, Config: doc})
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	return tgbotapi.Message{MessageID: call.MessageID, Chat: &tgbotapi.Chat{ID: doc.ChatID}}, nil
+}
+
+// Returns the administrators set in Admins
+func (r *Recorder) GetChatAdmins(chatID int64) ([]tgbotapi.ChatMember, error) {
+	if _, err := r.record(Call{Method: MethodGetChatAdmins, ChatID: chatID}); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.Admins[chatID], nil
+}
+
+// Records a pinned message
+func (r *Recorder) Pin(chatID int64, messageID int) error {
+	_, err := r.record(Call{Method: MethodPin, ChatID: chatID, MessageID: messageID})
+	return err
+}
+
+// Records an unpinned message
+func (r *Recorder) Unpin(chatID int64, messageID int) error {
+	_, err := r.record(Call{Method: MethodUnpin, ChatID: chatID, MessageID: messageID})
+	return err
+}
+
+// Records the command list
+func (r *Recorder) SetCommands(commands []tgbotapi.BotCommand) error {
+	_, err := r.record(Call{Method: MethodSetCommands, Config: commands})
+	return err
+}
+
+// Returns all successful calls in order
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Call(nil), r.calls...)
+}
+
+// Returns the successful calls of a method in order
+func (r *Recorder) CallsOf(method string) []Call {
+	var calls []Call
+
+	for _, call := range r.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Returns the texts of all sent messages in order
+func (r *Recorder) SentTexts() []string {
+	var texts []string
+
+	for _, call := range r.CallsOf(MethodSend) {
+		texts = append(texts, call.Text)
+	}
+
+	return texts
+}
+
+// Forgets all recorded calls, message IDs keep increasing
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+// Waits until at least count calls of the method are recorded, for calls made
+// from other goroutines. Returns the calls or false on timeout
+func (r *Recorder) Wait(method string, count int, timeout time.Duration) ([]Call, bool) {
+	deadline := time.After(timeout)
+
+	for {
+		r.mu.Lock()
+		changed := r.changed
+		r.mu.Unlock()
+
+		if calls := r.CallsOf(method); len(calls) >= count {
+			return calls, true
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return r.CallsOf(method), false
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package messenger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRecorderSend(t *testing.T) {
+	recorder := NewRecorder()
+
+	first, err := recorder.Send(tgbotapi.NewMessage(1, "first"))
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	second, err := recorder.Send(tgbotapi.NewMessage(1, "second"))
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	// ID сообщений растут по порядку
+	if first.MessageID != 1 || second.MessageID != 2 {
+		t.Errorf("Expected message IDs 1 and 2, got %d and %d", first.MessageID, second.MessageID)
+	}
+
+	if err := recorder.EditText(tgbotapi.NewEditMessageText(1, first.MessageID, "edited")); err != nil {
+		t.Fatalf("Failed to edit: %v", err)
+	}
+
+	if err := recorder.Pin(1, second.MessageID); err != nil {
+		t.Fatalf("Failed to pin: %v", err)
+	}
+
+	texts := recorder.SentTexts()
+	if len(texts) != 2 || texts[0] != "first" || texts[1] != "second" {
+		t.Errorf("Unexpected sent texts: %q", texts)
+	}
+
+	edits := recorder.CallsOf(MethodEditText)
+	if len(edits) != 1 || edits[0].MessageID != 1 || edits[0].Text != "edited" {
+		t.Errorf("Unexpected edits: %+v", edits)
+	}
+
+	if calls := recorder.Calls(); len(calls) != 4 || calls[3].Method != MethodPin {
+		t.Errorf("Unexpected calls: %+v", calls)
+	}
+
+	recorder.Reset()
+
+	if calls := recorder.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no calls after reset, got %d", len(calls))
+	}
+
+	third, err := recorder.Send(tgbotapi.NewMessage(1, "third"))
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if third.MessageID != 3 {
+		t.Errorf("Expected message ID 3 after reset, got %d", third.MessageID)
+	}
+}
+
+func TestRecorderErrors(t *testing.T) {
+	recorder := NewRecorder()
+	errBlocked := errors.New("bot was blocked by the user")
+	recorder.Errors[MethodSend] = errBlocked
+
+	if _, err := recorder.Send(tgbotapi.NewMessage(1, "hello")); !errors.Is(err, errBlocked) {
+		t.Errorf("Expected the configured error, got %v", err)
+	}
+
+	// Неудачные вызовы не записываются
+	if calls := recorder.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no recorded calls, got %+v", calls)
+	}
+}
+
+func TestRecorderChatErrors(t *testing.T) {
+	recorder := NewRecorder()
+	errBlocked := errors.New("bot was blocked by the user")
+	recorder.ChatErrors[42] = errBlocked
+
+	if _, err := recorder.Send(tgbotapi.NewMessage(42, "hello")); !errors.Is(err, errBlocked) {
+		t.Errorf("Expected the configured error, got %v", err)
+	}
+
+	// Другие чаты работают как обычно
+	if _, err := recorder.Send(tgbotapi.NewMessage(1, "hello")); err != nil {
+		t.Errorf("Failed to send to another chat: %v", err)
+	}
+
+	if texts := recorder.SentTexts(); len(texts) != 1 {
+		t.Errorf("Expected one sent message, got %q", texts)
+	}
+}
+
+func TestRecorderAdmins(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.Admins[1] = []tgbotapi.ChatMember{{User: &tgbotapi.User{ID: 7}, Status: "creator"}}
+
+	admins, err := recorder.GetChatAdmins(1)
+	if err != nil {
+		t.Fatalf("Failed to get admins: %v", err)
+	}
+
+	if len(admins) != 1 || admins[0].User.ID != 7 {
+		t.Errorf("Unexpected admins: %+v", admins)
+	}
+
+	if admins, err := recorder.GetChatAdmins(2); err != nil || len(admins) != 0 {
+		t.Errorf("Expected no admins of an unknown chat, got %+v, %v", admins, err)
+	}
+}
+
+func TestRecorderWait(t *testing.T) {
+	recorder := NewRecorder()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+
+		if _, err := recorder.Send(tgbotapi.NewMessage(1, "late")); err != nil {
+			t.Errorf("Failed to send: %v", err)
+		}
+	}()
+
+	calls, ok := recorder.Wait(MethodSend, 1, time.Second)
+	if !ok || len(calls) != 1 || calls[0].Text != "late" {
+		t.Fatalf("Expected the late message, got %+v, %v", calls, ok)
+	}
+
+	if _, ok := recorder.Wait(MethodPin, 1, 10*time.Millisecond); ok {
+		t.Error("Expected a timeout waiting for a pin")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package messenger
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var _ Messenger = (*Telegram)(nil)
+
+// Implements Messenger with the Telegram Bot API
+type Telegram struct {
+	api *tgbotapi.BotAPI
+}
+
+// Creates a Messenger that talks to Telegram through api
+func NewTelegram(api *tgbotapi.BotAPI) *Telegram {
+	return &Telegram{api: api}
+}
+
+// Sends a text message
+func (t *Telegram) Send(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	return t.api.Send(msg)
+}
+
+// Edits the text of a message
+func (t *Telegram) EditText(edit tgbotapi.EditMessageTextConfig) error {
+	_, err := t.api.Send(edit)
+	return err
+}
+
+// Edits the inline keyboard of a message
+func (t *Telegram) EditMarkup(edit tgbotapi.EditMessageReplyMarkupConfig) error {
+	_, err := t.api.Send(edit)
+	return err
+}
+
+// Answers a callback query
+func (t *Telegram) AnswerCallback(callback tgbotapi.CallbackConfig) error {
+	_, err := t.api.Request(callback)
+	return err
+}
+
+// Sends a file
+func (t *Telegram) SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error) {
+	return t.api.Send(doc)
+}
+
+// Returns the administrators of a chat
+func (t *Telegram) GetChatAdmins(chatID int64) ([]tgbotapi.ChatMember, error) {
+	return t.api.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+}
+
+// Pins a message without notifying the chat
+func (t *Telegram) Pin(chatID int64, messageID int) error {
+	_, err := t.api.Request(tgbotapi.PinChatMessageConfig{
+		ChatID:              chatID,
+		MessageID:           messageID,
+		DisableNotification: true,
+	})
+
+	return err
+}
+
+// Unpins a message
+func (t *Telegram) Unpin(chatID int64, messageID int) error {
+	_, err := t.api.Request(tgbotapi.UnpinChatMessageConfig{
+		ChatID:    chatID,
+		MessageID: messageID,
+	})
+
+	return err
+}
+
+// Sets the command list
+func (t *Telegram) SetCommands(commands []tgbotapi.BotCommand) error {
+	_, err := t.api.Request(tgbotapi.NewSetMyCommands(commands...))
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Records the duration of API requests by route
+// The route is the pattern of ServeMux that served the request, so path parameters
+// don't create new series. Requests that matched no route are recorded as "unmatched"
+func InstrumentHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+
+		if r.Pattern != "" {
+			// The method is a label of its own
+			_, pattern, found := strings.Cut(r.Pattern, " ")
+			if !found {
+				pattern = r.Pattern
+			}
+
+			route = pattern
+		}
+
+		httpDuration.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Remembers the status code of a response
+// Keeps flushing and hijacking working for event streams and WebSockets
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	r.status = http.StatusSwitchingProtocols
+
+	return hijacker.Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Counts failed Telegram Bot API requests
+// Telegram answers errors with a non-2xx status, so the body doesn't need to be read
+type telegramTransport struct {
+	next http.RoundTripper
+}
+
+// Returns an HTTP client for the Telegram Bot API that records failed requests
+func TelegramClient() *http.Client {
+	return &http.Client{Transport: &telegramTransport{next: http.DefaultTransport}}
+}
+
+func (t *telegramTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The method is the last part of the path: /bot<token>/sendMessage
+	method := path.Base(req.URL.Path)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		telegramFailures.WithLabelValues(method).Inc()
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		telegramFailures.WithLabelValues(method).Inc()
+	}
+
+	return resp, nil
+}
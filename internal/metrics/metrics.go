@@ -0,0 +1,249 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package metrics collects Prometheus metrics of the bot and the API
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prefix of all metric names
+const namespace = "timeguard"
+
+// Holds all metrics of the process
+var registry = prometheus.NewRegistry()
+
+var (
+	commandsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commands_total",
+		Help:      "Bot commands handled, by command and result.",
+	}, []string{"command", "result"})
+
+	timersStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "timers_started_total",
+		Help:      "Timers and leases started, by source.",
+	}, []string{"source"})
+
+	timersEnded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "timers_ended_total",
+		Help:      "Timers and leases ended, by outcome: done, cancelled, expired or released.",
+	}, []string{"outcome"})
+
+	timersRestored = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "timers_restored_total",
+		Help:      "Running timers restored from storage on start.",
+	})
+
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "Latency of storage operations, by Redis command.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	storageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_errors_total",
+		Help:      "Failed storage operations, by Redis command.",
+	}, []string{"operation"})
+
+	telegramFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_api_failures_total",
+		Help:      "Failed Telegram Bot API requests, by method.",
+	}, []string{"method"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of API requests, by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		commandsTotal,
+		timersStarted,
+		timersEnded,
+		timersRestored,
+		storageDuration,
+		storageErrors,
+		telegramFailures,
+		httpDuration,
+	)
+}
+
+// Returns the handler that serves metrics in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Records a handled bot command
+func CommandHandled(command string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	commandsTotal.WithLabelValues(command, result).Inc()
+}
+
+// Records a started timer or lease
+func TimerStarted(source string) {
+	timersStarted.WithLabelValues(source).Inc()
+}
+
+// Records an ended timer or lease
+func TimerEnded(outcome string) {
+	timersEnded.WithLabelValues(outcome).Inc()
+}
+
+// Records timers restored from storage
+func TimersRestored(count int) {
+	timersRestored.Add(float64(count))
+}
+
+// How long a scrape waits for the active timers count
+const collectTimeout = 5 * time.Second
+
+// Gives the active timers to the active timers gauges
+type ActiveTimerStore interface {
+	CountActiveTasks(ctx context.Context) (map[int64]int64, error)
+}
+
+// Chats whose active timers are also reported with a chat_id label
+// Labels are opt-in: chat IDs must not leak through metrics unless asked for
+type ChatLabels struct {
+	All   bool           // Label every chat with active timers
+	Chats map[int64]bool // Label only these chats
+}
+
+// Parses chat labels like "all" or "-1001234567890,-1009876543210"
+func ParseChatLabels(value string) (ChatLabels, error) {
+	if strings.TrimSpace(value) == "all" {
+		return ChatLabels{All: true}, nil
+	}
+
+	chats := make(map[int64]bool)
+
+	for _, item := range strings.Split(value, ",") {
+		chatID, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+		if err != nil {
+			return ChatLabels{}, fmt.Errorf("invalid chat ID %q: %w", item, err)
+		}
+
+		chats[chatID] = true
+	}
+
+	return ChatLabels{Chats: chats}, nil
+}
+
+// Reports whether any chat is labelled
+func (l ChatLabels) enabled() bool {
+	return l.All || len(l.Chats) > 0
+}
+
+// Reports whether the chat is labelled
+func (l ChatLabels) includes(chatID int64) bool {
+	return l.All || l.Chats[chatID]
+}
+
+// Reports the number of active timers, read from storage on every scrape
+// The total covers all chats, the per chat gauge only the labelled ones
+type activeTimersCollector struct {
+	store    ActiveTimerStore
+	labels   ChatLabels
+	desc     *prometheus.Desc
+	chatDesc *prometheus.Desc
+	timeout  time.Duration
+}
+
+// Adds the active timers gauges, read from the store
+func RegisterActiveTimers(store ActiveTimerStore, labels ChatLabels) error {
+	return registry.Register(newActiveTimersCollector(store, labels))
+}
+
+// Creates a collector of active timers
+func newActiveTimersCollector(store ActiveTimerStore, labels ChatLabels) *activeTimersCollector {
+	return &activeTimersCollector{
+		store:  store,
+		labels: labels,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_timers"),
+			"Running and paused timers of all chats.",
+			nil,
+			nil,
+		),
+		chatDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "chat_active_timers"),
+			"Running and paused timers of labelled chats, by chat.",
+			[]string{"chat_id"},
+			nil,
+		),
+		timeout: collectTimeout,
+	}
+}
+
+func (c *activeTimersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+
+	if c.labels.enabled() {
+		ch <- c.chatDesc
+	}
+}
+
+func (c *activeTimersCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	counts, err := c.store.CountActiveTasks(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	var total int64
+	for chatID, count := range counts {
+		total += count
+
+		if c.labels.includes(chatID) {
+			ch <- prometheus.MustNewConstMetric(c.chatDesc, prometheus.GaugeValue, float64(count), strconv.FormatInt(chatID, 10))
+		}
+	}
+
+	// Listed chats without timers report zero rather than disappear
+	for chatID := range c.labels.Chats {
+		if _, ok := counts[chatID]; !ok {
+			ch <- prometheus.MustNewConstMetric(c.chatDesc, prometheus.GaugeValue, 0, strconv.FormatInt(chatID, 10))
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(total))
+}
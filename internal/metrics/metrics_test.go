@@ -0,0 +1,242 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCounters(t *testing.T) {
+	before := testutil.ToFloat64(commandsTotal.WithLabelValues("add", "error"))
+
+	CommandHandled("add", errors.New("storage is down"))
+	CommandHandled("add", nil)
+
+	if got := testutil.ToFloat64(commandsTotal.WithLabelValues("add", "error")); got != before+1 {
+		t.Errorf("Expected %v failed add commands, got %v", before+1, got)
+	}
+
+	before = testutil.ToFloat64(timersRestored)
+	TimersRestored(3)
+
+	if got := testutil.ToFloat64(timersRestored); got != before+3 {
+		t.Errorf("Expected %v restored timers, got %v", before+3, got)
+	}
+}
+
+type fakeActiveStore struct {
+	counts map[int64]int64
+	hang   bool
+	err    error
+}
+
+func (f *fakeActiveStore) CountActiveTasks(ctx context.Context) (map[int64]int64, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if f.hang {
+		// Зависшее хранилище отвечает только по таймауту контекста
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return f.counts, nil
+}
+
+func TestActiveTimersCollector(t *testing.T) {
+	store := &fakeActiveStore{counts: map[int64]int64{-100: 2, -200: 1}}
+
+	// Без явного разрешения публикуется только сумма, идентификаторы чатов скрыты
+	expected := `
+# HELP timeguard_active_timers Running and paused timers of all chats.
+# TYPE timeguard_active_timers gauge
+timeguard_active_timers 3
+`
+	if err := testutil.CollectAndCompare(newActiveTimersCollector(store, ChatLabels{}), strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	// Разрешенные чаты получают метку, чат без таймеров показывает ноль
+	labels := ChatLabels{Chats: map[int64]bool{-100: true, -300: true}}
+	expected = `
+# HELP timeguard_active_timers Running and paused timers of all chats.
+# TYPE timeguard_active_timers gauge
+timeguard_active_timers 3
+# HELP timeguard_chat_active_timers Running and paused timers of labelled chats, by chat.
+# TYPE timeguard_chat_active_timers gauge
+timeguard_chat_active_timers{chat_id="-100"} 2
+timeguard_chat_active_timers{chat_id="-300"} 0
+`
+	if err := testutil.CollectAndCompare(newActiveTimersCollector(store, labels), strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	expected = `
+# HELP timeguard_chat_active_timers Running and paused timers of labelled chats, by chat.
+# TYPE timeguard_chat_active_timers gauge
+timeguard_chat_active_timers{chat_id="-100"} 2
+timeguard_chat_active_timers{chat_id="-200"} 1
+`
+	collector := newActiveTimersCollector(store, ChatLabels{All: true})
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "timeguard_chat_active_timers"); err != nil {
+		t.Error(err)
+	}
+
+	// Ошибка хранилища видна при сборе, а не скрыта нулями
+	store.err = errors.New("connection refused")
+	if _, err := testutil.CollectAndLint(newActiveTimersCollector(store, ChatLabels{})); err == nil {
+		t.Error("Expected collection to fail when storage fails")
+	}
+
+	// Сбор не висит дольше таймаута
+	store.err, store.hang = nil, true
+	collector = newActiveTimersCollector(store, ChatLabels{})
+	collector.timeout = 10 * time.Millisecond
+
+	if _, err := testutil.CollectAndLint(collector); err == nil {
+		t.Error("Expected collection to fail when storage doesn't answer in time")
+	}
+}
+
+func TestParseChatLabels(t *testing.T) {
+	labels, err := ParseChatLabels("all")
+	if err != nil || !labels.All {
+		t.Errorf("Expected all chats to be labelled, got %+v, %v", labels, err)
+	}
+
+	labels, err = ParseChatLabels("-100, -200")
+	if err != nil || labels.All || !labels.Chats[-100] || !labels.Chats[-200] || len(labels.Chats) != 2 {
+		t.Errorf("Expected chats -100 and -200 to be labelled, got %+v, %v", labels, err)
+	}
+
+	if _, err := ParseChatLabels("-100,general"); err == nil {
+		t.Error("Expected an error for a chat name")
+	}
+}
+
+func TestRedisHook(t *testing.T) {
+	miniRedis := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: miniRedis.Addr()})
+	client.AddHook(RedisHook{})
+
+	defer func() {
+		if err := client.Close(); err != nil {
+			t.Errorf("Failed to close client: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	getErrors := testutil.ToFloat64(storageErrors.WithLabelValues("get"))
+	hsetErrors := testutil.ToFloat64(storageErrors.WithLabelValues("hset"))
+
+	// Отсутствующий ключ - не ошибка
+	if err := client.Get(ctx, "missing").Err(); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Expected redis.Nil, got %v", err)
+	}
+
+	if err := client.Set(ctx, "string", "value", 0).Err(); err != nil {
+		t.Fatalf("Failed to set key: %v", err)
+	}
+
+	// HSET по строковому ключу - ошибка WRONGTYPE
+	if err := client.HSet(ctx, "string", "field", "value").Err(); err == nil {
+		t.Fatal("Expected WRONGTYPE error")
+	}
+
+	if got := testutil.ToFloat64(storageErrors.WithLabelValues("get")); got != getErrors {
+		t.Errorf("Expected missing key not to count as error, got %v errors", got-getErrors)
+	}
+
+	if got := testutil.ToFloat64(storageErrors.WithLabelValues("hset")); got != hsetErrors+1 {
+		t.Errorf("Expected one hset error, got %v", got-hsetErrors)
+	}
+
+	if testutil.CollectAndCount(storageDuration) == 0 {
+		t.Error("Expected storage latency to be recorded")
+	}
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	handler := InstrumentHandler(mux)
+
+	for _, target := range []string{"/api/v1/tasks/a1", "/api/v1/tasks/b2", "/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	// Параметры пути не создают новых серий
+	if got := testutil.CollectAndCount(httpDuration); got != 2 {
+		t.Errorf("Expected 2 series, got %d", got)
+	}
+
+	expected := []string{`route="/api/v1/tasks/{id}"`, `route="unmatched"`, `code="404"`}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, label := range expected {
+		if !strings.Contains(rec.Body.String(), label) {
+			t.Errorf("Expected metrics to contain %s", label)
+		}
+	}
+}
+
+func TestTelegramClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := TelegramClient()
+	before := testutil.ToFloat64(telegramFailures.WithLabelValues("sendMessage"))
+
+	for _, method := range []string{"sendMessage", "getMe"} {
+		resp, err := client.Post(server.URL+"/botTOKEN/"+method, "application/json", nil)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if err := resp.Body.Close(); err != nil {
+			t.Fatalf("Failed to close body: %v", err)
+		}
+	}
+
+	if got := testutil.ToFloat64(telegramFailures.WithLabelValues("sendMessage")); got != before+1 {
+		t.Errorf("Expected one sendMessage failure, got %v", got-before)
+	}
+
+	if got := testutil.ToFloat64(telegramFailures.WithLabelValues("getMe")); got != 0 {
+		t.Errorf("Expected no getMe failures, got %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Context key of the start time of a Redis command
+type startKey struct{}
+
+// Records latency and errors of Redis commands
+// A missing key (redis.Nil) and a failed optimistic transaction are normal results, not errors
+type RedisHook struct{}
+
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	observeRedis(ctx, cmd.Name(), cmd.Err())
+	return nil
+}
+
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+// Records a pipeline as one operation, it fails if any of its commands fails
+func (RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && !isRedisResult(cmd.Err()) {
+			err = cmd.Err()
+			break
+		}
+	}
+
+	observeRedis(ctx, "pipeline", err)
+
+	return nil
+}
+
+// Records the latency and the error of a Redis operation
+func observeRedis(ctx context.Context, operation string, err error) {
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		storageDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}
+
+	if err != nil && !isRedisResult(err) {
+		storageErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// Reports whether the error is an expected result rather than a failure
+func isRedisResult(err error) bool {
+	return errors.Is(err, redis.Nil) || errors.Is(err, redis.TxFailedErr)
+}
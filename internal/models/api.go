@@ -13,11 +13,15 @@
 // limitations under the License.
 package models
 
+import "time"
+
 // Represents the response for task status
 type TaskStatusResponse struct {
 	Status     string `json:"status"`                // "free", "busy", "locked"
 	LockReason string `json:"lock_reason,omitempty"` // Reason for lock if status is "locked"
 	TaskName   string `json:"task_name"`             // Name of the task
+	HolderID   int64  `json:"holder_id,omitempty"`   // ID of the user holding the task if status is "busy"
+	Holder     string `json:"holder,omitempty"`      // Name of the user holding the task if status is "busy"
 }
 
 // Represents a task in the list response
@@ -26,12 +30,176 @@ type TaskInfo struct {
 	Status      string `json:"status"`                // "free", "busy", "locked"
 	LockReason  string `json:"lock_reason,omitempty"` // Only present when status is "locked"
 	Description string `json:"description"`
+	HolderID    int64  `json:"holder_id,omitempty"` // Only present when status is "busy"
+	Holder      string `json:"holder,omitempty"`    // Only present when status is "busy"
 }
 
 // Represents the response for task list
 type TaskListResponse map[string]TaskInfo
 
+// Represents a page of tasks in the v1 API
+type TaskPageResponse struct {
+	Tasks      []TaskState `json:"tasks"`                 // Tasks of the page
+	Total      int         `json:"total"`                 // Number of tasks matching the filters
+	Limit      int         `json:"limit"`                 // Maximum number of tasks per page
+	Offset     int         `json:"offset"`                // Number of matching tasks before the page
+	NextOffset int         `json:"next_offset,omitempty"` // Offset of the next page, absent on the last page
+}
+
+// Represents the notes of the running session of a task
+type TaskNotesResponse struct {
+	TaskID string `json:"task_id"` // ID of the task
+	Notes  []Note `json:"notes"`   // Notes by time added, empty if the task is free
+}
+
+// Represents a request to start a timer on a task
+type StartTimerRequest struct {
+	TaskID   string `json:"task_id"`  // ID of the task to start
+	User     string `json:"user"`     // Label of whoever takes the task, e.g. "ci-staging"
+	Duration int    `json:"duration"` // Duration in minutes
+}
+
+// Represents a request to extend a running or paused timer
+type ExtendTimerRequest struct {
+	TaskID  string `json:"task_id"` // ID of the task
+	Minutes int    `json:"minutes"` // Minutes to add
+}
+
+// Represents a request to release a task before its timer is up
+type ReleaseTimerRequest struct {
+	TaskID  string `json:"task_id"`           // ID of the task
+	Outcome string `json:"outcome,omitempty"` // "done" (default) or "cancel"
+}
+
+// Represents a request to lock a task
+type LockTaskRequest struct {
+	TaskID string `json:"task_id"`          // ID of the task
+	Reason string `json:"reason,omitempty"` // Optional reason shown to users
+}
+
+// Represents a request to unlock a task
+type UnlockTaskRequest struct {
+	TaskID string `json:"task_id"` // ID of the task
+}
+
+// Represents a running or paused timer
+type TimerResponse struct {
+	TaskID    string    `json:"task_id"`          // ID of the task
+	TaskName  string    `json:"task_name"`        // Name of the task
+	HolderID  int64     `json:"holder_id"`        // ID of the user holding the task, negative for API labels
+	Holder    string    `json:"holder,omitempty"` // Name of the user holding the task
+	Duration  int       `json:"duration"`         // Total duration in minutes
+	StartTime time.Time `json:"start_time"`       // When the timer started
+	EndTime   time.Time `json:"end_time"`         // When the timer is scheduled to end
+	Remaining int64     `json:"remaining"`        // Seconds left
+	Paused    bool      `json:"paused"`           // Whether the timer is paused
+	Leased    bool      `json:"leased"`           // Whether the task is held by a CI lease that ends without heartbeats
+}
+
+// Represents a request to take a task with a lease
+type AcquireLeaseRequest struct {
+	TaskID string `json:"task_id"`       // ID of the task
+	Holder string `json:"holder"`        // Who takes the task, shown in the chat as "CI: {holder}"
+	TTL    int    `json:"ttl,omitempty"` // Seconds the lease lives without a heartbeat, 300 by default
+}
+
+// Represents a heartbeat or release of a lease
+type LeaseTokenRequest struct {
+	TaskID string `json:"task_id"` // ID of the task
+	Token  string `json:"token"`   // Token returned by the acquire call
+}
+
+// Represents a lease held on a task
+type LeaseResponse struct {
+	TaskID    string    `json:"task_id"`         // ID of the task
+	TaskName  string    `json:"task_name"`       // Name of the task
+	Holder    string    `json:"holder"`          // Holder as shown in the chat
+	Token     string    `json:"token,omitempty"` // Lease token, only returned by the acquire call
+	TTL       int       `json:"ttl"`             // Seconds the lease lives after each heartbeat
+	ExpiresAt time.Time `json:"expires_at"`      // When the lease expires without another heartbeat
+}
+
+// Represents a request to register a webhook
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`              // Receiver URL, http or https
+	Events []string `json:"events,omitempty"` // Event types to send, all if empty
+}
+
+// Represents a registered webhook
+type WebhookResponse struct {
+	ID        string    `json:"id"`               // Webhook ID
+	URL       string    `json:"url"`              // Receiver URL
+	Events    []string  `json:"events,omitempty"` // Event types sent, all if empty
+	Secret    string    `json:"secret,omitempty"` // Signing secret, only returned on creation
+	CreatedAt time.Time `json:"created_at"`       // When the webhook was added
+}
+
+// Represents a request to create a task
+type CreateTaskRequest struct {
+	Name        string `json:"name"`                  // Name of the task, same rules as /add
+	Description string `json:"description,omitempty"` // Optional description
+}
+
+// Represents a request to rename a task or change its description
+type UpdateTaskRequest struct {
+	TaskID      string  `json:"task_id"`               // ID of the task
+	Name        *string `json:"name,omitempty"`        // New name, unchanged if omitted
+	Description *string `json:"description,omitempty"` // New description, unchanged if omitted
+}
+
+// Represents a created, updated or deleted task
+type TaskResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Represents the result of a health or readiness probe
+type HealthResponse struct {
+	Status string            `json:"status"`           // "ok", "ready" or "not_ready"
+	Checks map[string]string `json:"checks,omitempty"` // Result of every readiness check: "ok" or what is wrong
+}
+
+// Machine-readable error codes of ErrorResponse
+const (
+	ErrorCodeBadRequest        = "bad_request"
+	ErrorCodeUnauthorized      = "unauthorized"
+	ErrorCodeInvalidAPIKey     = "invalid_api_key"
+	ErrorCodeForbidden         = "forbidden"
+	ErrorCodeMissingScope      = "missing_scope"
+	ErrorCodeNotFound          = "not_found"
+	ErrorCodeChatNotFound      = "chat_not_found"
+	ErrorCodeTaskNotFound      = "task_not_found"
+	ErrorCodeMethodNotAllowed  = "method_not_allowed"
+	ErrorCodeConflict          = "conflict"
+	ErrorCodeInternal          = "internal_error"
+	ErrorCodeInvalidTaskName   = "invalid_task_name"
+	ErrorCodeInvalidDuration   = "invalid_duration"
+	ErrorCodeTaskLimit         = "task_limit_reached"
+	ErrorCodeTaskNameTaken     = "task_name_taken"
+	ErrorCodeTaskInUse         = "task_in_use"
+	ErrorCodeTaskLocked        = "task_locked"
+	ErrorCodeTaskBusy          = "task_busy"
+	ErrorCodeTaskConflict      = "task_conflict"
+	ErrorCodeAlreadyHolding    = "already_holding"
+	ErrorCodeTooManyTasks      = "too_many_active_tasks"
+	ErrorCodeTaskNotActive     = "task_not_active"
+	ErrorCodeTimerPaused       = "timer_paused"
+	ErrorCodeTimerNotPaused    = "timer_not_paused"
+	ErrorCodeTaskAlreadyLocked = "task_already_locked"
+	ErrorCodeTaskNotLocked     = "task_not_locked"
+	ErrorCodeInvalidTTL        = "invalid_ttl"
+	ErrorCodeInvalidHolder     = "invalid_holder"
+	ErrorCodeTaskLeased        = "task_leased"
+	ErrorCodeLeaseLost         = "lease_lost"
+	ErrorCodeInvalidWebhook    = "invalid_webhook"
+	ErrorCodeWebhookLimit      = "webhook_limit_reached"
+	ErrorCodeWebhookNotFound   = "webhook_not_found"
+	ErrorCodeRateLimited       = "rate_limited"
+)
+
 // Represents an error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error string `json:"error"` // Human-readable message
+	Code  string `json:"code"`  // Machine-readable code, one of the ErrorCode constants
 }
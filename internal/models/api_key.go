@@ -0,0 +1,76 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import (
+	"path"
+	"slices"
+	"time"
+)
+
+// API key scopes
+const (
+	ScopeTasksRead   = "tasks:read"      // Read task list and statuses
+	ScopeTasksWrite  = "tasks:write"     // Create, edit, lock and delete tasks
+	ScopeTimersWrite = "timers:write"    // Start, extend and release task timers
+	ScopeHistoryRead = "history:read"    // Read the notes of task sessions
+	ScopeWebhooks    = "webhooks:manage" // Manage webhooks and inspect their deliveries
+)
+
+// All known API key scopes
+var APIKeyScopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeTimersWrite, ScopeHistoryRead, ScopeWebhooks}
+
+// Reports whether the scope is a known API key scope
+func IsValidScope(scope string) bool {
+	return slices.Contains(APIKeyScopes, scope)
+}
+
+// Represents an API key issued for a chat
+// Only the hash of the key is stored, the key itself is shown once on creation
+type APIKey struct {
+	ID           string    `json:"id"`                      // Public key identifier, part of the key
+	ChatID       int64     `json:"chat_id"`                 // Chat the key gives access to
+	Name         string    `json:"name"`                    // Optional human-readable name
+	Hash         string    `json:"hash"`                    // SHA-256 of the full key
+	Scopes       []string  `json:"scopes"`                  // Granted scopes
+	TaskPatterns []string  `json:"task_patterns,omitempty"` // Glob patterns of task IDs or names the key may access, empty allows all
+	Tier         string    `json:"tier,omitempty"`          // Rate limit tier, empty for the default one
+	CreatedBy    int64     `json:"created_by"`              // User who created the key
+	CreatedAt    time.Time `json:"created_at"`              // When the key was created
+	LastUsedAt   time.Time `json:"last_used_at"`            // When the key was last used, zero if never
+}
+
+// Reports whether the key grants the scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// Reports whether the key may access the task
+func (k *APIKey) AllowsTask(task *Task) bool {
+	if len(k.TaskPatterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range k.TaskPatterns {
+		if matched, _ := path.Match(pattern, task.ID); matched {
+			return true
+		}
+
+		if matched, _ := path.Match(pattern, task.Name); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import "testing"
+
+func TestAPIKeyHasScope(t *testing.T) {
+	key := &APIKey{Scopes: []string{ScopeTasksRead, ScopeTimersWrite}}
+
+	if !key.HasScope(ScopeTimersWrite) {
+		t.Error("Expected key to have timers:write scope")
+	}
+
+	if key.HasScope(ScopeTasksWrite) {
+		t.Error("Expected key not to have tasks:write scope")
+	}
+}
+
+func TestAPIKeyAllowsTask(t *testing.T) {
+	staging := &Task{ID: "a1b2", Name: "staging-db"}
+	prod := &Task{ID: "c3d4", Name: "prod-db"}
+
+	t.Run("No patterns allow all tasks", func(t *testing.T) {
+		key := &APIKey{}
+
+		if !key.AllowsTask(staging) || !key.AllowsTask(prod) {
+			t.Error("Expected unrestricted key to allow all tasks")
+		}
+	})
+
+	t.Run("Pattern matches name", func(t *testing.T) {
+		key := &APIKey{TaskPatterns: []string{"staging-*"}}
+
+		if !key.AllowsTask(staging) {
+			t.Error("Expected staging-db to be allowed")
+		}
+
+		if key.AllowsTask(prod) {
+			t.Error("Expected prod-db to be denied")
+		}
+	})
+
+	t.Run("Pattern matches ID", func(t *testing.T) {
+		key := &APIKey{TaskPatterns: []string{"c3d4"}}
+
+		if !key.AllowsTask(prod) {
+			t.Error("Expected task c3d4 to be allowed")
+		}
+	})
+}
+
+func TestIsValidScope(t *testing.T) {
+	for _, scope := range APIKeyScopes {
+		if !IsValidScope(scope) {
+			t.Errorf("Expected %s to be valid", scope)
+		}
+	}
+
+	if IsValidScope("tasks:admin") {
+		t.Error("Expected tasks:admin to be invalid")
+	}
+}
@@ -153,6 +153,7 @@ func TestAPIStructures(t *testing.T) {
 	t.Run("ErrorResponse", func(t *testing.T) {
 		errResp := ErrorResponse{
 			Error: "Something went wrong",
+			Code:  ErrorCodeInternal,
 		}
 
 		// Проверяем маршализацию в JSON
@@ -173,5 +174,9 @@ func TestAPIStructures(t *testing.T) {
 		if errResp.Error != unmarshaled.Error {
 			t.Errorf("Error message mismatch: expected %s, got %s", errResp.Error, unmarshaled.Error)
 		}
+
+		if errResp.Code != unmarshaled.Code {
+			t.Errorf("Error code mismatch: expected %s, got %s", errResp.Code, unmarshaled.Code)
+		}
 	})
 }
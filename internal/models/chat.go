@@ -0,0 +1,32 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import "time"
+
+// Platforms a chat namespace can belong to besides Telegram
+const (
+	PlatformSlack   = "slack"
+	PlatformDiscord = "discord"
+	PlatformMatrix  = "matrix"
+)
+
+// Ties a chat namespace to a channel of another messenger, so changes made
+// elsewhere can be announced there
+type ChatLink struct {
+	ChatID     int64     `json:"chat_id"`     // Chat namespace of tasks and timers
+	Platform   string    `json:"platform"`    // Messenger of the channel, e.g. PlatformSlack
+	ExternalID string    `json:"external_id"` // Channel ID on the platform
+	UpdatedAt  time.Time `json:"updated_at"`  // When the channel was last seen
+}
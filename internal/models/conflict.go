@@ -0,0 +1,21 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+// Represents a mutual-exclusion group: only one task of the group can be active at a time
+type ConflictGroup struct {
+	Name    string   `json:"name"`     // Group name, unique within a chat
+	ChatID  int64    `json:"chat_id"`  // Telegram chat ID
+	TaskIDs []string `json:"task_ids"` // IDs of the tasks in the group
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import "time"
+
+// Notification settings of a user, shared by all their chats
+type NotifySettings struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email,omitempty"` // Confirmed address notifications are sent to
+
+	// Address waiting for confirmation and the hash of the code sent to it
+	PendingEmail  string    `json:"pending_email,omitempty"`
+	CodeHash      string    `json:"code_hash,omitempty"`
+	CodeExpiresAt time.Time `json:"code_expires_at,omitempty"`
+	CodeAttempts  int       `json:"code_attempts,omitempty"` // Wrong codes entered so far
+
+	// Whether timer notifications also go by private message
+	DM bool `json:"dm,omitempty"`
+	// Private chat of the user with the bot, zero until they start one or after they block the bot
+	DMChatID int64 `json:"dm_chat_id,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
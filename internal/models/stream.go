@@ -0,0 +1,91 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import "time"
+
+// Names of the events sent on the event stream
+const (
+	StreamEventSnapshot = "snapshot" // Full state of all tasks, sent first
+	StreamEventTask     = "task"     // State of one task after a change
+	StreamEventTick     = "tick"     // Remaining time of running timers
+)
+
+// Number of users that can hold a task at once
+const TaskCapacity = 1
+
+// Represents the state of a task in event streams
+type TaskState struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`                // "free", "busy", "locked" or "deleted"
+	LockReason  string         `json:"lock_reason,omitempty"` // Only present when status is "locked"
+	Timer       *TimerResponse `json:"timer,omitempty"`       // Only present when status is "busy"
+	Capacity    int            `json:"capacity"`              // Users that can hold the task at once, always 1
+	QueueLength int64          `json:"queue_length"`          // Users waiting for the task to get free (/notify watch)
+}
+
+// Represents the full state sent when a stream opens
+type SnapshotEvent struct {
+	Time  time.Time   `json:"time"`  // When the snapshot was taken
+	Tasks []TaskState `json:"tasks"` // All tasks of the chat visible to the API key, by name
+}
+
+// Represents a change of one task
+type TaskEvent struct {
+	Event   string    `json:"event"`             // What happened, e.g. "timer.started"
+	Source  string    `json:"source"`            // Where the change came from: "telegram", "api" or "timer"
+	Time    time.Time `json:"time"`              // When it happened
+	Task    TaskState `json:"task"`              // Task after the change
+	Minutes int       `json:"minutes,omitempty"` // Extension in minutes for timer.extended
+}
+
+// Represents the periodic update of running timers
+type TickEvent struct {
+	Time   time.Time       `json:"time"`   // When the update was made
+	Timers []TimerResponse `json:"timers"` // Running and paused timers visible to the API key
+}
+
+// Message types of the WebSocket API
+const (
+	SocketSubscribe   = "subscribe"   // Start receiving changes, answered with a snapshot
+	SocketUnsubscribe = "unsubscribe" // Stop receiving changes
+	SocketStart       = "start"       // Start a timer, like POST /api/timer/start
+	SocketExtend      = "extend"      // Extend a timer, like POST /api/timer/extend
+	SocketRelease     = "release"     // Release a task, like POST /api/timer/release
+	SocketResult      = "result"      // Successful reply to a request
+	SocketError       = "error"       // Failed reply to a request
+)
+
+// Represents a message sent by a WebSocket client
+// Fields other than ID and Type are used by the request types that need them
+type SocketRequest struct {
+	ID       string   `json:"id,omitempty"`       // Chosen by the client, copied to the reply
+	Type     string   `json:"type"`               // One of the request types: subscribe, unsubscribe, start, extend or release
+	TaskIDs  []string `json:"task_ids,omitempty"` // Tasks to follow on subscribe, all if empty
+	TaskID   string   `json:"task_id,omitempty"`  // Task of start, extend and release
+	User     string   `json:"user,omitempty"`     // Label of whoever takes the task on start
+	Duration int      `json:"duration,omitempty"` // Minutes on start
+	Minutes  int      `json:"minutes,omitempty"`  // Minutes to add on extend
+	Outcome  string   `json:"outcome,omitempty"`  // "done" (default) or "cancel" on release
+}
+
+// Represents a message sent to a WebSocket client
+type SocketMessage struct {
+	ID    string         `json:"id,omitempty"`                        // ID of the answered request, absent on pushed events
+	Type  string         `json:"type"`                                // "result", "error" or a stream event: "task" or "tick"
+	Data  any            `json:"data,omitempty" swaggertype:"object"` // Reply or event payload
+	Error *ErrorResponse `json:"error,omitempty"`                     // Present when type is "error"
+}
@@ -18,6 +18,9 @@ import (
 	"time"
 )
 
+// How long a timer may stay paused, then it expires and frees the task
+const MaxPause = time.Hour
+
 // Represents a task in the system
 type Task struct {
 	ID          string `json:"id"`          // Short unique identifier for the task
@@ -30,6 +33,7 @@ type Task struct {
 	StartTime time.Time `json:"start_time"` // When the task was started
 	EndTime   time.Time `json:"end_time"`   // When the task is scheduled to end
 	Duration  int       `json:"duration"`   // Duration in minutes
+	PausedAt  time.Time `json:"paused_at"`  // When the timer was paused, zero if running
 
 	IsLocked   bool   `json:"is_locked"`   // Whether the task is locked
 	LockReason string `json:"lock_reason"` // Reason for locking the task
@@ -48,6 +52,24 @@ type ActiveTask struct {
 	Duration      int       `json:"duration"`        // Duration in minutes
 	MessageID     int       `json:"message_id"`      // ID of the message in Telegram that started the task
 	BotResponseID int       `json:"bot_response_id"` // Bot's response message ID
+	PausedAt      time.Time `json:"paused_at"`       // When the timer was paused, zero if running
+	Notes         []Note    `json:"notes,omitempty"` // Notes attached to the session by replies
+	Lease         *Lease    `json:"lease,omitempty"` // Set when the task is held by a lease instead of a timer
+}
+
+// Represents a lease of a task held by an automated client such as a CI job
+// The lease ends at EndTime of its active task unless renewed by a heartbeat
+type Lease struct {
+	TokenHash string    `json:"token_hash"` // SHA-256 of the lease token
+	TTL       int       `json:"ttl"`        // Seconds the lease lives after each heartbeat
+	RenewedAt time.Time `json:"renewed_at"` // Time of the last heartbeat
+}
+
+// Represents a note attached to a timer session
+type Note struct {
+	UserID    int64     `json:"user_id"`    // Author of the note
+	Text      string    `json:"text"`       // Note text
+	CreatedAt time.Time `json:"created_at"` // When the note was added
 }
 
 // Marshal converts the task to JSON
@@ -65,10 +87,12 @@ func UnmarshalTask(data []byte) (*Task, error) {
 	return &task, nil
 }
 
-func calcTimeRemaining(startTime time.Time, durationMin int) int64 {
-	endTime := startTime.Add(time.Duration(durationMin) * time.Minute)
+func calcTimeRemaining(startTime time.Time, durationMin int, now time.Time) int64 {
+	return calcTimeUntil(startTime.Add(time.Duration(durationMin)*time.Minute), now)
+}
 
-	remaining := endTime.Unix() - time.Now().Unix()
+func calcTimeUntil(endTime time.Time, now time.Time) int64 {
+	remaining := endTime.Unix() - now.Unix()
 	if remaining < 0 {
 		return 0
 	}
@@ -78,10 +102,91 @@ func calcTimeRemaining(startTime time.Time, durationMin int) int64 {
 
 // Returns the time remaining in seconds
 func (t *Task) TimeRemaining() int64 {
-	return calcTimeRemaining(t.StartTime, t.Duration)
+	return t.RemainingAt(time.Now())
 }
 
-// Returns the time remaining in seconds
+// Returns the time remaining in seconds at the given moment
+// A paused timer doesn't count down
+func (t *Task) RemainingAt(now time.Time) int64 {
+	if !t.PausedAt.IsZero() {
+		now = t.PausedAt
+	}
+
+	// The end time follows extensions and lease heartbeats
+	if !t.EndTime.IsZero() {
+		return calcTimeUntil(t.EndTime, now)
+	}
+
+	return calcTimeRemaining(t.StartTime, t.Duration, now)
+}
+
+// Returns the time remaining in seconds. A paused timer doesn't count down
 func (t *ActiveTask) TimeRemaining() int64 {
-	return calcTimeRemaining(t.StartTime, t.Duration)
+	return t.RemainingAt(time.Now())
+}
+
+// Returns the time remaining in seconds at the given moment
+func (t *ActiveTask) RemainingAt(now time.Time) int64 {
+	// Heartbeats move the end of a lease, its duration is only nominal
+	if t.IsLeased() {
+		return calcTimeUntil(t.EndTime, now)
+	}
+
+	if t.IsPaused() {
+		return calcTimeRemaining(t.StartTime, t.Duration, t.PausedAt)
+	}
+
+	return calcTimeRemaining(t.StartTime, t.Duration, now)
+}
+
+// Reports whether the task is held by a lease
+func (t *ActiveTask) IsLeased() bool {
+	return t.Lease != nil
+}
+
+// Moves the end of a lease TTL seconds past the heartbeat
+func (t *ActiveTask) Renew(at time.Time) {
+	if !t.IsLeased() {
+		return
+	}
+
+	t.Lease.RenewedAt = at
+	t.EndTime = at.Add(time.Duration(t.Lease.TTL) * time.Second)
+}
+
+// Reports whether the timer is paused
+func (t *ActiveTask) IsPaused() bool {
+	return !t.PausedAt.IsZero()
+}
+
+// Returns when a paused timer expires unless resumed
+func (t *ActiveTask) PauseEndsAt() time.Time {
+	return t.PausedAt.Add(MaxPause)
+}
+
+// Extends the timer by the given number of minutes
+func (t *ActiveTask) Extend(minutes int) {
+	t.Duration += minutes
+	t.EndTime = t.StartTime.Add(time.Duration(t.Duration) * time.Minute)
+}
+
+// Pauses the timer at the given moment
+func (t *ActiveTask) Pause(at time.Time) {
+	if t.IsPaused() {
+		return
+	}
+
+	t.PausedAt = at
+}
+
+// Resumes a paused timer, shifting its start and end by the time spent on pause
+func (t *ActiveTask) Resume(at time.Time) {
+	if !t.IsPaused() {
+		return
+	}
+
+	pause := at.Sub(t.PausedAt)
+	t.StartTime = t.StartTime.Add(pause)
+	t.EndTime = t.EndTime.Add(pause)
+	t.PausedAt = time.Time{}
 }
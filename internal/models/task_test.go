@@ -106,117 +106,84 @@ func TestUnmarshalTaskInvalid(t *testing.T) {
 }
 
 func TestTaskTimeRemaining(t *testing.T) {
-	// Тест для метода TimeRemaining структуры Task
-	t.Run("Task with time remaining", func(t *testing.T) {
-		now := time.Now()
-		task := &Task{
-			StartTime: now,
-			Duration:  30,
-		}
-
-		remaining := task.TimeRemaining()
-		// Проверяем, что оставшееся время близко к 30 минутам в секундах (1800)
-		if remaining <= 0 || remaining > 1800 {
-			t.Errorf("Expected remaining time to be between 0 and 1800 seconds, got %d", remaining)
-		}
-	})
-
-	t.Run("Task with time expired", func(t *testing.T) {
-		pastTime := time.Now().Add(-1 * time.Hour) // 1 час назад
-		task := &Task{
-			StartTime: pastTime,
-			Duration:  30,
-		}
-
-		remaining := task.TimeRemaining()
-		if remaining != 0 {
-			t.Errorf("Expected 0 for expired task, got %d", remaining)
-		}
-	})
-
-	// Тест граничных случаев
-	t.Run("Task with exactly expired time", func(t *testing.T) {
-		exactTime := time.Now().Add(-30 * time.Minute) // Ровно 30 минут назад
-		task := &Task{
-			StartTime: exactTime,
-			Duration:  30,
-		}
-
-		remaining := task.TimeRemaining()
-		if remaining != 0 {
-			t.Errorf("Expected 0 for task expired exactly now, got %d", remaining)
-		}
-	})
+	// Тест для метода RemainingAt структуры Task, время задается явно
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		task *Task
+		now  time.Time
+		want int64
+	}{
+		{"Task just started", &Task{StartTime: start, Duration: 30}, start, 1800},
+		{"Task with time remaining", &Task{StartTime: start, Duration: 30}, start.Add(10 * time.Minute), 1200},
+		{"Task with very short time remaining", &Task{StartTime: start, Duration: 30}, start.Add(29*time.Minute + 59*time.Second), 1},
+		{"Task with exactly expired time", &Task{StartTime: start, Duration: 30}, start.Add(30 * time.Minute), 0},
+		{"Task with time expired", &Task{StartTime: start, Duration: 30}, start.Add(time.Hour), 0},
+		// Время окончания учитывает продления и heartbeat
+		{"Task with end time", &Task{StartTime: start, Duration: 30, EndTime: start.Add(45 * time.Minute)}, start.Add(40 * time.Minute), 300},
+		// Пауза останавливает отсчет
+		{
+			"Paused task",
+			&Task{StartTime: start, Duration: 30, EndTime: start.Add(30 * time.Minute), PausedAt: start.Add(5 * time.Minute)},
+			start.Add(time.Hour),
+			1500,
+		},
+	}
 
-	t.Run("Task with very short time remaining", func(t *testing.T) {
-		almostExpiredTime := time.Now().Add(-29*time.Minute - 59*time.Second) // Почти 30 минут назад
-		task := &Task{
-			StartTime: almostExpiredTime,
-			Duration:  30,
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.RemainingAt(tt.now); got != tt.want {
+				t.Errorf("Expected %d seconds remaining, got %d", tt.want, got)
+			}
+		})
+	}
 
-		remaining := task.TimeRemaining()
-		if remaining > 1 {
-			t.Errorf("Expected remaining time to be <=1 second, got %d", remaining)
-		}
-	})
+	// TimeRemaining считает от текущего времени
+	task := &Task{StartTime: time.Now().Add(-time.Hour), Duration: 30}
+	if remaining := task.TimeRemaining(); remaining != 0 {
+		t.Errorf("Expected 0 for expired task, got %d", remaining)
+	}
 }
 
 func TestActiveTaskTimeRemaining(t *testing.T) {
-	// Тест для метода TimeRemaining структуры ActiveTask
-	t.Run("ActiveTask with time remaining", func(t *testing.T) {
-		now := time.Now()
-		activeTask := &ActiveTask{
-			StartTime: now,
-			Duration:  30,
-		}
-
-		remaining := activeTask.TimeRemaining()
-		// Проверяем, что оставшееся время близко к 30 минутам в секундах (1800)
-		if remaining <= 0 || remaining > 1800 {
-			t.Errorf("Expected remaining time to be between 0 and 1800 seconds, got %d", remaining)
-		}
-	})
-
-	t.Run("ActiveTask with time expired", func(t *testing.T) {
-		pastTime := time.Now().Add(-1 * time.Hour) // 1 час назад
-		activeTask := &ActiveTask{
-			StartTime: pastTime,
-			Duration:  30,
-		}
-
-		remaining := activeTask.TimeRemaining()
-		if remaining != 0 {
-			t.Errorf("Expected 0 for expired task, got %d", remaining)
-		}
-	})
-
-	// Тест граничных случаев
-	t.Run("ActiveTask with exactly expired time", func(t *testing.T) {
-		exactTime := time.Now().Add(-30 * time.Minute) // Ровно 30 минут назад
-		activeTask := &ActiveTask{
-			StartTime: exactTime,
-			Duration:  30,
-		}
-
-		remaining := activeTask.TimeRemaining()
-		if remaining != 0 {
-			t.Errorf("Expected 0 for task expired exactly now, got %d", remaining)
-		}
-	})
+	// Тест для метода RemainingAt структуры ActiveTask, время задается явно
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		activeTask *ActiveTask
+		now        time.Time
+		want       int64
+	}{
+		{"ActiveTask with time remaining", &ActiveTask{StartTime: start, Duration: 30}, start.Add(10 * time.Minute), 1200},
+		{"ActiveTask with very short time remaining", &ActiveTask{StartTime: start, Duration: 30}, start.Add(29*time.Minute + 59*time.Second), 1},
+		{"ActiveTask with exactly expired time", &ActiveTask{StartTime: start, Duration: 30}, start.Add(30 * time.Minute), 0},
+		{"ActiveTask with time expired", &ActiveTask{StartTime: start, Duration: 30}, start.Add(time.Hour), 0},
+		// Пауза останавливает отсчет
+		{"Paused ActiveTask", &ActiveTask{StartTime: start, Duration: 30, PausedAt: start.Add(5 * time.Minute)}, start.Add(time.Hour), 1500},
+		// Аренда живет до EndTime независимо от длительности
+		{
+			"Leased ActiveTask",
+			&ActiveTask{StartTime: start, Duration: 1, EndTime: start.Add(10 * time.Minute), Lease: &Lease{TTL: 600}},
+			start.Add(4 * time.Minute),
+			360,
+		},
+	}
 
-	t.Run("ActiveTask with very short time remaining", func(t *testing.T) {
-		almostExpiredTime := time.Now().Add(-29*time.Minute - 59*time.Second) // Почти 30 минут назад
-		activeTask := &ActiveTask{
-			StartTime: almostExpiredTime,
-			Duration:  30,
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.activeTask.RemainingAt(tt.now); got != tt.want {
+				t.Errorf("Expected %d seconds remaining, got %d", tt.want, got)
+			}
+		})
+	}
 
-		remaining := activeTask.TimeRemaining()
-		if remaining > 1 {
-			t.Errorf("Expected remaining time to be ≤1 second, got %d", remaining)
-		}
-	})
+	// TimeRemaining считает от текущего времени
+	activeTask := &ActiveTask{StartTime: time.Now().Add(-time.Hour), Duration: 30}
+	if remaining := activeTask.TimeRemaining(); remaining != 0 {
+		t.Errorf("Expected 0 for expired task, got %d", remaining)
+	}
 }
 
 func TestTaskJSON(t *testing.T) {
@@ -310,3 +277,97 @@ func TestActiveTaskJSON(t *testing.T) {
 		t.Errorf("BotResponseID mismatch: expected %d, got %d", activeTask.BotResponseID, unmarshaled.BotResponseID)
 	}
 }
+
+func TestActiveTaskPauseResumeExtend(t *testing.T) {
+	start := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	activeTask := &ActiveTask{
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+		Duration:  30,
+	}
+
+	t.Run("Extend", func(t *testing.T) {
+		activeTask.Extend(15)
+
+		if activeTask.Duration != 45 {
+			t.Errorf("Expected duration 45, got %d", activeTask.Duration)
+		}
+
+		if !activeTask.EndTime.Equal(start.Add(45 * time.Minute)) {
+			t.Errorf("Expected end time %v, got %v", start.Add(45*time.Minute), activeTask.EndTime)
+		}
+	})
+
+	t.Run("Pause", func(t *testing.T) {
+		pausedAt := start.Add(20 * time.Minute)
+		activeTask.Pause(pausedAt)
+
+		if !activeTask.IsPaused() {
+			t.Fatal("Expected task to be paused")
+		}
+
+		// Длительность 45 мин., пауза через 20 мин.
+		if remaining := activeTask.TimeRemaining(); remaining != 25*60 {
+			t.Errorf("Expected 1500 seconds remaining while paused, got %d", remaining)
+		}
+
+		// Повторная пауза не меняет момент остановки
+		activeTask.Pause(pausedAt.Add(time.Minute))
+
+		if !activeTask.PausedAt.Equal(pausedAt) {
+			t.Errorf("Expected PausedAt to stay %v, got %v", pausedAt, activeTask.PausedAt)
+		}
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		pausedAt := activeTask.PausedAt
+		resumedAt := pausedAt.Add(5 * time.Minute)
+		activeTask.Resume(resumedAt)
+
+		if activeTask.IsPaused() {
+			t.Fatal("Expected task to be running")
+		}
+
+		if !activeTask.StartTime.Equal(start.Add(5 * time.Minute)) {
+			t.Errorf("Expected start time shifted by the pause, got %v", activeTask.StartTime)
+		}
+
+		if !activeTask.EndTime.Equal(start.Add(50 * time.Minute)) {
+			t.Errorf("Expected end time shifted by the pause, got %v", activeTask.EndTime)
+		}
+	})
+}
+
+func TestActiveTaskLease(t *testing.T) {
+	now := time.Now()
+	activeTask := &ActiveTask{
+		StartTime: now.Add(-time.Hour),
+		EndTime:   now.Add(-time.Minute),
+		Duration:  1,
+		Lease:     &Lease{TTL: 120},
+	}
+
+	// Истекшая аренда без heartbeat
+	if remaining := activeTask.TimeRemaining(); remaining != 0 {
+		t.Errorf("Expected 0 for expired lease, got %d", remaining)
+	}
+
+	activeTask.Renew(now)
+
+	// После heartbeat аренда живет TTL секунд, независимо от Duration
+	if remaining := activeTask.TimeRemaining(); remaining < 119 || remaining > 120 {
+		t.Errorf("Expected about 120 seconds after renew, got %d", remaining)
+	}
+
+	if !activeTask.Lease.RenewedAt.Equal(now) {
+		t.Errorf("Expected RenewedAt %v, got %v", now, activeTask.Lease.RenewedAt)
+	}
+
+	// Обычный таймер не продлевается heartbeat'ом
+	timer := &ActiveTask{StartTime: now, Duration: 1}
+	timer.Renew(now.Add(time.Hour))
+
+	if !timer.EndTime.IsZero() {
+		t.Errorf("Expected timer without lease to stay unchanged, got %+v", timer)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Represents a chat member known to the bot
+type ChatUser struct {
+	ID        int64     `json:"id"`                  // Telegram user ID
+	Username  string    `json:"username,omitempty"`  // Telegram username without @
+	FirstName string    `json:"first_name"`          // First name
+	LastName  string    `json:"last_name,omitempty"` // Last name
+	UpdatedAt time.Time `json:"updated_at"`          // When the user was last seen with these names
+}
+
+// Returns the name to show for the user
+func (u *ChatUser) DisplayName() string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name != "" {
+		return name
+	}
+
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+
+	return fmt.Sprintf("user %d", u.ID)
+}
+
+// Reports whether both records hold the same names
+func (u *ChatUser) SameNames(other *ChatUser) bool {
+	return u.Username == other.Username && u.FirstName == other.FirstName && u.LastName == other.LastName
+}
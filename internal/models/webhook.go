@@ -0,0 +1,77 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"   // Waiting for the first attempt or a retry
+	DeliveryDelivered = "delivered" // Receiver answered with 2xx
+	DeliveryFailed    = "failed"    // All attempts failed or the webhook was removed
+)
+
+// Represents a URL that receives task events of a chat
+// The secret is kept in plain text because it is needed to sign payloads
+type Webhook struct {
+	ID        string    `json:"id"`               // Short unique identifier
+	ChatID    int64     `json:"chat_id"`          // Chat whose events are sent
+	URL       string    `json:"url"`              // Receiver URL
+	Secret    string    `json:"secret"`           // HMAC key of the signatures
+	Events    []string  `json:"events,omitempty"` // Event types to send, empty sends all
+	CreatedBy int64     `json:"created_by"`       // User who added the webhook, zero if added through the API
+	CreatedAt time.Time `json:"created_at"`       // When the webhook was added
+}
+
+// Reports whether the webhook wants events of the type
+func (w *Webhook) Accepts(eventType string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, eventType)
+}
+
+// Represents one event sent to one webhook, with all attempts to send it
+type WebhookDelivery struct {
+	ID            string            `json:"id"`                           // Unique identifier, also sent to the receiver
+	WebhookID     string            `json:"webhook_id"`                   // Webhook the event is sent to
+	ChatID        int64             `json:"chat_id"`                      // Chat of the webhook
+	Event         string            `json:"event"`                        // Event type
+	Payload       json.RawMessage   `json:"payload" swaggertype:"object"` // Signed request body
+	Status        string            `json:"status"`                       // One of the Delivery statuses
+	Attempts      []DeliveryAttempt `json:"attempts"`                     // Attempts made so far, oldest first
+	NextAttemptAt time.Time         `json:"next_attempt_at"`              // When the next attempt is due if pending
+	CreatedAt     time.Time         `json:"created_at"`                   // When the event happened
+}
+
+// Represents a single attempt to deliver an event
+type DeliveryAttempt struct {
+	At         time.Time `json:"at"`              // When the request was sent
+	StatusCode int       `json:"status_code"`     // Response status, zero if there was no response
+	Error      string    `json:"error,omitempty"` // Network error or unexpected status
+	DurationMs int64     `json:"duration_ms"`     // Time to response in milliseconds
+}
+
+// Represents the JSON body sent to webhooks
+type WebhookPayload struct {
+	ID      string         `json:"id"`                // Delivery ID, the same for all retries
+	Event   string         `json:"event"`             // Event type, e.g. "timer.started"
+	Time    time.Time      `json:"time"`              // When the event happened
+	ChatID  int64          `json:"chat_id"`           // Chat of the task
+	Source  string         `json:"source"`            // Where the change came from: "telegram", "api" or "timer"
+	Task    TaskResponse   `json:"task"`              // Task after the change
+	Timer   *TimerResponse `json:"timer,omitempty"`   // Timer after the change, absent for task events
+	Minutes int            `json:"minutes,omitempty"` // Extension in minutes for timer.extended
+}
@@ -0,0 +1,339 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/mail"
+	"sync"
+	"time"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	// How long a confirmation code is valid
+	codeTTL = 15 * time.Minute
+	// Minimum time between two codes sent to a user
+	codeResendInterval = time.Minute
+	// Wrong codes allowed before a new one has to be requested
+	maxCodeAttempts = 5
+	// Timeout of sending a single notification
+	sendTimeout = 30 * time.Second
+)
+
+var (
+	ErrInvalidEmail      = errors.New("invalid email address")
+	ErrCodeRecentlySent  = errors.New("confirmation code was sent recently")
+	ErrNoPendingEmail    = errors.New("no email address waiting for confirmation")
+	ErrCodeExpired       = errors.New("confirmation code has expired")
+	ErrWrongCode         = errors.New("wrong confirmation code")
+	ErrTooManyAttempts   = errors.New("too many wrong confirmation codes")
+	ErrEmailNotConfirmed = errors.New("no confirmed email address")
+)
+
+// Delivers notification emails
+type Sender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// Emails users about their timers and the tasks they wait for
+type Notifier struct {
+	storage storage.Storage
+	service *service.Service
+	sender  Sender
+
+	wg sync.WaitGroup
+}
+
+// Creates a notifier and subscribes it to the service events
+func New(storage storage.Storage, svc *service.Service, sender Sender) *Notifier {
+	n := &Notifier{
+		storage: storage,
+		service: svc,
+		sender:  sender,
+	}
+
+	svc.Subscribe(n.HandleEvent)
+
+	return n
+}
+
+// Waits for the notifications being sent
+func (n *Notifier) Stop() {
+	n.wg.Wait()
+}
+
+// Sends a confirmation code to a new address of the user
+// The address is used only after ConfirmEmail
+func (n *Notifier) RequestEmail(ctx context.Context, userID int64, address string) error {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Address != address {
+		return ErrInvalidEmail
+	}
+
+	settings, err := n.settings(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := n.service.Clock().Now()
+
+	if settings.PendingEmail != "" && now.Before(settings.CodeExpiresAt.Add(codeResendInterval-codeTTL)) {
+		return ErrCodeRecentlySent
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Your confirmation code is %s\n\nSend /notify confirm %s to the bot within %d minutes to get notifications at this address. "+
+			"If you didn't ask for it, just ignore this email.",
+		code,
+		code,
+		int(codeTTL.Minutes()),
+	)
+
+	if err := n.sender.Send(ctx, address, "Confirm your email for Time Guard Bot", body); err != nil {
+		return fmt.Errorf("failed to send confirmation code: %w", err)
+	}
+
+	settings.PendingEmail = address
+	settings.CodeHash = hashCode(code)
+	settings.CodeExpiresAt = now.Add(codeTTL)
+	settings.CodeAttempts = 0
+	settings.UpdatedAt = now
+
+	if err := n.storage.SaveNotifySettings(ctx, settings); err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	return nil
+}
+
+// Confirms the pending address of the user with the code sent to it
+// Returns the confirmed address
+func (n *Notifier) ConfirmEmail(ctx context.Context, userID int64, code string) (string, error) {
+	settings, err := n.settings(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if settings.PendingEmail == "" {
+		return "", ErrNoPendingEmail
+	}
+
+	now := n.service.Clock().Now()
+
+	if !now.Before(settings.CodeExpiresAt) {
+		return "", ErrCodeExpired
+	}
+
+	if settings.CodeAttempts >= maxCodeAttempts {
+		return "", ErrTooManyAttempts
+	}
+
+	settings.UpdatedAt = now
+
+	if subtle.ConstantTimeCompare([]byte(hashCode(code)), []byte(settings.CodeHash)) != 1 {
+		settings.CodeAttempts++
+
+		if err := n.storage.SaveNotifySettings(ctx, settings); err != nil {
+			return "", fmt.Errorf("failed to save notification settings: %w", err)
+		}
+
+		return "", ErrWrongCode
+	}
+
+	settings.Email = settings.PendingEmail
+	clearCode(settings)
+
+	if err := n.storage.SaveNotifySettings(ctx, settings); err != nil {
+		return "", fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	return settings.Email, nil
+}
+
+// Stops email notifications of the user and forgets their address
+func (n *Notifier) DisableEmail(ctx context.Context, userID int64) error {
+	settings, err := n.settings(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	settings.Email = ""
+	settings.UpdatedAt = n.service.Clock().Now()
+	clearCode(settings)
+
+	if err := n.storage.SaveNotifySettings(ctx, settings); err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	return nil
+}
+
+// Emails the user once the task gets free
+func (n *Notifier) Watch(ctx context.Context, chatID int64, taskID string, userID int64) error {
+	settings, err := n.settings(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if settings.Email == "" {
+		return ErrEmailNotConfirmed
+	}
+
+	if err := n.storage.AddTaskWatcher(ctx, chatID, taskID, userID); err != nil {
+		return fmt.Errorf("failed to add task watcher: %w", err)
+	}
+
+	return nil
+}
+
+// Emails the holder about their timer and the watchers about a freed task
+// Subscribed to the service as a listener
+func (n *Notifier) HandleEvent(ctx context.Context, event *service.Event) {
+	task := event.Task
+
+	switch event.Type {
+	case service.EventTimerExpiring:
+		n.notify(ctx, event.ActiveTask.UserID,
+			fmt.Sprintf("Timer for task %s expires in %d minutes", task.Name, int(service.TimerWarningLead.Minutes())),
+			fmt.Sprintf(
+				"Your timer for task %s expires in %d minutes. Extend it in the chat if you need more time.",
+				task.Name,
+				int(service.TimerWarningLead.Minutes()),
+			),
+		)
+	case service.EventTimerExpired:
+		if !event.ActiveTask.IsLeased() {
+			n.notify(ctx, event.ActiveTask.UserID,
+				fmt.Sprintf("Timer for task %s expired", task.Name),
+				fmt.Sprintf("Your timer for task %s has expired and the task is free again.", task.Name),
+			)
+		}
+
+		n.notifyWatchers(ctx, event)
+	case service.EventTimerDone, service.EventTimerCancelled, service.EventLeaseReleased, service.EventTaskUnlocked:
+		n.notifyWatchers(ctx, event)
+	case service.EventTaskDeleted:
+		if _, err := n.storage.TakeTaskWatchers(ctx, event.ChatID, task.ID); err != nil {
+			log.Printf("Failed to drop watchers of task %s: %v", task.ID, err)
+		}
+	}
+}
+
+// Emails everyone who waited for the task of the event, except its last holder
+func (n *Notifier) notifyWatchers(ctx context.Context, event *service.Event) {
+	task := event.Task
+
+	watchers, err := n.storage.TakeTaskWatchers(ctx, event.ChatID, task.ID)
+	if err != nil {
+		log.Printf("Failed to get watchers of task %s: %v", task.ID, err)
+		return
+	}
+
+	for _, userID := range watchers {
+		if event.ActiveTask != nil && event.ActiveTask.UserID == userID {
+			continue
+		}
+
+		n.notify(ctx, userID,
+			fmt.Sprintf("Task %s is free", task.Name),
+			fmt.Sprintf("Task %s you were waiting for is free now. Take it in the chat before someone else does.", task.Name),
+		)
+	}
+}
+
+// Emails the user in the background if they have a confirmed address
+func (n *Notifier) notify(ctx context.Context, userID int64, subject, body string) {
+	settings, err := n.storage.GetNotifySettings(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get notification settings of user %d: %v", userID, err)
+		}
+
+		return
+	}
+
+	if settings.Email == "" {
+		return
+	}
+
+	n.wg.Add(1)
+
+	go func() {
+		defer n.wg.Done()
+
+		sendCtx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		defer cancel()
+
+		if err := n.sender.Send(sendCtx, settings.Email, subject, body); err != nil {
+			log.Printf("Failed to email user %d: %v", userID, err)
+		}
+	}()
+}
+
+// Gets the settings of the user, empty ones if they have none yet
+func (n *Notifier) settings(ctx context.Context, userID int64) (*models.NotifySettings, error) {
+	settings, err := n.storage.GetNotifySettings(ctx, userID)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return &models.NotifySettings{UserID: userID}, nil
+		}
+
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Generates a random 6-digit confirmation code
+func generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate confirmation code: %w", err)
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// Hashes a confirmation code for storing
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Forgets the pending address and its code
+func clearCode(settings *models.NotifySettings) {
+	settings.PendingEmail = ""
+	settings.CodeHash = ""
+	settings.CodeExpiresAt = time.Time{}
+	settings.CodeAttempts = 0
+}
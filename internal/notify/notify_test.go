@@ -0,0 +1,362 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	testChatID  = int64(-100123)
+	waitTimeout = 2 * time.Second
+)
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+type testEnv struct {
+	notifier *Notifier
+	service  *service.Service
+	storage  *redis.Storage
+	sender   *Recorder
+	clock    *clock.Fake
+	task     *models.Task
+}
+
+func setup(t *testing.T) *testEnv {
+	t.Helper()
+
+	miniRedis := miniredis.RunT(t)
+
+	storage, err := redis.New(miniRedis.Addr(), "", 0)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	svc := service.New(storage)
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	svc.SetClock(fakeClock)
+
+	sender := NewRecorder()
+	notifier := New(storage, svc, sender)
+	t.Cleanup(notifier.Stop)
+
+	task, err := svc.CreateTask(context.Background(), testChatID, "deploy", "", service.SourceAPI)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	return &testEnv{
+		notifier: notifier,
+		service:  svc,
+		storage:  storage,
+		sender:   sender,
+		clock:    fakeClock,
+		task:     task,
+	}
+}
+
+// Регистрирует подтверждённый адрес пользователя
+func (env *testEnv) confirm(t *testing.T, userID int64, address string) {
+	t.Helper()
+
+	ctx := context.Background()
+	sent := len(env.sender.Emails())
+
+	if err := env.notifier.RequestEmail(ctx, userID, address); err != nil {
+		t.Fatalf("Failed to request email: %v", err)
+	}
+
+	emails := env.sender.Emails()
+	if len(emails) != sent+1 {
+		t.Fatalf("Expected a confirmation email, got %d emails", len(emails)-sent)
+	}
+
+	code := codePattern.FindString(emails[sent].Body)
+
+	if _, err := env.notifier.ConfirmEmail(ctx, userID, code); err != nil {
+		t.Fatalf("Failed to confirm email: %v", err)
+	}
+}
+
+func TestRequestEmail(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+
+	for _, address := range []string{"", "not an email", "Dev <dev@example.com>", "dev@example.com\r\nBcc: x@example.com"} {
+		if err := env.notifier.RequestEmail(ctx, 42, address); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("Expected ErrInvalidEmail for %q, got %v", address, err)
+		}
+	}
+
+	if err := env.notifier.RequestEmail(ctx, 42, "dev@example.com"); err != nil {
+		t.Fatalf("Failed to request email: %v", err)
+	}
+
+	emails := env.sender.Emails()
+	if len(emails) != 1 || emails[0].To != "dev@example.com" || !codePattern.MatchString(emails[0].Body) {
+		t.Fatalf("Expected a code sent to dev@example.com, got %+v", emails)
+	}
+
+	// Код хранится только в виде хеша
+	settings, err := env.storage.GetNotifySettings(ctx, 42)
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+
+	if settings.PendingEmail != "dev@example.com" || settings.Email != "" {
+		t.Errorf("Expected pending dev@example.com, got %+v", settings)
+	}
+
+	if strings.Contains(settings.CodeHash, codePattern.FindString(emails[0].Body)) {
+		t.Error("Expected the code not to be stored as is")
+	}
+
+	// Повторный код не раньше чем через минуту
+	if err := env.notifier.RequestEmail(ctx, 42, "dev@example.com"); !errors.Is(err, ErrCodeRecentlySent) {
+		t.Errorf("Expected ErrCodeRecentlySent, got %v", err)
+	}
+
+	env.clock.Advance(codeResendInterval)
+
+	if err := env.notifier.RequestEmail(ctx, 42, "dev@example.com"); err != nil {
+		t.Errorf("Failed to request a new code: %v", err)
+	}
+
+	// Не отправленный код не сохраняется
+	env.sender.Err = errors.New("smtp down")
+
+	if err := env.notifier.RequestEmail(ctx, 43, "ops@example.com"); err == nil {
+		t.Error("Expected an error when the code can't be sent")
+	}
+
+	if _, err := env.storage.GetNotifySettings(ctx, 43); !errors.Is(err, redis.ErrNotFound) {
+		t.Errorf("Expected no settings after a failed send, got %v", err)
+	}
+}
+
+func TestConfirmEmail(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Confirm", func(t *testing.T) {
+		env := setup(t)
+
+		if _, err := env.notifier.ConfirmEmail(ctx, 42, "123456"); !errors.Is(err, ErrNoPendingEmail) {
+			t.Errorf("Expected ErrNoPendingEmail, got %v", err)
+		}
+
+		env.confirm(t, 42, "dev@example.com")
+
+		settings, err := env.storage.GetNotifySettings(ctx, 42)
+		if err != nil {
+			t.Fatalf("Failed to get settings: %v", err)
+		}
+
+		if settings.Email != "dev@example.com" || settings.PendingEmail != "" || settings.CodeHash != "" {
+			t.Errorf("Expected confirmed dev@example.com, got %+v", settings)
+		}
+
+		if err := env.notifier.DisableEmail(ctx, 42); err != nil {
+			t.Fatalf("Failed to disable email: %v", err)
+		}
+
+		settings, err = env.storage.GetNotifySettings(ctx, 42)
+		if err != nil || settings.Email != "" {
+			t.Errorf("Expected no email after disabling, got %+v (%v)", settings, err)
+		}
+	})
+
+	t.Run("WrongCode", func(t *testing.T) {
+		env := setup(t)
+
+		if err := env.notifier.RequestEmail(ctx, 42, "dev@example.com"); err != nil {
+			t.Fatalf("Failed to request email: %v", err)
+		}
+
+		code := codePattern.FindString(env.sender.Emails()[0].Body)
+		wrong := "000000"
+
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		for range maxCodeAttempts {
+			if _, err := env.notifier.ConfirmEmail(ctx, 42, wrong); !errors.Is(err, ErrWrongCode) {
+				t.Fatalf("Expected ErrWrongCode, got %v", err)
+			}
+		}
+
+		// После лимита попыток не подходит даже верный код
+		if _, err := env.notifier.ConfirmEmail(ctx, 42, code); !errors.Is(err, ErrTooManyAttempts) {
+			t.Errorf("Expected ErrTooManyAttempts, got %v", err)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		env := setup(t)
+
+		if err := env.notifier.RequestEmail(ctx, 42, "dev@example.com"); err != nil {
+			t.Fatalf("Failed to request email: %v", err)
+		}
+
+		code := codePattern.FindString(env.sender.Emails()[0].Body)
+
+		env.clock.Advance(codeTTL)
+
+		if _, err := env.notifier.ConfirmEmail(ctx, 42, code); !errors.Is(err, ErrCodeExpired) {
+			t.Errorf("Expected ErrCodeExpired, got %v", err)
+		}
+	})
+}
+
+func TestTimerNotifications(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+
+	env.confirm(t, 42, "dev@example.com")
+
+	_, _, err := env.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   testChatID,
+		TaskID:   env.task.ID,
+		UserID:   42,
+		Duration: 30,
+		Source:   service.SourceAPI,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	env.clock.Advance(30*time.Minute - service.TimerWarningLead)
+
+	if _, _, err := env.service.WarnTimer(ctx, testChatID, env.task.ID); err != nil {
+		t.Fatalf("Failed to warn timer: %v", err)
+	}
+
+	env.clock.Advance(service.TimerWarningLead)
+
+	if _, _, err := env.service.ExpireTimer(ctx, testChatID, env.task.ID); err != nil {
+		t.Fatalf("Failed to expire timer: %v", err)
+	}
+
+	// Подтверждение адреса и два уведомления
+	emails, ok := env.sender.Wait(3, waitTimeout)
+	if !ok {
+		t.Fatalf("Expected 3 emails, got %d", len(emails))
+	}
+
+	// Письма отправляются в фоне, порядок не гарантирован
+	var subjects []string
+
+	for _, email := range emails[1:] {
+		if email.To != "dev@example.com" {
+			t.Errorf("Expected an email to dev@example.com, got %+v", email)
+		}
+
+		subjects = append(subjects, email.Subject)
+	}
+
+	slices.Sort(subjects)
+
+	expected := []string{"Timer for task deploy expired", "Timer for task deploy expires in 5 minutes"}
+	if !slices.Equal(subjects, expected) {
+		t.Errorf("Expected subjects %q, got %q", expected, subjects)
+	}
+}
+
+func TestWatchTask(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+
+	if err := env.notifier.Watch(ctx, testChatID, env.task.ID, 43); !errors.Is(err, ErrEmailNotConfirmed) {
+		t.Errorf("Expected ErrEmailNotConfirmed, got %v", err)
+	}
+
+	env.confirm(t, 43, "ops@example.com")
+
+	_, _, err := env.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   testChatID,
+		TaskID:   env.task.ID,
+		UserID:   42,
+		Duration: 30,
+		Source:   service.SourceAPI,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	if err := env.notifier.Watch(ctx, testChatID, env.task.ID, 43); err != nil {
+		t.Fatalf("Failed to watch task: %v", err)
+	}
+
+	if _, _, err := env.service.ReleaseTimer(ctx, testChatID, env.task.ID, true, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to release timer: %v", err)
+	}
+
+	emails, ok := env.sender.Wait(2, waitTimeout)
+	if !ok {
+		t.Fatalf("Expected a watch email, got %d emails", len(emails))
+	}
+
+	if emails[1].To != "ops@example.com" || emails[1].Subject != "Task deploy is free" {
+		t.Errorf("Expected 'Task deploy is free' to ops@example.com, got %+v", emails[1])
+	}
+
+	// Наблюдение срабатывает один раз
+	_, _, err = env.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   testChatID,
+		TaskID:   env.task.ID,
+		UserID:   42,
+		Duration: 30,
+		Source:   service.SourceAPI,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	if _, _, err := env.service.ReleaseTimer(ctx, testChatID, env.task.ID, false, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to release timer: %v", err)
+	}
+
+	// Удаление задачи забывает наблюдателей
+	if err := env.notifier.Watch(ctx, testChatID, env.task.ID, 43); err != nil {
+		t.Fatalf("Failed to watch task: %v", err)
+	}
+
+	if _, err := env.service.DeleteTask(ctx, testChatID, env.task.ID, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	watchers, err := env.storage.TakeTaskWatchers(ctx, testChatID, env.task.ID)
+	if err != nil || len(watchers) != 0 {
+		t.Errorf("Expected no watchers after delete, got %v (%v)", watchers, err)
+	}
+
+	env.notifier.Stop()
+
+	if emails := env.sender.Emails(); len(emails) != 2 {
+		t.Errorf("Expected no more emails, got %+v", emails[2:])
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Sender = (*Recorder)(nil)
+
+// Describes an email sent through a Recorder
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Implements Sender in memory for tests: records every email instead of sending it
+// Safe for concurrent use
+type Recorder struct {
+	// Error returned by Send instead of recording the email
+	Err error
+
+	mu      sync.Mutex
+	emails  []Email
+	changed chan struct{}
+}
+
+// Creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{changed: make(chan struct{})}
+}
+
+// Records an email
+func (r *Recorder) Send(ctx context.Context, to, subject, body string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Err != nil {
+		return r.Err
+	}
+
+	r.emails = append(r.emails, Email{To: to, Subject: subject, Body: body})
+
+	// Wake up everyone in Wait
+	close(r.changed)
+	r.changed = make(chan struct{})
+
+	return nil
+}
+
+// Returns a copy of the recorded emails
+func (r *Recorder) Emails() []Email {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Email(nil), r.emails...)
+}
+
+// Waits until at least count emails are recorded, emails are sent in the background
+// Returns the emails and whether there were enough of them before the timeout
+func (r *Recorder) Wait(count int, timeout time.Duration) ([]Email, bool) {
+	deadline := time.After(timeout)
+
+	for {
+		r.mu.Lock()
+		changed := r.changed
+		emails := append([]Email(nil), r.emails...)
+		r.mu.Unlock()
+
+		if len(emails) >= count {
+			return emails, true
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return emails, false
+		}
+	}
+}
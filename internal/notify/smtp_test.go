@@ -0,0 +1,146 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/notify/smtptest"
+)
+
+func startSMTPServer(t *testing.T) *smtptest.Server {
+	t.Helper()
+
+	server, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatalf("Failed to start SMTP server: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := server.Close(); err != nil {
+			t.Errorf("Failed to close SMTP server: %v", err)
+		}
+	})
+
+	return server
+}
+
+func TestNewSMTPSender(t *testing.T) {
+	for _, config := range []SMTPConfig{
+		{Addr: "smtp.example.com", From: "bot@example.com"},
+		{Addr: "smtp.example.com:587", From: "not an email"},
+	} {
+		if _, err := NewSMTPSender(config); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	server := startSMTPServer(t)
+
+	sender, err := NewSMTPSender(SMTPConfig{
+		Addr:     server.Addr(),
+		Username: "bot",
+		Password: "secret",
+		From:     "Time Guard <bot@example.com>",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	body := "Задача deploy свободна\n.\nTake it"
+	if err := sender.Send(ctx, "dev@example.com", "Task deploy is free ✅", body); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+
+	message := messages[0]
+
+	if message.From != "bot@example.com" || len(message.To) != 1 || message.To[0] != "dev@example.com" {
+		t.Errorf("Unexpected envelope: from %q to %q", message.From, message.To)
+	}
+
+	if message.Username != "bot" {
+		t.Errorf("Expected authentication as bot, got %q", message.Username)
+	}
+
+	for _, header := range []string{
+		`From: "Time Guard" <bot@example.com>`,
+		"To: <dev@example.com>",
+		"Subject: =?utf-8?q?Task_deploy_is_free_=E2=9C=85?=",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+	} {
+		if !strings.Contains(message.Data, header+"\n") {
+			t.Errorf("Expected header %q in:\n%s", header, message.Data)
+		}
+	}
+
+	// Тело в quoted-printable, строка из одной точки не обрывает письмо
+	if !strings.Contains(message.Data, "=D0=97=D0=B0=D0=B4") || !strings.Contains(message.Data, "\n.\nTake it") {
+		t.Errorf("Unexpected body:\n%s", message.Data)
+	}
+}
+
+func TestSMTPSendErrors(t *testing.T) {
+	server := startSMTPServer(t)
+
+	sender, err := NewSMTPSender(SMTPConfig{Addr: server.Addr(), From: "bot@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// Перевод строки в адресе позволил бы дописать свои заголовки
+	if err := sender.Send(ctx, "dev@example.com\r\nBcc: x@example.com", "Hi", "Hi"); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("Expected ErrInvalidEmail, got %v", err)
+	}
+
+	if len(server.Messages()) != 0 {
+		t.Errorf("Expected nothing sent, got %d messages", len(server.Messages()))
+	}
+
+	closed, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatalf("Failed to start SMTP server: %v", err)
+	}
+
+	addr := closed.Addr()
+
+	if err := closed.Close(); err != nil {
+		t.Fatalf("Failed to close SMTP server: %v", err)
+	}
+
+	sender, err = NewSMTPSender(SMTPConfig{Addr: addr, From: "bot@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+
+	if err := sender.Send(ctx, "dev@example.com", "Hi", "Hi"); err == nil {
+		t.Error("Expected an error when the server is down")
+	}
+}
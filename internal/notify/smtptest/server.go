@@ -0,0 +1,229 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package smtptest provides an in-process SMTP server for tests
+package smtptest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Describes a message received by a Server
+type Message struct {
+	From     string   // Envelope sender
+	To       []string // Envelope recipients
+	Username string   // Authenticated user, empty without AUTH
+	Data     string   // Headers and body as sent, dot-unstuffed
+}
+
+// Accepts SMTP sessions on a local port and keeps the messages in memory
+// Offers AUTH PLAIN and accepts any credentials, STARTTLS is not offered
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+	wg       sync.WaitGroup
+}
+
+// Starts a server on a random local port
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s := &Server{listener: listener}
+
+	s.wg.Add(1)
+
+	go s.serve()
+
+	return s, nil
+}
+
+// Returns the host:port the server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Returns a copy of the received messages
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// Stops accepting sessions and waits for the open ones to end
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+// Accepts sessions until the listener is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			text := textproto.NewConn(conn)
+			defer func() {
+				if err := text.Close(); err != nil {
+					log.Printf("smtptest: failed to close connection: %v", err)
+				}
+			}()
+
+			s.handle(text)
+		}()
+	}
+}
+
+// Runs one SMTP session
+func (s *Server) handle(conn *textproto.Conn) {
+	var (
+		message  Message
+		username string
+	)
+
+	reply := func(format string, args ...any) bool {
+		return conn.PrintfLine(format, args...) == nil
+	}
+
+	if !reply("220 smtptest ready") {
+		return
+	}
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if !reply("250-smtptest\r\n250-8BITMIME\r\n250 AUTH PLAIN") {
+				return
+			}
+		case "HELO":
+			if !reply("250 smtptest") {
+				return
+			}
+		case "AUTH":
+			user, ok := plainUser(arg)
+			if !ok {
+				if !reply("501 malformed AUTH PLAIN") {
+					return
+				}
+
+				continue
+			}
+
+			username = user
+
+			if !reply("235 authenticated") {
+				return
+			}
+		case "MAIL":
+			message = Message{From: envelopeAddress(arg), Username: username}
+
+			if !reply("250 ok") {
+				return
+			}
+		case "RCPT":
+			message.To = append(message.To, envelopeAddress(arg))
+
+			if !reply("250 ok") {
+				return
+			}
+		case "DATA":
+			if !reply("354 end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+
+			data, err := io.ReadAll(conn.DotReader())
+			if err != nil {
+				return
+			}
+
+			message.Data = string(data)
+
+			s.mu.Lock()
+			s.messages = append(s.messages, message)
+			s.mu.Unlock()
+
+			if !reply("250 queued") {
+				return
+			}
+		case "RSET", "NOOP":
+			if !reply("250 ok") {
+				return
+			}
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			if !reply("502 command not implemented") {
+				return
+			}
+		}
+	}
+}
+
+// Extracts the address from "FROM:<a@b>" or "TO:<a@b>"
+func envelopeAddress(arg string) string {
+	_, address, _ := strings.Cut(arg, ":")
+	address, _, _ = strings.Cut(strings.TrimSpace(address), " ")
+
+	return strings.Trim(address, "<>")
+}
+
+// Extracts the user name from "PLAIN <base64 of identity\0user\0password>"
+func plainUser(arg string) (string, bool) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mechanism, "PLAIN") {
+		return "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(initial)
+	if err != nil {
+		return "", false
+	}
+
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 {
+		return "", false
+	}
+
+	return parts[1], true
+}
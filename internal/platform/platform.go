@@ -0,0 +1,170 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package platform holds the parts the Slack, Discord and Matrix adapters share
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Formats the parts of a message that need the markup of a chat platform
+type Markup struct {
+	Bold func(text string) string // Task names
+	Code func(text string) string // Task IDs
+	Text func(text string) string // Text typed by users, like descriptions and lock reasons
+}
+
+// Returns Markdown-like markup that puts bold text between boldMark,
+// * in Slack and ** in Discord
+func Markdown(boldMark string) Markup {
+	return Markup{
+		Bold: func(text string) string { return boldMark + text + boldMark },
+		Code: func(text string) string { return "`" + text + "`" },
+		Text: func(text string) string { return text },
+	}
+}
+
+// Returns the announcement of a change, false for quiet ones
+// holder is called only by events that name the user holding the task
+func EventText(event *service.Event, now time.Time, markup Markup, holder func() string) (string, bool) {
+	task := event.Task
+	name := markup.Bold(task.Name)
+
+	var text string
+
+	switch event.Type {
+	case service.EventTimerStarted:
+		text = fmt.Sprintf("%s: task %s, %s", TimerStartedText(event.ActiveTask.Duration), name, holder())
+	case service.EventTimerExtended:
+		text = fmt.Sprintf(
+			"Timer for task %s extended by %d minutes (%s remaining)",
+			name,
+			event.Minutes,
+			FormatRemaining(event.ActiveTask.RemainingAt(now)),
+		)
+	case service.EventTimerPaused:
+		text = fmt.Sprintf("⏸ Timer for task %s paused", name)
+	case service.EventTimerResumed:
+		text = fmt.Sprintf("▶️ Timer for task %s resumed", name)
+	case service.EventTimerDone:
+		text = ReleasedText(markup, task.Name, true)
+	case service.EventTimerCancelled:
+		text = ReleasedText(markup, task.Name, false)
+	case service.EventTimerExpired:
+		text = fmt.Sprintf("⌛ Time for task %s has expired! How's it going, %s?", name, holder())
+		if event.ActiveTask.IsLeased() {
+			text = fmt.Sprintf("⌛ Lease on task %s expired, %s stopped sending heartbeats. The task is free", name, holder())
+		}
+	case service.EventTaskLocked:
+		text = fmt.Sprintf("🔒 Task %s locked", name)
+		if task.LockReason != "" {
+			text += fmt.Sprintf(". Reason: %s", markup.Text(task.LockReason))
+		}
+	case service.EventTaskUnlocked:
+		text = fmt.Sprintf("🟢 Task %s unlocked", name)
+	case service.EventLeaseAcquired:
+		text = fmt.Sprintf(
+			"🤖 Task %s taken by %s (lease, expires without a heartbeat for %d s)",
+			name,
+			holder(),
+			event.ActiveTask.Lease.TTL,
+		)
+	case service.EventLeaseReleased:
+		text = fmt.Sprintf("✅ Task %s released by %s", name, holder())
+	case service.EventTaskCreated:
+		text = TaskAddedText(markup, task)
+	case service.EventTaskUpdated:
+		text = fmt.Sprintf("Task %s (%s) updated", name, markup.Code(task.ID))
+	case service.EventTaskDeleted:
+		text = fmt.Sprintf("Task %s has been deleted", name)
+	default:
+		return "", false
+	}
+
+	return text, true
+}
+
+// Returns the answer to a new task
+func TaskAddedText(markup Markup, task *models.Task) string {
+	text := fmt.Sprintf("Task added successfully!\n\nName: %s\nID: %s", markup.Bold(task.Name), markup.Code(task.ID))
+	if task.Description != "" {
+		text += fmt.Sprintf("\nDescription: %s", markup.Text(task.Description))
+	}
+
+	return text
+}
+
+// Explains why a timer can't be started, false for unexpected errors
+// blockerName is called only by conflicts, with the ID of the task in use
+func StartErrorText(err error, task *models.Task, now time.Time, markup Markup, blockerName func(taskID string) string) (string, bool) {
+	var conflictErr *redis.ConflictError
+
+	switch {
+	case errors.Is(err, service.ErrTaskLocked):
+		text := "Task is locked"
+		if task.LockReason != "" {
+			text += fmt.Sprintf(". Reason: %s", markup.Text(task.LockReason))
+		}
+
+		return text, true
+	case errors.Is(err, service.ErrAlreadyHolding):
+		return fmt.Sprintf("You're already working on this task. %s remaining", FormatRemaining(task.RemainingAt(now))), true
+	case errors.Is(err, service.ErrTaskBusy):
+		return fmt.Sprintf("Another user is currently working on the task. %s remaining", FormatRemaining(task.RemainingAt(now))), true
+	case errors.As(err, &conflictErr):
+		return fmt.Sprintf(
+			"Task %s is in use and conflicts with this task (group %s)",
+			markup.Bold(blockerName(conflictErr.BlockerID)),
+			markup.Bold(conflictErr.Group),
+		), true
+	case errors.Is(err, service.ErrTooManyTasks):
+		return fmt.Sprintf("You've reached the maximum number of active tasks (%d)", helpers.MaxTasksPerUser), true
+	case errors.Is(err, service.ErrDurationLimit):
+		return fmt.Sprintf("Duration exceeds maximum allowed limit (%d minutes)", helpers.MaxTaskDuration), true
+	case errors.Is(err, service.ErrInvalidDuration):
+		return "Duration must be more than 1", true
+	}
+
+	return "", false
+}
+
+// Returns the start of a timer message
+func TimerStartedText(duration int) string {
+	if duration == 1 {
+		return fmt.Sprintf("Timer started for %d minute", duration)
+	}
+
+	return fmt.Sprintf("Timer started for %d minutes", duration)
+}
+
+// Returns the message of a task whose timer was stopped
+func ReleasedText(markup Markup, taskName string, done bool) string {
+	if done {
+		return fmt.Sprintf("✅ Task %s done", markup.Bold(taskName))
+	}
+
+	return fmt.Sprintf("Timer for task %s has been cancelled", markup.Bold(taskName))
+}
+
+// Formats remaining seconds as m:ss
+func FormatRemaining(remaining int64) string {
+	return fmt.Sprintf("%d:%02d", remaining/60, remaining%60)
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+func TestEventText(t *testing.T) {
+	now := time.Unix(1735732800, 0)
+	task := &models.Task{ID: "abc123", Name: "staging", LockReason: "<deploy>"}
+	activeTask := &models.ActiveTask{TaskID: task.ID, StartTime: now, EndTime: now.Add(20 * time.Minute), Duration: 20}
+
+	html := Markup{
+		Bold: func(text string) string { return "<b>" + text + "</b>" },
+		Code: func(text string) string { return "<code>" + text + "</code>" },
+		Text: func(text string) string { return "[" + text + "]" },
+	}
+
+	tests := []struct {
+		name     string
+		event    service.Event
+		markup   Markup
+		expected string
+	}{
+		{"Slack", service.Event{Type: service.EventTimerPaused, Task: task}, Markdown("*"), "⏸ Timer for task *staging* paused"},
+		{"Discord", service.Event{Type: service.EventTimerPaused, Task: task}, Markdown("**"), "⏸ Timer for task **staging** paused"},
+		{"Started", service.Event{Type: service.EventTimerStarted, Task: task, ActiveTask: activeTask}, Markdown("*"), "Timer started for 20 minutes: task *staging*, Alice"},
+		{"Extended", service.Event{Type: service.EventTimerExtended, Task: task, ActiveTask: activeTask, Minutes: 5}, Markdown("*"), "Timer for task *staging* extended by 5 minutes (20:00 remaining)"},
+		{"Updated", service.Event{Type: service.EventTaskUpdated, Task: task}, html, "Task <b>staging</b> (<code>abc123</code>) updated"},
+		{"Lock reason", service.Event{Type: service.EventTaskLocked, Task: task}, html, "🔒 Task <b>staging</b> locked. Reason: [<deploy>]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := EventText(&tt.event, now, tt.markup, func() string { return "Alice" })
+			if !ok {
+				t.Fatalf("Expected an announcement of %s", tt.event.Type)
+			}
+
+			if text != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, text)
+			}
+		})
+	}
+
+	t.Run("Quiet", func(t *testing.T) {
+		event := &service.Event{Type: service.EventLeaseRenewed, Task: task, ActiveTask: activeTask}
+		if text, ok := EventText(event, now, Markdown("*"), func() string { return "Alice" }); ok {
+			t.Errorf("Expected no announcement of a renewed lease, got %q", text)
+		}
+	})
+}
+
+func TestStartErrorText(t *testing.T) {
+	now := time.Unix(1735732800, 0)
+	task := &models.Task{ID: "abc123", Name: "staging", LockReason: "<deploy>", OwnerID: 7, EndTime: now.Add(90 * time.Second)}
+	blockerName := func(taskID string) string { return "db-" + taskID }
+
+	tests := []struct {
+		name     string
+		err      error
+		markup   Markup
+		expected string
+	}{
+		{"Locked", service.ErrTaskLocked, Markdown("*"), "Task is locked. Reason: <deploy>"},
+		{"Busy", service.ErrTaskBusy, Markdown("*"), "Another user is currently working on the task. 1:30 remaining"},
+		{"Conflict", &redis.ConflictError{Group: "db", BlockerID: "xyz"}, Markdown("**"), "Task **db-xyz** is in use and conflicts with this task (group **db**)"},
+		{"Invalid duration", service.ErrInvalidDuration, Markdown("*"), "Duration must be more than 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := StartErrorText(fmt.Errorf("failed to start timer: %w", tt.err), task, now, tt.markup, blockerName)
+			if !ok {
+				t.Fatalf("Expected an explanation of %v", tt.err)
+			}
+
+			if text != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, text)
+			}
+		})
+	}
+
+	if text, ok := StartErrorText(errors.New("connection refused"), task, now, Markdown("*"), blockerName); ok {
+		t.Errorf("Expected no explanation of a storage error, got %q", text)
+	}
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	now := time.Unix(1735732800, 0)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		expected  error
+	}{
+		{"Now", strconv.FormatInt(now.Unix(), 10), nil},
+		{"Inside window", strconv.FormatInt(now.Add(-MaxRequestAge).Unix(), 10), nil},
+		{"Too old", strconv.FormatInt(now.Add(-MaxRequestAge-time.Second).Unix(), 10), ErrStaleRequest},
+		{"Too far ahead", strconv.FormatInt(now.Add(MaxRequestAge+time.Second).Unix(), 10), ErrStaleRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckTimestamp(tt.timestamp, now); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		if err := CheckTimestamp("yesterday", now); err == nil || errors.Is(err, ErrStaleRequest) {
+			t.Errorf("Expected a parse error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package platformtest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+)
+
+// A message an adapter posted in the test channel
+type Post struct {
+	Text     string
+	Controls bool // Carries buttons or reactions that control the timer
+}
+
+// Drives an adapter under test through the checks all adapters share
+// Commands run as Alice in Channel
+type Harness struct {
+	Platform string // Platform of the adapter, like models.PlatformSlack
+	Channel  string // Channel of the test chat on the platform
+	UserID   string // Alice on the platform
+
+	// Adds a task through the adapter
+	AddTask func(t *testing.T, name string)
+	// Starts a timer through the adapter, false if no timer message came back
+	StartTimer func(t *testing.T, minutes int, name string) bool
+	// Returns and forgets the messages posted in the channel
+	Posts func(t *testing.T) []Post
+}
+
+// Creates task staging and starts a 30 minute timer of Alice on it
+func (b *Backend) StartTimer(t *testing.T, h *Harness) *models.Task {
+	t.Helper()
+
+	h.AddTask(t, "staging")
+
+	if !h.StartTimer(t, 30, "staging") {
+		t.Fatal("Failed to start timer")
+	}
+
+	return b.Task(t, h.Platform, h.Channel, "staging")
+}
+
+// Checks that changes made outside the adapter are announced in the channel
+// and changes of other chats are not. expired and started are the texts
+// of Alice's expired timer and of her 15 minute timer started through the API
+func (b *Backend) CheckAnnouncements(t *testing.T, h *Harness, expired string, started string) {
+	t.Helper()
+
+	task := b.StartTimer(t, h)
+	h.Posts(t)
+
+	ctx := context.Background()
+	chatID := service.ExternalChatID(h.Platform, h.Channel)
+
+	// Бот закрывает истекший таймер, адаптер узнает об этом из события
+	b.Clock.Advance(30 * time.Minute)
+
+	if _, _, err := b.Service.ExpireTimer(ctx, chatID, task.ID); err != nil {
+		t.Fatalf("Failed to expire timer: %v", err)
+	}
+
+	posts := h.Posts(t)
+	if len(posts) != 1 || posts[0].Controls {
+		t.Fatalf("Expected one message in the channel, got %+v", posts)
+	}
+
+	if posts[0].Text != expired {
+		t.Errorf("Expected %q, got %q", expired, posts[0].Text)
+	}
+
+	// Таймер, запущенный через API, приходит с кнопками
+	if _, _, err := b.Service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   chatID,
+		TaskID:   task.ID,
+		UserID:   service.ExternalUserID(h.Platform, h.UserID),
+		Duration: 15,
+		Source:   service.SourceAPI,
+	}); err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	posts = h.Posts(t)
+	if len(posts) != 1 || !posts[0].Controls {
+		t.Fatalf("Expected a timer message with controls, got %+v", posts)
+	}
+
+	if posts[0].Text != started {
+		t.Errorf("Expected %q, got %q", started, posts[0].Text)
+	}
+
+	// События чатов Telegram и других платформ в канал не попадают
+	if _, err := b.Service.CreateTask(ctx, -1001234567890, "prod", "", service.SourceAPI); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	other := models.PlatformSlack
+	if h.Platform == other {
+		other = models.PlatformDiscord
+	}
+
+	otherChatID, err := b.Service.LinkChat(ctx, other, "other-channel")
+	if err != nil {
+		t.Fatalf("Failed to link chat: %v", err)
+	}
+
+	if _, err := b.Service.CreateTask(ctx, otherChatID, "prod", "", service.SourceAPI); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	if posts := h.Posts(t); len(posts) != 0 {
+		t.Errorf("Expected nothing posted for other chats, got %+v", posts)
+	}
+}
+
+// Checks that the handler only takes POST requests at path
+func CheckPostOnly(t *testing.T, handler http.Handler, path string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, bytes.NewReader(nil)))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package platformtest provides fixtures for tests of the chat platform adapters
+package platformtest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Time of the recorded requests: 2025-01-01 12:00 UTC
+var Start = time.Unix(1735732800, 0)
+
+// Storage and service an adapter under test works with, both on a fake clock
+type Backend struct {
+	Storage *redis.Storage
+	Service *service.Service
+	Clock   *clock.Fake
+}
+
+// Starts an in-memory Redis for the test and sets the clock to Start
+func NewBackend(t *testing.T) *Backend {
+	t.Helper()
+
+	miniRedis := miniredis.RunT(t)
+
+	store, err := redis.New(miniRedis.Addr(), "", 0)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	fakeClock := clock.NewFake(Start)
+	store.SetClock(fakeClock)
+
+	svc := service.New(store)
+	svc.SetClock(fakeClock)
+
+	return &Backend{Storage: store, Service: svc, Clock: fakeClock}
+}
+
+// Returns a task of the chat a platform channel is linked to
+func (b *Backend) Task(t *testing.T, platform string, channel string, name string) *models.Task {
+	t.Helper()
+
+	task, err := b.Storage.GetTaskByName(context.Background(), service.ExternalChatID(platform, channel), name)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+
+	return task
+}
+
+// Reads a recorded request from testdata and puts values in place of {{name}}
+func ReadPayload(t *testing.T, name string, values map[string]string) string {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+
+	payload := string(data)
+	for key, value := range values {
+		payload = strings.ReplaceAll(payload, "{{"+key+"}}", value)
+	}
+
+	return payload
+}
+
+// Keeps what a fake platform server received. Safe for concurrent use
+type Recorder[T any] struct {
+	mu    sync.Mutex
+	items []T
+}
+
+// Records an item
+func (r *Recorder[T]) Add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items = append(r.items, item)
+}
+
+// Returns and forgets the recorded items
+func (r *Recorder[T]) Take() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := r.items
+	r.items = nil
+
+	return items
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Requests signed longer ago than this are rejected as replays
+const MaxRequestAge = 5 * time.Minute
+
+// Returned for a request signed outside of MaxRequestAge
+var ErrStaleRequest = errors.New("request timestamp is too old")
+
+// Checks the Unix timestamp a platform signs a request with against the replay window
+func CheckTimestamp(timestamp string, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > MaxRequestAge || age < -MaxRequestAge {
+		return ErrStaleRequest
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package ratelimit limits request rates with token buckets
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tier of API keys without an explicit tier
+const DefaultTier = "default"
+
+// How often idle buckets are removed from memory
+const sweepInterval = time.Minute
+
+// Periods of limits by their unit
+var limitPeriods = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// Valid tier names
+var tierNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Represents a rate limit: a bucket of Requests tokens refilled evenly over Period
+// A client may burst up to Requests at once, then gets Requests per Period on average
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Reports whether the limit is set
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Returns the time needed to refill the given number of tokens
+func (l Limit) refill(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(l.Period) / float64(l.Requests)))
+}
+
+func (l Limit) String() string {
+	switch l.Period {
+	case time.Second:
+		return fmt.Sprintf("%d/s", l.Requests)
+	case time.Minute:
+		return fmt.Sprintf("%d/m", l.Requests)
+	case time.Hour:
+		return fmt.Sprintf("%d/h", l.Requests)
+	default:
+		return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+	}
+}
+
+// Parses a limit like "120/m": requests per second (s), minute (m) or hour (h)
+// "off" disables the limit
+func ParseLimit(value string) (Limit, error) {
+	if value == "off" {
+		return Limit{}, nil
+	}
+
+	requests, unit, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q, expected e.g. 120/m", value)
+	}
+
+	count, err := strconv.Atoi(requests)
+	if err != nil || count < 1 {
+		return Limit{}, fmt.Errorf("invalid number of requests in %q", value)
+	}
+
+	period, ok := limitPeriods[unit]
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid period in %q, expected s, m or h", value)
+	}
+
+	return Limit{Requests: count, Period: period}, nil
+}
+
+// Parses tier limits like "default=120/m,ci=600/m"
+func ParseTiers(value string) (map[string]Limit, error) {
+	tiers := make(map[string]Limit)
+
+	for _, item := range strings.Split(value, ",") {
+		name, limitStr, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || !IsValidTier(name) {
+			return nil, fmt.Errorf("invalid tier %q, expected name=limit", item)
+		}
+
+		limit, err := ParseLimit(limitStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier %q: %w", name, err)
+		}
+
+		tiers[name] = limit
+	}
+
+	return tiers, nil
+}
+
+// Parses the tiers granted to chats like "-1001234567890=ci,42=partner"
+func ParseTierChats(value string) (map[int64]string, error) {
+	chats := make(map[int64]string)
+
+	for _, item := range strings.Split(value, ",") {
+		chatStr, name, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || !IsValidTier(name) {
+			return nil, fmt.Errorf("invalid tier chat %q, expected chat_id=tier", item)
+		}
+
+		chatID, err := strconv.ParseInt(chatStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chat ID %q: %w", chatStr, err)
+		}
+
+		chats[chatID] = name
+	}
+
+	return chats, nil
+}
+
+// Reports whether the name can be used as a tier
+func IsValidTier(name string) bool {
+	return tierNamePattern.MatchString(name)
+}
+
+// Describes the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Limit      int           // Size of the bucket
+	Remaining  int           // Whole tokens left
+	RetryAfter time.Duration // Time until the next token, zero if allowed
+	Reset      time.Duration // Time until the bucket is full again
+}
+
+// Takes tokens from buckets identified by keys
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Builds the result of taking a token from a bucket that has tokens left after the attempt
+func result(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(tokens),
+		Reset:     limit.refill(float64(limit.Requests) - tokens),
+	}
+
+	if !allowed {
+		res.RetryAfter = limit.refill(1 - tokens)
+	}
+
+	return res
+}
+
+// Token bucket state
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// Refills the bucket up to now and takes a token if there is one
+func (b *bucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Requests), b.tokens+float64(elapsed)*float64(b.limit.Requests)/float64(b.limit.Period))
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// Keeps buckets in memory, limits are per process
+type MemoryLimiter struct {
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// Creates a new in-memory limiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Takes a token from the bucket of the key
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), updated: now, limit: limit}
+		l.buckets[key] = b
+	}
+
+	allowed := b.take(now)
+
+	return result(limit, b.tokens, allowed), nil
+}
+
+// Removes buckets that have refilled completely, they are the same as new ones
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= b.limit.Period {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Keeps token buckets somewhere shared by all replicas
+type Store interface {
+	// Refills the bucket of the key up to now, takes a token if there is one
+	// and returns whether it did and the tokens left
+	TakeRateLimitToken(ctx context.Context, key string, requests int, period time.Duration, now time.Time) (bool, float64, error)
+}
+
+// Returned by StoreLimiter when the store can't be reached
+var ErrStoreUnavailable = errors.New("rate limit store unavailable")
+
+// Keeps buckets in a shared store, so limits hold across replicas
+type StoreLimiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// Creates a new limiter backed by a store
+func NewStoreLimiter(store Store) *StoreLimiter {
+	return &StoreLimiter{store: store, now: time.Now}
+}
+
+// Takes a token from the bucket of the key
+func (l *StoreLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	allowed, tokens, err := l.store.TakeRateLimitToken(ctx, key, limit.Requests, limit.Period, l.now())
+	if err != nil {
+		if unreachable(err) {
+			return Result{}, fmt.Errorf("%w: %w", ErrStoreUnavailable, err)
+		}
+
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return result(limit, tokens, allowed), nil
+}
+
+// Reports whether the error means the store couldn't be reached at all,
+// rather than that it refused or failed the request
+func unreachable(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
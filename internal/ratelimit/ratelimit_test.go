@@ -0,0 +1,191 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	valid := map[string]Limit{
+		"120/m":  {Requests: 120, Period: time.Minute},
+		"5/s":    {Requests: 5, Period: time.Second},
+		"1000/h": {Requests: 1000, Period: time.Hour},
+		"off":    {},
+	}
+
+	for value, expected := range valid {
+		limit, err := ParseLimit(value)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", value, err)
+			continue
+		}
+
+		if limit != expected {
+			t.Errorf("Expected %+v for %q, got %+v", expected, value, limit)
+		}
+	}
+
+	for _, value := range []string{"", "120", "0/m", "-1/m", "x/m", "10/d"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+
+	if limit := (Limit{Requests: 120, Period: time.Minute}); limit.String() != "120/m" {
+		t.Errorf("Expected 120/m, got %s", limit)
+	}
+}
+
+func TestParseTiers(t *testing.T) {
+	tiers, err := ParseTiers("default=120/m, ci=600/m")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if tiers[DefaultTier] != (Limit{Requests: 120, Period: time.Minute}) || tiers["ci"] != (Limit{Requests: 600, Period: time.Minute}) {
+		t.Errorf("Unexpected tiers: %v", tiers)
+	}
+
+	for _, value := range []string{"default", "CI=10/m", "ci=10"} {
+		if _, err := ParseTiers(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestParseTierChats(t *testing.T) {
+	chats, err := ParseTierChats("-1001234567890=ci, 42=partner")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(chats) != 2 || chats[-1001234567890] != "ci" || chats[42] != "partner" {
+		t.Errorf("Unexpected tier chats: %v", chats)
+	}
+
+	for _, value := range []string{"42", "chat=ci", "42=CI", "42="} {
+		if _, err := ParseTierChats(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Period: time.Minute}
+
+	// Полный bucket пропускает всплеск до размера лимита
+	for i := range 2 {
+		res, err := limiter.Allow(ctx, "key", limit)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !res.Allowed || res.Remaining != 1-i || res.Limit != 2 {
+			t.Errorf("Request %d: unexpected result %+v", i, res)
+		}
+	}
+
+	res, err := limiter.Allow(ctx, "key", limit)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Один токен восстанавливается за 30 секунд, весь bucket за минуту
+	if res.Allowed || res.RetryAfter != 30*time.Second || res.Reset != time.Minute {
+		t.Errorf("Expected rejection with retry in 30s, got %+v", res)
+	}
+
+	if res, _ := limiter.Allow(ctx, "other", limit); !res.Allowed {
+		t.Error("Expected another key to have its own bucket")
+	}
+
+	now = now.Add(30 * time.Second)
+
+	if res, _ := limiter.Allow(ctx, "key", limit); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("Expected refilled token to be taken, got %+v", res)
+	}
+
+	// Полностью восстановленные buckets удаляются из памяти
+	now = now.Add(2 * time.Minute)
+
+	if _, err := limiter.Allow(ctx, "key", limit); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected idle buckets to be swept, got %d buckets", len(limiter.buckets))
+	}
+}
+
+type fakeStore struct {
+	allowed bool
+	tokens  float64
+	err     error
+}
+
+func (f *fakeStore) TakeRateLimitToken(ctx context.Context, key string, requests int, period time.Duration, now time.Time) (bool, float64, error) {
+	return f.allowed, f.tokens, f.err
+}
+
+func TestStoreLimiter(t *testing.T) {
+	limit := Limit{Requests: 10, Period: time.Minute}
+
+	res, err := NewStoreLimiter(&fakeStore{allowed: false, tokens: 0.5}).Allow(context.Background(), "key", limit)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Половина токена восстанавливается за 3 секунды
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 3*time.Second {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+}
+
+func TestStoreLimiterErrors(t *testing.T) {
+	limit := Limit{Requests: 10, Period: time.Minute}
+
+	tests := []struct {
+		name        string
+		err         error
+		unavailable bool
+	}{
+		{"Connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"Connection closed", io.EOF, true},
+		{"Timeout", context.DeadlineExceeded, true},
+		{"Script error", errors.New("ERR script failed"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStoreLimiter(&fakeStore{err: tt.err}).Allow(context.Background(), "key", limit)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+
+			if errors.Is(err, ErrStoreUnavailable) != tt.unavailable {
+				t.Errorf("Expected unavailable to be %v, got %v", tt.unavailable, err)
+			}
+		})
+	}
+}
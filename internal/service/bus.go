@@ -0,0 +1,108 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// Number of events a subscription holds before it is considered too slow
+const subscriptionBuffer = 64
+
+// Fans service events out to subscribers that read them at their own pace
+// Subscribed to the service as a listener, so everything the bot, the API
+// and expiring timers change passes through it
+type Bus struct {
+	subscriptions map[*Subscription]struct{}
+	mu            sync.Mutex
+}
+
+// Receives events of one chat from a bus
+type Subscription struct {
+	// Events of the chat. Closed when the subscriber falls behind or the subscription is closed
+	C <-chan *Event
+
+	ch     chan *Event
+	chatID int64
+	bus    *Bus
+	closed bool
+}
+
+// Creates a new event bus
+func NewBus() *Bus {
+	return &Bus{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribes to events of a chat
+// The subscription must be closed when it's no longer needed
+func (b *Bus) Subscribe(chatID int64) *Subscription {
+	ch := make(chan *Event, subscriptionBuffer)
+	sub := &Subscription{C: ch, ch: ch, chatID: chatID, bus: b}
+
+	b.mu.Lock()
+	b.subscriptions[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Delivers an event to the subscribers of its chat without waiting for them
+// A subscriber whose buffer is full is dropped: its channel is closed, so it can
+// start over from a fresh state instead of silently missing changes
+func (b *Bus) Publish(ctx context.Context, event *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscriptions {
+		if sub.chatID != event.ChatID {
+			continue
+		}
+
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Returns the number of open subscriptions
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscriptions)
+}
+
+// Removes a subscription and closes its channel, the caller holds the lock
+func (b *Bus) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+
+	sub.closed = true
+	delete(b.subscriptions, sub)
+	close(sub.ch)
+}
+
+// Stops receiving events
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.remove(s)
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"testing"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestBus(t *testing.T) {
+	ctx := context.Background()
+	event := func(chatID int64) *Event {
+		return &Event{Type: EventTimerStarted, ChatID: chatID, Task: &models.Task{ID: "t1"}}
+	}
+
+	t.Run("DeliversToChat", func(t *testing.T) {
+		bus := NewBus()
+		sub := bus.Subscribe(testChatID)
+		other := bus.Subscribe(42)
+
+		defer sub.Close()
+		defer other.Close()
+
+		bus.Publish(ctx, event(testChatID))
+
+		select {
+		case got := <-sub.C:
+			if got.ChatID != testChatID {
+				t.Errorf("Unexpected event: %+v", got)
+			}
+		default:
+			t.Fatal("Expected an event for the chat")
+		}
+
+		// Подписчик другого чата ничего не получает
+		select {
+		case got := <-other.C:
+			t.Errorf("Unexpected event for another chat: %+v", got)
+		default:
+		}
+	})
+
+	t.Run("DropsSlowSubscriber", func(t *testing.T) {
+		bus := NewBus()
+		sub := bus.Subscribe(testChatID)
+
+		for range subscriptionBuffer + 1 {
+			bus.Publish(ctx, event(testChatID))
+		}
+
+		if bus.Subscribers() != 0 {
+			t.Errorf("Expected slow subscriber to be dropped, got %d subscribers", bus.Subscribers())
+		}
+
+		// Буфер вычитывается, затем канал закрыт
+		received := 0
+		for range sub.C {
+			received++
+		}
+
+		if received != subscriptionBuffer {
+			t.Errorf("Expected %d buffered events, got %d", subscriptionBuffer, received)
+		}
+
+		// Повторное закрытие безопасно
+		sub.Close()
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		bus := NewBus()
+		sub := bus.Subscribe(testChatID)
+		sub.Close()
+
+		bus.Publish(ctx, event(testChatID))
+
+		if _, ok := <-sub.C; ok {
+			t.Error("Expected closed channel after Close")
+		}
+
+		if bus.Subscribers() != 0 {
+			t.Errorf("Expected no subscribers, got %d", bus.Subscribers())
+		}
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"time-guard-bot/internal/models"
+)
+
+// Chat IDs of other messengers are at or below this value. Telegram chat IDs
+// are far above it, and it keeps IDs within the 53 bits JSON clients read exactly
+const externalChatIDMax = -(1 << 52)
+
+// Maps a channel of another messenger to its chat ID
+func ExternalChatID(platform string, externalID string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(platform + "\x00" + externalID))
+
+	return externalChatIDMax - int64(h.Sum64()%(1<<52))
+}
+
+// Reports whether the chat belongs to another messenger than Telegram
+func IsExternalChat(chatID int64) bool {
+	return chatID <= externalChatIDMax
+}
+
+// Returns the chat ID of a channel of another messenger and remembers the
+// channel, so changes made elsewhere can be announced there
+func (s *Service) LinkChat(ctx context.Context, platform string, externalID string) (int64, error) {
+	chatID := ExternalChatID(platform, externalID)
+
+	link := &models.ChatLink{
+		ChatID:     chatID,
+		Platform:   platform,
+		ExternalID: externalID,
+		UpdatedAt:  s.clock.Now(),
+	}
+
+	if err := s.storage.SaveChatLink(ctx, link); err != nil {
+		return 0, fmt.Errorf("failed to link chat: %w", err)
+	}
+
+	return chatID, nil
+}
+
+// Maps a user of another messenger to its user ID
+// Like labels, they get stable negative IDs that never clash with Telegram users
+func ExternalUserID(platform string, externalID string) int64 {
+	return LabelUserID(platform + "\x00" + externalID)
+}
+
+// Returns the user ID of a user of another messenger and saves their names to
+// the user directory of the chat. The ID of user is filled in
+func (s *Service) ExternalUser(ctx context.Context, chatID int64, platform string, externalID string, user *models.ChatUser) (int64, error) {
+	user.ID = ExternalUserID(platform, externalID)
+	user.UpdatedAt = s.clock.Now()
+
+	if err := s.storage.SaveChatUser(ctx, chatID, user); err != nil {
+		return 0, fmt.Errorf("failed to save user: %w", err)
+	}
+
+	return user.ID, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"slices"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+// Kind of change made through the service
+type EventType string
+
+// Event types
+const (
+	EventTimerStarted   EventType = "timer.started"
+	EventTimerExtended  EventType = "timer.extended"
+	EventTimerPaused    EventType = "timer.paused"
+	EventTimerResumed   EventType = "timer.resumed"
+	EventTimerDone      EventType = "timer.done"
+	EventTimerCancelled EventType = "timer.cancelled"
+	EventTimerExpiring  EventType = "timer.expiring"
+	EventTimerExpired   EventType = "timer.expired"
+	EventTaskCreated    EventType = "task.created"
+	EventTaskUpdated    EventType = "task.updated"
+	EventTaskDeleted    EventType = "task.deleted"
+	EventTaskLocked     EventType = "task.locked"
+	EventTaskUnlocked   EventType = "task.unlocked"
+	EventLeaseAcquired  EventType = "lease.acquired"
+	EventLeaseRenewed   EventType = "lease.renewed"
+	EventLeaseReleased  EventType = "lease.released"
+)
+
+// All event types, in the order they are listed to users
+var EventTypes = []EventType{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskDeleted,
+	EventTaskLocked,
+	EventTaskUnlocked,
+	EventTimerStarted,
+	EventTimerExtended,
+	EventTimerPaused,
+	EventTimerResumed,
+	EventTimerDone,
+	EventTimerCancelled,
+	EventTimerExpiring,
+	EventTimerExpired,
+	EventLeaseAcquired,
+	EventLeaseRenewed,
+	EventLeaseReleased,
+}
+
+// Reports whether the name is a known event type
+func IsEventType(name string) bool {
+	return slices.Contains(EventTypes, EventType(name))
+}
+
+// Where a change came from
+type Source string
+
+// Event sources
+const (
+	SourceTelegram Source = "telegram"
+	SourceAPI      Source = "api"
+	SourceSlack    Source = "slack"
+	SourceDiscord  Source = "discord"
+	SourceMatrix   Source = "matrix"
+	SourceTimer    Source = "timer" // A timer or lease ran out
+)
+
+// Describes a change made through the service
+type Event struct {
+	Type       EventType
+	Source     Source
+	ChatID     int64
+	Task       *models.Task       // Task after the change
+	ActiveTask *models.ActiveTask // Timer after the change, nil for task events
+	Minutes    int                // Extension in minutes for EventTimerExtended
+	Time       time.Time          // When the change happened
+}
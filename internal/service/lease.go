@@ -0,0 +1,149 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+)
+
+// Parameters of a new lease
+type AcquireLeaseRequest struct {
+	ChatID int64
+	TaskID string
+	Holder string // Who takes the task, e.g. "pipeline #1234"
+	TTL    int    // Seconds the lease lives without a heartbeat, helpers.DefaultLeaseTTL if zero
+	Source Source
+}
+
+// Takes a task with a lease that expires unless renewed
+// Returns the task, its active task and the lease token required to renew and release the lease
+func (s *Service) AcquireLease(ctx context.Context, req *AcquireLeaseRequest) (*models.Task, *models.ActiveTask, string, error) {
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = helpers.DefaultLeaseTTL
+	}
+
+	if ttl < helpers.MinLeaseTTL || ttl > helpers.MaxLeaseTTL {
+		return nil, nil, "", ErrInvalidLeaseTTL
+	}
+
+	if err := helpers.ValidateHolder(req.Holder); err != nil {
+		return nil, nil, "", fmt.Errorf("%w: %s", ErrInvalidHolder, err.Error())
+	}
+
+	userID, err := s.LabelUser(ctx, req.ChatID, helpers.LeaseHolderPrefix+req.Holder)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	token, err := helpers.GenerateLeaseToken()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	startTime := s.clock.Now()
+	endTime := startTime.Add(time.Duration(ttl) * time.Second)
+
+	activeTask := &models.ActiveTask{
+		TaskID:    req.TaskID,
+		UserID:    userID,
+		ChatID:    req.ChatID,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Duration:  (ttl + 59) / 60,
+		Lease: &models.Lease{
+			TokenHash: helpers.HashLeaseToken(token),
+			TTL:       ttl,
+			RenewedAt: startTime,
+		},
+	}
+
+	task, activeTask, err := s.startTimer(ctx, activeTask, req.Source, EventLeaseAcquired)
+	if err != nil {
+		return task, nil, "", err
+	}
+
+	return task, activeTask, token, nil
+}
+
+// Extends a lease by its TTL from now
+func (s *Service) RenewLease(ctx context.Context, chatID int64, taskID string, token string, source Source) (*models.Task, *models.ActiveTask, error) {
+	task, activeTask, err := s.updateTimer(ctx, chatID, taskID, source, EventLeaseRenewed, 0, func(activeTask *models.ActiveTask) error {
+		if !holdsLease(activeTask, token) {
+			return ErrLeaseLost
+		}
+
+		activeTask.Renew(s.clock.Now())
+
+		return nil
+	})
+
+	// The lease has expired and the task was freed
+	if errors.Is(err, ErrTaskNotActive) {
+		return task, nil, ErrLeaseLost
+	}
+
+	return task, activeTask, err
+}
+
+// Ends a lease and frees the task
+func (s *Service) ReleaseLease(ctx context.Context, chatID int64, taskID string, token string, source Source) (*models.Task, *models.ActiveTask, error) {
+	task, activeTask, err := s.getTimer(ctx, chatID, taskID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotActive) {
+			return task, nil, ErrLeaseLost
+		}
+
+		return task, nil, err
+	}
+
+	if !holdsLease(activeTask, token) {
+		return task, activeTask, ErrLeaseLost
+	}
+
+	if err := s.storage.EndTask(ctx, chatID, taskID); err != nil {
+		return task, activeTask, fmt.Errorf("failed to end task: %w", err)
+	}
+
+	task.OwnerID = 0
+
+	s.emit(ctx, &Event{
+		Type:       EventLeaseReleased,
+		Source:     source,
+		ChatID:     chatID,
+		Task:       task,
+		ActiveTask: activeTask,
+		Time:       s.clock.Now(),
+	})
+
+	return task, activeTask, nil
+}
+
+// Reports whether the token is the one of the lease holding the task
+func holdsLease(activeTask *models.ActiveTask, token string) bool {
+	if !activeTask.IsLeased() {
+		return false
+	}
+
+	hash := helpers.HashLeaseToken(token)
+
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(activeTask.Lease.TokenHash)) == 1
+}
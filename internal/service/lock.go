@@ -0,0 +1,83 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"time-guard-bot/internal/models"
+)
+
+// Locks a task, preventing it from being started
+func (s *Service) LockTask(ctx context.Context, chatID int64, taskID string, reason string, source Source) (*models.Task, error) {
+	task, err := s.storage.GetTask(ctx, chatID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if task.IsLocked {
+		return task, ErrAlreadyLocked
+	}
+
+	if task.OwnerID != 0 {
+		return task, ErrTaskInUse
+	}
+
+	task.IsLocked = true
+	task.LockReason = reason
+
+	if err := s.storage.UpdateTask(ctx, task); err != nil {
+		return task, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	s.emit(ctx, &Event{
+		Type:   EventTaskLocked,
+		Source: source,
+		ChatID: chatID,
+		Task:   task,
+		Time:   s.clock.Now(),
+	})
+
+	return task, nil
+}
+
+// Unlocks a previously locked task
+func (s *Service) UnlockTask(ctx context.Context, chatID int64, taskID string, source Source) (*models.Task, error) {
+	task, err := s.storage.GetTask(ctx, chatID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if !task.IsLocked {
+		return task, ErrNotLocked
+	}
+
+	task.IsLocked = false
+	task.LockReason = ""
+
+	if err := s.storage.UpdateTask(ctx, task); err != nil {
+		return task, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	s.emit(ctx, &Event{
+		Type:   EventTaskUnlocked,
+		Source: source,
+		ChatID: chatID,
+		Task:   task,
+		Time:   s.clock.Now(),
+	})
+
+	return task, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/storage"
+)
+
+// Rule violations returned by the service
+var (
+	ErrTaskLocked      = errors.New("task is locked")
+	ErrTaskBusy        = errors.New("another user is currently working on the task")
+	ErrAlreadyHolding  = errors.New("user is already working on this task")
+	ErrTooManyTasks    = errors.New("user has reached the maximum number of active tasks")
+	ErrInvalidDuration = errors.New("invalid duration")
+	ErrDurationLimit   = errors.New("duration exceeds maximum allowed limit")
+	ErrTaskNotActive   = errors.New("task has no active timer")
+	ErrTimerPaused     = errors.New("timer is already paused")
+	ErrTimerNotPaused  = errors.New("timer is not paused")
+	ErrAlreadyLocked   = errors.New("task is already locked")
+	ErrNotLocked       = errors.New("task is not locked")
+	ErrTaskInUse       = errors.New("task is currently in use")
+	ErrInvalidTaskName = errors.New("invalid task name")
+	ErrTaskLimit       = errors.New("maximum number of tasks per chat reached")
+	ErrTaskNameTaken   = errors.New("a task with this name already exists")
+	ErrInvalidLeaseTTL = errors.New("invalid lease TTL")
+	ErrInvalidHolder   = errors.New("invalid lease holder")
+	ErrTaskLeased      = errors.New("task is held by a lease")
+	ErrLeaseLost       = errors.New("lease is no longer held")
+	ErrTimerRunning    = errors.New("timer has time remaining")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+	ErrWebhookLimit    = errors.New("maximum number of webhooks per chat reached")
+)
+
+// Describes why a task name is rejected
+type InvalidNameError struct {
+	Reason error // Validation error from helpers.ValidateTaskName
+}
+
+func (e *InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid task name: %s", e.Reason)
+}
+
+func (e *InvalidNameError) Unwrap() error {
+	return ErrInvalidTaskName
+}
+
+// Receives events about changes made through the service
+type Listener func(ctx context.Context, event *Event)
+
+// Implements task rules shared by the bot and the API
+// Every change is announced to the subscribed listeners
+type Service struct {
+	storage storage.Storage
+	clock   clock.Clock
+
+	listeners   []Listener
+	listenersMx sync.RWMutex
+}
+
+// Creates a new service
+func New(storage storage.Storage) *Service {
+	return &Service{
+		storage: storage,
+		clock:   clock.New(),
+	}
+}
+
+// Replaces the clock timers are measured with, for tests. Call before any other method
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Returns the clock timers are measured with
+func (s *Service) Clock() clock.Clock {
+	return s.clock
+}
+
+// Adds a listener of service events
+func (s *Service) Subscribe(listener Listener) {
+	s.listenersMx.Lock()
+	defer s.listenersMx.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+// Delivers an event to all listeners
+func (s *Service) emit(ctx context.Context, event *Event) {
+	s.listenersMx.RLock()
+	listeners := s.listeners
+	s.listenersMx.RUnlock()
+
+	for _, listener := range listeners {
+		listener(ctx, event)
+	}
+}
@@ -0,0 +1,798 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const testChatID = int64(-100123)
+
+func setupService(t *testing.T) (*Service, *redis.Storage, *[]*Event) {
+	t.Helper()
+
+	miniRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to create miniredis: %v", err)
+	}
+
+	t.Cleanup(miniRedis.Close)
+
+	storage, err := redis.New(miniRedis.Addr(), "", 0)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	ctx := context.Background()
+
+	for _, task := range []*models.Task{
+		{ID: "t1", Name: "deploy", ChatID: testChatID},
+		{ID: "t2", Name: "db", ChatID: testChatID},
+	} {
+		if err := storage.AddTask(ctx, task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+
+	svc := New(storage)
+
+	// Собираем все события сервиса
+	events := &[]*Event{}
+	svc.Subscribe(func(ctx context.Context, event *Event) {
+		*events = append(*events, event)
+	})
+
+	return svc, storage, events
+}
+
+func startRequest(taskID string, userID int64, duration int) *StartTimerRequest {
+	return &StartTimerRequest{
+		ChatID:   testChatID,
+		TaskID:   taskID,
+		UserID:   userID,
+		Duration: duration,
+		Source:   SourceAPI,
+	}
+}
+
+func TestStartTimer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		svc, storage, events := setupService(t)
+
+		task, activeTask, err := svc.StartTimer(ctx, startRequest("t1", 1, 30))
+		if err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		if task.OwnerID != 1 || activeTask.Duration != 30 {
+			t.Errorf("Unexpected task %+v / active task %+v", task, activeTask)
+		}
+
+		if _, err := storage.GetActiveTask(ctx, testChatID, "t1"); err != nil {
+			t.Errorf("Expected active task in storage, got: %v", err)
+		}
+
+		if len(*events) != 1 || (*events)[0].Type != EventTimerStarted || (*events)[0].Source != SourceAPI {
+			t.Errorf("Expected one timer.started event from api, got %v", *events)
+		}
+	})
+
+	t.Run("InvalidDuration", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 0)); !errors.Is(err, ErrInvalidDuration) {
+			t.Errorf("Expected ErrInvalidDuration, got: %v", err)
+		}
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, helpers.MaxTaskDuration+1)); !errors.Is(err, ErrDurationLimit) {
+			t.Errorf("Expected ErrDurationLimit, got: %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("missing", 1, 30)); !errors.Is(err, redis.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		svc, _, events := setupService(t)
+
+		if _, err := svc.LockTask(ctx, testChatID, "t1", "maintenance", SourceTelegram); err != nil {
+			t.Fatalf("Failed to lock task: %v", err)
+		}
+
+		task, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30))
+		if !errors.Is(err, ErrTaskLocked) {
+			t.Errorf("Expected ErrTaskLocked, got: %v", err)
+		}
+
+		// Задача возвращается вместе с ошибкой, чтобы показать причину блокировки
+		if task == nil || task.LockReason != "maintenance" {
+			t.Errorf("Expected locked task with reason, got %+v", task)
+		}
+
+		if len(*events) != 1 {
+			t.Errorf("Expected only the lock event, got %d events", len(*events))
+		}
+	})
+
+	t.Run("BusyAndAlreadyHolding", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); !errors.Is(err, ErrAlreadyHolding) {
+			t.Errorf("Expected ErrAlreadyHolding, got: %v", err)
+		}
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 2, 30)); !errors.Is(err, ErrTaskBusy) {
+			t.Errorf("Expected ErrTaskBusy, got: %v", err)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		svc, storage, _ := setupService(t)
+
+		for _, taskID := range []string{"t1", "t2"} {
+			if err := storage.AddTaskToConflictGroup(ctx, testChatID, "env", taskID); err != nil {
+				t.Fatalf("Failed to add task to conflict group: %v", err)
+			}
+		}
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		var conflictErr *redis.ConflictError
+		if _, _, err := svc.StartTimer(ctx, startRequest("t2", 2, 30)); !errors.As(err, &conflictErr) {
+			t.Errorf("Expected ConflictError, got: %v", err)
+		}
+	})
+
+	t.Run("TooManyTasks", func(t *testing.T) {
+		svc, storage, _ := setupService(t)
+
+		for i := range helpers.MaxTasksPerUser {
+			task := &models.Task{ID: string(rune('a' + i)), Name: string(rune('a' + i)), ChatID: testChatID}
+			if err := storage.AddTask(ctx, task); err != nil {
+				t.Fatalf("Failed to add task: %v", err)
+			}
+
+			if _, _, err := svc.StartTimer(ctx, startRequest(task.ID, 1, 30)); err != nil {
+				t.Fatalf("Failed to start timer: %v", err)
+			}
+		}
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); !errors.Is(err, ErrTooManyTasks) {
+			t.Errorf("Expected ErrTooManyTasks, got: %v", err)
+		}
+	})
+}
+
+func TestTimerControl(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("NotActive", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, _, err := svc.ExtendTimer(ctx, testChatID, "t1", 10, SourceAPI); !errors.Is(err, ErrTaskNotActive) {
+			t.Errorf("Expected ErrTaskNotActive on extend, got: %v", err)
+		}
+
+		if _, _, err := svc.ReleaseTimer(ctx, testChatID, "t1", true, SourceAPI); !errors.Is(err, ErrTaskNotActive) {
+			t.Errorf("Expected ErrTaskNotActive on release, got: %v", err)
+		}
+	})
+
+	t.Run("ExtendPauseResumeRelease", func(t *testing.T) {
+		svc, storage, events := setupService(t)
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		_, activeTask, err := svc.ExtendTimer(ctx, testChatID, "t1", 15, SourceAPI)
+		if err != nil {
+			t.Fatalf("Failed to extend timer: %v", err)
+		}
+
+		if activeTask.Duration != 45 {
+			t.Errorf("Expected duration 45, got %d", activeTask.Duration)
+		}
+
+		if _, _, err := svc.ExtendTimer(ctx, testChatID, "t1", helpers.MaxTaskDuration, SourceAPI); !errors.Is(err, ErrDurationLimit) {
+			t.Errorf("Expected ErrDurationLimit, got: %v", err)
+		}
+
+		if _, _, err := svc.PauseTimer(ctx, testChatID, "t1", SourceTelegram); err != nil {
+			t.Fatalf("Failed to pause timer: %v", err)
+		}
+
+		if _, _, err := svc.PauseTimer(ctx, testChatID, "t1", SourceTelegram); !errors.Is(err, ErrTimerPaused) {
+			t.Errorf("Expected ErrTimerPaused, got: %v", err)
+		}
+
+		if _, _, err := svc.ResumeTimer(ctx, testChatID, "t1", SourceTelegram); err != nil {
+			t.Fatalf("Failed to resume timer: %v", err)
+		}
+
+		if _, _, err := svc.ResumeTimer(ctx, testChatID, "t1", SourceTelegram); !errors.Is(err, ErrTimerNotPaused) {
+			t.Errorf("Expected ErrTimerNotPaused, got: %v", err)
+		}
+
+		task, _, err := svc.ReleaseTimer(ctx, testChatID, "t1", false, SourceAPI)
+		if err != nil {
+			t.Fatalf("Failed to release timer: %v", err)
+		}
+
+		if task.OwnerID != 0 {
+			t.Errorf("Expected released task to have no owner, got %d", task.OwnerID)
+		}
+
+		if _, err := storage.GetActiveTask(ctx, testChatID, "t1"); !errors.Is(err, redis.ErrNotFound) {
+			t.Errorf("Expected no active task after release, got: %v", err)
+		}
+
+		expected := []EventType{EventTimerStarted, EventTimerExtended, EventTimerPaused, EventTimerResumed, EventTimerCancelled}
+		if len(*events) != len(expected) {
+			t.Fatalf("Expected %d events, got %d", len(expected), len(*events))
+		}
+
+		for i, eventType := range expected {
+			if (*events)[i].Type != eventType {
+				t.Errorf("Expected event %d to be %s, got %s", i, eventType, (*events)[i].Type)
+			}
+		}
+	})
+}
+
+func TestAddTimerNote(t *testing.T) {
+	ctx := context.Background()
+	svc, storage, _ := setupService(t)
+
+	_, activeTask, err := svc.StartTimer(ctx, startRequest("t1", 1, 30))
+	if err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	// Сессия хранит только последние заметки
+	for i := 0; i < helpers.MaxNotesPerSession+5; i++ {
+		if err := svc.AddTimerNote(ctx, activeTask, models.Note{UserID: 1, Text: fmt.Sprintf("note %d", i)}); err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+	}
+
+	stored, err := storage.GetActiveTask(ctx, testChatID, "t1")
+	if err != nil {
+		t.Fatalf("Failed to get active task: %v", err)
+	}
+
+	if len(stored.Notes) != helpers.MaxNotesPerSession {
+		t.Fatalf("Expected %d notes, got %d", helpers.MaxNotesPerSession, len(stored.Notes))
+	}
+
+	if first, last := stored.Notes[0].Text, stored.Notes[len(stored.Notes)-1].Text; first != "note 5" || last != fmt.Sprintf("note %d", helpers.MaxNotesPerSession+4) {
+		t.Errorf("Expected the oldest notes dropped, got %q ... %q", first, last)
+	}
+}
+
+func TestWarnTimer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Timer", func(t *testing.T) {
+		svc, _, events := setupService(t)
+
+		fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+		svc.SetClock(fakeClock)
+
+		if _, _, err := svc.WarnTimer(ctx, testChatID, "t1"); !errors.Is(err, ErrTaskNotActive) {
+			t.Errorf("Expected ErrTaskNotActive, got: %v", err)
+		}
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		// До конца ещё больше TimerWarningLead, например таймер продлили
+		if _, _, err := svc.WarnTimer(ctx, testChatID, "t1"); !errors.Is(err, ErrTimerRunning) {
+			t.Errorf("Expected ErrTimerRunning, got: %v", err)
+		}
+
+		fakeClock.Advance(30*time.Minute - TimerWarningLead)
+
+		if _, _, err := svc.WarnTimer(ctx, testChatID, "t1"); err != nil {
+			t.Fatalf("Failed to warn timer: %v", err)
+		}
+
+		last := (*events)[len(*events)-1]
+		if last.Type != EventTimerExpiring || last.Source != SourceTimer || last.ActiveTask.UserID != 1 {
+			t.Errorf("Expected timer.expiring from timer for user 1, got %s from %s", last.Type, last.Source)
+		}
+
+		if _, _, err := svc.PauseTimer(ctx, testChatID, "t1", SourceAPI); err != nil {
+			t.Fatalf("Failed to pause timer: %v", err)
+		}
+
+		if _, _, err := svc.WarnTimer(ctx, testChatID, "t1"); !errors.Is(err, ErrTimerPaused) {
+			t.Errorf("Expected ErrTimerPaused, got: %v", err)
+		}
+	})
+
+	t.Run("Lease", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		_, _, _, err := svc.AcquireLease(ctx, &AcquireLeaseRequest{
+			ChatID: testChatID,
+			TaskID: "t1",
+			Holder: "pipeline #1234",
+			TTL:    60,
+			Source: SourceAPI,
+		})
+		if err != nil {
+			t.Fatalf("Failed to acquire lease: %v", err)
+		}
+
+		if _, _, err := svc.WarnTimer(ctx, testChatID, "t1"); !errors.Is(err, ErrTaskLeased) {
+			t.Errorf("Expected ErrTaskLeased, got: %v", err)
+		}
+	})
+}
+
+func TestExpirePausedTimer(t *testing.T) {
+	ctx := context.Background()
+	svc, storage, events := setupService(t)
+
+	fakeClock := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	svc.SetClock(fakeClock)
+	storage.SetClock(fakeClock)
+
+	if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+
+	task, _, err := svc.PauseTimer(ctx, testChatID, "t1", SourceAPI)
+	if err != nil {
+		t.Fatalf("Failed to pause timer: %v", err)
+	}
+
+	// Остаток задачи не убывает, пока таймер на паузе
+	fakeClock.Advance(models.MaxPause - time.Second)
+
+	if remaining := task.RemainingAt(fakeClock.Now()); remaining != 1800 {
+		t.Errorf("Expected 1800 seconds remaining on pause, got %d", remaining)
+	}
+
+	if _, _, err := svc.ExpireTimer(ctx, testChatID, "t1"); !errors.Is(err, ErrTimerPaused) {
+		t.Errorf("Expected ErrTimerPaused before the pause ends, got: %v", err)
+	}
+
+	fakeClock.Advance(time.Second)
+
+	if _, _, err := svc.ExpireTimer(ctx, testChatID, "t1"); err != nil {
+		t.Fatalf("Failed to expire paused timer: %v", err)
+	}
+
+	if _, err := storage.GetActiveTask(ctx, testChatID, "t1"); !errors.Is(err, redis.ErrNotFound) {
+		t.Errorf("Expected no active task after the pause expired, got: %v", err)
+	}
+
+	last := (*events)[len(*events)-1]
+	if last.Type != EventTimerExpired || !last.ActiveTask.IsPaused() {
+		t.Errorf("Expected timer.expired of a paused timer, got %s", last.Type)
+	}
+}
+
+func TestLockTask(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("LockAndUnlock", func(t *testing.T) {
+		svc, storage, _ := setupService(t)
+
+		if _, err := svc.LockTask(ctx, testChatID, "t1", "maintenance", SourceAPI); err != nil {
+			t.Fatalf("Failed to lock task: %v", err)
+		}
+
+		if _, err := svc.LockTask(ctx, testChatID, "t1", "", SourceAPI); !errors.Is(err, ErrAlreadyLocked) {
+			t.Errorf("Expected ErrAlreadyLocked, got: %v", err)
+		}
+
+		if _, err := svc.UnlockTask(ctx, testChatID, "t1", SourceAPI); err != nil {
+			t.Fatalf("Failed to unlock task: %v", err)
+		}
+
+		task, err := storage.GetTask(ctx, testChatID, "t1")
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+
+		if task.IsLocked || task.LockReason != "" {
+			t.Errorf("Expected unlocked task, got %+v", task)
+		}
+
+		if _, err := svc.UnlockTask(ctx, testChatID, "t1", SourceAPI); !errors.Is(err, ErrNotLocked) {
+			t.Errorf("Expected ErrNotLocked, got: %v", err)
+		}
+	})
+
+	t.Run("InUse", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		if _, err := svc.LockTask(ctx, testChatID, "t1", "", SourceAPI); !errors.Is(err, ErrTaskInUse) {
+			t.Errorf("Expected ErrTaskInUse, got: %v", err)
+		}
+	})
+}
+
+func TestTaskCRUD(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateUpdateDelete", func(t *testing.T) {
+		svc, storage, events := setupService(t)
+
+		task, err := svc.CreateTask(ctx, testChatID, "staging", "Staging env", SourceAPI)
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		if len(task.ID) != helpers.TaskIDLength {
+			t.Errorf("Expected ID of length %d, got %q", helpers.TaskIDLength, task.ID)
+		}
+
+		name := "staging-eu"
+
+		if _, err := svc.UpdateTask(ctx, testChatID, task.ID, TaskUpdate{Name: &name}, SourceAPI); err != nil {
+			t.Fatalf("Failed to update task: %v", err)
+		}
+
+		// Описание не задано в обновлении и должно сохраниться
+		stored, err := storage.GetTaskByName(ctx, testChatID, "staging-eu")
+		if err != nil {
+			t.Fatalf("Failed to get renamed task: %v", err)
+		}
+
+		if stored.ID != task.ID || stored.Description != "Staging env" {
+			t.Errorf("Unexpected task after update: %+v", stored)
+		}
+
+		if _, err := svc.DeleteTask(ctx, testChatID, task.ID, SourceAPI); err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		if _, err := storage.GetTask(ctx, testChatID, task.ID); !errors.Is(err, redis.ErrNotFound) {
+			t.Errorf("Expected deleted task, got: %v", err)
+		}
+
+		want := []EventType{EventTaskCreated, EventTaskUpdated, EventTaskDeleted}
+		if len(*events) != len(want) {
+			t.Fatalf("Expected %d events, got %d", len(want), len(*events))
+		}
+
+		for i, event := range *events {
+			if event.Type != want[i] {
+				t.Errorf("Expected event %s, got %s", want[i], event.Type)
+			}
+		}
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		_, err := svc.CreateTask(ctx, testChatID, "bad name!", "", SourceAPI)
+
+		var nameErr *InvalidNameError
+		if !errors.As(err, &nameErr) || !errors.Is(err, ErrInvalidTaskName) {
+			t.Errorf("Expected InvalidNameError, got: %v", err)
+		}
+	})
+
+	t.Run("NameTaken", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, err := svc.CreateTask(ctx, testChatID, "deploy", "", SourceAPI); !errors.Is(err, ErrTaskNameTaken) {
+			t.Errorf("Expected ErrTaskNameTaken on create, got: %v", err)
+		}
+
+		name := "db"
+		if _, err := svc.UpdateTask(ctx, testChatID, "t1", TaskUpdate{Name: &name}, SourceAPI); !errors.Is(err, ErrTaskNameTaken) {
+			t.Errorf("Expected ErrTaskNameTaken on rename, got: %v", err)
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		// В чате уже есть две задачи
+		for i := 2; i < helpers.MaxTasksPerChat; i++ {
+			if _, err := svc.CreateTask(ctx, testChatID, fmt.Sprintf("env%d", i), "", SourceAPI); err != nil {
+				t.Fatalf("Failed to create task %d: %v", i, err)
+			}
+		}
+
+		if _, err := svc.CreateTask(ctx, testChatID, "extra", "", SourceAPI); !errors.Is(err, ErrTaskLimit) {
+			t.Errorf("Expected ErrTaskLimit, got: %v", err)
+		}
+	})
+
+	t.Run("DeleteInUse", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		if _, err := svc.DeleteTask(ctx, testChatID, "t1", SourceAPI); !errors.Is(err, ErrTaskInUse) {
+			t.Errorf("Expected ErrTaskInUse, got: %v", err)
+		}
+	})
+}
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+
+	acquire := func(svc *Service, taskID string, ttl int) (*models.ActiveTask, string) {
+		t.Helper()
+
+		_, activeTask, token, err := svc.AcquireLease(ctx, &AcquireLeaseRequest{
+			ChatID: testChatID,
+			TaskID: taskID,
+			Holder: "pipeline #1234",
+			TTL:    ttl,
+			Source: SourceAPI,
+		})
+		if err != nil {
+			t.Fatalf("Failed to acquire lease: %v", err)
+		}
+
+		return activeTask, token
+	}
+
+	t.Run("AcquireRenewRelease", func(t *testing.T) {
+		svc, storage, events := setupService(t)
+
+		activeTask, token := acquire(svc, "t1", 60)
+
+		if !activeTask.IsLeased() || activeTask.Lease.TTL != 60 {
+			t.Fatalf("Expected lease with TTL 60, got %+v", activeTask.Lease)
+		}
+
+		// Держатель аренды показывается как "CI: ..."
+		user, err := storage.GetChatUser(ctx, testChatID, activeTask.UserID)
+		if err != nil {
+			t.Fatalf("Failed to get holder: %v", err)
+		}
+
+		if user.DisplayName() != "CI: pipeline #1234" {
+			t.Errorf("Expected holder 'CI: pipeline #1234', got %q", user.DisplayName())
+		}
+
+		if _, _, err := svc.RenewLease(ctx, testChatID, "t1", "tgl_wrong", SourceAPI); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost for wrong token, got: %v", err)
+		}
+
+		if _, _, err := svc.RenewLease(ctx, testChatID, "t1", token, SourceAPI); err != nil {
+			t.Fatalf("Failed to renew lease: %v", err)
+		}
+
+		if _, _, err := svc.ExtendTimer(ctx, testChatID, "t1", 10, SourceAPI); !errors.Is(err, ErrTaskLeased) {
+			t.Errorf("Expected ErrTaskLeased on extend, got: %v", err)
+		}
+
+		// Без токена аренду не освободить
+		if _, _, err := svc.ReleaseTimer(ctx, testChatID, "t1", false, SourceAPI); !errors.Is(err, ErrTaskLeased) {
+			t.Errorf("Expected ErrTaskLeased on timer release, got: %v", err)
+		}
+
+		if _, _, err := svc.ReleaseLease(ctx, testChatID, "t1", token, SourceAPI); err != nil {
+			t.Fatalf("Failed to release lease: %v", err)
+		}
+
+		// После освобождения токен больше не действует
+		if _, _, err := svc.RenewLease(ctx, testChatID, "t1", token, SourceAPI); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost after release, got: %v", err)
+		}
+
+		want := []EventType{EventLeaseAcquired, EventLeaseRenewed, EventLeaseReleased}
+		if len(*events) != len(want) {
+			t.Fatalf("Expected %d events, got %d", len(want), len(*events))
+		}
+
+		for i, event := range *events {
+			if event.Type != want[i] {
+				t.Errorf("Expected event %s, got %s", want[i], event.Type)
+			}
+		}
+	})
+
+	t.Run("DefaultTTL", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		activeTask, _ := acquire(svc, "t1", 0)
+
+		if activeTask.Lease.TTL != helpers.DefaultLeaseTTL {
+			t.Errorf("Expected default TTL %d, got %d", helpers.DefaultLeaseTTL, activeTask.Lease.TTL)
+		}
+	})
+
+	t.Run("InvalidRequest", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		req := &AcquireLeaseRequest{ChatID: testChatID, TaskID: "t1", Holder: "job", TTL: helpers.MaxLeaseTTL + 1}
+		if _, _, _, err := svc.AcquireLease(ctx, req); !errors.Is(err, ErrInvalidLeaseTTL) {
+			t.Errorf("Expected ErrInvalidLeaseTTL, got: %v", err)
+		}
+
+		req = &AcquireLeaseRequest{ChatID: testChatID, TaskID: "t1", Holder: "*job*"}
+		if _, _, _, err := svc.AcquireLease(ctx, req); !errors.Is(err, ErrInvalidHolder) {
+			t.Errorf("Expected ErrInvalidHolder, got: %v", err)
+		}
+	})
+
+	t.Run("Busy", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, _, err := svc.StartTimer(ctx, startRequest("t1", 1, 30)); err != nil {
+			t.Fatalf("Failed to start timer: %v", err)
+		}
+
+		req := &AcquireLeaseRequest{ChatID: testChatID, TaskID: "t1", Holder: "job"}
+		if _, _, _, err := svc.AcquireLease(ctx, req); !errors.Is(err, ErrTaskBusy) {
+			t.Errorf("Expected ErrTaskBusy, got: %v", err)
+		}
+	})
+}
+
+func TestLabelUser(t *testing.T) {
+	svc, storage, _ := setupService(t)
+	ctx := context.Background()
+
+	userID, err := svc.LabelUser(ctx, testChatID, "ci-staging")
+	if err != nil {
+		t.Fatalf("Failed to label user: %v", err)
+	}
+
+	// Метки не пересекаются с пользователями Telegram
+	if userID >= 0 {
+		t.Errorf("Expected negative user ID, got %d", userID)
+	}
+
+	if userID != LabelUserID("ci-staging") || userID == LabelUserID("ci-prod") {
+		t.Error("Expected stable and distinct IDs for labels")
+	}
+
+	user, err := storage.GetChatUser(ctx, testChatID, userID)
+	if err != nil {
+		t.Fatalf("Failed to get label user: %v", err)
+	}
+
+	if user.DisplayName() != "ci-staging" {
+		t.Errorf("Expected display name 'ci-staging', got %q", user.DisplayName())
+	}
+}
+
+func TestLinkChat(t *testing.T) {
+	svc, storage, _ := setupService(t)
+	ctx := context.Background()
+
+	chatID, err := svc.LinkChat(ctx, models.PlatformSlack, "C0123ABC")
+	if err != nil {
+		t.Fatalf("Failed to link chat: %v", err)
+	}
+
+	// ID стабилен и не пересекается с чатами Telegram
+	if chatID != ExternalChatID(models.PlatformSlack, "C0123ABC") || chatID == ExternalChatID(models.PlatformSlack, "C0456DEF") {
+		t.Error("Expected stable and distinct IDs for channels")
+	}
+
+	if !IsExternalChat(chatID) || IsExternalChat(testChatID) || IsExternalChat(-1001234567890) {
+		t.Errorf("Unexpected IsExternalChat results for %d", chatID)
+	}
+
+	link, err := storage.GetChatLink(ctx, chatID)
+	if err != nil {
+		t.Fatalf("Failed to get chat link: %v", err)
+	}
+
+	if link.Platform != models.PlatformSlack || link.ExternalID != "C0123ABC" {
+		t.Errorf("Unexpected chat link: %+v", link)
+	}
+
+	userID, err := svc.ExternalUser(ctx, chatID, models.PlatformSlack, "U0123", &models.ChatUser{Username: "alice"})
+	if err != nil {
+		t.Fatalf("Failed to save external user: %v", err)
+	}
+
+	if userID != ExternalUserID(models.PlatformSlack, "U0123") || userID >= 0 {
+		t.Errorf("Unexpected user ID %d", userID)
+	}
+
+	user, err := storage.GetChatUser(ctx, chatID, userID)
+	if err != nil {
+		t.Fatalf("Failed to get external user: %v", err)
+	}
+
+	if user.DisplayName() != "@alice" {
+		t.Errorf("Expected display name '@alice', got %q", user.DisplayName())
+	}
+}
+
+func TestAddWebhook(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Validation", func(t *testing.T) {
+		svc, _, _ := setupService(t)
+
+		if _, err := svc.AddWebhook(ctx, testChatID, "ftp://example.com", nil, 1); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("Expected ErrInvalidWebhook for ftp URL, got: %v", err)
+		}
+
+		if _, err := svc.AddWebhook(ctx, testChatID, "https://example.com", []string{"timer.unknown"}, 1); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("Expected ErrInvalidWebhook for unknown event, got: %v", err)
+		}
+	})
+
+	t.Run("AddAndLimit", func(t *testing.T) {
+		svc, storage, _ := setupService(t)
+
+		webhook, err := svc.AddWebhook(ctx, testChatID, "https://example.com/hook", []string{"timer.started"}, 1)
+		if err != nil {
+			t.Fatalf("Failed to add webhook: %v", err)
+		}
+
+		if !strings.HasPrefix(webhook.Secret, helpers.WebhookSecretPrefix) || len(webhook.ID) != helpers.WebhookIDLength {
+			t.Errorf("Unexpected webhook: %+v", webhook)
+		}
+
+		saved, err := storage.GetWebhook(ctx, webhook.ID)
+		if err != nil {
+			t.Fatalf("Failed to get webhook: %v", err)
+		}
+
+		if saved.Secret != webhook.Secret || saved.ChatID != testChatID {
+			t.Errorf("Unexpected saved webhook: %+v", saved)
+		}
+
+		for range helpers.MaxWebhooksPerChat - 1 {
+			if _, err := svc.AddWebhook(ctx, testChatID, "https://example.com/hook", nil, 1); err != nil {
+				t.Fatalf("Failed to add webhook: %v", err)
+			}
+		}
+
+		if _, err := svc.AddWebhook(ctx, testChatID, "https://example.com/hook", nil, 1); !errors.Is(err, ErrWebhookLimit) {
+			t.Errorf("Expected ErrWebhookLimit, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Changes of a task, nil fields are left as they are
+type TaskUpdate struct {
+	Name        *string
+	Description *string
+}
+
+// Creates a new task in the chat
+func (s *Service) CreateTask(ctx context.Context, chatID int64, name string, description string, source Source) (*models.Task, error) {
+	if err := helpers.ValidateTaskName(name); err != nil {
+		return nil, &InvalidNameError{Reason: err}
+	}
+
+	count, err := s.storage.CountTasks(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	if count >= helpers.MaxTasksPerChat {
+		return nil, ErrTaskLimit
+	}
+
+	if err := s.checkNameFree(ctx, chatID, name); err != nil {
+		return nil, err
+	}
+
+	taskID, err := helpers.GenerateUniqueTaskID(helpers.TaskIDLength, func(id string) (bool, error) {
+		return s.storage.TaskExists(ctx, chatID, id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate task ID: %w", err)
+	}
+
+	task := &models.Task{
+		ID:          taskID,
+		Name:        name,
+		Description: description,
+		ChatID:      chatID,
+	}
+
+	if err := s.storage.AddTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to add task: %w", err)
+	}
+
+	s.emit(ctx, &Event{
+		Type:   EventTaskCreated,
+		Source: source,
+		ChatID: chatID,
+		Task:   task,
+		Time:   s.clock.Now(),
+	})
+
+	return task, nil
+}
+
+// Renames a task or changes its description
+func (s *Service) UpdateTask(ctx context.Context, chatID int64, taskID string, update TaskUpdate, source Source) (*models.Task, error) {
+	task, err := s.storage.GetTask(ctx, chatID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if update.Name != nil && *update.Name != task.Name {
+		if err := helpers.ValidateTaskName(*update.Name); err != nil {
+			return task, &InvalidNameError{Reason: err}
+		}
+
+		if err := s.checkNameFree(ctx, chatID, *update.Name); err != nil {
+			return task, err
+		}
+
+		task.Name = *update.Name
+	}
+
+	if update.Description != nil {
+		task.Description = *update.Description
+	}
+
+	if err := s.storage.UpdateTask(ctx, task); err != nil {
+		return task, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	s.emit(ctx, &Event{
+		Type:   EventTaskUpdated,
+		Source: source,
+		ChatID: chatID,
+		Task:   task,
+		Time:   s.clock.Now(),
+	})
+
+	return task, nil
+}
+
+// Deletes a task that is not in use
+func (s *Service) DeleteTask(ctx context.Context, chatID int64, taskID string, source Source) (*models.Task, error) {
+	task, err := s.storage.GetTask(ctx, chatID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if task.OwnerID != 0 {
+		return task, ErrTaskInUse
+	}
+
+	if err := s.storage.DeleteTask(ctx, chatID, taskID); err != nil {
+		return task, fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	s.emit(ctx, &Event{
+		Type:   EventTaskDeleted,
+		Source: source,
+		ChatID: chatID,
+		Task:   task,
+		Time:   s.clock.Now(),
+	})
+
+	return task, nil
+}
+
+// Returns ErrTaskNameTaken if the chat already has a task with the name
+func (s *Service) checkNameFree(ctx context.Context, chatID int64, name string) error {
+	_, err := s.storage.GetTaskByName(ctx, chatID, name)
+	if err == nil {
+		return ErrTaskNameTaken
+	}
+
+	if !errors.Is(err, redis.ErrNotFound) {
+		return fmt.Errorf("failed to check if task exists: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,376 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// How long before a timer runs out its holder is warned
+const TimerWarningLead = 5 * time.Minute
+
+// Parameters of a new timer
+type StartTimerRequest struct {
+	ChatID    int64
+	TaskID    string
+	UserID    int64
+	Duration  int // Minutes
+	MessageID int // Telegram message that started the timer, zero if it didn't come from Telegram
+	Source    Source
+}
+
+// Starts a timer on a task
+// Returns the task and the new active task
+func (s *Service) StartTimer(ctx context.Context, req *StartTimerRequest) (*models.Task, *models.ActiveTask, error) {
+	if req.Duration < helpers.MinTaskDuration {
+		return nil, nil, ErrInvalidDuration
+	}
+
+	if req.Duration > helpers.MaxTaskDuration {
+		return nil, nil, ErrDurationLimit
+	}
+
+	startTime := s.clock.Now()
+
+	activeTask := &models.ActiveTask{
+		TaskID:    req.TaskID,
+		UserID:    req.UserID,
+		ChatID:    req.ChatID,
+		StartTime: startTime,
+		EndTime:   startTime.Add(time.Duration(req.Duration) * time.Minute),
+		Duration:  req.Duration,
+		MessageID: req.MessageID,
+	}
+
+	return s.startTimer(ctx, activeTask, req.Source, EventTimerStarted)
+}
+
+// Checks the rules of taking a task and saves its active task
+// Shared by timers and leases, which differ only in how the active task is built
+func (s *Service) startTimer(
+	ctx context.Context,
+	activeTask *models.ActiveTask,
+	source Source,
+	eventType EventType,
+) (*models.Task, *models.ActiveTask, error) {
+	task, err := s.storage.GetTask(ctx, activeTask.ChatID, activeTask.TaskID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if task.IsLocked {
+		return task, nil, ErrTaskLocked
+	}
+
+	if task.OwnerID != 0 {
+		if task.OwnerID == activeTask.UserID {
+			return task, nil, ErrAlreadyHolding
+		}
+
+		return task, nil, ErrTaskBusy
+	}
+
+	if err := s.storage.CheckConflicts(ctx, activeTask.ChatID, task.ID); err != nil {
+		return task, nil, err
+	}
+
+	count, err := s.storage.GetCountUserActiveTasks(ctx, activeTask.ChatID, activeTask.UserID)
+	if err != nil {
+		return task, nil, fmt.Errorf("failed to count user active tasks: %w", err)
+	}
+
+	if count >= helpers.MaxTasksPerUser {
+		return task, nil, ErrTooManyTasks
+	}
+
+	// The checks above may race with another start, storage checks again atomically
+	if err := s.storage.StartTask(ctx, activeTask); err != nil {
+		switch {
+		case errors.Is(err, redis.ErrTaskActive):
+			return task, nil, ErrTaskBusy
+		case errors.Is(err, redis.ErrTaskLocked):
+			return task, nil, ErrTaskLocked
+		}
+
+		return task, nil, fmt.Errorf("failed to start task: %w", err)
+	}
+
+	task.OwnerID = activeTask.UserID
+	task.StartTime = activeTask.StartTime
+	task.EndTime = activeTask.EndTime
+	task.Duration = activeTask.Duration
+	task.MessageID = activeTask.MessageID
+
+	s.emit(ctx, &Event{
+		Type:       eventType,
+		Source:     source,
+		ChatID:     activeTask.ChatID,
+		Task:       task,
+		ActiveTask: activeTask,
+		Time:       activeTask.StartTime,
+	})
+
+	return task, activeTask, nil
+}
+
+// Remembers the bot message that shows the timer, so replies to it control the timer
+func (s *Service) SetTimerMessage(ctx context.Context, activeTask *models.ActiveTask, messageID int) error {
+	activeTask.BotResponseID = messageID
+
+	if err := s.storage.UpdateActiveTask(ctx, activeTask); err != nil {
+		return fmt.Errorf("failed to update active task: %w", err)
+	}
+
+	return nil
+}
+
+// Extends a running or paused timer by the given number of minutes
+func (s *Service) ExtendTimer(ctx context.Context, chatID int64, taskID string, minutes int, source Source) (*models.Task, *models.ActiveTask, error) {
+	if minutes < helpers.MinTaskDuration {
+		return nil, nil, ErrInvalidDuration
+	}
+
+	return s.updateTimer(ctx, chatID, taskID, source, EventTimerExtended, minutes, func(activeTask *models.ActiveTask) error {
+		if activeTask.IsLeased() {
+			return ErrTaskLeased
+		}
+
+		if activeTask.Duration+minutes > helpers.MaxTaskDuration {
+			return ErrDurationLimit
+		}
+
+		activeTask.Extend(minutes)
+
+		return nil
+	})
+}
+
+// Pauses a running timer
+func (s *Service) PauseTimer(ctx context.Context, chatID int64, taskID string, source Source) (*models.Task, *models.ActiveTask, error) {
+	return s.updateTimer(ctx, chatID, taskID, source, EventTimerPaused, 0, func(activeTask *models.ActiveTask) error {
+		if activeTask.IsLeased() {
+			return ErrTaskLeased
+		}
+
+		if activeTask.IsPaused() {
+			return ErrTimerPaused
+		}
+
+		activeTask.Pause(s.clock.Now())
+
+		return nil
+	})
+}
+
+// Resumes a paused timer
+func (s *Service) ResumeTimer(ctx context.Context, chatID int64, taskID string, source Source) (*models.Task, *models.ActiveTask, error) {
+	return s.updateTimer(ctx, chatID, taskID, source, EventTimerResumed, 0, func(activeTask *models.ActiveTask) error {
+		if activeTask.IsLeased() {
+			return ErrTaskLeased
+		}
+
+		if !activeTask.IsPaused() {
+			return ErrTimerNotPaused
+		}
+
+		activeTask.Resume(s.clock.Now())
+
+		return nil
+	})
+}
+
+// Adds a note to a running or paused timer
+// Only the last MaxNotesPerSession notes are kept
+func (s *Service) AddTimerNote(ctx context.Context, activeTask *models.ActiveTask, note models.Note) error {
+	activeTask.Notes = append(activeTask.Notes, note)
+	if extra := len(activeTask.Notes) - helpers.MaxNotesPerSession; extra > 0 {
+		activeTask.Notes = activeTask.Notes[extra:]
+	}
+
+	if err := s.storage.UpdateActiveTask(ctx, activeTask); err != nil {
+		return fmt.Errorf("failed to update active task: %w", err)
+	}
+
+	return nil
+}
+
+// Stops a timer and releases the task before its time is up
+// done tells a finished task apart from a cancelled one
+// Leases are ended only by ReleaseLease with their token
+func (s *Service) ReleaseTimer(ctx context.Context, chatID int64, taskID string, done bool, source Source) (*models.Task, *models.ActiveTask, error) {
+	task, activeTask, err := s.getTimer(ctx, chatID, taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if activeTask.IsLeased() {
+		return nil, nil, ErrTaskLeased
+	}
+
+	if err := s.storage.EndTask(ctx, chatID, taskID); err != nil {
+		return nil, nil, fmt.Errorf("failed to end task: %w", err)
+	}
+
+	task.OwnerID = 0
+
+	eventType := EventTimerCancelled
+	if done {
+		eventType = EventTimerDone
+	}
+
+	s.emit(ctx, &Event{
+		Type:       eventType,
+		Source:     source,
+		ChatID:     chatID,
+		Task:       task,
+		ActiveTask: activeTask,
+		Time:       s.clock.Now(),
+	})
+
+	return task, activeTask, nil
+}
+
+// Announces that a timer runs out within TimerWarningLead, the task is not changed
+// Leases aren't warned about, they expire only when heartbeats stop
+func (s *Service) WarnTimer(ctx context.Context, chatID int64, taskID string) (*models.Task, *models.ActiveTask, error) {
+	task, activeTask, err := s.getTimer(ctx, chatID, taskID)
+	if err != nil {
+		return task, nil, err
+	}
+
+	if activeTask.IsLeased() {
+		return task, activeTask, ErrTaskLeased
+	}
+
+	if activeTask.IsPaused() {
+		return task, activeTask, ErrTimerPaused
+	}
+
+	if time.Duration(activeTask.RemainingAt(s.clock.Now()))*time.Second > TimerWarningLead {
+		return task, activeTask, ErrTimerRunning
+	}
+
+	s.emit(ctx, &Event{
+		Type:       EventTimerExpiring,
+		Source:     SourceTimer,
+		ChatID:     chatID,
+		Task:       task,
+		ActiveTask: activeTask,
+		Time:       s.clock.Now(),
+	})
+
+	return task, activeTask, nil
+}
+
+// Ends a timer or lease whose time is up and frees the task
+// A paused timer expires once it has been paused for models.MaxPause, before that ErrTimerPaused is returned.
+// Returns ErrTimerRunning if the timer was extended or the lease renewed in the meantime
+func (s *Service) ExpireTimer(ctx context.Context, chatID int64, taskID string) (*models.Task, *models.ActiveTask, error) {
+	task, activeTask, err := s.getTimer(ctx, chatID, taskID)
+	if err != nil {
+		return task, nil, err
+	}
+
+	if activeTask.IsPaused() {
+		if s.clock.Now().Before(activeTask.PauseEndsAt()) {
+			return task, activeTask, ErrTimerPaused
+		}
+	} else if activeTask.RemainingAt(s.clock.Now()) > 0 {
+		return task, activeTask, ErrTimerRunning
+	}
+
+	if err := s.storage.EndTask(ctx, chatID, taskID); err != nil {
+		return task, activeTask, fmt.Errorf("failed to end task: %w", err)
+	}
+
+	task.OwnerID = 0
+
+	s.emit(ctx, &Event{
+		Type:       EventTimerExpired,
+		Source:     SourceTimer,
+		ChatID:     chatID,
+		Task:       task,
+		ActiveTask: activeTask,
+		Time:       s.clock.Now(),
+	})
+
+	return task, activeTask, nil
+}
+
+// Gets a task with its active timer
+func (s *Service) getTimer(ctx context.Context, chatID int64, taskID string) (*models.Task, *models.ActiveTask, error) {
+	task, err := s.storage.GetTask(ctx, chatID, taskID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	activeTask, err := s.storage.GetActiveTask(ctx, chatID, taskID)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return task, nil, ErrTaskNotActive
+		}
+
+		return task, nil, fmt.Errorf("failed to get active task: %w", err)
+	}
+
+	return task, activeTask, nil
+}
+
+// Applies a change to an active timer, saves it and announces it
+func (s *Service) updateTimer(
+	ctx context.Context,
+	chatID int64,
+	taskID string,
+	source Source,
+	eventType EventType,
+	minutes int,
+	change func(activeTask *models.ActiveTask) error,
+) (*models.Task, *models.ActiveTask, error) {
+	task, activeTask, err := s.getTimer(ctx, chatID, taskID)
+	if err != nil {
+		return task, nil, err
+	}
+
+	if err := change(activeTask); err != nil {
+		return task, activeTask, err
+	}
+
+	if err := s.storage.UpdateActiveTask(ctx, activeTask); err != nil {
+		return task, activeTask, fmt.Errorf("failed to update active task: %w", err)
+	}
+
+	task.StartTime = activeTask.StartTime
+	task.EndTime = activeTask.EndTime
+	task.Duration = activeTask.Duration
+	task.PausedAt = activeTask.PausedAt
+
+	s.emit(ctx, &Event{
+		Type:       eventType,
+		Source:     source,
+		ChatID:     chatID,
+		Task:       task,
+		ActiveTask: activeTask,
+		Minutes:    minutes,
+		Time:       s.clock.Now(),
+	})
+
+	return task, activeTask, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"time-guard-bot/internal/models"
+)
+
+// Returns the user ID of a holder label given through the API
+// Labels get stable negative IDs, so they never clash with Telegram users,
+// and are saved to the user directory to be shown as holder names
+func (s *Service) LabelUser(ctx context.Context, chatID int64, label string) (int64, error) {
+	userID := LabelUserID(label)
+
+	user := &models.ChatUser{
+		ID:        userID,
+		FirstName: label,
+		UpdatedAt: s.clock.Now(),
+	}
+
+	if err := s.storage.SaveChatUser(ctx, chatID, user); err != nil {
+		return 0, fmt.Errorf("failed to save label user: %w", err)
+	}
+
+	return userID, nil
+}
+
+// Maps a holder label to its user ID
+func LabelUserID(label string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(label))
+
+	return -int64(h.Sum64()>>1) - 1
+}
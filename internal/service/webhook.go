@@ -0,0 +1,71 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+)
+
+// Registers a webhook that receives events of the chat
+// An empty list of events subscribes to all of them
+func (s *Service) AddWebhook(ctx context.Context, chatID int64, url string, events []string, createdBy int64) (*models.Webhook, error) {
+	if err := helpers.ValidateWebhookURL(url); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidWebhook, err.Error())
+	}
+
+	for _, event := range events {
+		if !IsEventType(event) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+
+	webhooks, err := s.storage.ListWebhooks(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	if len(webhooks) >= helpers.MaxWebhooksPerChat {
+		return nil, ErrWebhookLimit
+	}
+
+	webhookID, err := helpers.GenerateTaskID(helpers.WebhookIDLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook ID: %w", err)
+	}
+
+	secret, err := helpers.GenerateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		ID:        webhookID,
+		ChatID:    chatID,
+		URL:       url,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: createdBy,
+		CreatedAt: s.clock.Now(),
+	}
+
+	if err := s.storage.AddWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to add webhook: %w", err)
+	}
+
+	return webhook, nil
+}
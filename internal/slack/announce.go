@@ -0,0 +1,80 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Posts changes of Slack chats that didn't come from Slack, like expired
+// timers or changes made through the API, to their channel
+func (a *Adapter) handleServiceEvent(ctx context.Context, event *service.Event) {
+	if event.Source == service.SourceSlack || !service.IsExternalChat(event.ChatID) {
+		return
+	}
+
+	link, err := a.storage.GetChatLink(ctx, event.ChatID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get chat link: %v", err)
+		}
+
+		return
+	}
+
+	if link.Platform != models.PlatformSlack {
+		return
+	}
+
+	msg := a.eventMessage(ctx, event)
+	if msg == nil {
+		return
+	}
+
+	if err := a.client.postMessage(ctx, link.ExternalID, msg); err != nil {
+		log.Printf("Failed to announce %s in Slack: %v", event.Type, err)
+	}
+}
+
+// Returns the announcement of a change, nil for quiet ones
+func (a *Adapter) eventMessage(ctx context.Context, event *service.Event) *message {
+	holder := func() string {
+		users, err := a.storage.GetChatUsers(ctx, event.ChatID)
+		if err != nil {
+			log.Printf("Failed to get chat users: %v", err)
+
+			users = map[int64]*models.ChatUser{}
+		}
+
+		return holderName(users, event.ActiveTask.UserID)
+	}
+
+	text, ok := platform.EventText(event, a.clock.Now(), markup, holder)
+	if !ok {
+		return nil
+	}
+
+	if event.Type == service.EventTimerStarted {
+		return timerMessage(text, event.Task.ID)
+	}
+
+	return publicMessage(text)
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"fmt"
+
+	"time-guard-bot/internal/platform"
+)
+
+// Visibility of a reply to a slash command or button
+const (
+	responseInChannel = "in_channel"
+	responseEphemeral = "ephemeral"
+)
+
+// Action IDs of the timer buttons, the value of each button is the task ID
+const (
+	actionCheckTime = "check_time"
+	actionExtend    = "extend"
+	actionDone      = "done"
+	actionCancel    = "cancel"
+)
+
+// Extension step of the "+15" timer button in minutes
+const timerExtendStep = 15
+
+// Slack mrkdwn puts bold text between single asterisks
+var markup = platform.Markdown("*")
+
+// Message posted with chat.postMessage or as a reply to a command or button
+type message struct {
+	Channel         string  `json:"channel,omitempty"`
+	User            string  `json:"user,omitempty"` // Recipient of chat.postEphemeral
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	Text            string  `json:"text"`
+	Blocks          []block `json:"blocks,omitempty"`
+}
+
+// Block Kit layout block: a section with text or a row of buttons
+type block struct {
+	Type     string    `json:"type"`
+	Text     *textItem `json:"text,omitempty"`
+	Elements []button  `json:"elements,omitempty"`
+}
+
+// Block Kit text object
+type textItem struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Block Kit button
+type button struct {
+	Type     string    `json:"type"`
+	Text     *textItem `json:"text"`
+	ActionID string    `json:"action_id"`
+	Value    string    `json:"value"`
+	Style    string    `json:"style,omitempty"`
+}
+
+// Creates a message visible to everyone in the channel
+func publicMessage(text string) *message {
+	return &message{ResponseType: responseInChannel, Text: text}
+}
+
+// Creates a message visible only to the user who asked
+func privateMessage(text string) *message {
+	return &message{ResponseType: responseEphemeral, Text: text}
+}
+
+// Creates the message of a running timer with its control buttons
+func timerMessage(text string, taskID string) *message {
+	newButton := func(label string, actionID string, style string) button {
+		return button{
+			Type:     "button",
+			Text:     &textItem{Type: "plain_text", Text: label},
+			ActionID: actionID,
+			Value:    taskID,
+			Style:    style,
+		}
+	}
+
+	msg := publicMessage(text)
+	msg.Blocks = []block{
+		{Type: "section", Text: &textItem{Type: "mrkdwn", Text: text}},
+		{
+			Type: "actions",
+			Elements: []button{
+				newButton("⌛", actionCheckTime, ""),
+				newButton(fmt.Sprintf("+%d", timerExtendStep), actionExtend, ""),
+				newButton("✅ Done", actionDone, "primary"),
+				newButton("✖️ Cancel", actionCancel, "danger"),
+			},
+		},
+	}
+
+	return msg
+}
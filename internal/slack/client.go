@@ -0,0 +1,125 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+// Largest response of Slack that is read
+const maxResponseSize = 1 << 20
+
+// Calls the Slack Web API and response URLs
+type client struct {
+	apiURL string
+	token  string
+	http   *http.Client
+}
+
+// Answer of the Web API, errors are reported in the body with status 200
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Posts a message to a channel
+func (c *client) postMessage(ctx context.Context, channel string, msg *message) error {
+	msg.Channel = channel
+	msg.ResponseType = ""
+
+	var result apiResponse
+	if err := c.post(ctx, c.apiURL+"/chat.postMessage", msg, &result); err != nil {
+		return err
+	}
+
+	if !result.OK {
+		return fmt.Errorf("chat.postMessage failed: %s", result.Error)
+	}
+
+	return nil
+}
+
+// Posts a message only the user sees
+func (c *client) postEphemeral(ctx context.Context, channel string, user string, msg *message) error {
+	msg.Channel = channel
+	msg.User = user
+	msg.ResponseType = ""
+
+	var result apiResponse
+	if err := c.post(ctx, c.apiURL+"/chat.postEphemeral", msg, &result); err != nil {
+		return err
+	}
+
+	if !result.OK {
+		return fmt.Errorf("chat.postEphemeral failed: %s", result.Error)
+	}
+
+	return nil
+}
+
+// Answers a command or a button through its response URL
+func (c *client) respond(ctx context.Context, responseURL string, msg *message) error {
+	return c.post(ctx, responseURL, msg, nil)
+}
+
+// Sends a message as JSON and decodes the answer into result unless it is nil
+// Calls of the Web API carry the bot token, response URLs need none
+func (c *client) post(ctx context.Context, url string, msg *message, result *apiResponse) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	if result != nil {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Slack: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close Slack response: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack answered with status %d", resp.StatusCode)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode Slack response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,340 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+// Command typed in Slack, as a slash command or a mention of the app
+type command struct {
+	prefix    string // How the command is invoked, e.g. /tg, for the help text
+	channelID string
+	userID    string // Slack user ID
+	userName  string
+	text      string // Arguments after the prefix
+}
+
+// Handles a slash command like /tg 30 staging
+func (a *Adapter) handleCommand(w http.ResponseWriter, r *http.Request, body []byte) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	msg := a.runCommand(r.Context(), &command{
+		prefix:    form.Get("command"),
+		channelID: form.Get("channel_id"),
+		userID:    form.Get("user_id"),
+		userName:  form.Get("user_name"),
+		text:      form.Get("text"),
+	})
+
+	writeJSON(w, msg)
+}
+
+// Runs a command and returns the answer to it
+func (a *Adapter) runCommand(ctx context.Context, cmd *command) *message {
+	chatID, err := a.service.LinkChat(ctx, models.PlatformSlack, cmd.channelID)
+	if err != nil {
+		log.Printf("Failed to link Slack channel: %v", err)
+		return privateMessage("Error processing command. Please try again")
+	}
+
+	userID, err := a.rememberUser(ctx, chatID, cmd)
+	if err != nil {
+		log.Printf("Failed to save Slack user: %v", err)
+		return privateMessage("Error processing command. Please try again")
+	}
+
+	args := strings.Fields(cmd.text)
+	if len(args) == 0 {
+		return privateMessage(helpText(cmd.prefix))
+	}
+
+	var msg *message
+
+	name := strings.ToLower(args[0])
+
+	switch name {
+	case "help":
+		msg = privateMessage(helpText(cmd.prefix))
+	case "add":
+		msg, err = a.addTask(ctx, chatID, args[1:])
+	case "cancel", "done":
+		msg, err = a.releaseTimer(ctx, chatID, userID, args[1:], name == "done")
+	case "status":
+		msg, err = a.status(ctx, chatID)
+	default:
+		duration, convErr := strconv.Atoi(name)
+		if convErr != nil {
+			return privateMessage(fmt.Sprintf("Unknown command *%s*. Try `%s help`", args[0], cmd.prefix))
+		}
+
+		msg, err = a.startTimer(ctx, chatID, userID, cmd.userID, duration, args[1:])
+	}
+
+	if err != nil {
+		log.Printf("Error handling Slack command %s: %v", name, err)
+		return privateMessage("Error processing command. Please try again")
+	}
+
+	return msg
+}
+
+// Returns the user ID of the author of a command and saves them to the user directory
+// The Slack user ID is kept as the username, so holders can be mentioned
+func (a *Adapter) rememberUser(ctx context.Context, chatID int64, cmd *command) (int64, error) {
+	user := &models.ChatUser{Username: cmd.userID, FirstName: cmd.userName}
+
+	// Mentions of the app come without a name, keep the one seen before
+	if cmd.userName == "" {
+		userID := service.ExternalUserID(models.PlatformSlack, cmd.userID)
+
+		_, err := a.storage.GetChatUser(ctx, chatID, userID)
+		if err == nil {
+			return userID, nil
+		}
+
+		if !errors.Is(err, redis.ErrNotFound) {
+			return 0, fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+
+	return a.service.ExternalUser(ctx, chatID, models.PlatformSlack, cmd.userID, user)
+}
+
+// Returns the list of commands
+func helpText(prefix string) string {
+	return fmt.Sprintf(
+		"*Commands*\n"+
+			"`%[1]s add name [description]` - add a new task\n"+
+			"`%[1]s 30 name` - start a timer for 30 minutes\n"+
+			"`%[1]s done [name]` - finish your timer, the last one if no name is given\n"+
+			"`%[1]s cancel [name]` - cancel your timer, the last one if no name is given\n"+
+			"`%[1]s status` - show the tasks of this channel",
+		prefix,
+	)
+}
+
+// Handles add name [description]
+func (a *Adapter) addTask(ctx context.Context, chatID int64, args []string) (*message, error) {
+	if len(args) == 0 {
+		return privateMessage("Please provide a task name"), nil
+	}
+
+	taskName := args[0]
+	description := strings.Join(args[1:], " ")
+
+	task, err := a.service.CreateTask(ctx, chatID, taskName, description, service.SourceSlack)
+
+	var nameErr *service.InvalidNameError
+
+	switch {
+	case errors.As(err, &nameErr):
+		return privateMessage(fmt.Sprintf("Invalid task name: %s", nameErr.Reason)), nil
+	case errors.Is(err, service.ErrTaskLimit):
+		return privateMessage(fmt.Sprintf("Maximum number of tasks per chat reached (%d)", helpers.MaxTasksPerChat)), nil
+	case errors.Is(err, service.ErrTaskNameTaken):
+		return privateMessage(fmt.Sprintf("A task with name *%s* already exists", taskName)), nil
+	case err != nil:
+		return nil, err
+	}
+
+	return publicMessage(platform.TaskAddedText(markup, task)), nil
+}
+
+// Handles {minutes} name
+func (a *Adapter) startTimer(ctx context.Context, chatID int64, userID int64, slackUserID string, duration int, args []string) (*message, error) {
+	if len(args) == 0 {
+		return privateMessage("Please provide a task name"), nil
+	}
+
+	task, err := a.storage.GetTaskByName(ctx, chatID, args[0])
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			return privateMessage(fmt.Sprintf("Task *%s* not found", args[0])), nil
+		}
+
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	task, _, err = a.service.StartTimer(ctx, &service.StartTimerRequest{
+		ChatID:   chatID,
+		TaskID:   task.ID,
+		UserID:   userID,
+		Duration: duration,
+		Source:   service.SourceSlack,
+	})
+	if err != nil {
+		if text, ok := a.startErrorText(ctx, chatID, task, err); ok {
+			return privateMessage(text), nil
+		}
+
+		return nil, err
+	}
+
+	text := fmt.Sprintf("%s: task *%s*, <@%s>", platform.TimerStartedText(duration), task.Name, slackUserID)
+
+	return timerMessage(text, task.ID), nil
+}
+
+// Explains why a timer can't be started, false for unexpected errors
+func (a *Adapter) startErrorText(ctx context.Context, chatID int64, task *models.Task, err error) (string, bool) {
+	return platform.StartErrorText(err, task, a.clock.Now(), markup, func(taskID string) string {
+		if blocker, err := a.storage.GetTask(ctx, chatID, taskID); err == nil {
+			return blocker.Name
+		}
+
+		return taskID
+	})
+}
+
+// Handles done [name] and cancel [name]: finishes a timer of the user, the
+// last started one if no name is given
+func (a *Adapter) releaseTimer(ctx context.Context, chatID int64, userID int64, args []string, done bool) (*message, error) {
+	activeTasks, err := a.storage.GetUserActiveTasks(ctx, chatID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user's active tasks: %w", err)
+	}
+
+	if len(activeTasks) == 0 {
+		return privateMessage("You don't have any active tasks"), nil
+	}
+
+	var target *models.ActiveTask
+
+	if len(args) > 0 {
+		task, err := a.storage.GetTaskByName(ctx, chatID, args[0])
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				return privateMessage(fmt.Sprintf("Task *%s* not found", args[0])), nil
+			}
+
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		for _, activeTask := range activeTasks {
+			if activeTask.TaskID == task.ID {
+				target = activeTask
+				break
+			}
+		}
+
+		if target == nil {
+			return privateMessage(fmt.Sprintf("You don't have an active timer for task *%s*", args[0])), nil
+		}
+	} else {
+		for _, activeTask := range activeTasks {
+			if target == nil || activeTask.StartTime.After(target.StartTime) {
+				target = activeTask
+			}
+		}
+	}
+
+	task, _, err := a.service.ReleaseTimer(ctx, chatID, target.TaskID, done, service.SourceSlack)
+	if err != nil {
+		return nil, err
+	}
+
+	return publicMessage(platform.ReleasedText(markup, task.Name, done)), nil
+}
+
+// Handles status: lists the tasks of the channel with their state
+func (a *Adapter) status(ctx context.Context, chatID int64) (*message, error) {
+	tasks, err := a.storage.ListTasks(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	if len(tasks) == 0 {
+		return privateMessage("No tasks yet. Add one with `add name`"), nil
+	}
+
+	users, err := a.storage.GetChatUsers(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat users: %w", err)
+	}
+
+	var text strings.Builder
+
+	text.WriteString("*Tasks*")
+
+	for _, task := range tasks {
+		text.WriteString("\n")
+		text.WriteString(a.taskStatusLine(task, users))
+	}
+
+	return privateMessage(text.String()), nil
+}
+
+// Returns a status line of a task
+func (a *Adapter) taskStatusLine(task *models.Task, users map[int64]*models.ChatUser) string {
+	switch {
+	case task.IsLocked:
+		line := fmt.Sprintf("🔒 *%s*: Locked", task.Name)
+		if task.LockReason != "" {
+			line += fmt.Sprintf(" (%s)", task.LockReason)
+		}
+
+		return line
+	case task.OwnerID != 0:
+		return fmt.Sprintf(
+			"⏱ *%s*: %s remaining (%s)",
+			task.Name,
+			platform.FormatRemaining(task.RemainingAt(a.clock.Now())),
+			holderName(users, task.OwnerID),
+		)
+	default:
+		return fmt.Sprintf("🟢 *%s*: Available", task.Name)
+	}
+}
+
+// Returns the name of a user from the directory, Slack users are mentioned
+func holderName(users map[int64]*models.ChatUser, userID int64) string {
+	user, exists := users[userID]
+	if !exists {
+		user = &models.ChatUser{ID: userID}
+	}
+
+	if user.Username != "" && service.ExternalUserID(models.PlatformSlack, user.Username) == userID {
+		return "<@" + user.Username + ">"
+	}
+
+	return user.DisplayName()
+}
+
+// Writes a message as the JSON answer to a request
+func writeJSON(w http.ResponseWriter, msg *message) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(msg); err != nil {
+		log.Printf("Failed to write Slack response: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+)
+
+// Mention of the app at the start of a message
+var leadingMention = regexp.MustCompile(`^\s*(<@[A-Z0-9]+>)\s*`)
+
+// Envelope of the Events API
+type eventEnvelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"` // Set for url_verification
+	Event     struct {
+		Type    string `json:"type"`
+		User    string `json:"user"`
+		Text    string `json:"text"`
+		Channel string `json:"channel"`
+	} `json:"event"`
+}
+
+// Handles the Events API: confirms the Request URL and runs commands
+// given by mentioning the app, like @TimeGuard 30 staging
+func (a *Adapter) handleEvent(w http.ResponseWriter, r *http.Request, body []byte) {
+	var envelope eventEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	if envelope.Type == "url_verification" {
+		w.Header().Set("Content-Type", "text/plain")
+
+		if _, err := w.Write([]byte(envelope.Challenge)); err != nil {
+			log.Printf("Failed to answer Slack URL verification: %v", err)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	// Slack retries events it got no answer for in time, the first attempt is still being handled
+	if r.Header.Get("X-Slack-Retry-Num") != "" {
+		return
+	}
+
+	if envelope.Type != "event_callback" || envelope.Event.Type != "app_mention" {
+		return
+	}
+
+	event := envelope.Event
+
+	match := leadingMention.FindStringSubmatch(event.Text)
+	if match == nil {
+		return
+	}
+
+	ctx := r.Context()
+
+	msg := a.runCommand(ctx, &command{
+		prefix:    match[1],
+		channelID: event.Channel,
+		userID:    event.User,
+		text:      event.Text[len(match[0]):],
+	})
+
+	var err error
+	if msg.ResponseType == responseEphemeral {
+		err = a.client.postEphemeral(ctx, event.Channel, event.User, msg)
+	} else {
+		err = a.client.postMessage(ctx, event.Channel, msg)
+	}
+
+	if err != nil {
+		log.Printf("Failed to answer Slack mention: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/platform"
+	"time-guard-bot/internal/service"
+)
+
+// Interaction Slack sends when a button is clicked
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	ResponseURL string   `json:"response_url"`
+	Actions     []action `json:"actions"`
+}
+
+// Clicked button
+type action struct {
+	ActionID string `json:"action_id"`
+	Value    string `json:"value"` // Task ID
+}
+
+// Handles clicks on timer buttons, answers through the response URL
+func (a *Adapter) handleInteraction(w http.ResponseWriter, r *http.Request, body []byte) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// Slack waits for the acknowledgement only, the answer goes to the response URL
+	w.WriteHeader(http.StatusOK)
+
+	if payload.Type != "block_actions" {
+		return
+	}
+
+	ctx := r.Context()
+
+	for _, act := range payload.Actions {
+		msg := a.runAction(ctx, &payload, act)
+
+		if err := a.client.respond(ctx, payload.ResponseURL, msg); err != nil {
+			log.Printf("Failed to answer Slack action %s: %v", act.ActionID, err)
+		}
+	}
+}
+
+// Runs a timer button and returns the answer to it
+func (a *Adapter) runAction(ctx context.Context, payload *interactionPayload, act action) *message {
+	chatID := service.ExternalChatID(models.PlatformSlack, payload.Channel.ID)
+	taskID := act.Value
+
+	activeTask, err := a.storage.GetActiveTask(ctx, chatID, taskID)
+	if err != nil {
+		return privateMessage("Task not found or not active")
+	}
+
+	if act.ActionID == actionCheckTime {
+		return privateMessage(remainingText(activeTask.RemainingAt(a.clock.Now())))
+	}
+
+	if service.ExternalUserID(models.PlatformSlack, payload.User.ID) != activeTask.UserID {
+		return privateMessage("Only the user who started the timer can do this")
+	}
+
+	switch act.ActionID {
+	case actionExtend:
+		task, activeTask, err := a.service.ExtendTimer(ctx, chatID, taskID, timerExtendStep, service.SourceSlack)
+		if errors.Is(err, service.ErrDurationLimit) {
+			return privateMessage(fmt.Sprintf("Duration can't exceed %d minutes", helpers.MaxTaskDuration))
+		}
+
+		if err != nil {
+			return actionError(act, err)
+		}
+
+		return publicMessage(fmt.Sprintf(
+			"Timer for task *%s* extended by %d minutes (%s remaining)",
+			task.Name,
+			timerExtendStep,
+			platform.FormatRemaining(activeTask.RemainingAt(a.clock.Now())),
+		))
+	case actionDone, actionCancel:
+		done := act.ActionID == actionDone
+
+		task, _, err := a.service.ReleaseTimer(ctx, chatID, taskID, done, service.SourceSlack)
+		if err != nil {
+			return actionError(act, err)
+		}
+
+		// The finished timer takes the place of its message, buttons included
+		msg := publicMessage(platform.ReleasedText(markup, task.Name, done))
+		msg.ReplaceOriginal = true
+
+		return msg
+	default:
+		log.Printf("Unknown Slack action: %s", act.ActionID)
+		return privateMessage("Unknown action")
+	}
+}
+
+// Explains a failed timer button
+func actionError(act action, err error) *message {
+	if errors.Is(err, service.ErrTaskNotActive) {
+		return privateMessage("Task not found or not active")
+	}
+
+	log.Printf("Error handling Slack action %s: %v", act.ActionID, err)
+
+	return privateMessage("Error processing action. Please try again")
+}
+
+// Returns the answer of the ⌛ button
+func remainingText(remaining int64) string {
+	if remaining <= 0 {
+		return "Task time has expired"
+	}
+
+	remainingMin := remaining / 60
+	if remainingMin < 5 {
+		return fmt.Sprintf("%d:%02d remaining", remainingMin, remaining%60)
+	}
+
+	return fmt.Sprintf("%d minutes remaining", remainingMin)
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"net/http"
+	"time"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage"
+)
+
+// Slack requests are served under this path: slash commands, interactions and events
+const PathPrefix = "/slack/"
+
+// Web API of Slack
+const defaultAPIURL = "https://slack.com/api"
+
+// Timeout of calls to the Web API and response URLs
+const requestTimeout = 10 * time.Second
+
+// Represents Slack app configuration
+type Config struct {
+	// Signing secret of the app, verifies that requests come from Slack
+	SigningSecret string
+	// Bot token (xoxb-...) used to post messages
+	BotToken string
+	// Web API base URL, defaultAPIURL if empty
+	APIURL string
+}
+
+// Serves a Slack app on top of the service: every Slack channel is a chat of
+// its own, timers of these chats run in the bot like all others
+type Adapter struct {
+	config  *Config
+	storage storage.Storage
+	service *service.Service
+	clock   clock.Clock
+	client  *client
+}
+
+// Creates a Slack adapter and subscribes it to svc to announce changes in Slack channels
+func New(config *Config, storage storage.Storage, svc *service.Service) *Adapter {
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+
+	a := &Adapter{
+		config:  config,
+		storage: storage,
+		service: svc,
+		clock:   svc.Clock(),
+		client: &client{
+			apiURL: apiURL,
+			token:  config.BotToken,
+			http:   &http.Client{Timeout: requestTimeout},
+		},
+	}
+
+	svc.Subscribe(a.handleServiceEvent)
+
+	return a
+}
+
+// Returns the handler of the Request URLs of the app, to be mounted on PathPrefix
+func (a *Adapter) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST "+PathPrefix+"commands", a.verified(a.handleCommand))
+	mux.HandleFunc("POST "+PathPrefix+"interactions", a.verified(a.handleInteraction))
+	mux.HandleFunc("POST "+PathPrefix+"events", a.verified(a.handleEvent))
+
+	return mux
+}
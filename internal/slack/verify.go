@@ -0,0 +1,91 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"time-guard-bot/internal/platform"
+)
+
+// Largest request body Slack sends is well below this
+const maxBodySize = 1 << 20
+
+// Version of the Slack signature scheme
+const signatureVersion = "v0"
+
+// Reasons to reject a request
+var (
+	errMissingSignature = errors.New("missing signature headers")
+	errBadSignature     = errors.New("signature mismatch")
+)
+
+// Handles a request whose body is already verified
+type verifiedHandler func(w http.ResponseWriter, r *http.Request, body []byte)
+
+// Checks the signature of a request before passing it on, rejects it with 401 otherwise
+func (a *Adapter) verified(next verifiedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		if err := a.verify(r.Header, body); err != nil {
+			log.Printf("Rejected Slack request: %v", err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+
+			return
+		}
+
+		next(w, r, body)
+	}
+}
+
+// Checks X-Slack-Signature of a request body
+func (a *Adapter) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+
+	if timestamp == "" || signature == "" {
+		return errMissingSignature
+	}
+
+	if err := platform.CheckTimestamp(timestamp, a.clock.Now()); err != nil {
+		return err
+	}
+
+	expected := sign(a.config.SigningSecret, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errBadSignature
+	}
+
+	return nil
+}
+
+// Returns the signature Slack sends with a request body
+func sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signatureVersion + ":" + timestamp + ":"))
+	mac.Write(body)
+
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/models"
+)
+
+// Is returned by AddAPIKey when a key with the same ID exists, the caller should pick another ID
+var ErrAPIKeyExists = errors.New("API key ID is already taken")
+
+// Saves a new API key
+// Key IDs are short random strings, so the ID is checked and taken in one WATCH transaction
+func (rs *Storage) AddAPIKey(ctx context.Context, key *models.APIKey) error {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key: %w", err)
+	}
+
+	apiKeyKey := fmt.Sprintf(apiKeyPrefix, key.ID)
+
+	err = rs.client.Watch(ctx, func(tx *redis.Tx) error {
+		exists, err := tx.Exists(ctx, apiKeyKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to check API key ID: %w", err)
+		}
+
+		if exists > 0 {
+			return ErrAPIKeyExists
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, apiKeyKey, keyJSON, 0)
+			pipe.Set(ctx, fmt.Sprintf(apiKeyHashPrefix, key.Hash), key.ID, 0)
+			pipe.SAdd(ctx, fmt.Sprintf(chatAPIKeysKey, key.ChatID), key.ID)
+
+			return nil
+		})
+
+		return err
+	}, apiKeyKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		// The ID was taken while the transaction was running
+		return ErrAPIKeyExists
+	}
+
+	if err != nil && !errors.Is(err, ErrAPIKeyExists) {
+		return fmt.Errorf("failed to add API key: %w", err)
+	}
+
+	return err
+}
+
+// Gets an API key by ID
+func (rs *Storage) getAPIKey(ctx context.Context, keyID string) (*models.APIKey, error) {
+	data, err := rs.client.Get(ctx, fmt.Sprintf(apiKeyPrefix, keyID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	var key models.APIKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// Gets an API key by the hash of the full key
+func (rs *Storage) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	keyID, err := rs.client.Get(ctx, fmt.Sprintf(apiKeyHashPrefix, hash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get API key ID by hash: %w", err)
+	}
+
+	return rs.getAPIKey(ctx, keyID)
+}
+
+// Gets all API keys of chat, oldest first
+func (rs *Storage) ListAPIKeys(ctx context.Context, chatID int64) ([]*models.APIKey, error) {
+	keyIDs, err := rs.client.SMembers(ctx, fmt.Sprintf(chatAPIKeysKey, chatID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key IDs: %w", err)
+	}
+
+	keys := make([]*models.APIKey, 0, len(keyIDs))
+
+	for _, keyID := range keyIDs {
+		key, err := rs.getAPIKey(ctx, keyID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// Revokes an API key of chat, it stops working immediately
+func (rs *Storage) RevokeAPIKey(ctx context.Context, chatID int64, keyID string) error {
+	key, err := rs.getAPIKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	// Keys of other chats look like missing ones
+	if key.ChatID != chatID {
+		return ErrNotFound
+	}
+
+	pipe := rs.client.TxPipeline()
+
+	pipe.Del(ctx, fmt.Sprintf(apiKeyPrefix, key.ID))
+	pipe.Del(ctx, fmt.Sprintf(apiKeyHashPrefix, key.Hash))
+	pipe.SRem(ctx, fmt.Sprintf(chatAPIKeysKey, chatID), key.ID)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return nil
+}
+
+// Records the last use of an API key
+func (rs *Storage) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	key, err := rs.getAPIKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	key.LastUsedAt = usedAt
+
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key: %w", err)
+	}
+
+	// SetXX doesn't bring back a key revoked meanwhile
+	if err := rs.client.SetXX(ctx, fmt.Sprintf(apiKeyPrefix, keyID), keyJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestAPIKeyOperations(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(-100123)
+	now := time.Now().Truncate(time.Second)
+
+	first := &models.APIKey{
+		ID:        "key00001",
+		ChatID:    chatID,
+		Name:      "ci",
+		Hash:      "hash1",
+		Scopes:    []string{models.ScopeTasksRead},
+		CreatedAt: now.Add(-time.Hour),
+	}
+	second := &models.APIKey{
+		ID:        "key00002",
+		ChatID:    chatID,
+		Hash:      "hash2",
+		Scopes:    []string{models.ScopeTasksRead},
+		CreatedAt: now,
+	}
+
+	t.Run("AddAndGetByHash", func(t *testing.T) {
+		for _, key := range []*models.APIKey{second, first} {
+			if err := storage.AddAPIKey(ctx, key); err != nil {
+				t.Fatalf("Failed to add API key: %v", err)
+			}
+		}
+
+		key, err := storage.GetAPIKeyByHash(ctx, "hash1")
+		if err != nil {
+			t.Fatalf("Failed to get API key by hash: %v", err)
+		}
+
+		if key.ID != first.ID || key.ChatID != chatID || key.Name != "ci" {
+			t.Errorf("Unexpected API key: %+v", key)
+		}
+
+		if _, err := storage.GetAPIKeyByHash(ctx, "unknown"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for unknown hash, got: %v", err)
+		}
+	})
+
+	t.Run("AddTakenID", func(t *testing.T) {
+		// Ключ другого чата с тем же ID не перезаписывает существующий
+		taken := &models.APIKey{ID: first.ID, ChatID: -100999, Hash: "hash3", CreatedAt: now}
+		if err := storage.AddAPIKey(ctx, taken); !errors.Is(err, ErrAPIKeyExists) {
+			t.Fatalf("Expected ErrAPIKeyExists, got: %v", err)
+		}
+
+		key, err := storage.GetAPIKeyByHash(ctx, "hash1")
+		if err != nil || key.ChatID != chatID {
+			t.Errorf("Expected the original key kept, got %+v, %v", key, err)
+		}
+
+		if _, err := storage.GetAPIKeyByHash(ctx, "hash3"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected the hash of the rejected key not stored, got: %v", err)
+		}
+
+		if keys, err := storage.ListAPIKeys(ctx, -100999); err != nil || len(keys) != 0 {
+			t.Errorf("Expected no keys in the other chat, got %v, %v", keys, err)
+		}
+	})
+
+	t.Run("ListSortedByCreation", func(t *testing.T) {
+		keys, err := storage.ListAPIKeys(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to list API keys: %v", err)
+		}
+
+		if len(keys) != 2 || keys[0].ID != first.ID || keys[1].ID != second.ID {
+			t.Errorf("Expected keys [%s %s], got %v", first.ID, second.ID, keys)
+		}
+	})
+
+	t.Run("Touch", func(t *testing.T) {
+		if err := storage.TouchAPIKey(ctx, first.ID, now); err != nil {
+			t.Fatalf("Failed to touch API key: %v", err)
+		}
+
+		key, err := storage.GetAPIKeyByHash(ctx, "hash1")
+		if err != nil {
+			t.Fatalf("Failed to get API key by hash: %v", err)
+		}
+
+		if !key.LastUsedAt.Equal(now) {
+			t.Errorf("Expected last used %v, got %v", now, key.LastUsedAt)
+		}
+	})
+
+	t.Run("RevokeFromOtherChat", func(t *testing.T) {
+		// Чужой чат не может отозвать ключ
+		if err := storage.RevokeAPIKey(ctx, chatID+1, first.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got: %v", err)
+		}
+
+		if _, err := storage.GetAPIKeyByHash(ctx, "hash1"); err != nil {
+			t.Errorf("Expected key to stay valid, got: %v", err)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		if err := storage.RevokeAPIKey(ctx, chatID, first.ID); err != nil {
+			t.Fatalf("Failed to revoke API key: %v", err)
+		}
+
+		if _, err := storage.GetAPIKeyByHash(ctx, "hash1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound after revoke, got: %v", err)
+		}
+
+		keys, err := storage.ListAPIKeys(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to list API keys: %v", err)
+		}
+
+		if len(keys) != 1 || keys[0].ID != second.ID {
+			t.Errorf("Expected only %s left, got %v", second.ID, keys)
+		}
+
+		// Отозванный ключ нельзя обновить
+		if err := storage.TouchAPIKey(ctx, first.ID, now); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound touching revoked key, got: %v", err)
+		}
+	})
+}
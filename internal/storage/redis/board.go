@@ -0,0 +1,84 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Saves the ID of the pinned status board message of chat
+func (rs *Storage) SetBoardMessage(ctx context.Context, chatID int64, messageID int) error {
+	pipe := rs.client.TxPipeline()
+
+	pipe.Set(ctx, fmt.Sprintf(boardMessageKey, chatID), messageID, 0)
+	pipe.SAdd(ctx, boardChatsKey, chatID)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save board message: %w", err)
+	}
+
+	return nil
+}
+
+// Gets the ID of the pinned status board message of chat
+func (rs *Storage) GetBoardMessage(ctx context.Context, chatID int64) (int, error) {
+	messageID, err := rs.client.Get(ctx, fmt.Sprintf(boardMessageKey, chatID)).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, ErrNotFound
+		}
+
+		return 0, fmt.Errorf("failed to get board message: %w", err)
+	}
+
+	return messageID, nil
+}
+
+// Forgets the status board of chat
+func (rs *Storage) DeleteBoardMessage(ctx context.Context, chatID int64) error {
+	pipe := rs.client.TxPipeline()
+
+	pipe.Del(ctx, fmt.Sprintf(boardMessageKey, chatID))
+	pipe.SRem(ctx, boardChatsKey, chatID)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete board message: %w", err)
+	}
+
+	return nil
+}
+
+// Gets all chats with a status board
+func (rs *Storage) GetBoardChats(ctx context.Context) ([]int64, error) {
+	chatIDStrs, err := rs.client.SMembers(ctx, boardChatsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board chats: %w", err)
+	}
+
+	chatIDs := make([]int64, 0, len(chatIDStrs))
+
+	for _, chatIDStr := range chatIDStrs {
+		chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+		if err == nil {
+			chatIDs = append(chatIDs, chatID)
+		}
+	}
+
+	return chatIDs, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBoardOperations(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(-100123)
+
+	t.Run("NoBoardInitially", func(t *testing.T) {
+		if _, err := storage.GetBoardMessage(ctx, chatID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("SetBoardMessage", func(t *testing.T) {
+		if err := storage.SetBoardMessage(ctx, chatID, 42); err != nil {
+			t.Fatalf("Failed to set board message: %v", err)
+		}
+
+		messageID, err := storage.GetBoardMessage(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to get board message: %v", err)
+		}
+
+		if messageID != 42 {
+			t.Errorf("Expected message ID 42, got %d", messageID)
+		}
+
+		chats, err := storage.GetBoardChats(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get board chats: %v", err)
+		}
+
+		if len(chats) != 1 || chats[0] != chatID {
+			t.Errorf("Expected board chats [%d], got %v", chatID, chats)
+		}
+	})
+
+	t.Run("DeleteBoardMessage", func(t *testing.T) {
+		if err := storage.DeleteBoardMessage(ctx, chatID); err != nil {
+			t.Fatalf("Failed to delete board message: %v", err)
+		}
+
+		if _, err := storage.GetBoardMessage(ctx, chatID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound after delete, got: %v", err)
+		}
+
+		chats, err := storage.GetBoardChats(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get board chats: %v", err)
+		}
+
+		if len(chats) != 0 {
+			t.Errorf("Expected no board chats, got %v", chats)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/models"
+)
+
+// Saves the channel a chat namespace belongs to
+func (rs *Storage) SaveChatLink(ctx context.Context, link *models.ChatLink) error {
+	linkJSON, err := json.Marshal(link)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chat link: %w", err)
+	}
+
+	if err := rs.client.Set(ctx, fmt.Sprintf(chatLinkPrefix, link.ChatID), linkJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save chat link: %w", err)
+	}
+
+	return nil
+}
+
+// Gets the channel a chat namespace belongs to, ErrNotFound for Telegram chats
+func (rs *Storage) GetChatLink(ctx context.Context, chatID int64) (*models.ChatLink, error) {
+	data, err := rs.client.Get(ctx, fmt.Sprintf(chatLinkPrefix, chatID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get chat link: %w", err)
+	}
+
+	var link models.ChatLink
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chat link: %w", err)
+	}
+
+	return &link, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestChatLink(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(-5000000000000000)
+
+	// Чаты Telegram не привязаны
+	if _, err := storage.GetChatLink(ctx, chatID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	link := &models.ChatLink{
+		ChatID:     chatID,
+		Platform:   models.PlatformSlack,
+		ExternalID: "C0123ABC",
+		UpdatedAt:  time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	if err := storage.SaveChatLink(ctx, link); err != nil {
+		t.Fatalf("Failed to save chat link: %v", err)
+	}
+
+	stored, err := storage.GetChatLink(ctx, chatID)
+	if err != nil {
+		t.Fatalf("Failed to get chat link: %v", err)
+	}
+
+	if *stored != *link {
+		t.Errorf("Expected %+v, got %+v", link, stored)
+	}
+}
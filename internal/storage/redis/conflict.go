@@ -0,0 +1,265 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/models"
+)
+
+// Is returned when a task can't be started because a conflicting task is active
+var ErrTaskConflict = errors.New("task conflicts with an active task")
+
+// Describes which active task blocks the start of another one
+type ConflictError struct {
+	Group     string // Conflict group shared by both tasks
+	BlockerID string // ID of the active task that blocks the start
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("task conflicts with active task %s (group %s)", e.BlockerID, e.Group)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrTaskConflict
+}
+
+// Adds a task to a conflict group, creating the group if needed
+func (rs *Storage) AddTaskToConflictGroup(ctx context.Context, chatID int64, group string, taskID string) error {
+	exists, err := rs.TaskExists(ctx, chatID, taskID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNotFound
+	}
+
+	pipe := rs.client.TxPipeline()
+
+	pipe.SAdd(ctx, fmt.Sprintf(conflictGroupKey, chatID, group), taskID)
+	pipe.SAdd(ctx, fmt.Sprintf(conflictGroupsKey, chatID), group)
+	pipe.SAdd(ctx, fmt.Sprintf(taskConflictsKey, chatID, taskID), group)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add task to conflict group: %w", err)
+	}
+
+	return nil
+}
+
+// Removes a task from a conflict group. Empty groups are deleted
+func (rs *Storage) RemoveTaskFromConflictGroup(ctx context.Context, chatID int64, group string, taskID string) error {
+	groupKey := fmt.Sprintf(conflictGroupKey, chatID, group)
+
+	removed, err := rs.client.SRem(ctx, groupKey, taskID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to remove task from conflict group: %w", err)
+	}
+
+	if removed == 0 {
+		return ErrNotFound
+	}
+
+	if err := rs.client.SRem(ctx, fmt.Sprintf(taskConflictsKey, chatID, taskID), group).Err(); err != nil {
+		return fmt.Errorf("failed to remove conflict group from task: %w", err)
+	}
+
+	return rs.cleanupConflictGroup(ctx, chatID, group)
+}
+
+// Deletes a conflict group with all its memberships
+func (rs *Storage) DeleteConflictGroup(ctx context.Context, chatID int64, group string) error {
+	groupKey := fmt.Sprintf(conflictGroupKey, chatID, group)
+
+	taskIDs, err := rs.client.SMembers(ctx, groupKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get conflict group: %w", err)
+	}
+
+	if len(taskIDs) == 0 {
+		return ErrNotFound
+	}
+
+	pipe := rs.client.TxPipeline()
+
+	for _, taskID := range taskIDs {
+		pipe.SRem(ctx, fmt.Sprintf(taskConflictsKey, chatID, taskID), group)
+	}
+
+	pipe.Del(ctx, groupKey)
+	pipe.SRem(ctx, fmt.Sprintf(conflictGroupsKey, chatID), group)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete conflict group: %w", err)
+	}
+
+	return nil
+}
+
+// Gets all conflict groups of chat sorted by name
+func (rs *Storage) GetConflictGroups(ctx context.Context, chatID int64) ([]*models.ConflictGroup, error) {
+	names, err := rs.client.SMembers(ctx, fmt.Sprintf(conflictGroupsKey, chatID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conflict groups: %w", err)
+	}
+
+	sort.Strings(names)
+
+	groups := make([]*models.ConflictGroup, 0, len(names))
+
+	for _, name := range names {
+		taskIDs, err := rs.client.SMembers(ctx, fmt.Sprintf(conflictGroupKey, chatID, name)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conflict group: %w", err)
+		}
+
+		sort.Strings(taskIDs)
+
+		groups = append(groups, &models.ConflictGroup{
+			Name:    name,
+			ChatID:  chatID,
+			TaskIDs: taskIDs,
+		})
+	}
+
+	return groups, nil
+}
+
+// Returns a *ConflictError if any task sharing a conflict group with taskID is active
+func (rs *Storage) CheckConflicts(ctx context.Context, chatID int64, taskID string) error {
+	return rs.checkConflicts(ctx, rs.client, chatID, taskID)
+}
+
+// Checks conflicts using the given command executor (client or WATCH transaction)
+func (rs *Storage) checkConflicts(ctx context.Context, cmd redis.Cmdable, chatID int64, taskID string) error {
+	groups, err := cmd.SMembers(ctx, fmt.Sprintf(taskConflictsKey, chatID, taskID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get task conflict groups: %w", err)
+	}
+
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		taskIDs, err := cmd.SMembers(ctx, fmt.Sprintf(conflictGroupKey, chatID, group)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get conflict group: %w", err)
+		}
+
+		sort.Strings(taskIDs)
+
+		for _, otherID := range taskIDs {
+			if otherID == taskID {
+				continue
+			}
+
+			active, err := cmd.Exists(ctx, fmt.Sprintf(activeTaskPrefix, chatID, otherID)).Result()
+			if err != nil {
+				return fmt.Errorf("failed to check if task is active: %w", err)
+			}
+
+			if active > 0 {
+				return &ConflictError{Group: group, BlockerID: otherID}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the active task keys of all tasks that share a conflict group with taskID
+func (rs *Storage) conflictWatchKeys(ctx context.Context, chatID int64, taskID string) ([]string, error) {
+	groups, err := rs.client.SMembers(ctx, fmt.Sprintf(taskConflictsKey, chatID, taskID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task conflict groups: %w", err)
+	}
+
+	keys := make([]string, 0, len(groups))
+
+	for _, group := range groups {
+		groupKey := fmt.Sprintf(conflictGroupKey, chatID, group)
+		keys = append(keys, groupKey)
+
+		taskIDs, err := rs.client.SMembers(ctx, groupKey).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conflict group: %w", err)
+		}
+
+		for _, otherID := range taskIDs {
+			if otherID != taskID {
+				keys = append(keys, fmt.Sprintf(activeTaskPrefix, chatID, otherID))
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// Removes a task from all its conflict groups
+func (rs *Storage) removeTaskConflicts(ctx context.Context, chatID int64, taskID string) error {
+	taskConflictsK := fmt.Sprintf(taskConflictsKey, chatID, taskID)
+
+	groups, err := rs.client.SMembers(ctx, taskConflictsK).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get task conflict groups: %w", err)
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	pipe := rs.client.TxPipeline()
+
+	for _, group := range groups {
+		pipe.SRem(ctx, fmt.Sprintf(conflictGroupKey, chatID, group), taskID)
+	}
+
+	pipe.Del(ctx, taskConflictsK)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove task from conflict groups: %w", err)
+	}
+
+	for _, group := range groups {
+		if err := rs.cleanupConflictGroup(ctx, chatID, group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Drops the group from the chat's group list when it has no tasks left
+func (rs *Storage) cleanupConflictGroup(ctx context.Context, chatID int64, group string) error {
+	count, err := rs.client.SCard(ctx, fmt.Sprintf(conflictGroupKey, chatID, group)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to count conflict group tasks: %w", err)
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	if err := rs.client.SRem(ctx, fmt.Sprintf(conflictGroupsKey, chatID), group).Err(); err != nil {
+		return fmt.Errorf("failed to remove conflict group: %w", err)
+	}
+
+	return nil
+}
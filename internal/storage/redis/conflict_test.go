@@ -0,0 +1,177 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestConflictGroups(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(12345)
+
+	for _, task := range []*models.Task{
+		{ID: "api01", Name: "api-staging", ChatID: chatID},
+		{ID: "wrk01", Name: "worker-staging", ChatID: chatID},
+		{ID: "oth01", Name: "other", ChatID: chatID},
+	} {
+		if err := storage.AddTask(ctx, task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+
+	newActiveTask := func(taskID string) *models.ActiveTask {
+		now := time.Now()
+
+		return &models.ActiveTask{
+			TaskID:    taskID,
+			UserID:    1,
+			ChatID:    chatID,
+			StartTime: now,
+			EndTime:   now.Add(30 * time.Minute),
+			Duration:  30,
+		}
+	}
+
+	t.Run("AddTaskToConflictGroup", func(t *testing.T) {
+		for _, taskID := range []string{"api01", "wrk01"} {
+			if err := storage.AddTaskToConflictGroup(ctx, chatID, "db-staging", taskID); err != nil {
+				t.Fatalf("Failed to add task to conflict group: %v", err)
+			}
+		}
+
+		err := storage.AddTaskToConflictGroup(ctx, chatID, "db-staging", "missing")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for missing task, got %v", err)
+		}
+
+		groups, err := storage.GetConflictGroups(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to get conflict groups: %v", err)
+		}
+
+		if len(groups) != 1 || groups[0].Name != "db-staging" || len(groups[0].TaskIDs) != 2 {
+			t.Errorf("Unexpected conflict groups: %+v", groups)
+		}
+	})
+
+	t.Run("StartTaskBlockedByConflict", func(t *testing.T) {
+		if err := storage.StartTask(ctx, newActiveTask("api01")); err != nil {
+			t.Fatalf("Failed to start task: %v", err)
+		}
+
+		err := storage.StartTask(ctx, newActiveTask("wrk01"))
+
+		var conflictErr *ConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("Expected ConflictError, got %v", err)
+		}
+
+		if conflictErr.BlockerID != "api01" || conflictErr.Group != "db-staging" {
+			t.Errorf("Unexpected conflict: %+v", conflictErr)
+		}
+
+		if !errors.Is(err, ErrTaskConflict) {
+			t.Errorf("Expected error to match ErrTaskConflict")
+		}
+
+		if _, err := storage.GetActiveTask(ctx, chatID, "wrk01"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Conflicting task must not become active, got %v", err)
+		}
+
+		// Задачи вне группы не затрагиваются
+		if err := storage.StartTask(ctx, newActiveTask("oth01")); err != nil {
+			t.Errorf("Failed to start unrelated task: %v", err)
+		}
+	})
+
+	t.Run("StartTaskAfterBlockerEnded", func(t *testing.T) {
+		if err := storage.EndTask(ctx, chatID, "api01"); err != nil {
+			t.Fatalf("Failed to end task: %v", err)
+		}
+
+		if err := storage.CheckConflicts(ctx, chatID, "wrk01"); err != nil {
+			t.Errorf("Expected no conflicts, got %v", err)
+		}
+
+		if err := storage.StartTask(ctx, newActiveTask("wrk01")); err != nil {
+			t.Fatalf("Failed to start task: %v", err)
+		}
+
+		var conflictErr *ConflictError
+		if err := storage.CheckConflicts(ctx, chatID, "api01"); !errors.As(err, &conflictErr) || conflictErr.BlockerID != "wrk01" {
+			t.Errorf("Expected conflict with wrk01, got %v", err)
+		}
+
+		if err := storage.EndTask(ctx, chatID, "wrk01"); err != nil {
+			t.Fatalf("Failed to end task: %v", err)
+		}
+	})
+
+	t.Run("RemoveTaskFromConflictGroup", func(t *testing.T) {
+		if err := storage.RemoveTaskFromConflictGroup(ctx, chatID, "db-staging", "oth01"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for task outside the group, got %v", err)
+		}
+
+		if err := storage.RemoveTaskFromConflictGroup(ctx, chatID, "db-staging", "wrk01"); err != nil {
+			t.Fatalf("Failed to remove task from conflict group: %v", err)
+		}
+
+		groups, err := storage.GetConflictGroups(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to get conflict groups: %v", err)
+		}
+
+		if len(groups) != 1 || len(groups[0].TaskIDs) != 1 || groups[0].TaskIDs[0] != "api01" {
+			t.Errorf("Unexpected conflict groups: %+v", groups)
+		}
+	})
+
+	t.Run("DeleteTaskLeavesGroups", func(t *testing.T) {
+		if err := storage.DeleteTask(ctx, chatID, "api01"); err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		groups, err := storage.GetConflictGroups(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to get conflict groups: %v", err)
+		}
+
+		if len(groups) != 0 {
+			t.Errorf("Expected empty group to be removed, got %+v", groups)
+		}
+	})
+
+	t.Run("DeleteConflictGroup", func(t *testing.T) {
+		if err := storage.AddTaskToConflictGroup(ctx, chatID, "shared", "wrk01"); err != nil {
+			t.Fatalf("Failed to add task to conflict group: %v", err)
+		}
+
+		if err := storage.DeleteConflictGroup(ctx, chatID, "shared"); err != nil {
+			t.Fatalf("Failed to delete conflict group: %v", err)
+		}
+
+		if err := storage.DeleteConflictGroup(ctx, chatID, "shared"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for deleted group, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/models"
+)
+
+// Watches of tasks that never get free are forgotten after this time
+const taskWatchersTTL = 7 * 24 * time.Hour
+
+// Saves the notification settings of a user
+func (rs *Storage) SaveNotifySettings(ctx context.Context, settings *models.NotifySettings) error {
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification settings: %w", err)
+	}
+
+	if err := rs.client.Set(ctx, fmt.Sprintf(notifySettingsPrefix, settings.UserID), settingsJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	return nil
+}
+
+// Gets the notification settings of a user, ErrNotFound if they never set any
+func (rs *Storage) GetNotifySettings(ctx context.Context, userID int64) (*models.NotifySettings, error) {
+	data, err := rs.client.Get(ctx, fmt.Sprintf(notifySettingsPrefix, userID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	var settings models.NotifySettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// Adds a user to the ones waiting for a task to get free
+func (rs *Storage) AddTaskWatcher(ctx context.Context, chatID int64, taskID string, userID int64) error {
+	key := fmt.Sprintf(taskWatchersKey, chatID, taskID)
+
+	pipe := rs.client.TxPipeline()
+	pipe.SAdd(ctx, key, userID)
+	pipe.Expire(ctx, key, taskWatchersTTL)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add task watcher: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the users waiting for a task and forgets them, a watch is notified once
+func (rs *Storage) TakeTaskWatchers(ctx context.Context, chatID int64, taskID string) ([]int64, error) {
+	key := fmt.Sprintf(taskWatchersKey, chatID, taskID)
+
+	pipe := rs.client.TxPipeline()
+	members := pipe.SMembers(ctx, key)
+	pipe.Del(ctx, key)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to take task watchers: %w", err)
+	}
+
+	userIDs := make([]int64, 0, len(members.Val()))
+
+	for _, member := range members.Val() {
+		userID, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task watcher %q: %w", member, err)
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, nil
+}
+
+// Counts the users waiting for each of the tasks, tasks nobody waits for are left out
+func (rs *Storage) CountTaskWatchers(ctx context.Context, chatID int64, taskIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return counts, nil
+	}
+
+	pipe := rs.client.Pipeline()
+
+	cmds := make([]*redis.IntCmd, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		cmds = append(cmds, pipe.SCard(ctx, fmt.Sprintf(taskWatchersKey, chatID, taskID)))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count task watchers: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		if count := cmd.Val(); count > 0 {
+			counts[taskIDs[i]] = count
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestNotifySettings(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+
+	if _, err := storage.GetNotifySettings(ctx, 42); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	settings := &models.NotifySettings{
+		UserID:        42,
+		PendingEmail:  "dev@example.com",
+		CodeHash:      "abc",
+		CodeExpiresAt: time.Date(2025, 1, 1, 12, 15, 0, 0, time.UTC),
+		UpdatedAt:     time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	if err := storage.SaveNotifySettings(ctx, settings); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+
+	stored, err := storage.GetNotifySettings(ctx, 42)
+	if err != nil {
+		t.Fatalf("Failed to get settings: %v", err)
+	}
+
+	if *stored != *settings {
+		t.Errorf("Expected %+v, got %+v", settings, stored)
+	}
+}
+
+func TestTaskWatchers(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(-1001234567890)
+
+	for _, userID := range []int64{42, 43, 42} {
+		if err := storage.AddTaskWatcher(ctx, chatID, "task1", userID); err != nil {
+			t.Fatalf("Failed to add watcher: %v", err)
+		}
+	}
+
+	// Наблюдение за задачей не вечное
+	if ttl := miniRedis.TTL("watchers:-1001234567890:task1"); ttl != taskWatchersTTL {
+		t.Errorf("Expected TTL %v, got %v", taskWatchersTTL, ttl)
+	}
+
+	// Очередь считается без изменения, задачи без наблюдателей не попадают в ответ
+	counts, err := storage.CountTaskWatchers(ctx, chatID, []string{"task1", "task2"})
+	if err != nil {
+		t.Fatalf("Failed to count watchers: %v", err)
+	}
+
+	if len(counts) != 1 || counts["task1"] != 2 {
+		t.Errorf("Expected 2 watchers of task1 only, got %v", counts)
+	}
+
+	watchers, err := storage.TakeTaskWatchers(ctx, chatID, "task1")
+	if err != nil {
+		t.Fatalf("Failed to take watchers: %v", err)
+	}
+
+	slices.Sort(watchers)
+
+	if !slices.Equal(watchers, []int64{42, 43}) {
+		t.Errorf("Expected watchers [42 43], got %v", watchers)
+	}
+
+	// Каждое наблюдение срабатывает один раз
+	watchers, err = storage.TakeTaskWatchers(ctx, chatID, "task1")
+	if err != nil || len(watchers) != 0 {
+		t.Errorf("Expected no watchers left, got %v (%v)", watchers, err)
+	}
+}
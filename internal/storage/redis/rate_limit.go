@@ -0,0 +1,97 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Refills and takes from a token bucket in one step, so concurrent requests
+// never see the same tokens. A missing bucket is a full one, the bucket expires
+// once it would be full again
+// KEYS[1] is the bucket, ARGV are the bucket size, the refill period and now,
+// both in milliseconds. Returns 1 or 0 for a taken token and the tokens left
+var takeTokenScript = redis.NewScript(`
+local requests = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local tokens = requests
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+if state[1] then
+	tokens = tonumber(state[1])
+	local elapsed = now - tonumber(state[2])
+	if elapsed > 0 then
+		tokens = tokens + elapsed * requests / period
+	end
+end
+
+if tokens > requests then
+	tokens = requests
+end
+
+local allowed = 0
+if tokens >= 1 then
+	allowed = 1
+	tokens = tokens - 1
+end
+
+local left = string.format('%.17g', tokens)
+local ttl = math.max(math.ceil((requests - tokens) * period / requests), 1)
+
+redis.call('HSET', KEYS[1], 'tokens', left, 'updated', ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ttl)
+
+return {allowed, left}
+`)
+
+// Refills the token bucket of the key up to now and takes a token if there is one
+// Returns whether a token was taken and the tokens left
+func (rs *Storage) TakeRateLimitToken(ctx context.Context, key string, requests int, period time.Duration, now time.Time) (bool, float64, error) {
+	bucketKey := fmt.Sprintf(rateLimitPrefix, key)
+
+	result, err := takeTokenScript.Run(ctx, rs.client, []string{bucketKey}, requests, period.Milliseconds(), now.UnixMilli()).Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	var (
+		allowed int64
+		left    string
+		ok      bool
+	)
+
+	if len(result) == 2 {
+		allowed, ok = result[0].(int64)
+		if ok {
+			left, ok = result[1].(string)
+		}
+	}
+
+	if !ok {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to parse rate limit tokens: %w", err)
+	}
+
+	return allowed == 1, tokens, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Полный bucket на 3 запроса в минуту: три запроса проходят, четвертый нет
+	for i := range 3 {
+		allowed, tokens, err := storage.TakeRateLimitToken(ctx, "key:a", 3, time.Minute, now)
+		if err != nil {
+			t.Fatalf("Failed to take token: %v", err)
+		}
+
+		if !allowed || tokens != float64(2-i) {
+			t.Errorf("Request %d: expected allowed with %d tokens left, got %v with %v", i, 2-i, allowed, tokens)
+		}
+	}
+
+	allowed, _, err := storage.TakeRateLimitToken(ctx, "key:a", 3, time.Minute, now)
+	if err != nil {
+		t.Fatalf("Failed to take token: %v", err)
+	}
+
+	if allowed {
+		t.Error("Expected request over the limit to be rejected")
+	}
+
+	// Другие ключи не затронуты
+	if allowed, _, _ := storage.TakeRateLimitToken(ctx, "key:b", 3, time.Minute, now); !allowed {
+		t.Error("Expected request of another key to be allowed")
+	}
+
+	// За 20 секунд восстанавливается один токен
+	allowed, tokens, err := storage.TakeRateLimitToken(ctx, "key:a", 3, time.Minute, now.Add(20*time.Second))
+	if err != nil {
+		t.Fatalf("Failed to take token: %v", err)
+	}
+
+	if !allowed || tokens != 0 {
+		t.Errorf("Expected refilled token to be taken, got %v with %v tokens", allowed, tokens)
+	}
+
+	// Bucket истекает, когда снова был бы полным
+	ttl := miniRedis.TTL(fmt.Sprintf(rateLimitPrefix, "key:a"))
+	if ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected bucket to expire within a minute, got %v", ttl)
+	}
+}
+
+func TestTakeRateLimitTokenConcurrent(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Одновременные запросы к одному ключу получают ровно столько токенов, сколько есть
+	const (
+		requests = 10
+		takers   = 50
+	)
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+
+	for range takers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ok, _, err := storage.TakeRateLimitToken(ctx, "key:hot", requests, time.Minute, now)
+			if err != nil {
+				t.Errorf("Failed to take token: %v", err)
+				return
+			}
+
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := allowed.Load(); got != requests {
+		t.Errorf("Expected %d of %d concurrent requests to be allowed, got %d", requests, takers, got)
+	}
+}
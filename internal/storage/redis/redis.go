@@ -20,11 +20,20 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/clock"
+	"time-guard-bot/internal/metrics"
 )
 
 // Is returned when a requested item is not found
 var ErrNotFound = errors.New("not found")
 
+// Are returned by StartTask when the task can't be started
+var (
+	ErrTaskActive = errors.New("task is already active")
+	ErrTaskLocked = errors.New("task is locked")
+)
+
 // Redis key prefixes
 const (
 	// Полная информация о task в JSON формате
@@ -41,11 +50,51 @@ const (
 	userTasksKey = "user:%d:%d" // user:chatID:userID
 	// Set всех чатов с активными задачами
 	activeChatsKey = "active_chats"
+	// Set id's задач, входящих в группу взаимного исключения
+	conflictGroupKey = "conflict:%d:%s" // conflict:chatID:group
+	// Set имен всех групп взаимного исключения чата
+	conflictGroupsKey = "conflicts:%d" // conflicts:chatID
+	// Set имен групп, в которые входит задача
+	taskConflictsKey = "task_conflicts:%d:%s" // task_conflicts:chatID:taskID
+	// ID закрепленного сообщения со статусом задач
+	boardMessageKey = "board:%d" // board:chatID
+	// Set всех чатов с закрепленным статусом
+	boardChatsKey = "boards"
+	// Hash известных пользователей чата: userID -> JSON
+	chatUsersKey = "users:%d" // users:chatID
+	// Информация об API ключе в JSON формате
+	apiKeyPrefix = "api_key:%s" // api_key:keyID
+	// Индекс для поиска API ключа по хэшу
+	apiKeyHashPrefix = "api_key_hash:%s" // api_key_hash:hash
+	// Set id's всех API ключей чата
+	chatAPIKeysKey = "api_keys:%d" // api_keys:chatID
+	// Информация о вебхуке в JSON формате
+	webhookPrefix = "webhook:%s" // webhook:webhookID
+	// Set id's всех вебхуков чата
+	chatWebhooksKey = "webhooks:%d" // webhooks:chatID
+	// Доставка события вебхуку в JSON формате, хранится deliveryTTL
+	deliveryPrefix = "delivery:%s" // delivery:deliveryID
+	// List id's последних доставок вебхука, новые в начале
+	webhookDeliveriesKey = "webhook_deliveries:%s" // webhook_deliveries:webhookID
+	// Sorted set id's доставок, ожидающих отправки, score - время следующей попытки в мс
+	deliveryQueueKey = "webhook_queue"
+	// Hash с состоянием token bucket: tokens и время обновления в мс
+	rateLimitPrefix = "rate_limit:%s" // rate_limit:key
+	// Канал другого мессенджера, которому принадлежит чат, в JSON формате
+	chatLinkPrefix = "chat_link:%d" // chat_link:chatID
+	// Настройки уведомлений пользователя в JSON формате
+	notifySettingsPrefix = "notify:%d" // notify:userID
+	// Set id's пользователей, ждущих освобождения задачи, хранится taskWatchersTTL
+	taskWatchersKey = "watchers:%d:%s" // watchers:chatID:taskID
 )
 
+// Number of attempts for optimistic (WATCH) transactions
+const maxTxRetries = 5
+
 // Implements Storage using Redis
 type Storage struct {
 	client *redis.Client
+	clock  clock.Clock // Measures the remaining time of timers for key TTLs
 }
 
 // Creates a new Redis storage
@@ -55,6 +104,7 @@ func New(addr, password string, db int) (*Storage, error) {
 		Password: password,
 		DB:       db,
 	})
+	client.AddHook(metrics.RedisHook{})
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -64,7 +114,21 @@ func New(addr, password string, db int) (*Storage, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &Storage{client: client}, nil
+	return &Storage{client: client, clock: clock.New()}, nil
+}
+
+// Replaces the clock key TTLs are calculated with, for tests
+func (rs *Storage) SetClock(c clock.Clock) {
+	rs.clock = c
+}
+
+// Checks that Redis answers
+func (rs *Storage) Ping(ctx context.Context) error {
+	if err := rs.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping Redis: %w", err)
+	}
+
+	return nil
 }
 
 // Closes the Redis connection
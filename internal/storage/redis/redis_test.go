@@ -14,10 +14,13 @@
 package redis
 
 import (
+	"context"
 	"testing"
 
 	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/clock"
 )
 
 // настраиваем минисервер Redis для тестирования
@@ -36,7 +39,7 @@ func setupMiniRedis(t *testing.T) (*miniredis.Miniredis, *Storage) {
 	})
 
 	// Создаем хранилище с нашим тестовым клиентом
-	storage := &Storage{client: client}
+	storage := &Storage{client: client, clock: clock.New()}
 
 	return miniRedis, storage
 }
@@ -57,3 +60,18 @@ func TestCreateConnectionAndClose(t *testing.T) {
 		t.Fatalf("Failed to close Redis connection: %v", err)
 	}
 }
+
+func TestPing(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+
+	if err := storage.Ping(context.Background()); err != nil {
+		t.Fatalf("Unexpected ping error: %v", err)
+	}
+
+	// Остановленный сервер не отвечает
+	miniRedis.Close()
+
+	if err := storage.Ping(context.Background()); err == nil {
+		t.Error("Expected ping to fail after Redis stopped")
+	}
+}
@@ -202,7 +202,8 @@ func (rs *Storage) DeleteTask(ctx context.Context, chatID int64, taskID string)
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
 
-	return nil
+	// Remove task from its conflict groups
+	return rs.removeTaskConflicts(ctx, chatID, taskID)
 }
 
 // Retrieves all tasks of chat
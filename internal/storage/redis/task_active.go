@@ -26,29 +26,74 @@ import (
 )
 
 // Starts a task
+// The whole check-and-set runs in a WATCH transaction, so a conflicting task
+// started concurrently makes one of the two calls fail instead of both succeeding
 func (rs *Storage) StartTask(ctx context.Context, activeTask *models.ActiveTask) error {
+	activeTaskKey := fmt.Sprintf(activeTaskPrefix, activeTask.ChatID, activeTask.TaskID)
+	taskKey := fmt.Sprintf(taskIDPrefix, activeTask.ChatID, activeTask.TaskID)
+	taskConflictsK := fmt.Sprintf(taskConflictsKey, activeTask.ChatID, activeTask.TaskID)
+
+	for range maxTxRetries {
+		conflictKeys, err := rs.conflictWatchKeys(ctx, activeTask.ChatID, activeTask.TaskID)
+		if err != nil {
+			return err
+		}
+
+		watchKeys := append([]string{activeTaskKey, taskKey, taskConflictsK}, conflictKeys...)
+
+		err = rs.client.Watch(ctx, func(tx *redis.Tx) error {
+			return rs.startTaskTx(ctx, tx, activeTask)
+		}, watchKeys...)
+		if errors.Is(err, redis.TxFailedErr) {
+			// Watched keys changed, retry with fresh data
+			continue
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("failed to start task: too many concurrent updates")
+}
+
+// Checks and starts a task inside a WATCH transaction
+func (rs *Storage) startTaskTx(ctx context.Context, tx *redis.Tx, activeTask *models.ActiveTask) error {
 	// Check if task exists
-	task, err := rs.GetTask(ctx, activeTask.ChatID, activeTask.TaskID)
+	taskKey := fmt.Sprintf(taskIDPrefix, activeTask.ChatID, activeTask.TaskID)
+
+	data, err := tx.Get(ctx, taskKey).Bytes()
 	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return fmt.Errorf("failed to get task: %w", ErrNotFound)
+		}
+
 		return fmt.Errorf("failed to get task: %w", err)
 	}
 
-	// TODO do with Storage method?
+	task, err := models.UnmarshalTask(data)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
 	// Check if task is already active
 	activeTaskKey := fmt.Sprintf(activeTaskPrefix, activeTask.ChatID, activeTask.TaskID)
 
-	exists, err := rs.client.Exists(ctx, activeTaskKey).Result()
+	exists, err := tx.Exists(ctx, activeTaskKey).Result()
 	if err != nil {
 		return fmt.Errorf("failed to check if task is active: %w", err)
 	}
 
 	if exists > 0 {
-		return fmt.Errorf("task is already active")
+		return ErrTaskActive
 	}
 
 	// Check if task is locked
 	if task.IsLocked {
-		return fmt.Errorf("task is locked: %s", task.LockReason) // FIXME?
+		return fmt.Errorf("%w: %s", ErrTaskLocked, task.LockReason)
+	}
+
+	// Check if a task from the same conflict group is active
+	if err := rs.checkConflicts(ctx, tx, activeTask.ChatID, activeTask.TaskID); err != nil {
+		return err
 	}
 
 	// Count user's active tasks
@@ -65,6 +110,7 @@ func (rs *Storage) StartTask(ctx context.Context, activeTask *models.ActiveTask)
 	task.StartTime = activeTask.StartTime
 	task.EndTime = activeTask.EndTime
 	task.Duration = activeTask.Duration
+	task.PausedAt = activeTask.PausedAt
 	task.MessageID = activeTask.MessageID
 	task.BotResponseID = activeTask.BotResponseID
 
@@ -74,32 +120,33 @@ func (rs *Storage) StartTask(ctx context.Context, activeTask *models.ActiveTask)
 		return fmt.Errorf("failed to marshal task: %w", err)
 	}
 
-	// Get Redis pipeline
-	pipe := rs.client.Pipeline()
-
 	// Calculate TTL: task duration + 10 minutes safety margin
 	ttl := time.Duration(activeTask.Duration+10) * time.Minute
 
-	// Save active task with TTL
-	pipe.Set(ctx, activeTaskKey, activeTaskJSON, ttl)
+	_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// Save active task with TTL
+		pipe.Set(ctx, activeTaskKey, activeTaskJSON, ttl)
 
-	// Add to active task list
-	activeTaskListKey := fmt.Sprintf(activeTaskListKey, activeTask.ChatID)
-	pipe.SAdd(ctx, activeTaskListKey, activeTask.TaskID)
+		// Add to active task list
+		activeTaskListKey := fmt.Sprintf(activeTaskListKey, activeTask.ChatID)
+		pipe.SAdd(ctx, activeTaskListKey, activeTask.TaskID)
 
-	// Add to user's active tasks
-	pipe.SAdd(ctx, userTasksKey, activeTask.TaskID)
+		// Add to user's active tasks
+		pipe.SAdd(ctx, userTasksKey, activeTask.TaskID)
 
-	// Add chat to active chats set
-	pipe.SAdd(ctx, activeChatsKey, activeTask.ChatID)
+		// Add chat to active chats set
+		pipe.SAdd(ctx, activeChatsKey, activeTask.ChatID)
 
-	// Update task status
-	taskKey := fmt.Sprintf(taskIDPrefix, task.ChatID, task.ID)
-	pipe.Set(ctx, taskKey, taskJSON, 0)
+		// Update task status
+		pipe.Set(ctx, taskKey, taskJSON, 0)
 
-	// Execute pipeline
-	_, err = pipe.Exec(ctx)
+		return nil
+	})
 	if err != nil {
+		if errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+
 		return fmt.Errorf("failed to start task: %w", err)
 	}
 
@@ -125,6 +172,7 @@ func (rs *Storage) EndTask(ctx context.Context, chatID int64, taskID string) err
 	task.StartTime = time.Time{}
 	task.EndTime = time.Time{}
 	task.Duration = 0
+	task.PausedAt = time.Time{}
 	task.MessageID = 0
 
 	// Marshal updated task to JSON
@@ -175,6 +223,61 @@ func (rs *Storage) EndTask(ctx context.Context, chatID int64, taskID string) err
 	return nil
 }
 
+// Updates an active task (extension, pause, resume) and mirrors its timing into the task
+func (rs *Storage) UpdateActiveTask(ctx context.Context, activeTask *models.ActiveTask) error {
+	activeTaskKey := fmt.Sprintf(activeTaskPrefix, activeTask.ChatID, activeTask.TaskID)
+
+	exists, err := rs.client.Exists(ctx, activeTaskKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check if task is active: %w", err)
+	}
+
+	if exists == 0 {
+		return ErrNotFound
+	}
+
+	task, err := rs.GetTask(ctx, activeTask.ChatID, activeTask.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	activeTaskJSON, err := json.Marshal(activeTask)
+	if err != nil {
+		return fmt.Errorf("failed to marshal active task: %w", err)
+	}
+
+	task.StartTime = activeTask.StartTime
+	task.EndTime = activeTask.EndTime
+	task.Duration = activeTask.Duration
+	task.PausedAt = activeTask.PausedAt
+	task.BotResponseID = activeTask.BotResponseID
+
+	taskJSON, err := task.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	// A paused timer lives until its pause runs out, a running one until its end,
+	// both with the usual 10 minutes safety margin
+	ttl := time.Duration(activeTask.RemainingAt(rs.clock.Now())) * time.Second
+	if activeTask.IsPaused() {
+		ttl = max(activeTask.PauseEndsAt().Sub(rs.clock.Now()), 0)
+	}
+
+	ttl += 10 * time.Minute
+
+	pipe := rs.client.TxPipeline()
+
+	pipe.Set(ctx, activeTaskKey, activeTaskJSON, ttl)
+	pipe.Set(ctx, fmt.Sprintf(taskIDPrefix, task.ChatID, task.ID), taskJSON, 0)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update active task: %w", err)
+	}
+
+	return nil
+}
+
 // Gets an active task by ID
 func (rs *Storage) GetActiveTask(ctx context.Context, chatID int64, taskID string) (*models.ActiveTask, error) {
 	activeTaskKey := fmt.Sprintf(activeTaskPrefix, chatID, taskID)
@@ -198,6 +301,22 @@ func (rs *Storage) GetActiveTask(ctx context.Context, chatID int64, taskID strin
 	return &activeTask, nil
 }
 
+// Gets the active task whose command or bot response has the given message ID
+func (rs *Storage) GetActiveTaskByMessage(ctx context.Context, chatID int64, messageID int) (*models.ActiveTask, error) {
+	activeTasks, err := rs.GetActiveTasks(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, activeTask := range activeTasks {
+		if activeTask.BotResponseID == messageID || activeTask.MessageID == messageID {
+			return activeTask, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
 // Gets all active chat tasks
 func (rs *Storage) GetActiveTasks(ctx context.Context, chatID int64) ([]*models.ActiveTask, error) {
 	activeTaskListKey := fmt.Sprintf(activeTaskListKey, chatID)
@@ -266,6 +385,64 @@ func (rs *Storage) GetCountUserActiveTasks(ctx context.Context, chatID int64, us
 	return count, nil
 }
 
+// Counts active tasks by chat in three round trips
+// Set members whose active task key has expired are skipped, as in GetActiveTasks
+func (rs *Storage) CountActiveTasks(ctx context.Context) (map[int64]int64, error) {
+	chatIDs, err := rs.GetActiveChats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]int64, len(chatIDs))
+	if len(chatIDs) == 0 {
+		return counts, nil
+	}
+
+	pipe := rs.client.Pipeline()
+
+	members := make([]*redis.StringSliceCmd, 0, len(chatIDs))
+	for _, chatID := range chatIDs {
+		members = append(members, pipe.SMembers(ctx, fmt.Sprintf(activeTaskListKey, chatID)))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get active task lists: %w", err)
+	}
+
+	pipe = rs.client.Pipeline()
+
+	exists := make(map[int64]*redis.IntCmd, len(chatIDs))
+	for i, chatID := range chatIDs {
+		taskIDs := members[i].Val()
+		if len(taskIDs) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(taskIDs))
+		for _, taskID := range taskIDs {
+			keys = append(keys, fmt.Sprintf(activeTaskPrefix, chatID, taskID))
+		}
+
+		exists[chatID] = pipe.Exists(ctx, keys...)
+	}
+
+	if len(exists) == 0 {
+		return counts, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count active tasks: %w", err)
+	}
+
+	for chatID, cmd := range exists {
+		if count := cmd.Val(); count > 0 {
+			counts[chatID] = count
+		}
+	}
+
+	return counts, nil
+}
+
 // Gets all chats with active tasks
 func (rs *Storage) GetActiveChats(ctx context.Context) ([]int64, error) {
 	// Get all chat IDs from the active chats set
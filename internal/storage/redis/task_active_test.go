@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"time-guard-bot/internal/clock"
 	"time-guard-bot/internal/models"
 )
 
@@ -389,3 +390,268 @@ func TestEmptyActiveTasks(t *testing.T) {
 		t.Errorf("Expected user active tasks count 0, got %d", count)
 	}
 }
+
+func TestCountActiveTasks(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+
+	if counts, err := storage.CountActiveTasks(ctx); err != nil || len(counts) != 0 {
+		t.Fatalf("Expected no active tasks, got %v, %v", counts, err)
+	}
+
+	// Задачи считаются по чатам
+	now := time.Now()
+	for i, chatID := range []int64{-100, -100, -200} {
+		taskID := fmt.Sprintf("task%d", i)
+
+		if err := storage.AddTask(ctx, &models.Task{ID: taskID, Name: taskID, ChatID: chatID}); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+
+		activeTask := &models.ActiveTask{TaskID: taskID, UserID: int64(i + 1), ChatID: chatID, StartTime: now, Duration: 30}
+		if err := storage.StartTask(ctx, activeTask); err != nil {
+			t.Fatalf("Failed to start task: %v", err)
+		}
+	}
+
+	counts, err := storage.CountActiveTasks(ctx)
+	if err != nil {
+		t.Fatalf("Failed to count active tasks: %v", err)
+	}
+
+	if len(counts) != 2 || counts[-100] != 2 || counts[-200] != 1 {
+		t.Errorf("Expected 2 active tasks in chat -100 and 1 in chat -200, got %v", counts)
+	}
+
+	// Запись в списке без ключа активной задачи (истек TTL) не считается
+	if _, err := miniRedis.SAdd(fmt.Sprintf(activeTaskListKey, -100), "expired"); err != nil {
+		t.Fatalf("Failed to add stale entry: %v", err)
+	}
+
+	miniRedis.Del(fmt.Sprintf(activeTaskPrefix, -200, "task2"))
+
+	counts, err = storage.CountActiveTasks(ctx)
+	if err != nil {
+		t.Fatalf("Failed to count active tasks: %v", err)
+	}
+
+	if len(counts) != 1 || counts[-100] != 2 {
+		t.Errorf("Expected only 2 active tasks in chat -100, got %v", counts)
+	}
+}
+
+func TestUpdateActiveTask(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(12345)
+	taskID := "task9"
+
+	if err := storage.AddTask(ctx, &models.Task{ID: taskID, Name: "Test_Task", ChatID: chatID}); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	activeTask := &models.ActiveTask{
+		TaskID:    taskID,
+		UserID:    1,
+		ChatID:    chatID,
+		StartTime: now,
+		EndTime:   now.Add(30 * time.Minute),
+		Duration:  30,
+	}
+
+	// Обновление неактивной задачи
+	if err := storage.UpdateActiveTask(ctx, activeTask); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for inactive task, got: %v", err)
+	}
+
+	if err := storage.StartTask(ctx, activeTask); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+
+	t.Run("Extend", func(t *testing.T) {
+		activeTask.Extend(15)
+
+		if err := storage.UpdateActiveTask(ctx, activeTask); err != nil {
+			t.Fatalf("Failed to update active task: %v", err)
+		}
+
+		stored, err := storage.GetActiveTask(ctx, chatID, taskID)
+		if err != nil {
+			t.Fatalf("Failed to get active task: %v", err)
+		}
+
+		if stored.Duration != 45 {
+			t.Errorf("Expected duration 45, got %d", stored.Duration)
+		}
+
+		task, err := storage.GetTask(ctx, chatID, taskID)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+
+		if task.Duration != 45 || !task.EndTime.Equal(activeTask.EndTime) {
+			t.Errorf("Task timing was not updated: %+v", task)
+		}
+	})
+
+	t.Run("PausedTaskLivesUntilPauseEnds", func(t *testing.T) {
+		activeTask.Pause(time.Now())
+
+		if err := storage.UpdateActiveTask(ctx, activeTask); err != nil {
+			t.Fatalf("Failed to update active task: %v", err)
+		}
+
+		ttl := miniRedis.TTL(fmt.Sprintf(activeTaskPrefix, chatID, taskID))
+		if ttl <= models.MaxPause || ttl > models.MaxPause+10*time.Minute {
+			t.Errorf("Expected TTL of the pause plus margin, got %v", ttl)
+		}
+
+		stored, err := storage.GetActiveTask(ctx, chatID, taskID)
+		if err != nil {
+			t.Fatalf("Failed to get active task: %v", err)
+		}
+
+		if !stored.IsPaused() {
+			t.Errorf("Expected stored task to be paused")
+		}
+
+		// Пауза отражается в задаче, чтобы остаток времени не убывал
+		task, err := storage.GetTask(ctx, chatID, taskID)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+
+		if !task.PausedAt.Equal(activeTask.PausedAt) {
+			t.Errorf("Expected task paused at %v, got %v", activeTask.PausedAt, task.PausedAt)
+		}
+	})
+
+	t.Run("ResumedTaskHasTTL", func(t *testing.T) {
+		activeTask.Resume(time.Now())
+
+		if err := storage.UpdateActiveTask(ctx, activeTask); err != nil {
+			t.Fatalf("Failed to update active task: %v", err)
+		}
+
+		ttl := miniRedis.TTL(fmt.Sprintf(activeTaskPrefix, chatID, taskID))
+		if ttl <= 45*time.Minute {
+			t.Errorf("Expected TTL over remaining time plus margin, got %v", ttl)
+		}
+
+		task, err := storage.GetTask(ctx, chatID, taskID)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+
+		if !task.PausedAt.IsZero() {
+			t.Errorf("Expected resumed task not paused, got %v", task.PausedAt)
+		}
+	})
+}
+
+func TestUpdateActiveTaskTTL(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(start)
+	storage.SetClock(fakeClock)
+
+	ctx := context.Background()
+	chatID := int64(12345)
+	taskID := "task9"
+	activeTaskKey := fmt.Sprintf(activeTaskPrefix, chatID, taskID)
+
+	if err := storage.AddTask(ctx, &models.Task{ID: taskID, Name: "Test_Task", ChatID: chatID}); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+
+	activeTask := &models.ActiveTask{
+		TaskID:    taskID,
+		UserID:    1,
+		ChatID:    chatID,
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+		Duration:  30,
+	}
+
+	if err := storage.StartTask(ctx, activeTask); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+
+	// TTL равен оставшемуся времени по часам плюс 10 минут запаса
+	tests := []struct {
+		name    string
+		advance time.Duration
+		extend  int
+		want    time.Duration
+	}{
+		{"After 10 minutes", 10 * time.Minute, 0, 30 * time.Minute},
+		{"Extended by 15 minutes", 0, 15, 45 * time.Minute},
+		{"After the end", time.Hour, 0, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock.Advance(tt.advance)
+			activeTask.Extend(tt.extend)
+
+			if err := storage.UpdateActiveTask(ctx, activeTask); err != nil {
+				t.Fatalf("Failed to update active task: %v", err)
+			}
+
+			if ttl := miniRedis.TTL(activeTaskKey); ttl != tt.want {
+				t.Errorf("Expected TTL %v, got %v", tt.want, ttl)
+			}
+		})
+	}
+}
+
+func TestGetActiveTaskByMessage(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(12345)
+
+	if err := storage.AddTask(ctx, &models.Task{ID: "task9", Name: "Test_Task", ChatID: chatID}); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+
+	now := time.Now()
+	activeTask := &models.ActiveTask{
+		TaskID:        "task9",
+		UserID:        1,
+		ChatID:        chatID,
+		StartTime:     now,
+		EndTime:       now.Add(30 * time.Minute),
+		Duration:      30,
+		MessageID:     100,
+		BotResponseID: 101,
+	}
+
+	if err := storage.StartTask(ctx, activeTask); err != nil {
+		t.Fatalf("Failed to start task: %v", err)
+	}
+
+	// Поиск по сообщению команды и по ответу бота
+	for _, messageID := range []int{100, 101} {
+		found, err := storage.GetActiveTaskByMessage(ctx, chatID, messageID)
+		if err != nil {
+			t.Fatalf("Failed to get active task by message %d: %v", messageID, err)
+		}
+
+		if found.TaskID != "task9" {
+			t.Errorf("Expected task9, got %s", found.TaskID)
+		}
+	}
+
+	if _, err := storage.GetActiveTaskByMessage(ctx, chatID, 102); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown message, got: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/models"
+)
+
+// Saves or updates a user in the chat's user directory
+func (rs *Storage) SaveChatUser(ctx context.Context, chatID int64, user *models.ChatUser) error {
+	userJSON, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %w", err)
+	}
+
+	usersKey := fmt.Sprintf(chatUsersKey, chatID)
+
+	if err := rs.client.HSet(ctx, usersKey, strconv.FormatInt(user.ID, 10), userJSON).Err(); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	return nil
+}
+
+// Gets a user from the chat's user directory
+func (rs *Storage) GetChatUser(ctx context.Context, chatID int64, userID int64) (*models.ChatUser, error) {
+	usersKey := fmt.Sprintf(chatUsersKey, chatID)
+
+	data, err := rs.client.HGet(ctx, usersKey, strconv.FormatInt(userID, 10)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var user models.ChatUser
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// Gets all known users of chat by ID
+func (rs *Storage) GetChatUsers(ctx context.Context, chatID int64) (map[int64]*models.ChatUser, error) {
+	usersKey := fmt.Sprintf(chatUsersKey, chatID)
+
+	data, err := rs.client.HGetAll(ctx, usersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	users := make(map[int64]*models.ChatUser, len(data))
+
+	for _, userJSON := range data {
+		var user models.ChatUser
+		if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
+			// Skip broken records
+			continue
+		}
+
+		users[user.ID] = &user
+	}
+
+	return users, nil
+}
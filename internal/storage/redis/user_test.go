@@ -0,0 +1,83 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestChatUserOperations(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(12345)
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		if _, err := storage.GetChatUser(ctx, chatID, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("SaveAndRename", func(t *testing.T) {
+		user := &models.ChatUser{ID: 1, Username: "alice", FirstName: "Alice", UpdatedAt: time.Now()}
+		if err := storage.SaveChatUser(ctx, chatID, user); err != nil {
+			t.Fatalf("Failed to save user: %v", err)
+		}
+
+		// Пользователь сменил имя
+		renamed := &models.ChatUser{ID: 1, Username: "alice", FirstName: "Alicia", LastName: "Smith", UpdatedAt: time.Now()}
+		if err := storage.SaveChatUser(ctx, chatID, renamed); err != nil {
+			t.Fatalf("Failed to save user: %v", err)
+		}
+
+		stored, err := storage.GetChatUser(ctx, chatID, 1)
+		if err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
+
+		if stored.DisplayName() != "Alicia Smith" {
+			t.Errorf("Expected display name 'Alicia Smith', got '%s'", stored.DisplayName())
+		}
+	})
+
+	t.Run("GetChatUsers", func(t *testing.T) {
+		if err := storage.SaveChatUser(ctx, chatID, &models.ChatUser{ID: 2, Username: "bob"}); err != nil {
+			t.Fatalf("Failed to save user: %v", err)
+		}
+
+		// Пользователь другого чата
+		if err := storage.SaveChatUser(ctx, chatID+1, &models.ChatUser{ID: 3, FirstName: "Carol"}); err != nil {
+			t.Fatalf("Failed to save user: %v", err)
+		}
+
+		users, err := storage.GetChatUsers(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to get users: %v", err)
+		}
+
+		if len(users) != 2 {
+			t.Fatalf("Expected 2 users, got %d", len(users))
+		}
+
+		if users[2].DisplayName() != "@bob" {
+			t.Errorf("Expected display name '@bob', got '%s'", users[2].DisplayName())
+		}
+	})
+}
@@ -0,0 +1,305 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"time-guard-bot/internal/models"
+)
+
+const (
+	// How long deliveries are kept for inspection
+	deliveryTTL = 7 * 24 * time.Hour
+	// Number of recent deliveries kept per webhook
+	maxWebhookDeliveries = 50
+)
+
+// Saves a new webhook
+func (rs *Storage) AddWebhook(ctx context.Context, webhook *models.Webhook) error {
+	webhookJSON, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	pipe := rs.client.TxPipeline()
+
+	pipe.Set(ctx, fmt.Sprintf(webhookPrefix, webhook.ID), webhookJSON, 0)
+	pipe.SAdd(ctx, fmt.Sprintf(chatWebhooksKey, webhook.ChatID), webhook.ID)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Gets a webhook by ID
+func (rs *Storage) GetWebhook(ctx context.Context, webhookID string) (*models.Webhook, error) {
+	data, err := rs.client.Get(ctx, fmt.Sprintf(webhookPrefix, webhookID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	var webhook models.Webhook
+	if err := json.Unmarshal(data, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+// Gets all webhooks of chat, oldest first
+func (rs *Storage) ListWebhooks(ctx context.Context, chatID int64) ([]*models.Webhook, error) {
+	webhookIDs, err := rs.client.SMembers(ctx, fmt.Sprintf(chatWebhooksKey, chatID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook IDs: %w", err)
+	}
+
+	webhooks := make([]*models.Webhook, 0, len(webhookIDs))
+
+	for _, webhookID := range webhookIDs {
+		webhook, err := rs.GetWebhook(ctx, webhookID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+
+	return webhooks, nil
+}
+
+// Deletes a webhook of chat, its pending deliveries fail on their next attempt
+func (rs *Storage) DeleteWebhook(ctx context.Context, chatID int64, webhookID string) error {
+	webhook, err := rs.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return err
+	}
+
+	// Webhooks of other chats look like missing ones
+	if webhook.ChatID != chatID {
+		return ErrNotFound
+	}
+
+	pipe := rs.client.TxPipeline()
+
+	pipe.Del(ctx, fmt.Sprintf(webhookPrefix, webhookID))
+	pipe.SRem(ctx, fmt.Sprintf(chatWebhooksKey, chatID), webhookID)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Saves a new delivery and queues it for its first attempt
+func (rs *Storage) AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	deliveryJSON, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery: %w", err)
+	}
+
+	deliveriesKey := fmt.Sprintf(webhookDeliveriesKey, delivery.WebhookID)
+
+	pipe := rs.client.TxPipeline()
+
+	pipe.Set(ctx, fmt.Sprintf(deliveryPrefix, delivery.ID), deliveryJSON, deliveryTTL)
+	pipe.LPush(ctx, deliveriesKey, delivery.ID)
+	pipe.LTrim(ctx, deliveriesKey, 0, maxWebhookDeliveries-1)
+	pipe.Expire(ctx, deliveriesKey, deliveryTTL)
+	pipe.ZAdd(ctx, deliveryQueueKey, &redis.Z{
+		Score:  float64(delivery.NextAttemptAt.UnixMilli()),
+		Member: delivery.ID,
+	})
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add delivery: %w", err)
+	}
+
+	return nil
+}
+
+// Saves the result of an attempt
+// A pending delivery is queued again for NextAttemptAt, a finished one leaves the queue
+func (rs *Storage) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	deliveryJSON, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery: %w", err)
+	}
+
+	pipe := rs.client.TxPipeline()
+
+	pipe.Set(ctx, fmt.Sprintf(deliveryPrefix, delivery.ID), deliveryJSON, deliveryTTL)
+
+	if delivery.Status == models.DeliveryPending {
+		pipe.ZAdd(ctx, deliveryQueueKey, &redis.Z{
+			Score:  float64(delivery.NextAttemptAt.UnixMilli()),
+			Member: delivery.ID,
+		})
+	} else {
+		pipe.ZRem(ctx, deliveryQueueKey, delivery.ID)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	return nil
+}
+
+// Takes up to limit deliveries that are due at now
+// Taken deliveries are hidden from other callers until claimUntil, so a delivery
+// whose sender died is picked up again instead of being lost
+func (rs *Storage) ClaimDueDeliveries(ctx context.Context, now time.Time, claimUntil time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveryIDs []string
+
+	claimed := false
+
+	for range maxTxRetries {
+		err := rs.client.Watch(ctx, func(tx *redis.Tx) error {
+			ids, err := tx.ZRangeByScore(ctx, deliveryQueueKey, &redis.ZRangeBy{
+				Min:   "-inf",
+				Max:   strconv.FormatInt(now.UnixMilli(), 10),
+				Count: int64(limit),
+			}).Result()
+			if err != nil {
+				return fmt.Errorf("failed to get due deliveries: %w", err)
+			}
+
+			if len(ids) == 0 {
+				deliveryIDs = nil
+				return nil
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, id := range ids {
+					pipe.ZAdd(ctx, deliveryQueueKey, &redis.Z{Score: float64(claimUntil.UnixMilli()), Member: id})
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			deliveryIDs = ids
+
+			return nil
+		}, deliveryQueueKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			// Another sender claimed deliveries at the same time, retry with fresh data
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		claimed = true
+
+		break
+	}
+
+	if !claimed {
+		return nil, fmt.Errorf("failed to claim deliveries: too many concurrent updates")
+	}
+
+	deliveries := make([]*models.WebhookDelivery, 0, len(deliveryIDs))
+
+	for _, id := range deliveryIDs {
+		delivery, err := rs.getDelivery(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				// Expired delivery, drop it from the queue
+				if err := rs.client.ZRem(ctx, deliveryQueueKey, id).Err(); err != nil {
+					return nil, fmt.Errorf("failed to remove expired delivery: %w", err)
+				}
+
+				continue
+			}
+
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Gets the recent deliveries of a webhook, newest first
+func (rs *Storage) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	ids, err := rs.client.LRange(ctx, fmt.Sprintf(webhookDeliveriesKey, webhookID), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery IDs: %w", err)
+	}
+
+	deliveries := make([]*models.WebhookDelivery, 0, len(ids))
+
+	for _, id := range ids {
+		delivery, err := rs.getDelivery(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Gets a delivery by ID
+func (rs *Storage) getDelivery(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	data, err := rs.client.Get(ctx, fmt.Sprintf(deliveryPrefix, deliveryID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	var delivery models.WebhookDelivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"time-guard-bot/internal/models"
+)
+
+func TestWebhookOperations(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	chatID := int64(-100123)
+	now := time.Now().Truncate(time.Second)
+
+	first := &models.Webhook{ID: "hook0001", ChatID: chatID, URL: "https://example.com/a", Secret: "s1", CreatedAt: now.Add(-time.Hour)}
+	second := &models.Webhook{ID: "hook0002", ChatID: chatID, URL: "https://example.com/b", Secret: "s2", CreatedAt: now}
+
+	t.Run("AddAndList", func(t *testing.T) {
+		for _, webhook := range []*models.Webhook{second, first} {
+			if err := storage.AddWebhook(ctx, webhook); err != nil {
+				t.Fatalf("Failed to add webhook: %v", err)
+			}
+		}
+
+		webhooks, err := storage.ListWebhooks(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to list webhooks: %v", err)
+		}
+
+		if len(webhooks) != 2 || webhooks[0].ID != first.ID || webhooks[1].ID != second.ID {
+			t.Errorf("Expected webhooks [%s %s], got %v", first.ID, second.ID, webhooks)
+		}
+
+		webhook, err := storage.GetWebhook(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("Failed to get webhook: %v", err)
+		}
+
+		if webhook.Secret != "s1" || webhook.URL != first.URL {
+			t.Errorf("Unexpected webhook: %+v", webhook)
+		}
+	})
+
+	t.Run("DeleteOtherChat", func(t *testing.T) {
+		// Вебхук другого чата выглядит как отсутствующий
+		if err := storage.DeleteWebhook(ctx, 42, first.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := storage.DeleteWebhook(ctx, chatID, first.ID); err != nil {
+			t.Fatalf("Failed to delete webhook: %v", err)
+		}
+
+		if _, err := storage.GetWebhook(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound after delete, got: %v", err)
+		}
+
+		webhooks, err := storage.ListWebhooks(ctx, chatID)
+		if err != nil {
+			t.Fatalf("Failed to list webhooks: %v", err)
+		}
+
+		if len(webhooks) != 1 || webhooks[0].ID != second.ID {
+			t.Errorf("Expected only %s, got %v", second.ID, webhooks)
+		}
+
+		if err := storage.DeleteWebhook(ctx, chatID, first.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for deleted webhook, got: %v", err)
+		}
+	})
+}
+
+func TestDeliveryQueue(t *testing.T) {
+	miniRedis, storage := setupMiniRedis(t)
+	defer miniRedis.Close()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+
+	newDelivery := func(id string, due time.Time) *models.WebhookDelivery {
+		return &models.WebhookDelivery{
+			ID:            id,
+			WebhookID:     "hook0001",
+			Event:         "timer.started",
+			Payload:       json.RawMessage(`{"id":"` + id + `"}`),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: due,
+			CreatedAt:     due,
+		}
+	}
+
+	for _, delivery := range []*models.WebhookDelivery{
+		newDelivery("d1", now.Add(-time.Second)),
+		newDelivery("d2", now),
+		newDelivery("d3", now.Add(time.Minute)),
+	} {
+		if err := storage.AddDelivery(ctx, delivery); err != nil {
+			t.Fatalf("Failed to add delivery: %v", err)
+		}
+	}
+
+	t.Run("ClaimDue", func(t *testing.T) {
+		deliveries, err := storage.ClaimDueDeliveries(ctx, now, now.Add(time.Minute*2), 10)
+		if err != nil {
+			t.Fatalf("Failed to claim deliveries: %v", err)
+		}
+
+		if len(deliveries) != 2 || deliveries[0].ID != "d1" || deliveries[1].ID != "d2" {
+			t.Fatalf("Expected d1 and d2 to be due, got %v", deliveries)
+		}
+
+		if string(deliveries[0].Payload) != `{"id":"d1"}` {
+			t.Errorf("Unexpected payload: %s", deliveries[0].Payload)
+		}
+
+		// Взятые доставки скрыты до истечения claimUntil
+		deliveries, err = storage.ClaimDueDeliveries(ctx, now, now.Add(time.Minute*2), 10)
+		if err != nil {
+			t.Fatalf("Failed to claim deliveries: %v", err)
+		}
+
+		if len(deliveries) != 0 {
+			t.Errorf("Expected claimed deliveries to be hidden, got %v", deliveries)
+		}
+	})
+
+	t.Run("ClaimLimit", func(t *testing.T) {
+		// После истечения claimUntil доставки снова доступны, d3 тоже наступила
+		deliveries, err := storage.ClaimDueDeliveries(ctx, now.Add(time.Minute*3), now.Add(time.Minute*4), 2)
+		if err != nil {
+			t.Fatalf("Failed to claim deliveries: %v", err)
+		}
+
+		if len(deliveries) != 2 || deliveries[0].ID != "d3" {
+			t.Errorf("Expected d3 first and 2 deliveries, got %v", deliveries)
+		}
+	})
+
+	t.Run("UpdateFinished", func(t *testing.T) {
+		delivered := newDelivery("d1", now)
+		delivered.Status = models.DeliveryDelivered
+		delivered.Attempts = []models.DeliveryAttempt{{At: now, StatusCode: 200}}
+
+		retried := newDelivery("d2", now.Add(time.Hour))
+
+		for _, delivery := range []*models.WebhookDelivery{delivered, retried} {
+			if err := storage.UpdateDelivery(ctx, delivery); err != nil {
+				t.Fatalf("Failed to update delivery: %v", err)
+			}
+		}
+
+		deliveries, err := storage.ClaimDueDeliveries(ctx, now.Add(time.Hour*2), now.Add(time.Hour*3), 10)
+		if err != nil {
+			t.Fatalf("Failed to claim deliveries: %v", err)
+		}
+
+		// Доставленная доставка ушла из очереди, остальные остались
+		ids := make([]string, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+
+		if len(ids) != 2 || ids[0] != "d3" || ids[1] != "d2" {
+			t.Errorf("Expected [d3 d2] in the queue, got %v", ids)
+		}
+	})
+
+	t.Run("ListNewestFirst", func(t *testing.T) {
+		deliveries, err := storage.ListDeliveries(ctx, "hook0001", 2)
+		if err != nil {
+			t.Fatalf("Failed to list deliveries: %v", err)
+		}
+
+		if len(deliveries) != 2 || deliveries[0].ID != "d3" || deliveries[1].ID != "d2" {
+			t.Fatalf("Expected [d3 d2], got %v", deliveries)
+		}
+
+		deliveries, err = storage.ListDeliveries(ctx, "hook0001", 10)
+		if err != nil {
+			t.Fatalf("Failed to list deliveries: %v", err)
+		}
+
+		if len(deliveries) != 3 || deliveries[2].Status != models.DeliveryDelivered || len(deliveries[2].Attempts) != 1 {
+			t.Errorf("Expected d1 delivered with one attempt, got %+v", deliveries[2])
+		}
+	})
+}
@@ -16,6 +16,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"time-guard-bot/internal/models"
 	"time-guard-bot/internal/storage/redis"
@@ -37,14 +38,67 @@ type Storage interface {
 	// Active Task management
 	StartTask(ctx context.Context, activeTask *models.ActiveTask) error
 	EndTask(ctx context.Context, chatID int64, taskID string) error
+	UpdateActiveTask(ctx context.Context, activeTask *models.ActiveTask) error
 	GetActiveTask(ctx context.Context, chatID int64, taskID string) (*models.ActiveTask, error)
 	GetActiveTasks(ctx context.Context, chatID int64) ([]*models.ActiveTask, error)
+	GetActiveTaskByMessage(ctx context.Context, chatID int64, messageID int) (*models.ActiveTask, error)
 	GetActiveChats(ctx context.Context) ([]int64, error)
+	CountActiveTasks(ctx context.Context) (map[int64]int64, error)
 	GetUserActiveTasks(ctx context.Context, chatID int64, userID int64) ([]*models.ActiveTask, error)
 	GetCountUserActiveTasks(ctx context.Context, chatID int64, userID int64) (int64, error)
 
+	// Conflict group operations
+	AddTaskToConflictGroup(ctx context.Context, chatID int64, group string, taskID string) error
+	RemoveTaskFromConflictGroup(ctx context.Context, chatID int64, group string, taskID string) error
+	DeleteConflictGroup(ctx context.Context, chatID int64, group string) error
+	GetConflictGroups(ctx context.Context, chatID int64) ([]*models.ConflictGroup, error)
+	CheckConflicts(ctx context.Context, chatID int64, taskID string) error
+
+	// Status board operations
+	SetBoardMessage(ctx context.Context, chatID int64, messageID int) error
+	GetBoardMessage(ctx context.Context, chatID int64) (int, error)
+	DeleteBoardMessage(ctx context.Context, chatID int64) error
+	GetBoardChats(ctx context.Context) ([]int64, error)
+
+	// User directory operations
+	SaveChatUser(ctx context.Context, chatID int64, user *models.ChatUser) error
+	GetChatUser(ctx context.Context, chatID int64, userID int64) (*models.ChatUser, error)
+	GetChatUsers(ctx context.Context, chatID int64) (map[int64]*models.ChatUser, error)
+
+	// API key operations
+	AddAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, chatID int64) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, chatID int64, keyID string) error
+	TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error
+
+	// Webhook operations
+	AddWebhook(ctx context.Context, webhook *models.Webhook) error
+	GetWebhook(ctx context.Context, webhookID string) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, chatID int64) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, chatID int64, webhookID string) error
+	AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, now time.Time, claimUntil time.Time, limit int) ([]*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*models.WebhookDelivery, error)
+
+	// Rate limit operations
+	TakeRateLimitToken(ctx context.Context, key string, requests int, period time.Duration, now time.Time) (bool, float64, error)
+
 	// Chat operations
 	ChatExists(ctx context.Context, chatID int64) (bool, error)
+	SaveChatLink(ctx context.Context, link *models.ChatLink) error
+	GetChatLink(ctx context.Context, chatID int64) (*models.ChatLink, error)
+
+	// Notification operations
+	SaveNotifySettings(ctx context.Context, settings *models.NotifySettings) error
+	GetNotifySettings(ctx context.Context, userID int64) (*models.NotifySettings, error)
+	AddTaskWatcher(ctx context.Context, chatID int64, taskID string, userID int64) error
+	TakeTaskWatchers(ctx context.Context, chatID int64, taskID string) ([]int64, error)
+	CountTaskWatchers(ctx context.Context, chatID int64, taskIDs []string) (map[string]int64, error)
+
+	// Checks the connection
+	Ping(ctx context.Context) error
 
 	// Close connection
 	Close() error
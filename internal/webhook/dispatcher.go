@@ -0,0 +1,381 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	// How often the queue is checked for due deliveries
+	pollInterval = time.Second
+	// Timeout of a single request to a receiver
+	requestTimeout = 10 * time.Second
+	// How long a claimed delivery stays hidden from other senders, longer than requestTimeout
+	claimTimeout = time.Minute
+	// Maximum number of deliveries sent at once
+	batchSize = 20
+	// Length of delivery IDs
+	deliveryIDLength = 16
+	// Maximum number of response bytes read from a receiver
+	maxResponseSize = 64 << 10
+)
+
+// Delays before retries, the delivery fails after the last one
+var retryDelays = []time.Duration{
+	10 * time.Second,
+	30 * time.Second,
+	2 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+}
+
+// Queues service events for the webhooks of their chats and sends them
+type Dispatcher struct {
+	storage storage.Storage
+	client  *http.Client
+	now     func() time.Time
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Creates a new dispatcher
+func NewDispatcher(storage storage.Storage) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Dispatcher{
+		storage: storage,
+		client:  newClient(helpers.IsPublicAddress),
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Creates the HTTP client of webhook requests, connecting only to addresses allowed by the check
+// The check runs on the resolved address of every connection, so a host name that
+// resolves to a private address (DNS rebinding) is refused even after it passed validation
+func newClient(allowed func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("invalid address %q: %w", address, err)
+			}
+
+			if !allowed(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", helpers.ErrPrivateAddress, addrPort.Addr())
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			// No proxy from the environment: the check must see the receiver, not the proxy
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: requestTimeout,
+			MaxIdleConns:        batchSize,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// A redirect is an unexpected answer, the payload is not sent anywhere else
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Starts sending queued deliveries
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+
+	go d.run()
+}
+
+// Stops sending, deliveries left in the queue are sent after restart
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Queues an event for every webhook of its chat that wants it
+// Subscribed to the service as a listener
+func (d *Dispatcher) HandleEvent(ctx context.Context, event *service.Event) {
+	webhooks, err := d.storage.ListWebhooks(ctx, event.ChatID)
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		return
+	}
+
+	queued := false
+
+	for _, webhook := range webhooks {
+		if !wants(webhook, event.Type) {
+			continue
+		}
+
+		if err := d.enqueue(ctx, webhook, event); err != nil {
+			log.Printf("Failed to queue %s for webhook %s: %v", event.Type, webhook.ID, err)
+			continue
+		}
+
+		queued = true
+	}
+
+	if queued {
+		// Send right away instead of waiting for the next poll
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Reports whether the webhook wants events of the type
+// Lease heartbeats are frequent, they are only sent to webhooks that ask for them
+func wants(webhook *models.Webhook, eventType service.EventType) bool {
+	if eventType == service.EventLeaseRenewed {
+		return slices.Contains(webhook.Events, string(eventType))
+	}
+
+	return webhook.Accepts(string(eventType))
+}
+
+// Saves a delivery of the event to the webhook
+func (d *Dispatcher) enqueue(ctx context.Context, webhook *models.Webhook, event *service.Event) error {
+	deliveryID, err := helpers.GenerateTaskID(deliveryIDLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate delivery ID: %w", err)
+	}
+
+	payload, err := json.Marshal(d.payload(ctx, deliveryID, event))
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	delivery := &models.WebhookDelivery{
+		ID:            deliveryID,
+		WebhookID:     webhook.ID,
+		ChatID:        webhook.ChatID,
+		Event:         string(event.Type),
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: d.now(),
+		CreatedAt:     event.Time,
+	}
+
+	return d.storage.AddDelivery(ctx, delivery)
+}
+
+// Builds the body sent to webhooks
+func (d *Dispatcher) payload(ctx context.Context, deliveryID string, event *service.Event) *models.WebhookPayload {
+	payload := &models.WebhookPayload{
+		ID:     deliveryID,
+		Event:  string(event.Type),
+		Time:   event.Time,
+		ChatID: event.ChatID,
+		Source: string(event.Source),
+		Task: models.TaskResponse{
+			ID:          event.Task.ID,
+			Name:        event.Task.Name,
+			Description: event.Task.Description,
+		},
+		Minutes: event.Minutes,
+	}
+
+	if activeTask := event.ActiveTask; activeTask != nil {
+		payload.Timer = &models.TimerResponse{
+			TaskID:    activeTask.TaskID,
+			TaskName:  event.Task.Name,
+			HolderID:  activeTask.UserID,
+			Holder:    d.holderName(ctx, event.ChatID, activeTask.UserID),
+			Duration:  activeTask.Duration,
+			StartTime: activeTask.StartTime,
+			EndTime:   activeTask.EndTime,
+			Remaining: activeTask.TimeRemaining(),
+			Paused:    activeTask.IsPaused(),
+			Leased:    activeTask.IsLeased(),
+		}
+	}
+
+	return payload
+}
+
+// Returns the display name of a user, empty if the user is unknown
+func (d *Dispatcher) holderName(ctx context.Context, chatID int64, userID int64) string {
+	user, err := d.storage.GetChatUser(ctx, chatID, userID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get chat user: %v", err)
+		}
+
+		return ""
+	}
+
+	return user.DisplayName()
+}
+
+// Sends due deliveries until the dispatcher is stopped
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+
+		d.sendDue(d.ctx)
+	}
+}
+
+// Sends the deliveries that are due, returns how many were attempted
+func (d *Dispatcher) sendDue(ctx context.Context) int {
+	now := d.now()
+
+	deliveries, err := d.storage.ClaimDueDeliveries(ctx, now, now.Add(claimTimeout), batchSize)
+	if err != nil {
+		log.Printf("Failed to claim webhook deliveries: %v", err)
+		return 0
+	}
+
+	var wg sync.WaitGroup
+
+	for _, delivery := range deliveries {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			d.deliver(ctx, delivery)
+		}()
+	}
+
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// Makes one attempt to send a delivery and saves the result
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := d.storage.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		if !errors.Is(err, redis.ErrNotFound) {
+			log.Printf("Failed to get webhook %s: %v", delivery.WebhookID, err)
+			return
+		}
+
+		// The webhook was removed, nothing to send to
+		delivery.Status = models.DeliveryFailed
+	} else {
+		attempt := d.attempt(ctx, webhook, delivery)
+		delivery.Attempts = append(delivery.Attempts, attempt)
+
+		switch {
+		case attempt.Error == "":
+			delivery.Status = models.DeliveryDelivered
+		case len(delivery.Attempts) > len(retryDelays):
+			delivery.Status = models.DeliveryFailed
+		default:
+			delivery.NextAttemptAt = d.now().Add(retryDelays[len(delivery.Attempts)-1])
+		}
+	}
+
+	if err := d.storage.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to update webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// Sends the payload of a delivery to the webhook once
+func (d *Dispatcher) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) models.DeliveryAttempt {
+	start := d.now()
+	attempt := models.DeliveryAttempt{At: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("failed to create request: %v", err)
+		return attempt
+	}
+
+	timestamp := start.Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TimeGuardBot-Webhook")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+
+	attempt.DurationMs = d.now().Sub(start).Milliseconds()
+
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Failed to close webhook response: %v", err)
+		}
+	}()
+
+	// Drain the body so the connection can be reused
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize)); err != nil {
+		log.Printf("Failed to read webhook response: %v", err)
+	}
+
+	attempt.StatusCode = resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+
+	return attempt
+}
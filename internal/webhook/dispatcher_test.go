@@ -0,0 +1,425 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+
+	"time-guard-bot/internal/helpers"
+	"time-guard-bot/internal/models"
+	"time-guard-bot/internal/service"
+	"time-guard-bot/internal/storage/redis"
+)
+
+const (
+	testChatID = int64(-100123)
+	testSecret = "whsec_test"
+)
+
+// Запрос, полученный тестовым приемником
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// Тестовый приемник вебхуков, отвечает заданными статусами по очереди
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []received
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.requests = append(rc.requests, received{header: r.Header.Clone(), body: body})
+
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status = rc.statuses[0]
+		rc.statuses = rc.statuses[1:]
+	}
+
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) received() []received {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return append([]received(nil), rc.requests...)
+}
+
+// Создает сервис, диспетчер с управляемым временем и вебхук на тестовый приемник
+func setupDispatcher(t *testing.T, events []string, statuses ...int) (*service.Service, *Dispatcher, *redis.Storage, *receiver, *time.Time) {
+	t.Helper()
+
+	miniRedis, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to create miniredis: %v", err)
+	}
+
+	t.Cleanup(miniRedis.Close)
+
+	storage, err := redis.New(miniRedis.Addr(), "", 0)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := storage.AddTask(ctx, &models.Task{ID: "t1", Name: "deploy", ChatID: testChatID}); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+
+	rc := &receiver{statuses: statuses}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	webhook := &models.Webhook{
+		ID:        "hook0001",
+		ChatID:    testChatID,
+		URL:       server.URL,
+		Secret:    testSecret,
+		Events:    events,
+		CreatedAt: time.Now(),
+	}
+	if err := storage.AddWebhook(ctx, webhook); err != nil {
+		t.Fatalf("Failed to add webhook: %v", err)
+	}
+
+	now := time.Now()
+	dispatcher := NewDispatcher(storage)
+	dispatcher.now = func() time.Time { return now }
+	// Тестовый приемник слушает на localhost
+	dispatcher.client = newClient(func(netip.Addr) bool { return true })
+
+	svc := service.New(storage)
+	svc.Subscribe(dispatcher.HandleEvent)
+
+	return svc, dispatcher, storage, rc, &now
+}
+
+func startTimer(t *testing.T, svc *service.Service) {
+	t.Helper()
+
+	_, _, err := svc.StartTimer(context.Background(), &service.StartTimerRequest{
+		ChatID:   testChatID,
+		TaskID:   "t1",
+		UserID:   1,
+		Duration: 30,
+		Source:   service.SourceAPI,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start timer: %v", err)
+	}
+}
+
+func listDeliveries(t *testing.T, storage *redis.Storage) []*models.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := storage.ListDeliveries(context.Background(), "hook0001", 10)
+	if err != nil {
+		t.Fatalf("Failed to list deliveries: %v", err)
+	}
+
+	return deliveries
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	svc, dispatcher, storage, rc, _ := setupDispatcher(t, nil)
+	ctx := context.Background()
+
+	startTimer(t, svc)
+
+	if sent := dispatcher.sendDue(ctx); sent != 1 {
+		t.Fatalf("Expected 1 delivery to be sent, got %d", sent)
+	}
+
+	requests := rc.received()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+
+	req := requests[0]
+
+	if req.header.Get(HeaderEvent) != string(service.EventTimerStarted) {
+		t.Errorf("Expected event header %s, got %q", service.EventTimerStarted, req.header.Get(HeaderEvent))
+	}
+
+	// Подпись проверяется секретом вебхука и меткой времени из заголовка
+	timestamp, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("Invalid timestamp header: %v", err)
+	}
+
+	if !Verify(testSecret, timestamp, req.body, req.header.Get(HeaderSignature)) {
+		t.Errorf("Invalid signature %q", req.header.Get(HeaderSignature))
+	}
+
+	if Verify("whsec_other", timestamp, req.body, req.header.Get(HeaderSignature)) {
+		t.Error("Signature must not match another secret")
+	}
+
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+
+	if payload.Event != string(service.EventTimerStarted) || payload.ChatID != testChatID || payload.Source != "api" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	if payload.Task.ID != "t1" || payload.Timer == nil || payload.Timer.Duration != 30 {
+		t.Errorf("Expected task t1 with a 30 minute timer, got %+v", payload)
+	}
+
+	if payload.ID != req.header.Get(HeaderDelivery) {
+		t.Errorf("Expected payload ID %s to match delivery header %s", payload.ID, req.header.Get(HeaderDelivery))
+	}
+
+	deliveries := listDeliveries(t, storage)
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliveryDelivered {
+		t.Fatalf("Expected 1 delivered delivery, got %+v", deliveries)
+	}
+
+	if attempts := deliveries[0].Attempts; len(attempts) != 1 || attempts[0].StatusCode != http.StatusOK {
+		t.Errorf("Expected 1 successful attempt, got %+v", attempts)
+	}
+
+	// Очередь пуста
+	if sent := dispatcher.sendDue(ctx); sent != 0 {
+		t.Errorf("Expected empty queue, sent %d", sent)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	svc, dispatcher, storage, rc, now := setupDispatcher(t, nil, http.StatusInternalServerError, http.StatusBadGateway)
+	ctx := context.Background()
+
+	startTimer(t, svc)
+
+	if sent := dispatcher.sendDue(ctx); sent != 1 {
+		t.Fatalf("Expected 1 delivery to be sent, got %d", sent)
+	}
+
+	deliveries := listDeliveries(t, storage)
+	if deliveries[0].Status != models.DeliveryPending || len(deliveries[0].Attempts) != 1 {
+		t.Fatalf("Expected pending delivery after a failed attempt, got %+v", deliveries[0])
+	}
+
+	if attempt := deliveries[0].Attempts[0]; attempt.StatusCode != http.StatusInternalServerError || attempt.Error == "" {
+		t.Errorf("Expected a failed attempt with status 500, got %+v", attempt)
+	}
+
+	// Повтор не наступил
+	if sent := dispatcher.sendDue(ctx); sent != 0 {
+		t.Fatalf("Expected no retry before the delay, sent %d", sent)
+	}
+
+	*now = now.Add(retryDelays[0])
+
+	if sent := dispatcher.sendDue(ctx); sent != 1 {
+		t.Fatalf("Expected the first retry, sent %d", sent)
+	}
+
+	*now = now.Add(retryDelays[1])
+
+	if sent := dispatcher.sendDue(ctx); sent != 1 {
+		t.Fatalf("Expected the second retry, sent %d", sent)
+	}
+
+	deliveries = listDeliveries(t, storage)
+	if deliveries[0].Status != models.DeliveryDelivered || len(deliveries[0].Attempts) != 3 {
+		t.Fatalf("Expected delivery on the third attempt, got %+v", deliveries[0])
+	}
+
+	// Все повторы несут одинаковый ID доставки
+	requests := rc.received()
+	for _, req := range requests[1:] {
+		if req.header.Get(HeaderDelivery) != requests[0].header.Get(HeaderDelivery) {
+			t.Errorf("Expected the same delivery ID on retries")
+		}
+	}
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	statuses := make([]int, len(retryDelays)+1)
+	for i := range statuses {
+		statuses[i] = http.StatusServiceUnavailable
+	}
+
+	svc, dispatcher, storage, rc, now := setupDispatcher(t, nil, statuses...)
+	ctx := context.Background()
+
+	startTimer(t, svc)
+
+	dispatcher.sendDue(ctx)
+
+	for _, delay := range retryDelays {
+		*now = now.Add(delay)
+
+		if sent := dispatcher.sendDue(ctx); sent != 1 {
+			t.Fatalf("Expected a retry after %s, sent %d", delay, sent)
+		}
+	}
+
+	deliveries := listDeliveries(t, storage)
+	if deliveries[0].Status != models.DeliveryFailed || len(deliveries[0].Attempts) != len(retryDelays)+1 {
+		t.Fatalf("Expected failed delivery after all retries, got %+v", deliveries[0])
+	}
+
+	*now = now.Add(24 * time.Hour)
+
+	if sent := dispatcher.sendDue(ctx); sent != 0 {
+		t.Errorf("Expected failed delivery to leave the queue, sent %d", sent)
+	}
+
+	if len(rc.received()) != len(retryDelays)+1 {
+		t.Errorf("Expected %d requests, got %d", len(retryDelays)+1, len(rc.received()))
+	}
+}
+
+func TestDispatcherFiltersEvents(t *testing.T) {
+	svc, dispatcher, storage, _, _ := setupDispatcher(t, []string{string(service.EventTimerCancelled)})
+	ctx := context.Background()
+
+	startTimer(t, svc)
+
+	if _, _, err := svc.ReleaseTimer(ctx, testChatID, "t1", false, service.SourceAPI); err != nil {
+		t.Fatalf("Failed to cancel timer: %v", err)
+	}
+
+	dispatcher.sendDue(ctx)
+
+	deliveries := listDeliveries(t, storage)
+	if len(deliveries) != 1 || deliveries[0].Event != string(service.EventTimerCancelled) {
+		t.Errorf("Expected only timer.cancelled, got %+v", deliveries)
+	}
+}
+
+func TestDispatcherSkipsLeaseRenewals(t *testing.T) {
+	webhook := &models.Webhook{}
+
+	if wants(webhook, service.EventLeaseRenewed) {
+		t.Error("Lease renewals must not be sent to webhooks subscribed to all events")
+	}
+
+	if !wants(webhook, service.EventLeaseAcquired) {
+		t.Error("Expected lease.acquired to be sent to webhooks subscribed to all events")
+	}
+
+	webhook.Events = []string{string(service.EventLeaseRenewed)}
+
+	if !wants(webhook, service.EventLeaseRenewed) {
+		t.Error("Expected lease renewals to be sent when listed")
+	}
+}
+
+func TestDispatcherRemovedWebhook(t *testing.T) {
+	svc, dispatcher, storage, rc, _ := setupDispatcher(t, nil)
+	ctx := context.Background()
+
+	startTimer(t, svc)
+
+	if err := storage.DeleteWebhook(ctx, testChatID, "hook0001"); err != nil {
+		t.Fatalf("Failed to delete webhook: %v", err)
+	}
+
+	if sent := dispatcher.sendDue(ctx); sent != 1 {
+		t.Fatalf("Expected the queued delivery to be processed, got %d", sent)
+	}
+
+	deliveries := listDeliveries(t, storage)
+	if deliveries[0].Status != models.DeliveryFailed || len(deliveries[0].Attempts) != 0 {
+		t.Errorf("Expected failed delivery without attempts, got %+v", deliveries[0])
+	}
+
+	if len(rc.received()) != 0 {
+		t.Errorf("Expected no requests to a removed webhook, got %d", len(rc.received()))
+	}
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	tests := []struct {
+		name string
+		host func(serverURL string) string // Адрес вебхука на основе адреса приемника
+	}{
+		{
+			name: "IP literal",
+			host: func(serverURL string) string { return serverURL },
+		},
+		{
+			// Имя прошло проверку при добавлении, но теперь указывает на 127.0.0.1
+			name: "Host name resolving to loopback",
+			host: func(serverURL string) string { return strings.Replace(serverURL, "127.0.0.1", "localhost", 1) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, dispatcher, storage, rc, _ := setupDispatcher(t, nil)
+			ctx := context.Background()
+
+			// Настоящий клиент вместо тестового
+			dispatcher.client = newClient(helpers.IsPublicAddress)
+
+			webhook, err := storage.GetWebhook(ctx, "hook0001")
+			if err != nil {
+				t.Fatalf("Failed to get webhook: %v", err)
+			}
+
+			webhook.URL = tt.host(webhook.URL)
+			if err := storage.AddWebhook(ctx, webhook); err != nil {
+				t.Fatalf("Failed to update webhook: %v", err)
+			}
+
+			startTimer(t, svc)
+
+			if sent := dispatcher.sendDue(ctx); sent != 1 {
+				t.Fatalf("Expected 1 delivery attempt, got %d", sent)
+			}
+
+			if requests := rc.received(); len(requests) != 0 {
+				t.Fatalf("Expected no requests to reach the receiver, got %d", len(requests))
+			}
+
+			deliveries := listDeliveries(t, storage)
+
+			attempt := deliveries[0].Attempts[0]
+			if attempt.StatusCode != 0 || !strings.Contains(attempt.Error, helpers.ErrPrivateAddress.Error()) {
+				t.Errorf("Expected the connection refused as private, got %+v", attempt)
+			}
+		})
+	}
+}
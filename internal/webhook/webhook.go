@@ -0,0 +1,46 @@
+// Copyright 2025 LikeButterfly
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package webhook sends task events of chats to their registered webhooks
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers of webhook requests
+const (
+	HeaderEvent     = "X-TimeGuard-Event"     // Event type
+	HeaderDelivery  = "X-TimeGuard-Delivery"  // Delivery ID, the same for all retries
+	HeaderTimestamp = "X-TimeGuard-Timestamp" // Unix time of the attempt
+	HeaderSignature = "X-TimeGuard-Signature" // "sha256=" + HMAC of "{timestamp}.{body}"
+)
+
+// Signs a payload with the webhook secret
+// The timestamp is signed too, so receivers can reject replayed requests
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Reports whether the signature of a payload is valid
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}